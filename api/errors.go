@@ -81,3 +81,114 @@ func (err InvalidURLParameterError) String() string {
 func (err InvalidURLParameterError) PublicError() (int, string) {
 	return http.StatusBadRequest, err.Error()
 }
+
+/************************* DEPENDENCIES NOT RECORDED **************************/
+
+// DependenciesNotRecordedError is an error that occurs when the dependencies of
+// a package version are requested, but were never recorded.
+type DependenciesNotRecordedError struct {
+	SHA    string
+	Repo   string
+	Author string
+}
+
+// NewDependenciesNotRecordedError creates a new DependenciesNotRecordedError.
+func NewDependenciesNotRecordedError(
+	author string,
+	repo string,
+	sha string,
+) DependenciesNotRecordedError {
+	return DependenciesNotRecordedError{
+		SHA:    sha,
+		Repo:   repo,
+		Author: author,
+	}
+}
+
+func (err DependenciesNotRecordedError) Error() string {
+	return fmt.Sprintf(
+		`The dependencies of "%s/%s@%s" have not been recorded.`,
+		err.Author,
+		err.Repo,
+		err.SHA,
+	)
+}
+
+func (err DependenciesNotRecordedError) String() string {
+	return err.Error()
+}
+
+// PublicError is an error that has an outside-friendly error message, and a
+// corresponding status code.
+func (err DependenciesNotRecordedError) PublicError() (int, string) {
+	return http.StatusNotFound, err.Error()
+}
+
+/************************ PACKAGE VERSION NOT ARCHIVED ************************/
+
+// PackageVersionNotArchivedError is an error that occurs when the archive of a
+// package version is requested, but the package version was never archived.
+type PackageVersionNotArchivedError struct {
+	SHA    string
+	Repo   string
+	Author string
+}
+
+// NewPackageVersionNotArchivedError creates a new
+// PackageVersionNotArchivedError.
+func NewPackageVersionNotArchivedError(
+	author string,
+	repo string,
+	sha string,
+) PackageVersionNotArchivedError {
+	return PackageVersionNotArchivedError{
+		SHA:    sha,
+		Repo:   repo,
+		Author: author,
+	}
+}
+
+func (err PackageVersionNotArchivedError) Error() string {
+	return fmt.Sprintf(
+		`"%s/%s@%s" has not been archived.`,
+		err.Author,
+		err.Repo,
+		err.SHA,
+	)
+}
+
+func (err PackageVersionNotArchivedError) String() string {
+	return err.Error()
+}
+
+// PublicError is an error that has an outside-friendly error message, and a
+// corresponding status code.
+func (err PackageVersionNotArchivedError) PublicError() (int, string) {
+	return http.StatusNotFound, err.Error()
+}
+
+/********************** DEPOT SIGNING KEY NOT CONFIGURED **********************/
+
+// DepotSigningKeyNotConfiguredError is an error that occurs when the public
+// key of depot commits is requested, but depot commits are not signed.
+type DepotSigningKeyNotConfiguredError struct{}
+
+// NewDepotSigningKeyNotConfiguredError creates a new
+// DepotSigningKeyNotConfiguredError.
+func NewDepotSigningKeyNotConfiguredError() DepotSigningKeyNotConfiguredError {
+	return DepotSigningKeyNotConfiguredError{}
+}
+
+func (err DepotSigningKeyNotConfiguredError) Error() string {
+	return "Depot commits are not signed."
+}
+
+func (err DepotSigningKeyNotConfiguredError) String() string {
+	return err.Error()
+}
+
+// PublicError is an error that has an outside-friendly error message, and a
+// corresponding status code.
+func (err DepotSigningKeyNotConfiguredError) PublicError() (int, string) {
+	return http.StatusNotFound, err.Error()
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gophr-pm/gophr/lib/errors"
+)
+
+// GetDepotSigningKeyHandler creates an HTTP request handler that responds with
+// the public key that depot commits are signed with. The key is formatted like
+// an authorized_keys entry, so that it can be added to the allowed signers of
+// git to verify depot commits. The public key is empty if depot commits are
+// not signed.
+func GetDepotSigningKeyHandler(
+	publicKey string,
+) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(publicKey) < 1 {
+			errors.RespondWithError(w, NewDepotSigningKeyNotConfiguredError())
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, publicKey)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/gophr-pm/gophr/lib/datadog"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archive"
+	"github.com/gophr-pm/gophr/lib/errors"
+	"github.com/gorilla/mux"
+)
+
+// ddEventName is the name of the custom datadog event for this handler.
+const ddEventGetPackageArchive = "api.get-package-archive"
+
+// getPackageArchiveRequestArgs is the args struct for get package archive
+// requests.
+type getPackageArchiveRequestArgs struct {
+	sha    string
+	repo   string
+	author string
+}
+
+// String serializes the arguments of the get package archive handler into a
+// representative string.
+func (args getPackageArchiveRequestArgs) String() string {
+	return fmt.Sprintf(
+		`{ author: "%s", repo: "%s", sha: "%s" }`,
+		args.author,
+		args.repo,
+		args.sha)
+}
+
+// GetPackageArchiveHandler creates an HTTP request handler that responds to
+// package version archive get requests. The response includes the tree hash of
+// the archive so that clients can verify its integrity.
+func GetPackageArchiveHandler(
+	q db.Client,
+	dataDogClient datadog.Client,
+) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			err          error
+			args         getPackageArchiveRequestArgs
+			json         []byte
+			result       *archives.Archive
+			trackingArgs = datadog.TrackTransactionArgs{
+				Tags:            []string{apiDDTag, datadog.TagExternal},
+				Client:          dataDogClient,
+				AlertType:       datadog.Success,
+				StartTime:       time.Now(),
+				MetricName:      datadog.MetricRequestDuration,
+				CreateEvent:     statsd.NewEvent,
+				CustomEventName: ddEventGetPackageArchive,
+			}
+		)
+
+		// Track the request with DataDog.
+		defer datadog.TrackTransaction(&trackingArgs)
+
+		// Parse out the args.
+		if args, err = extractGetPackageArchiveRequestArgs(r); err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(
+				trackingArgs.EventInfo,
+				args.String(),
+				err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		// Track request metadata.
+		trackingArgs.EventInfo = append(trackingArgs.EventInfo, args.String())
+
+		// Get from the database.
+		if result, err = archives.Get(
+			q,
+			args.author,
+			args.repo,
+			args.sha); err == nil && result == nil {
+			err = NewPackageVersionNotArchivedError(args.author, args.repo, args.sha)
+		}
+		if err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		// Turn the result into JSON.
+		if json, err = result.ToJSON(); err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		respondWithJSON(w, json)
+	}
+}
+
+// extractGetPackageArchiveRequestArgs validates and extracts the necessary
+// parameters for a get package archive request.
+func extractGetPackageArchiveRequestArgs(
+	r *http.Request,
+) (getPackageArchiveRequestArgs, error) {
+	var (
+		vars = mux.Vars(r)
+		args getPackageArchiveRequestArgs
+	)
+
+	if args.author = vars[urlVarAuthor]; len(args.author) < 1 {
+		return args, NewInvalidURLParameterError(urlVarAuthor, args.author)
+	}
+	if args.repo = vars[urlVarRepo]; len(args.repo) < 1 {
+		return args, NewInvalidURLParameterError(urlVarRepo, args.repo)
+	}
+	if args.sha = vars[urlVarSHA]; len(args.sha) < 1 {
+		return args, NewInvalidURLParameterError(urlVarSHA, args.sha)
+	}
+
+	return args, nil
+}
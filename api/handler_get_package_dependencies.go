@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/gophr-pm/gophr/lib/datadog"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/dependency"
+	"github.com/gophr-pm/gophr/lib/errors"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// ddEventName is the name of the custom datadog event for this handler.
+	ddEventGetPackageDependencies = "api.get-package-dependencies"
+	// maxDependencyGraphNodes is the most package versions that a transitive
+	// dependency graph can include.
+	maxDependencyGraphNodes = 500
+	dependenciesFormatDOT   = "dot"
+	dependenciesFormatJSON  = "json"
+)
+
+// getPackageDependenciesRequestArgs is the args struct for get package
+// dependencies requests.
+type getPackageDependenciesRequestArgs struct {
+	sha        string
+	repo       string
+	author     string
+	format     string
+	transitive bool
+}
+
+// String serializes the arguments of the get package dependencies handler into
+// a representative string.
+func (args getPackageDependenciesRequestArgs) String() string {
+	return fmt.Sprintf(
+		`{ author: "%s", repo: "%s", sha: "%s", transitive: %t, format: "%s" }`,
+		args.author,
+		args.repo,
+		args.sha,
+		args.transitive,
+		args.format)
+}
+
+// GetPackageDependenciesHandler creates an HTTP request handler that responds
+// to package version dependencies get requests. Only direct dependencies are
+// included unless the whole transitive graph is requested. Graphs may be
+// requested in the DOT language of Graphviz too.
+func GetPackageDependenciesHandler(
+	q db.Client,
+	dataDogClient datadog.Client,
+) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			err          error
+			args         getPackageDependenciesRequestArgs
+			json         []byte
+			graph        *dependency.Graph
+			deps         *dependency.Dependencies
+			trackingArgs = datadog.TrackTransactionArgs{
+				Tags:            []string{apiDDTag, datadog.TagExternal},
+				Client:          dataDogClient,
+				AlertType:       datadog.Success,
+				StartTime:       time.Now(),
+				MetricName:      datadog.MetricRequestDuration,
+				CreateEvent:     statsd.NewEvent,
+				CustomEventName: ddEventGetPackageDependencies,
+			}
+		)
+
+		// Track the request with DataDog.
+		defer datadog.TrackTransaction(&trackingArgs)
+
+		// Parse out the args.
+		if args, err = extractGetPackageDependenciesRequestArgs(r); err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(
+				trackingArgs.EventInfo,
+				args.String(),
+				err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		// Track request metadata.
+		trackingArgs.EventInfo = append(trackingArgs.EventInfo, args.String())
+
+		// Get from the database. Direct dependencies are a graph that is one
+		// level deep.
+		if args.transitive {
+			graph, err = dependency.GetGraph(
+				q,
+				args.author,
+				args.repo,
+				args.sha,
+				maxDependencyGraphNodes)
+		} else if deps, err = dependency.Get(
+			q,
+			args.author,
+			args.repo,
+			args.sha); err == nil && deps != nil {
+			graph = deps.ToGraph()
+		}
+		if err == nil && graph == nil {
+			err = NewDependenciesNotRecordedError(args.author, args.repo, args.sha)
+		}
+		if err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		if args.format == dependenciesFormatDOT {
+			respondWithDOT(w, graph.ToDOT())
+			return
+		}
+
+		// Turn the result into JSON.
+		if args.transitive {
+			json, err = graph.ToJSON()
+		} else {
+			json, err = deps.ToJSON()
+		}
+		if err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		respondWithJSON(w, json)
+	}
+}
+
+// extractGetPackageDependenciesRequestArgs validates and extracts the
+// necessary parameters for a get package dependencies request.
+func extractGetPackageDependenciesRequestArgs(
+	r *http.Request,
+) (getPackageDependenciesRequestArgs, error) {
+	var (
+		err           error
+		vars          = mux.Vars(r)
+		args          getPackageDependenciesRequestArgs
+		formatStr     = r.URL.Query().Get(urlVarFormat)
+		transitiveStr = r.URL.Query().Get(urlVarTransitive)
+	)
+
+	if args.author = vars[urlVarAuthor]; len(args.author) < 1 {
+		return args, NewInvalidURLParameterError(urlVarAuthor, args.author)
+	}
+	if args.repo = vars[urlVarRepo]; len(args.repo) < 1 {
+		return args, NewInvalidURLParameterError(urlVarRepo, args.repo)
+	}
+	if args.sha = vars[urlVarSHA]; len(args.sha) < 1 {
+		return args, NewInvalidURLParameterError(urlVarSHA, args.sha)
+	}
+
+	if len(transitiveStr) > 0 {
+		if args.transitive, err = strconv.ParseBool(transitiveStr); err != nil {
+			return args, NewInvalidQueryStringParameterError(
+				urlVarTransitive,
+				transitiveStr)
+		}
+	}
+
+	switch formatStr {
+	case "", dependenciesFormatJSON:
+		args.format = dependenciesFormatJSON
+	case dependenciesFormatDOT:
+		args.format = dependenciesFormatDOT
+	default:
+		return args, NewInvalidQueryStringParameterError(urlVarFormat, formatStr)
+	}
+
+	return args, nil
+}
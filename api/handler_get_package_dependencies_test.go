@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gophr-pm/gophr/lib/datadog"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/dtos"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockPackageDependenciesQueries mocks the queries that read the dependencies
+// of a package version. Package versions with nil dependencies were never
+// recorded.
+func mockPackageDependenciesQueries(
+	client *db.MockClient,
+	author string,
+	repo string,
+	sha string,
+	deps [][]string,
+	err error,
+) {
+	var (
+		recordStmt = `select dependencies from gophr.package_dependency_records ` +
+			`where author=? and repo=? and sha=? limit 1`
+		depsStmt = `select dep_author,dep_repo,dep_sha,dep_strategy,dep_subpaths ` +
+			`from gophr.package_dependencies where author=? and repo=? and sha=?`
+		recordQuery = db.NewMockQuery()
+		depsQuery   = db.NewMockQuery()
+		iter        = db.NewMockResultsIterator()
+		scan        = []interface{}{
+			mock.Anything,
+			mock.Anything,
+			mock.Anything,
+			mock.Anything,
+			mock.Anything,
+		}
+	)
+
+	if deps == nil {
+		recordQuery.On("Scan", mock.Anything).Return(gocql.ErrNotFound)
+	} else {
+		recordQuery.On("Scan", mock.Anything).Return(nil)
+	}
+
+	// Every dependency is an author, a repo and a SHA.
+	for _, dep := range deps {
+		dep := dep
+		iter.On("Scan", scan...).Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = dep[0]
+			*args.Get(1).(*string) = dep[1]
+			*args.Get(2).(*string) = dep[2]
+			*args.Get(3).(*string) = "commit-date"
+			*args.Get(4).(*[]string) = []string{""}
+		}).Return(true).Once()
+	}
+	iter.On("Scan", scan...).Return(false)
+	iter.On("Close").Return(err)
+	depsQuery.On("Iter").Return(iter)
+
+	client.On("Query", recordStmt, author, repo, sha).Return(recordQuery)
+	client.On("Query", depsStmt, author, repo, sha).Return(depsQuery)
+}
+
+func TestGetPackageDependenciesHandler(t *testing.T) {
+	newServer := func(client *db.MockClient) *httptest.Server {
+		r := mux.NewRouter()
+		r.HandleFunc(fmt.Sprintf(
+			"/packages/{%s}/{%s}/versions/{%s}/dependencies",
+			urlVarAuthor,
+			urlVarRepo,
+			urlVarSHA),
+			GetPackageDependenciesHandler(client, datadog.NewFakeDataDogClient()))
+		return httptest.NewServer(r)
+	}
+
+	get := func(client *db.MockClient, query string) (*http.Response, error) {
+		server := newServer(client)
+		defer server.Close()
+		return http.Get(server.URL + "/packages/a/b/versions/sha/dependencies" + query)
+	}
+
+	// Direct dependencies are served as JSON by default.
+	client := db.NewMockClient()
+	mockPackageDependenciesQueries(client, "a", "b", "sha", [][]string{{"c", "d", "sha1"}}, nil)
+	res, err := get(client, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, contentTypeJSON, res.Header.Get(contentTypeHeader))
+	var deps dtos.PackageDependencies
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&deps))
+	res.Body.Close()
+	assert.Equal(t, dtos.PackageDependencies{
+		SHA:    "sha",
+		Repo:   "b",
+		Author: "a",
+		Dependencies: []dtos.PackageDependency{
+			{SHA: "sha1", Repo: "d", Author: "c", Strategy: "commit-date", Subpaths: []string{""}},
+		},
+	}, deps)
+
+	// Transitive dependencies are served as a graph.
+	client = db.NewMockClient()
+	mockPackageDependenciesQueries(client, "a", "b", "sha", [][]string{{"c", "d", "sha1"}}, nil)
+	mockPackageDependenciesQueries(client, "c", "d", "sha1", nil, nil)
+	res, err = get(client, "?transitive=true")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	var graph dtos.PackageDependencyGraph
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&graph))
+	res.Body.Close()
+	assert.Equal(t, "github.com/a/b@sha", graph.Root)
+	assert.Equal(t, []dtos.PackageDependencyGraphNode{
+		{ID: "github.com/a/b@sha", SHA: "sha", Repo: "b", Author: "a", Recorded: true},
+		{ID: "github.com/c/d@sha1", SHA: "sha1", Repo: "d", Author: "c"},
+	}, graph.Nodes)
+	assert.Len(t, graph.Edges, 1)
+
+	// Either can be served in the DOT language.
+	client = db.NewMockClient()
+	mockPackageDependenciesQueries(client, "a", "b", "sha", [][]string{}, nil)
+	res, err = get(client, "?format=dot")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, contentTypeDOT, res.Header.Get(contentTypeHeader))
+	res.Body.Close()
+
+	// Dependencies that were never recorded can't be found.
+	client = db.NewMockClient()
+	mockPackageDependenciesQueries(client, "a", "b", "sha", nil, nil)
+	res, err = get(client, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	res.Body.Close()
+
+	// Bad query strings are rejected before reaching the database, which the
+	// client would panic at.
+	client = db.NewMockClient()
+	res, err = get(client, "?format=svg")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res.Body.Close()
+	res, err = get(client, "?transitive=maybe")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res.Body.Close()
+
+	// Database failures are not the client's fault.
+	client = db.NewMockClient()
+	mockPackageDependenciesQueries(client, "a", "b", "sha", [][]string{}, errors.New("this is an error"))
+	res, err = get(client, "")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	res.Body.Close()
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/gophr-pm/gophr/lib/datadog"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/dependency"
+	"github.com/gophr-pm/gophr/lib/errors"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// ddEventName is the name of the custom datadog event for this handler.
+	ddEventGetPackageDependents = "api.get-package-dependents"
+	maxPackageDependentsLimit   = 100
+	// defaultPackageDependentsLimit is the page size used when none is
+	// specified.
+	defaultPackageDependentsLimit = 20
+)
+
+// getPackageDependentsRequestArgs is the args struct for get package
+// dependents requests.
+type getPackageDependentsRequestArgs struct {
+	repo   string
+	after  string
+	limit  int
+	author string
+}
+
+// String serializes the arguments of the get package dependents handler into
+// a representative string.
+func (args getPackageDependentsRequestArgs) String() string {
+	return fmt.Sprintf(
+		`{ author: "%s", repo: "%s", after: "%s", limit: %d }`,
+		args.author,
+		args.repo,
+		args.after,
+		args.limit)
+}
+
+// GetPackageDependentsHandler creates an HTTP request handler that responds to
+// package dependents get requests. Dependents are paged: each page includes
+// the cursor that the next page starts after.
+func GetPackageDependentsHandler(
+	q db.Client,
+	dataDogClient datadog.Client,
+) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			err          error
+			args         getPackageDependentsRequestArgs
+			json         []byte
+			result       dependency.Dependents
+			trackingArgs = datadog.TrackTransactionArgs{
+				Tags:            []string{apiDDTag, datadog.TagExternal},
+				Client:          dataDogClient,
+				AlertType:       datadog.Success,
+				StartTime:       time.Now(),
+				MetricName:      datadog.MetricRequestDuration,
+				CreateEvent:     statsd.NewEvent,
+				CustomEventName: ddEventGetPackageDependents,
+			}
+		)
+
+		// Track the request with DataDog.
+		defer datadog.TrackTransaction(&trackingArgs)
+
+		// Parse out the args.
+		if args, err = extractGetPackageDependentsRequestArgs(r); err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(
+				trackingArgs.EventInfo,
+				args.String(),
+				err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		// Track request metadata.
+		trackingArgs.EventInfo = append(trackingArgs.EventInfo, args.String())
+
+		// Get from the database.
+		if result, err = dependency.GetDependents(
+			q,
+			args.author,
+			args.repo,
+			args.after,
+			args.limit); err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		// Turn the result into JSON.
+		if json, err = result.ToJSON(); err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		respondWithJSON(w, json)
+	}
+}
+
+// extractGetPackageDependentsRequestArgs validates and extracts the necessary
+// parameters for a get package dependents request.
+func extractGetPackageDependentsRequestArgs(
+	r *http.Request,
+) (getPackageDependentsRequestArgs, error) {
+	var (
+		err      error
+		vars     = mux.Vars(r)
+		args     getPackageDependentsRequestArgs
+		limitStr = r.URL.Query().Get(urlVarLimit)
+	)
+
+	if args.author = vars[urlVarAuthor]; len(args.author) < 1 {
+		return args, NewInvalidURLParameterError(urlVarAuthor, args.author)
+	}
+	if args.repo = vars[urlVarRepo]; len(args.repo) < 1 {
+		return args, NewInvalidURLParameterError(urlVarRepo, args.repo)
+	}
+
+	if len(limitStr) == 0 {
+		args.limit = defaultPackageDependentsLimit
+	} else if args.limit, err = strconv.Atoi(limitStr); err != nil ||
+		args.limit < 1 {
+		return args, NewInvalidQueryStringParameterError(urlVarLimit, limitStr)
+	}
+	if args.limit > maxPackageDependentsLimit {
+		args.limit = maxPackageDependentsLimit
+	}
+
+	args.after = r.URL.Query().Get(urlVarAfter)
+
+	return args, nil
+}
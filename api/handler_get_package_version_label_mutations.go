@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/gophr-pm/gophr/lib/datadog"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/label"
+	"github.com/gophr-pm/gophr/lib/errors"
+	"github.com/gorilla/mux"
+)
+
+// ddEventName is the name of the custom datadog event for this handler.
+const ddEventGetPackageVersionLabelMutations = "api.get-package-version-label-mutations"
+
+// getPackageVersionLabelMutationsRequestArgs is the args struct for get
+// package version label mutations requests.
+type getPackageVersionLabelMutationsRequestArgs struct {
+	repo   string
+	author string
+}
+
+// String serializes the arguments of the get package version label mutations
+// handler into a representative string.
+func (args getPackageVersionLabelMutationsRequestArgs) String() string {
+	return fmt.Sprintf(
+		`{ author: "%s", repo: "%s" }`,
+		args.author,
+		args.repo)
+}
+
+// GetPackageVersionLabelMutationsHandler creates an HTTP request handler that
+// responds to package version label mutations get requests. Mutations are the
+// version labels (e.g. tags) of a package that were moved upstream after gophr
+// pinned them.
+func GetPackageVersionLabelMutationsHandler(
+	q db.Client,
+	dataDogClient datadog.Client,
+) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			err          error
+			args         getPackageVersionLabelMutationsRequestArgs
+			json         []byte
+			result       label.Mutations
+			trackingArgs = datadog.TrackTransactionArgs{
+				Tags:            []string{apiDDTag, datadog.TagExternal},
+				Client:          dataDogClient,
+				AlertType:       datadog.Success,
+				StartTime:       time.Now(),
+				MetricName:      datadog.MetricRequestDuration,
+				CreateEvent:     statsd.NewEvent,
+				CustomEventName: ddEventGetPackageVersionLabelMutations,
+			}
+		)
+
+		// Track the request with DataDog.
+		defer datadog.TrackTransaction(&trackingArgs)
+
+		// Parse out the args.
+		if args, err = extractGetPackageVersionLabelMutationsRequestArgs(r); err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(
+				trackingArgs.EventInfo,
+				args.String(),
+				err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		// Track request metadata.
+		trackingArgs.EventInfo = append(trackingArgs.EventInfo, args.String())
+
+		// Get from the database.
+		if result, err = label.GetMutations(
+			q,
+			args.author,
+			args.repo); err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		// Turn the result into JSON.
+		if json, err = result.ToJSON(); err != nil {
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+			errors.RespondWithError(w, err)
+			return
+		}
+
+		respondWithJSON(w, json)
+	}
+}
+
+// extractGetPackageVersionLabelMutationsRequestArgs validates and extracts
+// the necessary parameters for a get package version label mutations request.
+func extractGetPackageVersionLabelMutationsRequestArgs(
+	r *http.Request,
+) (getPackageVersionLabelMutationsRequestArgs, error) {
+	var (
+		vars = mux.Vars(r)
+		args getPackageVersionLabelMutationsRequestArgs
+	)
+
+	if args.author = vars[urlVarAuthor]; len(args.author) < 1 {
+		return args, NewInvalidURLParameterError(urlVarAuthor, args.author)
+	}
+	if args.repo = vars[urlVarRepo]; len(args.repo) < 1 {
+		return args, NewInvalidURLParameterError(urlVarRepo, args.repo)
+	}
+
+	return args, nil
+}
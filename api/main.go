@@ -6,26 +6,44 @@ import (
 	"net/http"
 
 	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/config"
 	"github.com/gophr-pm/gophr/lib/datadog"
+	"github.com/gophr-pm/gophr/lib/depot"
 	"github.com/gorilla/mux"
 )
 
 func main() {
 	// Initialize the API.
-	config, client := lib.Init()
+	conf, client := lib.Init()
 
 	// Ensure that the client is closed eventually.
 	defer client.Close()
 
 	// Initialize datadog client.
-	dataDogClient, err := datadog.NewClient(config, "api.")
+	dataDogClient, err := datadog.NewClient(conf, "api.")
 	if err != nil {
 		log.Println(err)
 	}
 
+	// Read the public half of the depot signing key, if there is one.
+	var depotPublicKey string
+	signingKey, err := config.ReadDepotSigningKey(conf)
+	if err != nil {
+		log.Fatalln("Failed to read depot signing key secret:", err)
+	} else if signingKey != nil {
+		if _, err = depot.ParsePublicKey(signingKey.PublicKey); err != nil {
+			log.Fatalln("Failed to read depot signing key secret:", err)
+		}
+
+		depotPublicKey = signingKey.PublicKey
+	}
+
 	// Register all of the routes.
 	r := mux.NewRouter()
 	r.HandleFunc("/status", StatusHandler()).Methods("GET")
+	r.HandleFunc(
+		"/depot/signing-key",
+		GetDepotSigningKeyHandler(depotPublicKey)).Methods("GET")
 	r.HandleFunc(fmt.Sprintf(
 		"/blob/{%s}/{%s}/{%s}/{%s}",
 		urlVarAuthor,
@@ -52,8 +70,30 @@ func main() {
 		urlVarAuthor,
 		urlVarRepo),
 		GetPackageHandler(client, dataDogClient)).Methods("GET")
+	r.HandleFunc(fmt.Sprintf(
+		"/packages/{%s}/{%s}/dependents",
+		urlVarAuthor,
+		urlVarRepo),
+		GetPackageDependentsHandler(client, dataDogClient)).Methods("GET")
+	r.HandleFunc(fmt.Sprintf(
+		"/packages/{%s}/{%s}/versions/{%s}/dependencies",
+		urlVarAuthor,
+		urlVarRepo,
+		urlVarSHA),
+		GetPackageDependenciesHandler(client, dataDogClient)).Methods("GET")
+	r.HandleFunc(fmt.Sprintf(
+		"/packages/{%s}/{%s}/versions/{%s}/archive",
+		urlVarAuthor,
+		urlVarRepo,
+		urlVarSHA),
+		GetPackageArchiveHandler(client, dataDogClient)).Methods("GET")
+	r.HandleFunc(fmt.Sprintf(
+		"/packages/{%s}/{%s}/mutations",
+		urlVarAuthor,
+		urlVarRepo),
+		GetPackageVersionLabelMutationsHandler(client, dataDogClient)).Methods("GET")
 
 	// Start serving.
-	log.Printf("Servicing HTTP requests on port %d.\n", config.Port)
-	http.ListenAndServe(fmt.Sprintf(":%d", config.Port), r)
+	log.Printf("Servicing HTTP requests on port %d.\n", conf.Port)
+	http.ListenAndServe(fmt.Sprintf(":%d", conf.Port), r)
 }
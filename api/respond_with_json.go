@@ -3,6 +3,7 @@ package main
 import "net/http"
 
 const (
+	contentTypeDOT    = "text/vnd.graphviz"
 	contentTypeJSON   = "application/json"
 	contentTypeHeader = "Content-Type"
 )
@@ -12,3 +13,9 @@ func respondWithJSON(w http.ResponseWriter, json []byte) {
 	w.Header().Set(contentTypeHeader, contentTypeJSON)
 	w.Write(json)
 }
+
+// respondWithDOT sends a Graphviz graph response with status code 200.
+func respondWithDOT(w http.ResponseWriter, dot []byte) {
+	w.Header().Set(contentTypeHeader, contentTypeDOT)
+	w.Write(dot)
+}
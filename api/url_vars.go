@@ -2,10 +2,13 @@ package main
 
 const (
 	urlVarSHA         = "sha"
+	urlVarAfter       = "after"
 	urlVarPath        = "path"
 	urlVarRepo        = "repo"
 	urlVarLimit       = "limit"
 	urlVarAuthor      = "author"
+	urlVarFormat      = "format"
 	urlVarTimeSplit   = "split"
+	urlVarTransitive  = "transitive"
 	urlVarSearchQuery = "q"
 )
@@ -14,10 +14,14 @@ import (
 // attempted.
 const repoCreationAttemptsLimit = 3
 
+// branchRefFormat is the format of the ref of a branch.
+const branchRefFormat = "refs/heads/%s"
+
 // createNewRepo creates a new repo in the depot *record scratch*. If the repo
 // was created by this function invocation, repoCreated will return true. If the
-// the repo was created by something else, repoCreated will be false.
-func createNewRepo(depotReposPath, author, repo, sha string) (bool, error) {
+// the repo was created by something else, repoCreated will be false. The HEAD
+// of the new repo points to branch, or to master if branch is empty.
+func createNewRepo(depotReposPath, author, repo, sha, branch string) (bool, error) {
 	log.Printf("Creating New Repo on depot %s/%s@%s \n", author, repo, sha)
 
 	// Create the repo dir out here so that in can be used after the for loop.
@@ -39,12 +43,23 @@ func createNewRepo(depotReposPath, author, repo, sha string) (bool, error) {
 		// The repo directory doesn't exist, so creating it should be ok.
 		if err := os.Mkdir(filepath.Join(depotReposPath, repoDir), 0644); err == nil {
 			// The folder was created just fine. Now create the bare git repo.
-			if _, err = git.InitRepository(filepath.Join(depotReposPath, repoDir), true); err != nil {
+			gitRepo, err := git.InitRepository(filepath.Join(depotReposPath, repoDir), true)
+			if err != nil {
 				return false, fmt.Errorf(
 					"Could not initialize new repository: %v.",
 					err)
 			}
 
+			// Mirror the default branch of the original repo.
+			if len(branch) > 0 {
+				if err = gitRepo.SetHead(fmt.Sprintf(branchRefFormat, branch)); err != nil {
+					return false, fmt.Errorf(
+						"Could not point HEAD to branch \"%s\": %v.",
+						branch,
+						err)
+				}
+			}
+
 			// Woop! New repo in the depot!
 			return false, nil
 		}
@@ -51,7 +51,8 @@ func CreateRepoHandler(
 			conf.DepotPath,
 			vars.author,
 			vars.repo,
-			vars.sha)
+			vars.sha,
+			vars.branch)
 		if err != nil {
 			trackingArgs.AlertType = datadog.Error
 			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
@@ -12,7 +12,10 @@ import (
 
 const ddEventRepoExists = "depot.repo.exists"
 
-// RepoExistsHandler returns a 200 if the repo exists, or 404 if it doesn't.
+// RepoExistsHandler returns a 200 along with the archive hash of the repo if an
+// archive was pushed to the repo, or 404 if it wasn't. Repos that were created
+// but never pushed to are left behind by interrupted archivals, so they don't
+// count.
 func RepoExistsHandler(
 	conf *config.Config,
 	dataDogClient datadog.Client,
@@ -48,7 +51,7 @@ func RepoExistsHandler(
 			return
 		}
 
-		exists, err := repoExists(
+		archiveHash, exists, err := readRepoArchiveHash(
 			conf.DepotPath,
 			vars.author,
 			vars.repo,
@@ -71,6 +74,7 @@ func RepoExistsHandler(
 		// Otherwise, the repo exists.
 		trackingArgs.AlertType = datadog.Success
 		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(archiveHash))
 		return
 	}
 }
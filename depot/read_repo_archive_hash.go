@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/gophr-pm/gophr/lib/depot"
+)
+
+const (
+	gitBinaryName         = "git"
+	gitHeadRef            = "HEAD"
+	gitRevParseSubcommand = "rev-parse"
+	gitRevParseVerify     = "--verify"
+	gitRevParseQuiet      = "--quiet"
+	gitCatFileSubcommand  = "cat-file"
+	gitCatFileCommitType  = "commit"
+)
+
+// readRepoArchiveHash reads the archive hash that the HEAD commit of the depot
+// repo matching author, repo and sha vouches for. Depot repos only get a HEAD
+// commit once the archive is pushed to them, so false is returned if nothing
+// was pushed yet, or if the repo doesn't exist at all. The hash is empty if
+// the archive was pushed without one.
+func readRepoArchiveHash(
+	depotReposPath string,
+	author string,
+	repo string,
+	sha string,
+) (string, bool, error) {
+	if exists, err := repoExists(depotReposPath, author, repo, sha); err != nil {
+		return "", false, err
+	} else if !exists {
+		return "", false, nil
+	}
+
+	var (
+		stderr  bytes.Buffer
+		stdout  bytes.Buffer
+		repoDir = fmt.Sprintf(
+			"%s.git",
+			depot.BuildHashedRepoName(author, repo, sha))
+		gitDirFlag = "--git-dir=" + filepath.Join(depotReposPath, repoDir)
+	)
+
+	// Quietly, git refuses to verify a HEAD that doesn't point at a commit yet.
+	cmd := exec.Command(
+		gitBinaryName,
+		gitDirFlag,
+		gitRevParseSubcommand,
+		gitRevParseVerify,
+		gitRevParseQuiet,
+		gitHeadRef)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if _, exited := err.(*exec.ExitError); exited && stderr.Len() == 0 {
+			return "", false, nil
+		}
+
+		return "", false, fmt.Errorf(
+			"Failed to resolve the HEAD of repo \"%s\": %v. %s",
+			repoDir,
+			err,
+			stderr.String())
+	}
+
+	// The archive hash is in the message of the HEAD commit.
+	cmd = exec.Command(
+		gitBinaryName,
+		gitDirFlag,
+		gitCatFileSubcommand,
+		gitCatFileCommitType,
+		gitHeadRef)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", false, fmt.Errorf(
+			"Failed to read the HEAD commit of repo \"%s\": %v. %s",
+			repoDir,
+			err,
+			stderr.String())
+	}
+
+	return depot.ReadArchiveHash(stdout.String()), true, nil
+}
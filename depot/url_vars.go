@@ -8,18 +8,21 @@ import (
 )
 
 const (
-	urlVarAuthor = "author"
-	urlVarRepo   = "repo"
-	urlVarSHA    = "sha"
+	urlVarAuthor   = "author"
+	urlVarRepo     = "repo"
+	urlVarSHA      = "sha"
+	queryVarBranch = "branch"
 )
 
 type urlVars struct {
 	sha    string
 	repo   string
 	author string
+	branch string
 }
 
-// readURLVars reads author, repo & sha from the URL.
+// readURLVars reads author, repo & sha from the URL, along with the optional
+// branch.
 func readURLVars(r *http.Request) (urlVars, error) {
 	var (
 		vars = mux.Vars(r)
@@ -43,5 +46,6 @@ func readURLVars(r *http.Request) (urlVars, error) {
 		sha:    sha,
 		repo:   repo,
 		author: author,
+		branch: r.URL.Query().Get(queryVarBranch),
 	}, nil
 }
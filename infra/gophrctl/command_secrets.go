@@ -2,7 +2,10 @@ package main
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -10,6 +13,8 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/gophr-pm/gophr/lib/config"
+	"github.com/gophr-pm/gophr/lib/depot"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -46,6 +51,43 @@ func secretsNewKeyCommand(c *cli.Context) error {
 	return nil
 }
 
+func secretsNewSigningKeyCommand(c *cli.Context) error {
+	printInfo("Creating a new depot signing key")
+	keyFilePath := c.Args().First()
+	if len(keyFilePath) < 1 {
+		exit(exitCodeSigningKeyFailed, nil, "", fmt.Errorf("Invalid signing key file path: \"%s\".", keyFilePath))
+	}
+
+	keyFilePath, err := filepath.Abs(keyFilePath)
+	if err != nil {
+		exit(exitCodeSigningKeyFailed, nil, "", err)
+		return nil
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		exit(exitCodeSigningKeyFailed, nil, "", errors.New("Failed to generate the signing key."))
+	}
+
+	// The secret is read by the router and the API (see
+	// config.ReadDepotSigningKey).
+	data, err := json.MarshalIndent(config.DepotSigningKey{
+		PublicKey:  depot.FormatPublicKey(publicKey),
+		PrivateKey: base64.StdEncoding.EncodeToString(privateKey.Seed()),
+	}, "", "  ")
+	if err != nil {
+		exit(exitCodeSigningKeyFailed, nil, "", err)
+	}
+	if err = ioutil.WriteFile(keyFilePath, data, 0600); err != nil {
+		exit(exitCodeSigningKeyFailed, nil, "", fmt.Errorf("Invalid signing key file path: \"%s\".", keyFilePath))
+	}
+
+	printSuccess(fmt.Sprintf(
+		"New signing key written at \"%s\". Record it as a secret named \"depot-signing-key.json\".",
+		keyFilePath))
+	return nil
+}
+
 func secretsRecordCommand(c *cli.Context) error {
 	var (
 		err            error
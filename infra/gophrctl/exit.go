@@ -22,6 +22,7 @@ const (
 	exitCodeUpFailed           = 111
 	exitCodeRevealSecretFailed = 112
 	exitCodeCMD                = 113
+	exitCodeSigningKeyFailed   = 114
 )
 
 func exit(
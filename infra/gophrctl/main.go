@@ -76,6 +76,10 @@ const (
 	commandDescUp                 = "Starts all unstarted modules in order"
 	commandNameUpdate             = "update"
 	commandDescUpdate             = "Updates module kubernetes definition"
+
+	commandNameSecretsNewSigningKey      = "new-signing-key"
+	commandDescSecretsNewSigningKey      = "Creates a new depot signing key secret (depot-signing-key.json)"
+	commandArgsUsageSecretsNewSigningKey = "[new signing key filepath]"
 )
 
 var (
@@ -250,6 +254,12 @@ func main() {
 					Action:    secretsNewKeyCommand,
 					ArgsUsage: commandArgsUsageSecretsNewKey,
 				},
+				{
+					Name:      commandNameSecretsNewSigningKey,
+					Usage:     commandDescSecretsNewSigningKey,
+					Action:    secretsNewSigningKeyCommand,
+					ArgsUsage: commandArgsUsageSecretsNewSigningKey,
+				},
 				{
 					Name:      commandNameSecretsRecord,
 					Usage:     commandDescSecretsRecord,
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"os"
 	"strconv"
+	"strings"
 
 	"gopkg.in/urfave/cli.v1"
 )
@@ -16,6 +17,9 @@ const (
 	envVarsDepotPath            = "GOPHR_DEPOT_PATH"
 	envVarsDbAddress            = "GOPHR_DB_ADDR"
 	envVarsEnvironment          = "GOPHR_ENV"
+	envVarsArchivalWorkers      = "GOPHR_ARCHIVAL_WORKERS"
+	envVarsServeStale           = "GOPHR_SERVE_STALE"
+	envVarsGenericHosts         = "GOPHR_GENERIC_HOSTS"
 	envVarsSecretsPath          = "GOPHR_SECRETS_PATH"
 	envVarsMigrationsPath       = "GOPHR_MIGRATIONS_PATH"
 	envVarsConstructionZonePath = "GOPHR_CONSTRUCTION_ZONE_PATH"
@@ -29,6 +33,9 @@ type Config struct {
 	DbAddress            string
 	SecretsPath          string
 	MigrationsPath       string
+	ServeStale           bool
+	GenericHosts         []string
+	ArchivalWorkers      int
 	ConstructionZonePath string
 }
 
@@ -60,6 +67,21 @@ func (c *Config) String() string {
 		buffer.WriteString(c.MigrationsPath)
 	}
 
+	if c.ArchivalWorkers > 0 {
+		buffer.WriteString("\nArchival workers:       ")
+		buffer.WriteString(strconv.Itoa(c.ArchivalWorkers))
+	}
+
+	if c.ServeStale {
+		buffer.WriteString("\nServe stale:            ")
+		buffer.WriteString(strconv.FormatBool(c.ServeStale))
+	}
+
+	if len(c.GenericHosts) > 0 {
+		buffer.WriteString("\nGeneric hosts:          ")
+		buffer.WriteString(strings.Join(c.GenericHosts, ", "))
+	}
+
 	if len(c.ConstructionZonePath) > 0 {
 		buffer.WriteString("\nConstruction zone path: ")
 		buffer.WriteString(c.ConstructionZonePath)
@@ -75,8 +97,11 @@ func GetConfig() *Config {
 		depotPath            string
 		dbAddress            string
 		secretsPath          string
+		serveStale           bool
 		environment          string
+		genericHosts         []string
 		migrationsPath       string
+		archivalWorkers      int
 		constructionZonePath string
 
 		app            = cli.NewApp()
@@ -127,6 +152,24 @@ func GetConfig() *Config {
 			EnvVar:      envVarsMigrationsPath,
 			Destination: &migrationsPath,
 		},
+		cli.IntFlag{
+			Name:        "archival-workers",
+			Value:       4,
+			Usage:       "number of packages that may be archived concurrently",
+			EnvVar:      envVarsArchivalWorkers,
+			Destination: &archivalWorkers,
+		},
+		cli.BoolFlag{
+			Name:        "serve-stale",
+			Usage:       "serve archived versions of packages while newer matches are archived",
+			EnvVar:      envVarsServeStale,
+			Destination: &serveStale,
+		},
+		cli.StringSliceFlag{
+			Name:   "generic-hosts",
+			Usage:  "domains of the plain git hosts that packages may be fetched from",
+			EnvVar: envVarsGenericHosts,
+		},
 		cli.StringFlag{
 			Name:        "construction-zone-path, c",
 			Usage:       "path to the construction zone",
@@ -141,6 +184,9 @@ func GetConfig() *Config {
 			return cli.NewExitError("invalid environment", 1)
 		}
 
+		// String slice flags can't have destinations.
+		genericHosts = c.StringSlice("generic-hosts")
+
 		actionExecuted = true
 		return nil
 	}
@@ -159,7 +205,10 @@ func GetConfig() *Config {
 		DepotPath:            depotPath,
 		DbAddress:            dbAddress,
 		SecretsPath:          secretsPath,
+		ServeStale:           serveStale,
+		GenericHosts:         genericHosts,
 		MigrationsPath:       migrationsPath,
+		ArchivalWorkers:      archivalWorkers,
 		ConstructionZonePath: constructionZonePath,
 	}
 }
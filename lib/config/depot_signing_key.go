@@ -0,0 +1,36 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// DepotSigningKey is the key pair that the commits of depot repos are signed
+// with.
+type DepotSigningKey struct {
+	PublicKey  string `json:"publicKey"`
+	PrivateKey string `json:"privateKey"`
+}
+
+const depotSigningKeyFileName = "depot-signing-key.json"
+
+// ReadDepotSigningKey reads the depot signing key from the depot signing key
+// secret. Signing depot commits is optional, so a missing secret is not an
+// error: the key is nil instead.
+func ReadDepotSigningKey(conf *Config) (*DepotSigningKey, error) {
+	data, err := ioutil.ReadFile(filepath.Join(conf.SecretsPath, depotSigningKeyFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	key := DepotSigningKey{}
+	if err = json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,40 @@
+package db
+
+import "github.com/stretchr/testify/mock"
+
+// MockQuery mocks Query.
+type MockQuery struct {
+	mock.Mock
+}
+
+// NewMockQuery creates a new MockQuery.
+func NewMockQuery() *MockQuery {
+	return &MockQuery{}
+}
+
+// Exec executes the query without returning any rows.
+func (m *MockQuery) Exec() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// Iter executes the query and returns an iterator capable of iterating over
+// all results.
+func (m *MockQuery) Iter() ResultsIterator {
+	args := m.Called()
+	return args.Get(0).(ResultsIterator)
+}
+
+// Scan executes the query, copies the columns of the first selected row into
+// the values pointed at by dest and discards the rest.
+func (m *MockQuery) Scan(dest ...interface{}) error {
+	args := m.Called(dest...)
+	return args.Error(0)
+}
+
+// ExecCAS executes a lightweight transaction and returns true if it was
+// applied.
+func (m *MockQuery) ExecCAS() (bool, error) {
+	args := m.Called()
+	return args.Bool(0), args.Error(1)
+}
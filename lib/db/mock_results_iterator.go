@@ -0,0 +1,27 @@
+package db
+
+import "github.com/stretchr/testify/mock"
+
+// MockResultsIterator mocks ResultsIterator.
+type MockResultsIterator struct {
+	mock.Mock
+}
+
+// NewMockResultsIterator creates a new MockResultsIterator.
+func NewMockResultsIterator() *MockResultsIterator {
+	return &MockResultsIterator{}
+}
+
+// Scan consumes the next row of the iterator and copies the columns of the
+// current row into the values pointed at by dest.
+func (m *MockResultsIterator) Scan(dest ...interface{}) bool {
+	args := m.Called(dest...)
+	return args.Bool(0)
+}
+
+// Close closes the iterator and returns any errors that happened during the
+// query or the iteration.
+func (m *MockResultsIterator) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
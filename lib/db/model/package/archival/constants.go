@@ -0,0 +1,27 @@
+package archival
+
+const (
+	tableName                   = "package_archival_jobs"
+	columnNameSHA               = "sha"
+	columnNameRepo              = "repo"
+	columnNameAuthor            = "author"
+	columnNameStatus            = "status"
+	columnNameSelector          = "selector"
+	columnNameAttempts          = "attempts"
+	columnNameLastError         = "last_error"
+	columnNameDateUpdated       = "date_updated"
+	columnNameDateEnqueued      = "date_enqueued"
+	columnNameDateDeferredUntil = "date_deferred_until"
+)
+
+const (
+	// StatusQueued is the status of a job that is waiting for a worker.
+	StatusQueued = "queued"
+	// StatusRunning is the status of a job that has been claimed by a worker.
+	StatusRunning = "running"
+	// StatusFailed is the status of a job that ran out of attempts.
+	StatusFailed = "failed"
+	// StatusSucceeded is the status of a job whose package version has been
+	// archived.
+	StatusSucceeded = "succeeded"
+)
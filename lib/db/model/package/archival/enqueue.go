@@ -0,0 +1,95 @@
+package archival
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+)
+
+// Enqueue adds a job for the specified package version to the queue. Returns
+// true if the job was queued by this call, or false if there was already an
+// unfinished job for the package version. Callers are expected to check
+// whether the package version has been archived before enqueueing it.
+func Enqueue(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	selector string,
+) (bool, error) {
+	now := time.Now()
+
+	// Jobs are de-duplicated by their primary key, so only insert if nobody
+	// has gotten here first.
+	queued, err := query.InsertInto(tableName).
+		Value(columnNameAuthor, author).
+		Value(columnNameRepo, repo).
+		Value(columnNameSHA, sha).
+		Value(columnNameStatus, StatusQueued).
+		Value(columnNameSelector, selector).
+		Value(columnNameAttempts, 0).
+		Value(columnNameLastError, "").
+		Value(columnNameDateUpdated, now).
+		Value(columnNameDateEnqueued, now).
+		IfNotExists().
+		Create(q).
+		ExecCAS()
+	if err != nil {
+		return false, fmt.Errorf(
+			"Failed to enqueue archival of %s/%s@%s: %v",
+			author,
+			repo,
+			sha,
+			err)
+	} else if queued {
+		return true, nil
+	}
+
+	// Jobs that are done get queued again from scratch: failures may have been
+	// fixed in the meantime, and successful archives may have gone missing.
+	for _, doneStatus := range []string{StatusFailed, StatusSucceeded} {
+		if queued, err = requeue(q, author, repo, sha, selector, doneStatus); err != nil || queued {
+			return queued, err
+		}
+	}
+
+	return false, nil
+}
+
+// requeue resets the job for the specified package version if it currently
+// has the specified status. Returns true if the job was reset.
+func requeue(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	selector string,
+	status string,
+) (bool, error) {
+	now := time.Now()
+	queued, err := query.Update(tableName).
+		Set(columnNameStatus, StatusQueued).
+		Set(columnNameSelector, selector).
+		Set(columnNameAttempts, 0).
+		Set(columnNameLastError, "").
+		Set(columnNameDateUpdated, now).
+		Set(columnNameDateEnqueued, now).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		And(query.Column(columnNameSHA).Equals(sha)).
+		If(query.Column(columnNameStatus).Equals(status)).
+		Create(q).
+		ExecCAS()
+	if err != nil {
+		return false, fmt.Errorf(
+			"Failed to re-enqueue archival of %s/%s@%s: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	return queued, nil
+}
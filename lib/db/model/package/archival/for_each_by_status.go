@@ -0,0 +1,60 @@
+package archival
+
+import (
+	"fmt"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+)
+
+// ForEachByStatus visits every job that has the specified status until visit
+// returns false. The jobs are read a page at a time, so jobs that are not
+// ready to be worked on can't hide the ones behind them no matter how many of
+// them there are.
+func ForEachByStatus(
+	q db.Queryable,
+	status string,
+	visit func(job Job) bool,
+) error {
+	iter := query.Select(
+		columnNameAuthor,
+		columnNameRepo,
+		columnNameSHA,
+		columnNameStatus,
+		columnNameSelector,
+		columnNameAttempts,
+		columnNameLastError,
+		columnNameDateUpdated,
+		columnNameDateEnqueued,
+		columnNameDateDeferredUntil).
+		From(tableName).
+		Where(query.Column(columnNameStatus).Equals(status)).
+		Create(q).
+		Iter()
+
+	var nextJob Job
+	for iter.Scan(
+		&nextJob.Author,
+		&nextJob.Repo,
+		&nextJob.SHA,
+		&nextJob.Status,
+		&nextJob.Selector,
+		&nextJob.Attempts,
+		&nextJob.LastError,
+		&nextJob.DateUpdated,
+		&nextJob.DateEnqueued,
+		&nextJob.DateDeferredUntil) {
+		if !visit(nextJob) {
+			break
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf(
+			`Failed to get %s archival jobs from the db: %v`,
+			status,
+			err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package archival
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockForEachByStatusQuery mocks the query that reads the queued archival
+// jobs, yielding a job for each of the specified SHAs.
+func mockForEachByStatusQuery(shas []string, err error) *db.MockClient {
+	var (
+		stmt = `select author,repo,sha,status,selector,attempts,last_error,` +
+			`date_updated,date_enqueued,date_deferred_until ` +
+			`from gophr.package_archival_jobs where status=?`
+		client = db.NewMockClient()
+		query  = db.NewMockQuery()
+		iter   = db.NewMockResultsIterator()
+		scan   = make([]interface{}, 10)
+	)
+
+	for i := range scan {
+		scan[i] = mock.Anything
+	}
+	for _, sha := range shas {
+		sha := sha
+		iter.On("Scan", scan...).Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = "a"
+			*args.Get(1).(*string) = "b"
+			*args.Get(2).(*string) = sha
+			*args.Get(3).(*string) = StatusQueued
+		}).Return(true).Once()
+	}
+	iter.On("Scan", scan...).Return(false)
+	iter.On("Close").Return(err)
+	query.On("Iter").Return(iter)
+	client.On("Query", stmt, StatusQueued).Return(query)
+
+	return client
+}
+
+func TestForEachByStatus(t *testing.T) {
+	var visited []string
+
+	// Every job is visited.
+	client := mockForEachByStatusQuery([]string{"sha1", "sha2", "sha3"}, nil)
+	err := ForEachByStatus(client, StatusQueued, func(job Job) bool {
+		assert.Equal(t, StatusQueued, job.Status)
+		visited = append(visited, job.SHA)
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sha1", "sha2", "sha3"}, visited)
+
+	// Visiting stops as soon as it is asked to.
+	visited = nil
+	client = mockForEachByStatusQuery([]string{"sha1", "sha2", "sha3"}, nil)
+	err = ForEachByStatus(client, StatusQueued, func(job Job) bool {
+		visited = append(visited, job.SHA)
+		return len(visited) < 2
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"sha1", "sha2"}, visited)
+
+	// The query fails.
+	client = mockForEachByStatusQuery(nil, errors.New("this is an error"))
+	err = ForEachByStatus(client, StatusQueued, func(job Job) bool {
+		t.Fatal("no job should have been visited")
+		return false
+	})
+	assert.NotNil(t, err)
+}
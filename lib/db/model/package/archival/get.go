@@ -0,0 +1,59 @@
+package archival
+
+import (
+	"fmt"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+)
+
+// Get fetches the job for the specified package version. Returns nil if no
+// such job exists.
+func Get(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+) (*Job, error) {
+	job := Job{
+		SHA:    sha,
+		Repo:   repo,
+		Author: author,
+	}
+
+	if err := query.Select(
+		columnNameStatus,
+		columnNameSelector,
+		columnNameAttempts,
+		columnNameLastError,
+		columnNameDateUpdated,
+		columnNameDateEnqueued,
+		columnNameDateDeferredUntil).
+		From(tableName).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		And(query.Column(columnNameSHA).Equals(sha)).
+		Limit(1).
+		Create(q).
+		Scan(
+			&job.Status,
+			&job.Selector,
+			&job.Attempts,
+			&job.LastError,
+			&job.DateUpdated,
+			&job.DateEnqueued,
+			&job.DateDeferredUntil); err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"Failed to get archival job for %s/%s@%s: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,29 @@
+package archival
+
+import "time"
+
+// Job is a request to sub-version and archive a specific version of a
+// package. There is at most one job for every author, repo and sha.
+type Job struct {
+	SHA               string
+	Repo              string
+	Author            string
+	Status            string
+	Selector          string
+	Attempts          int
+	LastError         string
+	DateUpdated       time.Time
+	DateEnqueued      time.Time
+	DateDeferredUntil time.Time
+}
+
+// IsDone returns true if the job is never going to be worked on again.
+func (job Job) IsDone() bool {
+	return job.Status == StatusSucceeded || job.Status == StatusFailed
+}
+
+// IsDeferred returns true if the job is waiting in the queue for something
+// other than a worker, such as API quota.
+func (job Job) IsDeferred() bool {
+	return job.Status == StatusQueued && time.Now().Before(job.DateDeferredUntil)
+}
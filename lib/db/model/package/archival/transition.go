@@ -0,0 +1,119 @@
+package archival
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+)
+
+// Claim marks a job as running on behalf of a worker. A job can only be
+// claimed if it is still in the state that the worker last saw it in, so only
+// one worker gets to claim any given job. Returns true if the job was claimed.
+func Claim(q db.Queryable, job Job) (bool, error) {
+	claimed, err := query.Update(tableName).
+		Set(columnNameStatus, StatusRunning).
+		Set(columnNameAttempts, job.Attempts+1).
+		Set(columnNameDateUpdated, time.Now()).
+		Where(query.Column(columnNameAuthor).Equals(job.Author)).
+		And(query.Column(columnNameRepo).Equals(job.Repo)).
+		And(query.Column(columnNameSHA).Equals(job.SHA)).
+		If(query.Column(columnNameStatus).Equals(job.Status)).
+		If(query.Column(columnNameAttempts).Equals(job.Attempts)).
+		Create(q).
+		ExecCAS()
+	if err != nil {
+		return false, fmt.Errorf(
+			"Failed to claim archival job for %s/%s@%s: %v",
+			job.Author,
+			job.Repo,
+			job.SHA,
+			err)
+	}
+
+	return claimed, nil
+}
+
+// Succeed marks a job as successfully completed. Returns false if the job was
+// taken over by another worker in the meantime.
+func Succeed(q db.Queryable, job Job) (bool, error) {
+	return transition(q, job, StatusSucceeded, "")
+}
+
+// Retry puts a job that did not complete successfully back in the queue.
+// Returns false if the job was taken over by another worker in the meantime.
+func Retry(q db.Queryable, job Job, cause error) (bool, error) {
+	return transition(q, job, StatusQueued, cause.Error())
+}
+
+// Fail marks a job as having failed for good. Returns false if the job was
+// taken over by another worker in the meantime.
+func Fail(q db.Queryable, job Job, cause error) (bool, error) {
+	return transition(q, job, StatusFailed, cause.Error())
+}
+
+// Defer puts a running job that was held up by something other than the job
+// itself back in the queue until the specified time. The attempt that was held
+// up does not count against the job. Returns false if the job was taken over
+// by another worker in the meantime.
+func Defer(q db.Queryable, job Job, until time.Time, cause error) (bool, error) {
+	deferred, err := query.Update(tableName).
+		Set(columnNameStatus, StatusQueued).
+		Set(columnNameAttempts, job.Attempts-1).
+		Set(columnNameLastError, cause.Error()).
+		Set(columnNameDateUpdated, time.Now()).
+		Set(columnNameDateDeferredUntil, until).
+		Where(query.Column(columnNameAuthor).Equals(job.Author)).
+		And(query.Column(columnNameRepo).Equals(job.Repo)).
+		And(query.Column(columnNameSHA).Equals(job.SHA)).
+		If(query.Column(columnNameStatus).Equals(StatusRunning)).
+		If(query.Column(columnNameAttempts).Equals(job.Attempts)).
+		Create(q).
+		ExecCAS()
+	if err != nil {
+		return false, fmt.Errorf(
+			"Failed to defer archival job for %s/%s@%s: %v",
+			job.Author,
+			job.Repo,
+			job.SHA,
+			err)
+	}
+
+	return deferred, nil
+}
+
+// transition moves a running job to a new status. Like Claim, it only does so
+// if the job is still in the state that the worker last saw it in: a worker
+// that took too long may have had its job reclaimed by another worker, and
+// must not clobber the outcome of that worker. Returns true if the job was
+// moved.
+func transition(
+	q db.Queryable,
+	job Job,
+	status string,
+	lastError string,
+) (bool, error) {
+	transitioned, err := query.Update(tableName).
+		Set(columnNameStatus, status).
+		Set(columnNameLastError, lastError).
+		Set(columnNameDateUpdated, time.Now()).
+		Where(query.Column(columnNameAuthor).Equals(job.Author)).
+		And(query.Column(columnNameRepo).Equals(job.Repo)).
+		And(query.Column(columnNameSHA).Equals(job.SHA)).
+		If(query.Column(columnNameStatus).Equals(StatusRunning)).
+		If(query.Column(columnNameAttempts).Equals(job.Attempts)).
+		Create(q).
+		ExecCAS()
+	if err != nil {
+		return false, fmt.Errorf(
+			"Failed to mark archival job for %s/%s@%s as %s: %v",
+			job.Author,
+			job.Repo,
+			job.SHA,
+			status,
+			err)
+	}
+
+	return transitioned, nil
+}
@@ -0,0 +1,126 @@
+package archival
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockTransitionQuery mocks the query that moves the archival job of a/b@sha
+// to the specified status, as long as it is still running its second attempt.
+func mockTransitionQuery(
+	status string,
+	lastError string,
+	applied bool,
+	err error,
+) *db.MockClient {
+	var (
+		stmt = `update gophr.package_archival_jobs ` +
+			`set status=?,last_error=?,date_updated=? ` +
+			`where author=? and repo=? and sha=? if status=? and attempts=?`
+		client = db.NewMockClient()
+		query  = db.NewMockQuery()
+	)
+
+	query.On("ExecCAS").Return(applied, err)
+	client.On(
+		"Query",
+		stmt,
+		status,
+		lastError,
+		mock.AnythingOfType("time.Time"),
+		"a",
+		"b",
+		"sha",
+		StatusRunning,
+		2).Return(query)
+
+	return client
+}
+
+func TestTransition(t *testing.T) {
+	job := Job{Author: "a", Repo: "b", SHA: "sha", Status: StatusRunning, Attempts: 2}
+
+	transitioned, err := Succeed(mockTransitionQuery(StatusSucceeded, "", true, nil), job)
+	assert.Nil(t, err)
+	assert.True(t, transitioned)
+
+	transitioned, err = Retry(
+		mockTransitionQuery(StatusQueued, "this is a cause", true, nil),
+		job,
+		errors.New("this is a cause"))
+	assert.Nil(t, err)
+	assert.True(t, transitioned)
+
+	// Jobs that were taken over by another worker are left alone.
+	transitioned, err = Fail(
+		mockTransitionQuery(StatusFailed, "this is a cause", false, nil),
+		job,
+		errors.New("this is a cause"))
+	assert.Nil(t, err)
+	assert.False(t, transitioned)
+
+	transitioned, err = Succeed(
+		mockTransitionQuery(StatusSucceeded, "", false, errors.New("this is an error")),
+		job)
+	assert.NotNil(t, err)
+	assert.False(t, transitioned)
+}
+
+func TestDefer(t *testing.T) {
+	var (
+		job   = Job{Author: "a", Repo: "b", SHA: "sha", Status: StatusRunning, Attempts: 2}
+		until = time.Now().Add(time.Hour)
+		stmt  = `update gophr.package_archival_jobs ` +
+			`set status=?,attempts=?,last_error=?,date_updated=?,date_deferred_until=? ` +
+			`where author=? and repo=? and sha=? if status=? and attempts=?`
+	)
+
+	for _, applied := range []bool{true, false} {
+		client, query := db.NewMockClient(), db.NewMockQuery()
+		query.On("ExecCAS").Return(applied, nil)
+
+		// The attempt that was held up is handed back.
+		client.On(
+			"Query",
+			stmt,
+			StatusQueued,
+			1,
+			"this is a cause",
+			mock.AnythingOfType("time.Time"),
+			until,
+			"a",
+			"b",
+			"sha",
+			StatusRunning,
+			2).Return(query)
+
+		deferred, err := Defer(client, job, until, errors.New("this is a cause"))
+		assert.Nil(t, err)
+		assert.Equal(t, applied, deferred)
+	}
+
+	client, query := db.NewMockClient(), db.NewMockQuery()
+	query.On("ExecCAS").Return(false, errors.New("this is an error"))
+	client.On("Query", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything).Return(query)
+	_, err := Defer(client, job, until, errors.New("this is a cause"))
+	assert.NotNil(t, err)
+}
+
+func TestJobIsDeferred(t *testing.T) {
+	job := Job{Status: StatusQueued, DateDeferredUntil: time.Now().Add(time.Hour)}
+	assert.True(t, job.IsDeferred())
+
+	job.Status = StatusRunning
+	assert.False(t, job.IsDeferred())
+
+	job = Job{Status: StatusQueued, DateDeferredUntil: time.Now().Add(-time.Hour)}
+	assert.False(t, job.IsDeferred())
+	assert.False(t, Job{Status: StatusQueued}.IsDeferred())
+}
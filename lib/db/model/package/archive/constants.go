@@ -1,8 +1,12 @@
 package archives
 
 const (
-	tableName        = "package_archive_records"
-	columnNameSHA    = "sha"
-	columnNameRepo   = "repo"
-	columnNameAuthor = "author"
+	tableName         = "package_archive_records"
+	lockTableName     = "package_archive_locks"
+	columnNameSHA     = "sha"
+	columnNameHash    = "hash"
+	columnNameRepo    = "repo"
+	columnNameOwner   = "owner"
+	columnNameAuthor  = "author"
+	columnNameFailure = "failure"
 )
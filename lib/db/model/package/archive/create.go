@@ -5,20 +5,27 @@ import (
 	"github.com/gophr-pm/gophr/lib/db/query"
 )
 
-// Create records that an archive of a package version exists.
+// Create records that an archive of a package version exists. The hash is the
+// tree hash of the archive (see depot.HashArchive). It is left alone if it is
+// empty, since archives that are found in depot without a record have not been
+// hashed.
 func Create(
 	q db.Queryable,
 	author string,
 	repo string,
 	sha string,
+	hash string,
 ) error {
-	// Execute the first update query. Exit if it fails.
-	if err := query.InsertInto(tableName).
+	insert := query.InsertInto(tableName).
 		Value(columnNameAuthor, author).
 		Value(columnNameRepo, repo).
-		Value(columnNameSHA, sha).
-		Create(q).
-		Exec(); err != nil {
+		Value(columnNameSHA, sha)
+	if len(hash) > 0 {
+		insert = insert.Value(columnNameHash, hash)
+	}
+
+	// Execute the first update query. Exit if it fails.
+	if err := insert.Create(q).Exec(); err != nil {
 		return err
 	}
 
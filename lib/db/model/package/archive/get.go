@@ -0,0 +1,67 @@
+package archives
+
+import (
+	"fmt"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+	"github.com/gophr-pm/gophr/lib/dtos"
+)
+
+// Archive is the record of an archive of a package version.
+type Archive struct {
+	SHA    string
+	Repo   string
+	Hash   string
+	Author string
+}
+
+// ToJSON turns an archive into JSON.
+func (a Archive) ToJSON() ([]byte, error) {
+	dto := dtos.PackageArchive{
+		SHA:    a.SHA,
+		Repo:   a.Repo,
+		Hash:   a.Hash,
+		Author: a.Author,
+	}
+
+	return dto.MarshalJSON()
+}
+
+// Get gets the record of the archive of a package version. Returns nil if the
+// package version was never archived. The hash of the archive is empty if the
+// archive was recorded without one.
+func Get(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+) (*Archive, error) {
+	var hash string
+	if err := query.Select(columnNameHash).
+		From(tableName).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		And(query.Column(columnNameSHA).Equals(sha)).
+		Limit(1).
+		Create(q).
+		Scan(&hash); err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"Failed to get the archive record of %s/%s@%s from the db: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	return &Archive{
+		SHA:    sha,
+		Repo:   repo,
+		Hash:   hash,
+		Author: author,
+	}, nil
+}
@@ -0,0 +1,198 @@
+package archives
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+)
+
+// Lock is the state of the lock that guards the archival of a package
+// version. Locks are leases: unless they are renewed by their owner, they
+// expire so that archival can be taken over elsewhere.
+type Lock struct {
+	// Owner is the identity of whoever is archiving the package version. It is
+	// empty if the lock was released.
+	Owner string
+	// Failure is the reason why the last owner of the lock failed to archive
+	// the package version, if it did.
+	Failure string
+}
+
+// IsHeld returns true if somebody is archiving the package version.
+func (lock Lock) IsHeld() bool {
+	return len(lock.Owner) > 0
+}
+
+// AcquireLock attempts to lock the archival of a package version on behalf of
+// owner for the duration of ttl. Returns true if the lock was acquired. Locks
+// that expired, or that were released after a failure, may be acquired.
+func AcquireLock(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	owner string,
+	ttl time.Duration,
+) (bool, error) {
+	acquired, err := query.InsertInto(lockTableName).
+		Value(columnNameAuthor, author).
+		Value(columnNameRepo, repo).
+		Value(columnNameSHA, sha).
+		Value(columnNameOwner, owner).
+		Value(columnNameFailure, "").
+		IfNotExists().
+		UsingTTL(ttl).
+		Create(q).
+		ExecCAS()
+	if err == nil && !acquired {
+		// Failed owners leave their failure behind for waiters to read, but the
+		// lock itself is free.
+		acquired, err = query.Update(lockTableName).
+			UsingTTL(ttl).
+			Set(columnNameOwner, owner).
+			Set(columnNameFailure, "").
+			Where(query.Column(columnNameAuthor).Equals(author)).
+			And(query.Column(columnNameRepo).Equals(repo)).
+			And(query.Column(columnNameSHA).Equals(sha)).
+			If(query.Column(columnNameOwner).Equals("")).
+			Create(q).
+			ExecCAS()
+	}
+	if err != nil {
+		return false, fmt.Errorf(
+			"Failed to acquire the archival lock of %s/%s@%s: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	return acquired, nil
+}
+
+// RenewLock extends the lease of a lock held by owner by ttl. Returns false if
+// owner no longer holds the lock.
+func RenewLock(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	owner string,
+	ttl time.Duration,
+) (bool, error) {
+	renewed, err := query.Update(lockTableName).
+		UsingTTL(ttl).
+		Set(columnNameOwner, owner).
+		Set(columnNameFailure, "").
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		And(query.Column(columnNameSHA).Equals(sha)).
+		If(query.Column(columnNameOwner).Equals(owner)).
+		Create(q).
+		ExecCAS()
+	if err != nil {
+		return false, fmt.Errorf(
+			"Failed to renew the archival lock of %s/%s@%s: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	return renewed, nil
+}
+
+// ReleaseLock releases a lock held by owner after the package version was
+// archived successfully.
+func ReleaseLock(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	owner string,
+) error {
+	if _, err := query.DeleteRows().
+		From(lockTableName).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		And(query.Column(columnNameSHA).Equals(sha)).
+		If(query.Column(columnNameOwner).Equals(owner)).
+		Create(q).
+		ExecCAS(); err != nil {
+		return fmt.Errorf(
+			"Failed to release the archival lock of %s/%s@%s: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	return nil
+}
+
+// ReleaseLockWithFailure releases a lock held by owner after the package
+// version could not be archived. The failure is kept around for ttl so that
+// waiters can find out what went wrong.
+func ReleaseLockWithFailure(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	owner string,
+	failure string,
+	ttl time.Duration,
+) error {
+	if _, err := query.Update(lockTableName).
+		UsingTTL(ttl).
+		Set(columnNameOwner, "").
+		Set(columnNameFailure, failure).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		And(query.Column(columnNameSHA).Equals(sha)).
+		If(query.Column(columnNameOwner).Equals(owner)).
+		Create(q).
+		ExecCAS(); err != nil {
+		return fmt.Errorf(
+			"Failed to release the archival lock of %s/%s@%s: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	return nil
+}
+
+// GetLock reads the lock of a package version. Returns nil if nobody has
+// attempted to archive the package version recently.
+func GetLock(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+) (*Lock, error) {
+	var lock Lock
+	if err := query.Select(columnNameOwner, columnNameFailure).
+		From(lockTableName).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		And(query.Column(columnNameSHA).Equals(sha)).
+		Limit(1).
+		Create(q).
+		Scan(&lock.Owner, &lock.Failure); err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"Failed to read the archival lock of %s/%s@%s: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	return &lock, nil
+}
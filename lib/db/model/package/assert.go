@@ -8,6 +8,7 @@ import (
 	"github.com/gophr-pm/gophr/lib/db/query"
 	"github.com/gophr-pm/gophr/lib/dtos"
 	"github.com/gophr-pm/gophr/lib/github"
+	"github.com/gophr-pm/gophr/lib/vcs"
 )
 
 // AssertExistence asserts that a package exists.
@@ -48,8 +49,8 @@ func AssertExistence(
 		)
 
 		// Start two workers that get package github metadata, and whether the
-		// package is awesome.
-		wg.Add(2)
+		// package is awesome. Packages hosted elsewhere have no github metadata.
+		wg.Add(1)
 		go checkIfAwesomeAsynchronously(
 			q,
 			author,
@@ -57,13 +58,16 @@ func AssertExistence(
 			&awesome,
 			&awesomeCheckError,
 			&wg)
-		go getGithubRepoDataAsynchronously(
-			ghSvc,
-			author,
-			repo,
-			&repoData,
-			&repoDataFetchError,
-			&wg)
+		if domain, _ := vcs.SplitAuthor(author); domain == vcs.GithubDomain {
+			wg.Add(1)
+			go getGithubRepoDataAsynchronously(
+				ghSvc,
+				author,
+				repo,
+				&repoData,
+				&repoDataFetchError,
+				&wg)
+		}
 
 		// Wait, then handle the outputs.
 		wg.Wait()
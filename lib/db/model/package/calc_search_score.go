@@ -6,24 +6,28 @@ const (
 	searchScoreWeightTotal = starsSearchScoreWeight +
 		awesomeSearchScoreWeight +
 		trendScoreSearchScoreWeight +
-		allTimeDownloadsSearchScoreWeight
+		allTimeDownloadsSearchScoreWeight +
+		dependentsSearchScoreWeight
 	starsSearchScoreWeight            = 5
 	awesomeSearchScoreWeight          = 2
+	dependentsSearchScoreWeight       = 3
 	trendScoreSearchScoreWeight       = 2
 	allTimeDownloadsSearchScoreWeight = 3
 
 	approximateMaxStars            = float32(50000)
+	approximateMaxDependents       = float32(1000)
 	approximateMaxTrendScore       = float32(10)
 	approximateMaxAllTimeDownloads = float32(10000)
 )
 
 // CalcSearchScore calculates the search score of a package given its stars,
-// downloads, awesome and trend score.
+// downloads, awesome, trend score and how many packages depend on it.
 func CalcSearchScore(
 	stars int,
 	allTimeDownloads int,
 	awesome bool,
 	trendScore float32,
+	dependents int,
 ) float32 {
 	var (
 		starsSubScore = baseSubScore *
@@ -33,6 +37,8 @@ func CalcSearchScore(
 		awesomeSubScore          float32
 		allTimeDownloadsSubScore = baseSubScore *
 			(float32(allTimeDownloads) / approximateMaxAllTimeDownloads)
+		dependentsSubScore = baseSubScore *
+			(float32(dependents) / approximateMaxDependents)
 	)
 
 	if awesome {
@@ -42,6 +48,7 @@ func CalcSearchScore(
 	return ((starsSubScore * starsSearchScoreWeight) +
 		(trendSubScore * trendScoreSearchScoreWeight) +
 		(awesomeSubScore * awesomeSearchScoreWeight) +
-		(allTimeDownloadsSubScore * allTimeDownloadsSearchScoreWeight)) /
+		(allTimeDownloadsSubScore * allTimeDownloadsSearchScoreWeight) +
+		(dependentsSubScore * dependentsSearchScoreWeight)) /
 		searchScoreWeightTotal
 }
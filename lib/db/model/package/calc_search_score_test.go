@@ -0,0 +1,30 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcSearchScore(t *testing.T) {
+	assert.Equal(t, float32(0), CalcSearchScore(0, 0, false, 0, 0))
+
+	// Maxing out every metric should max out the score.
+	assert.InDelta(t, baseSubScore, CalcSearchScore(
+		int(approximateMaxStars),
+		int(approximateMaxAllTimeDownloads),
+		true,
+		approximateMaxTrendScore,
+		int(approximateMaxDependents)), 0.001)
+
+	// Dependents should count towards the score by their weight.
+	assert.InDelta(
+		t,
+		float32(baseSubScore*dependentsSearchScoreWeight)/searchScoreWeightTotal,
+		CalcSearchScore(0, 0, false, 0, int(approximateMaxDependents)),
+		0.001)
+	assert.True(
+		t,
+		CalcSearchScore(10, 10, false, 1, 20) > CalcSearchScore(10, 10, false, 1, 10),
+		"more dependents should mean a better score")
+}
@@ -0,0 +1,23 @@
+package dependency
+
+const (
+	tableName                  = "package_dependencies"
+	recordsTableName           = "package_dependency_records"
+	dependentsTableName        = "package_dependents"
+	dependentPackagesTableName = "package_dependent_packages"
+	columnNameSHA              = "sha"
+	columnNameRepo             = "repo"
+	columnNameAuthor           = "author"
+	columnNameDepSHA           = "dep_sha"
+	columnNameDepRepo          = "dep_repo"
+	columnNameDepAuthor        = "dep_author"
+	columnNameDepStrategy      = "dep_strategy"
+	columnNameDepSubpaths      = "dep_subpaths"
+	columnNameDependencies     = "dependencies"
+	columnNameDateRecorded     = "date_recorded"
+	columnNameSubpaths         = "subpaths"
+	columnNameDependent        = "dependent"
+	columnNameDependentSHA     = "dependent_sha"
+	columnNameDependentRepo    = "dependent_repo"
+	columnNameDependentAuthor  = "dependent_author"
+)
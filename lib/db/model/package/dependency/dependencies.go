@@ -0,0 +1,48 @@
+package dependency
+
+import "github.com/gophr-pm/gophr/lib/dtos"
+
+// Dependency is a specific version of a package that another package version
+// depends on.
+type Dependency struct {
+	SHA      string
+	Repo     string
+	Author   string
+	Strategy string
+	Subpaths []string
+}
+
+// Dependencies are the direct dependencies of a package version.
+type Dependencies struct {
+	SHA    string
+	Repo   string
+	Author string
+	List   []Dependency
+}
+
+// toDTO turns dependencies into their most appropriate DTO.
+func (d Dependencies) toDTO() dtos.PackageDependencies {
+	deps := make([]dtos.PackageDependency, len(d.List))
+	for i, dep := range d.List {
+		deps[i] = dtos.PackageDependency{
+			SHA:      dep.SHA,
+			Repo:     dep.Repo,
+			Author:   dep.Author,
+			Strategy: dep.Strategy,
+			Subpaths: dep.Subpaths,
+		}
+	}
+
+	return dtos.PackageDependencies{
+		SHA:          d.SHA,
+		Repo:         d.Repo,
+		Author:       d.Author,
+		Dependencies: deps,
+	}
+}
+
+// ToJSON turns dependencies into JSON.
+func (d Dependencies) ToJSON() ([]byte, error) {
+	dto := d.toDTO()
+	return dto.MarshalJSON()
+}
@@ -0,0 +1,144 @@
+package dependency
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+	"github.com/gophr-pm/gophr/lib/dtos"
+)
+
+// Dependent is a specific version of a package that depends on another
+// package.
+type Dependent struct {
+	SHA    string
+	Repo   string
+	Author string
+	// DependencySHA is the version of the other package that the dependent
+	// depends on.
+	DependencySHA string
+	Subpaths      []string
+}
+
+// Dependents is a page of the dependents of a package.
+type Dependents struct {
+	Repo   string
+	Author string
+	List   []Dependent
+	// Next is the cursor that the next page starts after. It is empty if there
+	// are no more pages.
+	Next string
+}
+
+// toDTO turns dependents into their most appropriate DTO.
+func (d Dependents) toDTO() dtos.PackageDependents {
+	dependents := make([]dtos.PackageDependent, len(d.List))
+	for i, dependent := range d.List {
+		dependents[i] = dtos.PackageDependent{
+			SHA:           dependent.SHA,
+			Repo:          dependent.Repo,
+			Author:        dependent.Author,
+			Subpaths:      dependent.Subpaths,
+			DependencySHA: dependent.DependencySHA,
+		}
+	}
+
+	return dtos.PackageDependents{
+		Next:       d.Next,
+		Repo:       d.Repo,
+		Author:     d.Author,
+		Dependents: dependents,
+	}
+}
+
+// ToJSON turns dependents into JSON.
+func (d Dependents) ToJSON() ([]byte, error) {
+	dto := d.toDTO()
+	return dto.MarshalJSON()
+}
+
+// GetDependents gets up to "limit" package versions that depend on a package,
+// in order. The page starts after the "after" cursor, or at the beginning if
+// the cursor is empty.
+func GetDependents(
+	q db.Queryable,
+	author string,
+	repo string,
+	after string,
+	limit int,
+) (Dependents, error) {
+	if limit < 1 {
+		return Dependents{}, errors.New("Limit must be greater than zero")
+	}
+
+	qb := query.Select(
+		columnNameDependentAuthor,
+		columnNameDependentRepo,
+		columnNameDependentSHA,
+		columnNameSHA,
+		columnNameSubpaths).
+		From(dependentsTableName).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo))
+	if len(after) > 0 {
+		qb = qb.And(query.Column(columnNameDependent).IsGreaterThan(after))
+	}
+
+	// Read one extra dependent to find out whether there is another page.
+	iter := qb.Limit(limit + 1).Create(q).Iter()
+
+	var (
+		dependent  Dependent
+		dependents = Dependents{Repo: repo, Author: author}
+	)
+
+	for iter.Scan(
+		&dependent.Author,
+		&dependent.Repo,
+		&dependent.SHA,
+		&dependent.DependencySHA,
+		&dependent.Subpaths) {
+		dependents.List = append(dependents.List, dependent)
+	}
+
+	if err := iter.Close(); err != nil {
+		return Dependents{}, fmt.Errorf(
+			"Failed to get the dependents of %s/%s from the db: %v",
+			author,
+			repo,
+			err)
+	}
+
+	if len(dependents.List) > limit {
+		last := dependents.List[limit-1]
+		dependents.List = dependents.List[:limit]
+		dependents.Next = Node{
+			SHA:    last.SHA,
+			Repo:   last.Repo,
+			Author: last.Author,
+		}.ID()
+	}
+
+	return dependents, nil
+}
+
+// CountDependents counts the packages that have at least one version that
+// depends on a package.
+func CountDependents(q db.Queryable, author, repo string) (int, error) {
+	var count int
+	if err := query.SelectCount().
+		From(dependentPackagesTableName).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		Create(q).
+		Scan(&count); err != nil {
+		return 0, fmt.Errorf(
+			"Failed to count the dependents of %s/%s: %v",
+			author,
+			repo,
+			err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,114 @@
+package dependency
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockDependentsQuery mocks the query that reads the dependents of a/b,
+// yielding the specified rows.
+func mockDependentsQuery(
+	stmt string,
+	values []interface{},
+	rows []Dependent,
+	err error,
+) *db.MockClient {
+	var (
+		client = db.NewMockClient()
+		query  = db.NewMockQuery()
+		iter   = db.NewMockResultsIterator()
+		scan   = []interface{}{
+			mock.Anything,
+			mock.Anything,
+			mock.Anything,
+			mock.Anything,
+			mock.Anything,
+		}
+	)
+
+	for _, row := range rows {
+		row := row
+		iter.On("Scan", scan...).Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = row.Author
+			*args.Get(1).(*string) = row.Repo
+			*args.Get(2).(*string) = row.SHA
+			*args.Get(3).(*string) = row.DependencySHA
+			*args.Get(4).(*[]string) = row.Subpaths
+		}).Return(true).Once()
+	}
+	iter.On("Scan", scan...).Return(false)
+	iter.On("Close").Return(err)
+	query.On("Iter").Return(iter)
+	client.On("Query", append([]interface{}{stmt}, values...)...).Return(query)
+
+	return client
+}
+
+func TestGetDependents(t *testing.T) {
+	var (
+		firstPageStmt = `select dependent_author,dependent_repo,dependent_sha,sha,subpaths ` +
+			`from gophr.package_dependents where author=? and repo=? limit 3`
+		nextPageStmt = `select dependent_author,dependent_repo,dependent_sha,sha,subpaths ` +
+			`from gophr.package_dependents where author=? and repo=? and dependent>? limit 3`
+		rows = []Dependent{
+			{Author: "c", Repo: "d", SHA: "sha1", DependencySHA: "dep1", Subpaths: []string{"/x"}},
+			{Author: "e", Repo: "f", SHA: "sha2", DependencySHA: "dep1"},
+			{Author: "g", Repo: "h", SHA: "sha3", DependencySHA: "dep2"},
+		}
+	)
+
+	// A full page should point at the page after it.
+	client := mockDependentsQuery(firstPageStmt, []interface{}{"a", "b"}, rows, nil)
+	dependents, err := GetDependents(client, "a", "b", "", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, Dependents{
+		Repo:   "b",
+		Author: "a",
+		List:   rows[:2],
+		Next:   "github.com/e/f@sha2",
+	}, dependents)
+	client.AssertExpectations(t)
+
+	// The last page should not.
+	client = mockDependentsQuery(
+		nextPageStmt,
+		[]interface{}{"a", "b", "github.com/e/f@sha2"},
+		rows[2:],
+		nil)
+	dependents, err = GetDependents(client, "a", "b", "github.com/e/f@sha2", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, Dependents{Repo: "b", Author: "a", List: rows[2:]}, dependents)
+	client.AssertExpectations(t)
+
+	client = mockDependentsQuery(firstPageStmt, []interface{}{"a", "b"}, nil, errors.New("this is an error"))
+	_, err = GetDependents(client, "a", "b", "", 2)
+	assert.NotNil(t, err)
+
+	_, err = GetDependents(db.NewMockClient(), "a", "b", "", 0)
+	assert.NotNil(t, err)
+}
+
+func TestCountDependents(t *testing.T) {
+	stmt := `select count(*) from gophr.package_dependent_packages where author=? and repo=?`
+
+	client, query := db.NewMockClient(), db.NewMockQuery()
+	client.On("Query", stmt, "a", "b").Return(query)
+	query.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*int) = 42
+	}).Return(nil)
+
+	count, err := CountDependents(client, "a", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, 42, count)
+
+	client, query = db.NewMockClient(), db.NewMockQuery()
+	client.On("Query", stmt, "a", "b").Return(query)
+	query.On("Scan", mock.Anything).Return(errors.New("this is an error"))
+
+	_, err = CountDependents(client, "a", "b")
+	assert.NotNil(t, err)
+}
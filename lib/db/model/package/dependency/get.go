@@ -0,0 +1,81 @@
+package dependency
+
+import (
+	"fmt"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+)
+
+// Get gets the dependencies of a package version. Returns nil if the
+// dependencies of the package version were never recorded.
+func Get(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+) (*Dependencies, error) {
+	var count int
+	if err := query.Select(columnNameDependencies).
+		From(recordsTableName).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		And(query.Column(columnNameSHA).Equals(sha)).
+		Limit(1).
+		Create(q).
+		Scan(&count); err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"Failed to get the dependency record of %s/%s@%s from the db: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	iter := query.Select(
+		columnNameDepAuthor,
+		columnNameDepRepo,
+		columnNameDepSHA,
+		columnNameDepStrategy,
+		columnNameDepSubpaths).
+		From(tableName).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		And(query.Column(columnNameSHA).Equals(sha)).
+		Create(q).
+		Iter()
+
+	var (
+		dep  Dependency
+		deps = &Dependencies{
+			SHA:    sha,
+			Repo:   repo,
+			Author: author,
+			List:   make([]Dependency, 0, count),
+		}
+	)
+
+	for iter.Scan(
+		&dep.Author,
+		&dep.Repo,
+		&dep.SHA,
+		&dep.Strategy,
+		&dep.Subpaths) {
+		deps.List = append(deps.List, dep)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf(
+			"Failed to get the dependencies of %s/%s@%s from the db: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	return deps, nil
+}
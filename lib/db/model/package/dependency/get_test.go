@@ -0,0 +1,106 @@
+package dependency
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockGetQueries mocks the queries that Get makes to read the dependencies of
+// the specified package version. Package versions without any dependencies are
+// recorded as having none, unless recorded is false.
+func mockGetQueries(
+	client *db.MockClient,
+	author string,
+	repo string,
+	sha string,
+	recorded bool,
+	deps []Dependency,
+	err error,
+) {
+	var (
+		recordStmt = `select dependencies from gophr.package_dependency_records ` +
+			`where author=? and repo=? and sha=? limit 1`
+		depsStmt = `select dep_author,dep_repo,dep_sha,dep_strategy,dep_subpaths ` +
+			`from gophr.package_dependencies where author=? and repo=? and sha=?`
+		recordQuery = db.NewMockQuery()
+		depsQuery   = db.NewMockQuery()
+		iter        = db.NewMockResultsIterator()
+		scan        = []interface{}{
+			mock.Anything,
+			mock.Anything,
+			mock.Anything,
+			mock.Anything,
+			mock.Anything,
+		}
+	)
+
+	if recorded {
+		recordQuery.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+			*args.Get(0).(*int) = len(deps)
+		}).Return(nil)
+	} else {
+		recordQuery.On("Scan", mock.Anything).Return(gocql.ErrNotFound)
+	}
+
+	for _, dep := range deps {
+		dep := dep
+		iter.On("Scan", scan...).Run(func(args mock.Arguments) {
+			*args.Get(0).(*string) = dep.Author
+			*args.Get(1).(*string) = dep.Repo
+			*args.Get(2).(*string) = dep.SHA
+			*args.Get(3).(*string) = dep.Strategy
+			*args.Get(4).(*[]string) = dep.Subpaths
+		}).Return(true).Once()
+	}
+	iter.On("Scan", scan...).Return(false)
+	iter.On("Close").Return(err)
+	depsQuery.On("Iter").Return(iter)
+
+	client.On("Query", recordStmt, author, repo, sha).Return(recordQuery)
+	client.On("Query", depsStmt, author, repo, sha).Return(depsQuery)
+}
+
+func TestGet(t *testing.T) {
+	deps := []Dependency{
+		{Author: "c", Repo: "d", SHA: "sha1", Strategy: "tagged-release", Subpaths: []string{""}},
+		{Author: "e", Repo: "f", SHA: "sha2", Strategy: "commit-date", Subpaths: []string{"/x"}},
+	}
+
+	// Recorded dependencies are read in full.
+	client := db.NewMockClient()
+	mockGetQueries(client, "a", "b", "sha", true, deps, nil)
+	actual, err := Get(client, "a", "b", "sha")
+	assert.Nil(t, err)
+	assert.Equal(t, &Dependencies{
+		SHA:    "sha",
+		Repo:   "b",
+		Author: "a",
+		List:   deps,
+	}, actual)
+
+	// Package versions without dependencies have an empty list of them.
+	client = db.NewMockClient()
+	mockGetQueries(client, "a", "b", "sha", true, nil, nil)
+	actual, err = Get(client, "a", "b", "sha")
+	assert.Nil(t, err)
+	assert.NotNil(t, actual)
+	assert.Empty(t, actual.List)
+
+	// Dependencies that were never recorded don't exist.
+	client = db.NewMockClient()
+	mockGetQueries(client, "a", "b", "sha", false, nil, nil)
+	actual, err = Get(client, "a", "b", "sha")
+	assert.Nil(t, err)
+	assert.Nil(t, actual)
+
+	// The dependencies can't be read.
+	client = db.NewMockClient()
+	mockGetQueries(client, "a", "b", "sha", true, deps, errors.New("this is an error"))
+	_, err = Get(client, "a", "b", "sha")
+	assert.NotNil(t, err)
+}
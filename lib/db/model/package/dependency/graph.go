@@ -0,0 +1,213 @@
+package dependency
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/dtos"
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+// dotRootSubpathLabel is how the root of a package is labelled in DOT graphs.
+const dotRootSubpathLabel = "/"
+
+// Node is a package version in a dependency graph.
+type Node struct {
+	SHA    string
+	Repo   string
+	Author string
+	// Recorded is true if the dependencies of the package version are in the
+	// graph. They are not if they were never recorded (e.g. because the package
+	// version has not been archived yet), or if they were not read.
+	Recorded bool
+}
+
+// ID uniquely identifies the node in its graph (e.g.
+// "github.com/author/repo@sha").
+func (node Node) ID() string {
+	return vcs.RepoPath(node.Author, node.Repo) + "@" + node.SHA
+}
+
+// Edge is a dependency of one package version on another in a dependency
+// graph.
+type Edge struct {
+	To       string
+	From     string
+	Strategy string
+	Subpaths []string
+}
+
+// Graph is the transitive dependency graph of a package version.
+type Graph struct {
+	// Root is the ID of the package version that the graph starts from.
+	Root  string
+	Nodes []Node
+	Edges []Edge
+	// Truncated is true if the graph was too big to be read in its entirety.
+	Truncated bool
+}
+
+// ToGraph turns direct dependencies into a dependency graph that is one level
+// deep.
+func (d Dependencies) ToGraph() *Graph {
+	var (
+		root  = Node{SHA: d.SHA, Repo: d.Repo, Author: d.Author, Recorded: true}
+		graph = &Graph{Root: root.ID(), Nodes: []Node{root}}
+	)
+
+	for _, dep := range d.List {
+		node := Node{SHA: dep.SHA, Repo: dep.Repo, Author: dep.Author}
+		graph.Nodes = append(graph.Nodes, node)
+		graph.Edges = append(graph.Edges, Edge{
+			To:       node.ID(),
+			From:     graph.Root,
+			Strategy: dep.Strategy,
+			Subpaths: dep.Subpaths,
+		})
+	}
+
+	return graph
+}
+
+// GetGraph gets the transitive dependency graph of a package version, reading
+// at most maxNodes package versions. Returns nil if the dependencies of the
+// package version were never recorded.
+func GetGraph(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	maxNodes int,
+) (*Graph, error) {
+	root, err := Get(q, author, repo, sha)
+	if err != nil || root == nil {
+		return nil, err
+	}
+
+	var (
+		rootNode = Node{SHA: sha, Repo: repo, Author: author, Recorded: true}
+		graph    = &Graph{Root: rootNode.ID(), Nodes: []Node{rootNode}}
+		visited  = map[string]bool{rootNode.ID(): true}
+		frontier = []*Dependencies{root}
+	)
+
+	// Walk the graph breadth-first so that truncated graphs are as shallow as
+	// possible.
+	for len(frontier) > 0 {
+		deps := frontier[0]
+		frontier = frontier[1:]
+
+		from := Node{SHA: deps.SHA, Repo: deps.Repo, Author: deps.Author}.ID()
+		for _, dep := range deps.List {
+			node := Node{SHA: dep.SHA, Repo: dep.Repo, Author: dep.Author}
+			if !visited[node.ID()] {
+				if len(graph.Nodes) >= maxNodes {
+					graph.Truncated = true
+					continue
+				}
+
+				next, err := Get(q, dep.Author, dep.Repo, dep.SHA)
+				if err != nil {
+					return nil, err
+				} else if next != nil {
+					node.Recorded = true
+					frontier = append(frontier, next)
+				}
+
+				visited[node.ID()] = true
+				graph.Nodes = append(graph.Nodes, node)
+			}
+
+			graph.Edges = append(graph.Edges, Edge{
+				To:       node.ID(),
+				From:     from,
+				Strategy: dep.Strategy,
+				Subpaths: dep.Subpaths,
+			})
+		}
+	}
+
+	return graph, nil
+}
+
+// toDTO turns a graph into its most appropriate DTO.
+func (g Graph) toDTO() dtos.PackageDependencyGraph {
+	var (
+		nodes = make([]dtos.PackageDependencyGraphNode, len(g.Nodes))
+		edges = make([]dtos.PackageDependencyGraphEdge, len(g.Edges))
+	)
+
+	for i, node := range g.Nodes {
+		nodes[i] = dtos.PackageDependencyGraphNode{
+			ID:       node.ID(),
+			SHA:      node.SHA,
+			Repo:     node.Repo,
+			Author:   node.Author,
+			Recorded: node.Recorded,
+		}
+	}
+
+	for i, edge := range g.Edges {
+		edges[i] = dtos.PackageDependencyGraphEdge{
+			To:       edge.To,
+			From:     edge.From,
+			Strategy: edge.Strategy,
+			Subpaths: edge.Subpaths,
+		}
+	}
+
+	return dtos.PackageDependencyGraph{
+		Root:      g.Root,
+		Nodes:     nodes,
+		Edges:     edges,
+		Truncated: g.Truncated,
+	}
+}
+
+// ToJSON turns a graph into JSON.
+func (g Graph) ToJSON() ([]byte, error) {
+	dto := g.toDTO()
+	return dto.MarshalJSON()
+}
+
+// ToDOT turns a graph into the DOT language of Graphviz. Edges are labelled
+// with the sub-packages that they import. Package versions whose dependencies
+// are not in the graph are dashed.
+func (g Graph) ToDOT() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString("digraph ")
+	buffer.WriteString(strconv.Quote(g.Root))
+	buffer.WriteString(" {\n")
+
+	for _, node := range g.Nodes {
+		buffer.WriteString("  ")
+		buffer.WriteString(strconv.Quote(node.ID()))
+		if !node.Recorded {
+			buffer.WriteString(" [style=dashed]")
+		}
+		buffer.WriteString(";\n")
+	}
+
+	for _, edge := range g.Edges {
+		labels := make([]string, len(edge.Subpaths))
+		for i, subpath := range edge.Subpaths {
+			if labels[i] = subpath; len(subpath) < 1 {
+				labels[i] = dotRootSubpathLabel
+			}
+		}
+
+		buffer.WriteString("  ")
+		buffer.WriteString(strconv.Quote(edge.From))
+		buffer.WriteString(" -> ")
+		buffer.WriteString(strconv.Quote(edge.To))
+		buffer.WriteString(" [label=")
+		buffer.WriteString(strconv.Quote(strings.Join(labels, "\n")))
+		buffer.WriteString("];\n")
+	}
+
+	buffer.WriteString("}\n")
+
+	return buffer.Bytes()
+}
@@ -0,0 +1,84 @@
+package dependency
+
+import (
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGraph(t *testing.T) {
+	// a/b depends on c/d and e/f, c/d depends on e/f and g/h, and nothing was
+	// recorded for g/h.
+	newClient := func() *db.MockClient {
+		client := db.NewMockClient()
+		mockGetQueries(client, "a", "b", "sha", true, []Dependency{
+			{Author: "c", Repo: "d", SHA: "sha1", Strategy: "tagged-release", Subpaths: []string{""}},
+			{Author: "e", Repo: "f", SHA: "sha2", Strategy: "commit-date", Subpaths: []string{"/x"}},
+		}, nil)
+		mockGetQueries(client, "c", "d", "sha1", true, []Dependency{
+			{Author: "e", Repo: "f", SHA: "sha2", Strategy: "commit-date", Subpaths: []string{""}},
+			{Author: "g", Repo: "h", SHA: "sha3", Strategy: "commit-date", Subpaths: []string{""}},
+		}, nil)
+		mockGetQueries(client, "e", "f", "sha2", true, nil, nil)
+		mockGetQueries(client, "g", "h", "sha3", false, nil, nil)
+		return client
+	}
+
+	// Every package version is visited once, breadth-first.
+	graph, err := GetGraph(newClient(), "a", "b", "sha", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, &Graph{
+		Root: "github.com/a/b@sha",
+		Nodes: []Node{
+			{SHA: "sha", Repo: "b", Author: "a", Recorded: true},
+			{SHA: "sha1", Repo: "d", Author: "c", Recorded: true},
+			{SHA: "sha2", Repo: "f", Author: "e", Recorded: true},
+			{SHA: "sha3", Repo: "h", Author: "g"},
+		},
+		Edges: []Edge{
+			{To: "github.com/c/d@sha1", From: "github.com/a/b@sha", Strategy: "tagged-release", Subpaths: []string{""}},
+			{To: "github.com/e/f@sha2", From: "github.com/a/b@sha", Strategy: "commit-date", Subpaths: []string{"/x"}},
+			{To: "github.com/e/f@sha2", From: "github.com/c/d@sha1", Strategy: "commit-date", Subpaths: []string{""}},
+			{To: "github.com/g/h@sha3", From: "github.com/c/d@sha1", Strategy: "commit-date", Subpaths: []string{""}},
+		},
+	}, graph)
+
+	// Graphs that are too big are truncated, but keep the edges between the
+	// package versions that made it in.
+	graph, err = GetGraph(newClient(), "a", "b", "sha", 2)
+	assert.Nil(t, err)
+	assert.True(t, graph.Truncated)
+	assert.Equal(t, []Node{
+		{SHA: "sha", Repo: "b", Author: "a", Recorded: true},
+		{SHA: "sha1", Repo: "d", Author: "c", Recorded: true},
+	}, graph.Nodes)
+	assert.Len(t, graph.Edges, 1)
+
+	// Package versions that were never recorded have no graph.
+	client := db.NewMockClient()
+	mockGetQueries(client, "a", "b", "sha", false, nil, nil)
+	graph, err = GetGraph(client, "a", "b", "sha", 10)
+	assert.Nil(t, err)
+	assert.Nil(t, graph)
+}
+
+func TestGraphToDOT(t *testing.T) {
+	graph := Graph{
+		Root: "github.com/a/b@sha",
+		Nodes: []Node{
+			{SHA: "sha", Repo: "b", Author: "a", Recorded: true},
+			{SHA: "sha1", Repo: "d", Author: "c"},
+		},
+		Edges: []Edge{
+			{To: "github.com/c/d@sha1", From: "github.com/a/b@sha", Subpaths: []string{"", "/x"}},
+		},
+	}
+
+	assert.Equal(t, `digraph "github.com/a/b@sha" {
+  "github.com/a/b@sha";
+  "github.com/c/d@sha1" [style=dashed];
+  "github.com/a/b@sha" -> "github.com/c/d@sha1" [label="/\n/x"];
+}
+`, string(graph.ToDOT()))
+}
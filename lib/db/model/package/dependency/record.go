@@ -0,0 +1,106 @@
+package dependency
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+// Record records the dependencies of a package version, and indexes the
+// package version as a dependent of each of them. Since package versions never
+// change, their dependencies only need to be recorded once. Every write is
+// kept to a single partition; the package version is only marked as recorded
+// once all of them have succeeded, so a failed record can simply be retried.
+func Record(
+	q db.BatchingQueryable,
+	author string,
+	repo string,
+	sha string,
+	deps []Dependency,
+) error {
+	// The dependencies of a package version share a partition.
+	if len(deps) > 0 {
+		b := q.NewUnloggedBatch()
+		for _, dep := range deps {
+			query.InsertInto(tableName).
+				Value(columnNameAuthor, author).
+				Value(columnNameRepo, repo).
+				Value(columnNameSHA, sha).
+				Value(columnNameDepAuthor, dep.Author).
+				Value(columnNameDepRepo, dep.Repo).
+				Value(columnNameDepSHA, dep.SHA).
+				Value(columnNameDepStrategy, dep.Strategy).
+				Value(columnNameDepSubpaths, dep.Subpaths).
+				AppendTo(b)
+		}
+
+		if err := b.Execute(); err != nil {
+			return fmt.Errorf(
+				"Failed to record the dependencies of %s/%s@%s: %v",
+				author,
+				repo,
+				sha,
+				err)
+		}
+	}
+
+	// The dependents of a dependency share a partition too, but every
+	// dependency has its own.
+	for _, dep := range deps {
+		b := q.NewUnloggedBatch()
+		query.InsertInto(dependentsTableName).
+			Value(columnNameAuthor, dep.Author).
+			Value(columnNameRepo, dep.Repo).
+			Value(columnNameDependent, Node{
+				SHA:    sha,
+				Repo:   repo,
+				Author: author,
+			}.ID()).
+			Value(columnNameDependentAuthor, author).
+			Value(columnNameDependentRepo, repo).
+			Value(columnNameDependentSHA, sha).
+			Value(columnNameSHA, dep.SHA).
+			Value(columnNameSubpaths, dep.Subpaths).
+			AppendTo(b)
+		query.InsertInto(dependentPackagesTableName).
+			Value(columnNameAuthor, dep.Author).
+			Value(columnNameRepo, dep.Repo).
+			Value(columnNameDependentAuthor, author).
+			Value(columnNameDependentRepo, repo).
+			AppendTo(b)
+
+		if err := b.Execute(); err != nil {
+			return fmt.Errorf(
+				"Failed to index %s/%s@%s as a dependent of %s: %v",
+				author,
+				repo,
+				sha,
+				vcs.RepoPath(dep.Author, dep.Repo),
+				err)
+		}
+	}
+
+	// Mark the dependencies as recorded so that package versions without any
+	// dependencies can be told apart from package versions that were never
+	// recorded.
+	if err := query.InsertInto(recordsTableName).
+		Value(columnNameAuthor, author).
+		Value(columnNameRepo, repo).
+		Value(columnNameSHA, sha).
+		Value(columnNameDependencies, len(deps)).
+		Value(columnNameDateRecorded, time.Now()).
+		Create(q).
+		Exec(); err != nil {
+		return fmt.Errorf(
+			"Failed to mark the dependencies of %s/%s@%s as recorded: %v",
+			author,
+			repo,
+			sha,
+			err)
+	}
+
+	return nil
+}
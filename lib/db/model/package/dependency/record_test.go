@@ -0,0 +1,157 @@
+package dependency
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testDependencyStmt = `insert into gophr.package_dependencies ` +
+		`(author,repo,sha,dep_author,dep_repo,dep_sha,dep_strategy,dep_subpaths) ` +
+		`values (?,?,?,?,?,?,?,?)`
+	testDependentStmt = `insert into gophr.package_dependents ` +
+		`(author,repo,dependent,dependent_author,dependent_repo,dependent_sha,sha,subpaths) ` +
+		`values (?,?,?,?,?,?,?,?)`
+	testDependentPackageStmt = `insert into gophr.package_dependent_packages ` +
+		`(author,repo,dependent_author,dependent_repo) values (?,?,?,?)`
+	testRecordStmt = `insert into gophr.package_dependency_records ` +
+		`(author,repo,sha,dependencies,date_recorded) values (?,?,?,?,?)`
+)
+
+// mockRecordBatches mocks a batch for every partition that Record writes to
+// while recording the dependencies of a/b@sha, and returns them in the order
+// that they are written to.
+func mockRecordBatches(client *db.MockClient, deps []Dependency) []*db.MockBatch {
+	var batches []*db.MockBatch
+	newBatch := func() *db.MockBatch {
+		batch := db.NewMockBatch()
+		client.On("NewUnloggedBatch").Return(batch).Once()
+		batches = append(batches, batch)
+		return batch
+	}
+
+	if len(deps) > 0 {
+		batch := newBatch()
+		for _, dep := range deps {
+			batch.On(
+				"Query",
+				testDependencyStmt,
+				"a",
+				"b",
+				"sha",
+				dep.Author,
+				dep.Repo,
+				dep.SHA,
+				dep.Strategy,
+				dep.Subpaths)
+		}
+	}
+
+	for _, dep := range deps {
+		batch := newBatch()
+		batch.On(
+			"Query",
+			testDependentStmt,
+			dep.Author,
+			dep.Repo,
+			"github.com/a/b@sha",
+			"a",
+			"b",
+			"sha",
+			dep.SHA,
+			dep.Subpaths)
+		batch.On(
+			"Query",
+			testDependentPackageStmt,
+			dep.Author,
+			dep.Repo,
+			"a",
+			"b")
+	}
+
+	return batches
+}
+
+// mockRecordQuery mocks the query that marks the dependencies of a/b@sha as
+// recorded.
+func mockRecordQuery(client *db.MockClient, count int, err error) *db.MockQuery {
+	query := db.NewMockQuery()
+	query.On("Exec").Return(err)
+	client.On(
+		"Query",
+		testRecordStmt,
+		"a",
+		"b",
+		"sha",
+		count,
+		mock.AnythingOfType("time.Time")).Return(query)
+
+	return query
+}
+
+func TestRecord(t *testing.T) {
+	deps := []Dependency{
+		{Author: "c", Repo: "d", SHA: "sha1", Strategy: "tagged-release", Subpaths: []string{""}},
+		{Author: "e", Repo: "f", SHA: "sha2", Strategy: "commit-date", Subpaths: []string{"/x"}},
+	}
+
+	// Every partition is written to on its own, and the package version is
+	// marked as recorded last.
+	client := db.NewMockClient()
+	batches := mockRecordBatches(client, deps)
+	for _, batch := range batches {
+		batch.On("Execute").Return(nil)
+	}
+	query := mockRecordQuery(client, 2, nil)
+	assert.Nil(t, Record(client, "a", "b", "sha", deps))
+	assert.Len(t, batches, 3)
+	for _, batch := range batches {
+		batch.AssertExpectations(t)
+	}
+	query.AssertExpectations(t)
+	client.AssertExpectations(t)
+
+	// Package versions without dependencies are only marked as recorded.
+	client = db.NewMockClient()
+	query = mockRecordQuery(client, 0, nil)
+	assert.Nil(t, Record(client, "a", "b", "sha", nil))
+	query.AssertExpectations(t)
+	client.AssertNotCalled(t, "NewUnloggedBatch")
+
+	// Package versions are not marked as recorded if their dependents can't be
+	// indexed.
+	client = db.NewMockClient()
+	batches = mockRecordBatches(client, deps)
+	batches[0].On("Execute").Return(nil)
+	batches[1].On("Execute").Return(errors.New("this is an error"))
+	assert.NotNil(t, Record(client, "a", "b", "sha", deps))
+	client.AssertNotCalled(
+		t,
+		"Query",
+		testRecordStmt,
+		"a",
+		"b",
+		"sha",
+		2,
+		mock.AnythingOfType("time.Time"))
+
+	// Or if their dependencies can't be written.
+	client = db.NewMockClient()
+	batches = mockRecordBatches(client, deps)
+	batches[0].On("Execute").Return(errors.New("this is an error"))
+	assert.NotNil(t, Record(client, "a", "b", "sha", deps))
+	batches[1].AssertNotCalled(t, "Execute")
+
+	// Failing to mark them as recorded fails too.
+	client = db.NewMockClient()
+	batches = mockRecordBatches(client, deps)
+	for _, batch := range batches {
+		batch.On("Execute").Return(nil)
+	}
+	mockRecordQuery(client, 2, errors.New("this is an error"))
+	assert.NotNil(t, Record(client, "a", "b", "sha", deps))
+}
@@ -29,7 +29,7 @@ func Insert(args InsertArgs) error {
 		Value(packagesColumnNameTrendScore, float32(0)).
 		Value(
 			packagesColumnNameSearchScore,
-			CalcSearchScore(args.Stars, 0, args.Awesome, 0)).
+			CalcSearchScore(args.Stars, 0, args.Awesome, 0, 0)).
 		Value(packagesColumnNameDescription, args.Description).
 		Value(packagesColumnNameDateDiscovered, time.Now()).
 		Value(
@@ -0,0 +1,13 @@
+package label
+
+const (
+	tableName              = "package_version_labels"
+	mutationsTableName     = "package_version_label_mutations"
+	columnNameSHA          = "sha"
+	columnNameRepo         = "repo"
+	columnNameLabel        = "label"
+	columnNameAuthor       = "author"
+	columnNameDatePinned   = "date_pinned"
+	columnNameUpstreamSHA  = "upstream_sha"
+	columnNameDateDetected = "date_detected"
+)
@@ -0,0 +1,123 @@
+package label
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+	"github.com/gophr-pm/gophr/lib/dtos"
+)
+
+// Mutation is an event in which a pinned version label of a package was moved
+// upstream (e.g. a tag that was force-pushed to another commit).
+type Mutation struct {
+	// SHA is the SHA that the label is pinned to, and still resolves to.
+	SHA   string
+	Label string
+	// UpstreamSHA is the SHA that the label was moved to upstream.
+	UpstreamSHA  string
+	DateDetected time.Time
+}
+
+// Mutations are the mutations of the version labels of a package.
+type Mutations struct {
+	Repo   string
+	Author string
+	List   []Mutation
+}
+
+// ToJSON turns mutations into JSON.
+func (m Mutations) ToJSON() ([]byte, error) {
+	mutations := make([]dtos.PackageVersionLabelMutation, len(m.List))
+	for i, mutation := range m.List {
+		mutations[i] = dtos.PackageVersionLabelMutation{
+			SHA:          mutation.SHA,
+			Label:        mutation.Label,
+			UpstreamSHA:  mutation.UpstreamSHA,
+			DateDetected: mutation.DateDetected,
+		}
+	}
+
+	dto := dtos.PackageVersionLabelMutations{
+		Repo:      m.Repo,
+		Author:    m.Author,
+		Mutations: mutations,
+	}
+
+	return dto.MarshalJSON()
+}
+
+// RecordMutation records that a pinned version label of a package was moved
+// upstream. Returns true if the mutation had not been recorded before.
+func RecordMutation(
+	q db.Queryable,
+	author string,
+	repo string,
+	label string,
+	sha string,
+	upstreamSHA string,
+) (bool, error) {
+	recorded, err := query.InsertInto(mutationsTableName).
+		Value(columnNameAuthor, author).
+		Value(columnNameRepo, repo).
+		Value(columnNameLabel, label).
+		Value(columnNameUpstreamSHA, upstreamSHA).
+		Value(columnNameSHA, sha).
+		Value(columnNameDateDetected, time.Now()).
+		IfNotExists().
+		Create(q).
+		ExecCAS()
+	if err != nil {
+		return false, fmt.Errorf(
+			"Failed to record that %s/%s@%s moved to %s: %v",
+			author,
+			repo,
+			label,
+			upstreamSHA,
+			err)
+	}
+
+	return recorded, nil
+}
+
+// GetMutations gets every recorded mutation of the version labels of a
+// package, ordered by label.
+func GetMutations(
+	q db.Queryable,
+	author string,
+	repo string,
+) (Mutations, error) {
+	var (
+		mutation  Mutation
+		mutations = Mutations{Repo: repo, Author: author}
+		iter      = query.Select(
+			columnNameLabel,
+			columnNameSHA,
+			columnNameUpstreamSHA,
+			columnNameDateDetected).
+			From(mutationsTableName).
+			Where(query.Column(columnNameAuthor).Equals(author)).
+			And(query.Column(columnNameRepo).Equals(repo)).
+			Create(q).
+			Iter()
+	)
+
+	for iter.Scan(
+		&mutation.Label,
+		&mutation.SHA,
+		&mutation.UpstreamSHA,
+		&mutation.DateDetected) {
+		mutations.List = append(mutations.List, mutation)
+	}
+
+	if err := iter.Close(); err != nil {
+		return Mutations{}, fmt.Errorf(
+			"Failed to get the version label mutations of %s/%s: %v",
+			author,
+			repo,
+			err)
+	}
+
+	return mutations, nil
+}
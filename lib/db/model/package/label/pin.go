@@ -0,0 +1,96 @@
+package label
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+)
+
+// Pin records that a version label (e.g. the tag "v1.2.0") of a package
+// resolved to a SHA. Labels are pinned the first time that they are resolved,
+// and stay pinned to the same SHA from then on. Returns the SHA that the label
+// is pinned to, which is not the specified SHA if the label was pinned before.
+func Pin(
+	q db.Queryable,
+	author string,
+	repo string,
+	label string,
+	sha string,
+) (string, error) {
+	pinned, err := query.InsertInto(tableName).
+		Value(columnNameAuthor, author).
+		Value(columnNameRepo, repo).
+		Value(columnNameLabel, label).
+		Value(columnNameSHA, sha).
+		Value(columnNameDatePinned, time.Now()).
+		IfNotExists().
+		Create(q).
+		ExecCAS()
+	if err != nil {
+		return "", fmt.Errorf(
+			"Failed to pin %s/%s@%s to %s: %v",
+			author,
+			repo,
+			label,
+			sha,
+			err)
+	} else if pinned {
+		return sha, nil
+	}
+
+	// Somebody got here first, so go with what they pinned.
+	var pinnedSHA string
+	if err = query.Select(columnNameSHA).
+		From(tableName).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		And(query.Column(columnNameLabel).Equals(label)).
+		Limit(1).
+		Create(q).
+		Scan(&pinnedSHA); err != nil {
+		return "", fmt.Errorf(
+			"Failed to read the pinned SHA of %s/%s@%s: %v",
+			author,
+			repo,
+			label,
+			err)
+	}
+
+	return pinnedSHA, nil
+}
+
+// GetAll gets every pinned version label of a package, mapped to the SHA that
+// it is pinned to.
+func GetAll(
+	q db.Queryable,
+	author string,
+	repo string,
+) (map[string]string, error) {
+	var (
+		sha   string
+		label string
+		iter  = query.Select(columnNameLabel, columnNameSHA).
+			From(tableName).
+			Where(query.Column(columnNameAuthor).Equals(author)).
+			And(query.Column(columnNameRepo).Equals(repo)).
+			Create(q).
+			Iter()
+		pins = make(map[string]string)
+	)
+
+	for iter.Scan(&label, &sha) {
+		pins[label] = sha
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, fmt.Errorf(
+			"Failed to get the pinned version labels of %s/%s: %v",
+			author,
+			repo,
+			err)
+	}
+
+	return pins, nil
+}
@@ -0,0 +1,9 @@
+package refsnapshot
+
+const (
+	tableName             = "package_refs_snapshots"
+	columnNameRefs        = "refs"
+	columnNameRepo        = "repo"
+	columnNameAuthor      = "author"
+	columnNameDateFetched = "date_fetched"
+)
@@ -0,0 +1,72 @@
+package refsnapshot
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/query"
+)
+
+// Snapshot is the last refs advertisement that was fetched for a package.
+// The refs are kept as they were fetched, so parsing them (see lib.NewRefs)
+// yields the version candidates and the default branch of the package at the
+// time.
+type Snapshot struct {
+	Refs        []byte
+	DateFetched time.Time
+}
+
+// Save replaces the refs snapshot of a package with refs that were just
+// fetched.
+func Save(
+	q db.Queryable,
+	author string,
+	repo string,
+	refs []byte,
+) error {
+	if err := query.InsertInto(tableName).
+		Value(columnNameAuthor, author).
+		Value(columnNameRepo, repo).
+		Value(columnNameRefs, refs).
+		Value(columnNameDateFetched, time.Now()).
+		Create(q).
+		Exec(); err != nil {
+		return fmt.Errorf(
+			"Failed to save the refs snapshot of %s/%s: %v",
+			author,
+			repo,
+			err)
+	}
+
+	return nil
+}
+
+// Get gets the refs snapshot of a package. Returns nil if the refs of the
+// package were never saved.
+func Get(
+	q db.Queryable,
+	author string,
+	repo string,
+) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := query.Select(columnNameRefs, columnNameDateFetched).
+		From(tableName).
+		Where(query.Column(columnNameAuthor).Equals(author)).
+		And(query.Column(columnNameRepo).Equals(repo)).
+		Limit(1).
+		Create(q).
+		Scan(&snapshot.Refs, &snapshot.DateFetched); err != nil {
+		if db.IsErrNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf(
+			"Failed to get the refs snapshot of %s/%s: %v",
+			author,
+			repo,
+			err)
+	}
+
+	return &snapshot, nil
+}
@@ -11,4 +11,7 @@ type Query interface {
 	// the values pointed at by dest and discards the rest. If no rows were
 	// selected, ErrNotFound is returned.
 	Scan(dest ...interface{}) error
+	// ExecCAS executes a lightweight transaction (i.e. an UPDATE or INSERT
+	// statement containing an IF clause) and returns true if it was applied.
+	ExecCAS() (bool, error)
 }
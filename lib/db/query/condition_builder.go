@@ -41,6 +41,15 @@ func (cb *ColumnConditionBuilder) IsLessThanOrEqualTo(
 	}
 }
 
+// IsGreaterThan creates a gt condition.
+func (cb *ColumnConditionBuilder) IsGreaterThan(value interface{}) *Condition {
+	return &Condition{
+		expression:   cb.column + ">?",
+		parameter:    value,
+		hasParameter: true,
+	}
+}
+
 // IsGreaterThanOrEqualTo creates an gte condition.
 func (cb *ColumnConditionBuilder) IsGreaterThanOrEqualTo(
 	value interface{},
@@ -8,9 +8,10 @@ import (
 
 // DeleteQueryBuilder constructs a delete query.
 type DeleteQueryBuilder struct {
-	table      string
-	columns    []string
-	conditions []*Condition
+	table        string
+	columns      []string
+	conditions   []*Condition
+	ifConditions []*Condition
 }
 
 // Delete starts constructing a delete query.
@@ -45,6 +46,13 @@ func (qb *DeleteQueryBuilder) And(condition *Condition) *DeleteQueryBuilder {
 	return qb.Where(condition)
 }
 
+// If adds a condition to which the deleted rows must adhere in order for the
+// delete to be applied. This turns the query into a lightweight transaction.
+func (qb *DeleteQueryBuilder) If(condition *Condition) *DeleteQueryBuilder {
+	qb.ifConditions = append(qb.ifConditions, condition)
+	return qb
+}
+
 // Create serializes and creates the query.
 func (qb *DeleteQueryBuilder) Create(q db.Queryable) db.Query {
 	var (
@@ -78,6 +86,20 @@ func (qb *DeleteQueryBuilder) Create(q db.Queryable) db.Query {
 			buffer.WriteString(cond.expression)
 		}
 	}
+	if qb.ifConditions != nil {
+		buffer.WriteString(" if ")
+		for i, cond := range qb.ifConditions {
+			if i > 0 {
+				buffer.WriteString(" and ")
+			}
+
+			if cond.hasParameter {
+				parameters = append(parameters, cond.parameter)
+			}
+
+			buffer.WriteString(cond.expression)
+		}
+	}
 
 	return q.Query(buffer.String(), parameters...)
 }
@@ -109,7 +109,7 @@ func (qb *InsertQueryBuilder) compose() (string, []interface{}) {
 	}
 	if qb.ttl > 0 {
 		buffer.WriteString(" using TTL ")
-		buffer.WriteString(strconv.FormatUint(uint64(qb.ttl/time.Microsecond), 10))
+		buffer.WriteString(strconv.FormatUint(uint64(qb.ttl/time.Second), 10))
 	}
 
 	return buffer.String(), params
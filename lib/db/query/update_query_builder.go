@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gophr-pm/gophr/lib/db"
 )
@@ -20,8 +21,10 @@ type columnValueAssignment struct {
 type UpdateQueryBuilder struct {
 	valueAssignments []columnValueAssignment
 	conditions       []*Condition
+	ifConditions     []*Condition
 	ifExists         bool
 	table            string
+	ttl              time.Duration
 }
 
 // Update starts constructing an insert query.
@@ -57,6 +60,12 @@ func (qb *UpdateQueryBuilder) Increment(
 	return qb
 }
 
+// UsingTTL adds a TTL to the values written by the query.
+func (qb *UpdateQueryBuilder) UsingTTL(ttl time.Duration) *UpdateQueryBuilder {
+	qb.ttl = ttl
+	return qb
+}
+
 // Where adds a condition to which all of the updated rows should adhere.
 func (qb *UpdateQueryBuilder) Where(condition *Condition) *UpdateQueryBuilder {
 	qb.conditions = append(qb.conditions, condition)
@@ -74,6 +83,13 @@ func (qb *UpdateQueryBuilder) IfExists() *UpdateQueryBuilder {
 	return qb
 }
 
+// If adds a condition to which the updated rows must adhere in order for the
+// update to be applied. This turns the query into a lightweight transaction.
+func (qb *UpdateQueryBuilder) If(condition *Condition) *UpdateQueryBuilder {
+	qb.ifConditions = append(qb.ifConditions, condition)
+	return qb
+}
+
 // compose composes the text and parameters for this query.
 func (qb *UpdateQueryBuilder) compose() (string, []interface{}) {
 	var (
@@ -85,6 +101,10 @@ func (qb *UpdateQueryBuilder) compose() (string, []interface{}) {
 	buffer.WriteString(DBKeyspaceName)
 	buffer.WriteByte('.')
 	buffer.WriteString(qb.table)
+	if qb.ttl > 0 {
+		buffer.WriteString(" using TTL ")
+		buffer.WriteString(strconv.FormatUint(uint64(qb.ttl/time.Second), 10))
+	}
 	buffer.WriteString(" set ")
 	for i, valueAssignment := range qb.valueAssignments {
 		if i > 0 {
@@ -116,6 +136,19 @@ func (qb *UpdateQueryBuilder) compose() (string, []interface{}) {
 	}
 	if qb.ifExists {
 		buffer.WriteString(" if exists")
+	} else if qb.ifConditions != nil {
+		buffer.WriteString(" if ")
+		for i, cond := range qb.ifConditions {
+			if i > 0 {
+				buffer.WriteString(" and ")
+			}
+
+			if cond.hasParameter {
+				parameters = append(parameters, cond.parameter)
+			}
+
+			buffer.WriteString(cond.expression)
+		}
 	}
 
 	return buffer.String(), parameters
@@ -23,3 +23,11 @@ func (q queryImpl) Iter() ResultsIterator {
 func (q queryImpl) Scan(dest ...interface{}) error {
 	return q.query.Scan(dest...)
 }
+
+// ExecCAS executes a lightweight transaction (i.e. an UPDATE or INSERT
+// statement containing an IF clause) and returns true if it was applied.
+func (q queryImpl) ExecCAS() (bool, error) {
+	// The previous values of the row are not interesting to callers, but they
+	// still have to be scanned somewhere.
+	return q.query.MapScanCAS(make(map[string]interface{}))
+}
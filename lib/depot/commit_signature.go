@@ -0,0 +1,385 @@
+package depot
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/gophr-pm/gophr/lib/io"
+)
+
+const (
+	// ArchiveHashTrailer is the trailer of depot commit messages that holds the
+	// tree hash of the archive (see HashArchive). Since commit messages are
+	// signed, the trailer vouches for the contents of the depot repo.
+	ArchiveHashTrailer = "Archive-Hash"
+	// CommitSignatureField is the header of depot commits that holds their
+	// signature.
+	CommitSignatureField = "gpgsig"
+)
+
+const (
+	sshKeyType              = "ssh-ed25519"
+	sshSignatureMagic       = "SSHSIG"
+	sshSignatureVersion     = 1
+	sshSignatureNamespace   = "git"
+	sshSignatureHashSHA256  = "sha256"
+	sshSignatureHashSHA512  = "sha512"
+	sshSignatureArmorBegin  = "-----BEGIN SSH SIGNATURE-----"
+	sshSignatureArmorEnd    = "-----END SSH SIGNATURE-----"
+	sshSignatureArmorWidth  = 70
+	publicKeyComment        = "gophr-depot"
+	commitHeaderSeparator   = "\n\n"
+	archiveHashTrailerStart = ArchiveHashTrailer + ": "
+)
+
+// ErrUnsignedCommit is returned by VerifyCommit for commits that are not
+// signed.
+var ErrUnsignedCommit = errors.New("The commit is not signed")
+
+// ParseSigningKey reads the ed25519 key that depot commits are signed with out
+// of its base64 encoding. Either the 32 byte seed or the 64 byte private key
+// may be encoded.
+func ParseSigningKey(encodedKey string) (ed25519.PrivateKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encodedKey))
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode the signing key: %v", err)
+	}
+
+	switch len(key) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	default:
+		return nil, fmt.Errorf(
+			"The signing key is %d bytes long instead of %d",
+			len(key),
+			ed25519.PrivateKeySize)
+	}
+}
+
+// FormatPublicKey formats the public half of the depot signing key like
+// authorized_keys entries (e.g. "ssh-ed25519 AAAA... gophr-depot"), so that
+// it can be added to the allowed signers of git.
+func FormatPublicKey(publicKey ed25519.PublicKey) string {
+	return sshKeyType + " " +
+		base64.StdEncoding.EncodeToString(sshPublicKeyBlob(publicKey)) + " " +
+		publicKeyComment
+}
+
+// ParsePublicKey reads a public key formatted by FormatPublicKey.
+func ParsePublicKey(formattedKey string) (ed25519.PublicKey, error) {
+	fields := strings.Fields(formattedKey)
+	if len(fields) < 2 || fields[0] != sshKeyType {
+		return nil, fmt.Errorf("%q is not an %s public key", formattedKey, sshKeyType)
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("Could not decode the public key: %v", err)
+	}
+
+	return readSSHPublicKeyBlob(blob)
+}
+
+// SignCommit signs the contents of a commit object as an SSH signature (see
+// PROTOCOL.sshsig of OpenSSH), which is what git verifies commits signed with
+// "gpg.format=ssh" against. The signature is armored, ready to be stored in the
+// CommitSignatureField header of the commit.
+func SignCommit(key ed25519.PrivateKey, commit []byte) string {
+	var (
+		signature = ed25519.Sign(key, sshSignedData(
+			commit,
+			sshSignatureHashSHA512,
+			sha512.New()))
+		blob bytes.Buffer
+	)
+
+	blob.WriteString(sshSignatureMagic)
+	binary.Write(&blob, binary.BigEndian, uint32(sshSignatureVersion))
+	writeSSHString(&blob, sshPublicKeyBlob(key.Public().(ed25519.PublicKey)))
+	writeSSHString(&blob, []byte(sshSignatureNamespace))
+	writeSSHString(&blob, nil)
+	writeSSHString(&blob, []byte(sshSignatureHashSHA512))
+	writeSSHString(&blob, sshSignatureBlob(signature))
+
+	encoded := base64.StdEncoding.EncodeToString(blob.Bytes())
+	armored := []string{sshSignatureArmorBegin}
+	for len(encoded) > sshSignatureArmorWidth {
+		armored = append(armored, encoded[:sshSignatureArmorWidth])
+		encoded = encoded[sshSignatureArmorWidth:]
+	}
+
+	return strings.Join(append(armored, encoded, sshSignatureArmorEnd), "\n")
+}
+
+// VerifyCommit checks that a depot commit object (as printed by "git cat-file
+// commit HEAD") was signed with the depot signing key, and that it vouches for
+// the archive hash that the archive record of the package version holds.
+// Returns ErrUnsignedCommit if the commit is not signed at all.
+func VerifyCommit(
+	publicKey ed25519.PublicKey,
+	commit []byte,
+	archiveHash string,
+) error {
+	payload, armoredSignature, message := splitSignedCommit(string(commit))
+	if len(armoredSignature) < 1 {
+		return ErrUnsignedCommit
+	}
+
+	if err := verifySSHSignature(
+		publicKey,
+		[]byte(payload),
+		armoredSignature); err != nil {
+		return err
+	}
+
+	// The signature is good, so the message can be trusted.
+	signedArchiveHash := ReadArchiveHash(message)
+	if len(signedArchiveHash) < 1 {
+		return fmt.Errorf("The commit does not have an %s trailer", ArchiveHashTrailer)
+	} else if signedArchiveHash != archiveHash {
+		return fmt.Errorf(
+			"The commit vouches for archive hash %s instead of %s",
+			signedArchiveHash,
+			archiveHash)
+	}
+
+	return nil
+}
+
+// ReadArchiveHash reads the archive hash out of the ArchiveHashTrailer of a
+// depot commit message. Returns an empty string if the message has no such
+// trailer.
+func ReadArchiveHash(commitMessage string) string {
+	var archiveHash string
+	for _, line := range strings.Split(commitMessage, "\n") {
+		if strings.HasPrefix(line, archiveHashTrailerStart) {
+			archiveHash = strings.TrimSpace(line[len(archiveHashTrailerStart):])
+		}
+	}
+
+	return archiveHash
+}
+
+// VerifyArchive checks that the depot repo checked out in repoDirPath is the
+// archive that gophr recorded: the HEAD commit of the repo must be signed with
+// the depot signing key, and both the commit and the files of the repo must
+// match the archive hash of the archive record.
+func VerifyArchive(
+	io io.IO,
+	publicKey ed25519.PublicKey,
+	repoDirPath string,
+	headCommit []byte,
+	archiveHash string,
+) error {
+	if err := VerifyCommit(publicKey, headCommit, archiveHash); err != nil {
+		return err
+	}
+
+	repoHash, err := HashArchive(io, repoDirPath)
+	if err != nil {
+		return err
+	} else if repoHash != archiveHash {
+		return fmt.Errorf(
+			"The files of the depot repo hash to %s instead of %s",
+			repoHash,
+			archiveHash)
+	}
+
+	return nil
+}
+
+// splitSignedCommit splits a commit object into the payload that was signed,
+// the armored signature, and the commit message.
+func splitSignedCommit(commit string) (payload, signature, message string) {
+	headers, rest := commit, ""
+	if i := strings.Index(commit, commitHeaderSeparator); i != -1 {
+		headers, rest = commit[:i+1], commit[i+1:]
+	}
+
+	var (
+		payloadBuffer  bytes.Buffer
+		signatureLines []string
+		inSignature    = false
+	)
+
+	// The signature header is the only one left out of the payload. Its
+	// continuation lines start with a space.
+	for _, line := range strings.SplitAfter(headers, "\n") {
+		if inSignature && strings.HasPrefix(line, " ") {
+			signatureLines = append(signatureLines, strings.TrimSuffix(line[1:], "\n"))
+			continue
+		}
+
+		inSignature = strings.HasPrefix(line, CommitSignatureField+" ")
+		if inSignature {
+			signatureLines = append(
+				signatureLines,
+				strings.TrimSuffix(line[len(CommitSignatureField)+1:], "\n"))
+			continue
+		}
+
+		payloadBuffer.WriteString(line)
+	}
+
+	payloadBuffer.WriteString(rest)
+
+	return payloadBuffer.String(),
+		strings.Join(signatureLines, "\n"),
+		strings.TrimPrefix(rest, "\n")
+}
+
+// verifySSHSignature checks that an armored SSH signature of the payload was
+// made with the public key.
+func verifySSHSignature(
+	publicKey ed25519.PublicKey,
+	payload []byte,
+	armoredSignature string,
+) error {
+	lines := strings.Split(strings.TrimSpace(armoredSignature), "\n")
+	if len(lines) < 3 ||
+		lines[0] != sshSignatureArmorBegin ||
+		lines[len(lines)-1] != sshSignatureArmorEnd {
+		return errors.New("The commit signature is not an SSH signature")
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(
+		strings.Join(lines[1:len(lines)-1], ""))
+	if err != nil || !bytes.HasPrefix(blob, []byte(sshSignatureMagic)) {
+		return errors.New("The commit signature is malformed")
+	}
+
+	var (
+		reader  = bytes.NewReader(blob[len(sshSignatureMagic):])
+		version uint32
+	)
+	if err = binary.Read(reader, binary.BigEndian, &version); err != nil ||
+		version != sshSignatureVersion {
+		return errors.New("The commit signature has an unsupported version")
+	}
+
+	var fields [5][]byte
+	for i := range fields {
+		if fields[i], err = readSSHString(reader); err != nil {
+			return errors.New("The commit signature is malformed")
+		}
+	}
+
+	signerBlob, namespace, hashAlgorithm, signatureBlob :=
+		fields[0], string(fields[1]), string(fields[3]), fields[4]
+	if !bytes.Equal(signerBlob, sshPublicKeyBlob(publicKey)) {
+		return errors.New("The commit was not signed with the depot signing key")
+	} else if namespace != sshSignatureNamespace {
+		return fmt.Errorf("The commit signature is for %q instead of git", namespace)
+	}
+
+	var h hash.Hash
+	switch hashAlgorithm {
+	case sshSignatureHashSHA256:
+		h = sha256.New()
+	case sshSignatureHashSHA512:
+		h = sha512.New()
+	default:
+		return fmt.Errorf(
+			"The commit signature uses an unsupported hash algorithm: %q",
+			hashAlgorithm)
+	}
+
+	signatureReader := bytes.NewReader(signatureBlob)
+	signatureType, err := readSSHString(signatureReader)
+	if err != nil || string(signatureType) != sshKeyType {
+		return errors.New("The commit signature is not an ed25519 signature")
+	}
+	signature, err := readSSHString(signatureReader)
+	if err != nil {
+		return errors.New("The commit signature is malformed")
+	}
+
+	if !ed25519.Verify(
+		publicKey,
+		sshSignedData(payload, hashAlgorithm, h),
+		signature) {
+		return errors.New("The commit signature does not match the commit")
+	}
+
+	return nil
+}
+
+// sshSignedData is the data that SSH signatures actually sign: a digest of the
+// payload, along with the namespace of the signature.
+func sshSignedData(payload []byte, hashAlgorithm string, h hash.Hash) []byte {
+	h.Write(payload)
+
+	var data bytes.Buffer
+	data.WriteString(sshSignatureMagic)
+	writeSSHString(&data, []byte(sshSignatureNamespace))
+	writeSSHString(&data, nil)
+	writeSSHString(&data, []byte(hashAlgorithm))
+	writeSSHString(&data, h.Sum(nil))
+
+	return data.Bytes()
+}
+
+// sshPublicKeyBlob encodes an ed25519 public key in the SSH wire format.
+func sshPublicKeyBlob(publicKey ed25519.PublicKey) []byte {
+	var blob bytes.Buffer
+	writeSSHString(&blob, []byte(sshKeyType))
+	writeSSHString(&blob, publicKey)
+	return blob.Bytes()
+}
+
+// readSSHPublicKeyBlob decodes an ed25519 public key from the SSH wire format.
+func readSSHPublicKeyBlob(blob []byte) (ed25519.PublicKey, error) {
+	reader := bytes.NewReader(blob)
+	keyType, err := readSSHString(reader)
+	if err != nil || string(keyType) != sshKeyType {
+		return nil, fmt.Errorf("The public key is not an %s key", sshKeyType)
+	}
+
+	key, err := readSSHString(reader)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("The public key is malformed")
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+// sshSignatureBlob encodes an ed25519 signature in the SSH wire format.
+func sshSignatureBlob(signature []byte) []byte {
+	var blob bytes.Buffer
+	writeSSHString(&blob, []byte(sshKeyType))
+	writeSSHString(&blob, signature)
+	return blob.Bytes()
+}
+
+// writeSSHString writes a length-prefixed string of the SSH wire format.
+func writeSSHString(buffer *bytes.Buffer, s []byte) {
+	binary.Write(buffer, binary.BigEndian, uint32(len(s)))
+	buffer.Write(s)
+}
+
+// readSSHString reads a length-prefixed string of the SSH wire format.
+func readSSHString(reader *bytes.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return nil, err
+	} else if int64(length) > int64(reader.Len()) {
+		return nil, errors.New("The string is longer than what is left")
+	}
+
+	s := make([]byte, length)
+	if _, err := reader.Read(s); err != nil && length > 0 {
+		return nil, err
+	}
+
+	return s, nil
+}
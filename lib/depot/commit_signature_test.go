@@ -0,0 +1,143 @@
+package depot
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/stretchr/testify/assert"
+)
+
+const testArchiveHash = "tree1:LLoolrIpx14PlBWLNML0muKvv5WD7EgIAW7M1nj8tJY="
+
+var testSigningKey = ed25519.NewKeyFromSeed([]byte("0123456789abcdef0123456789abcdef"))
+
+// signTestCommit builds a commit object like the ones of depot repos, and
+// signs it the same way that git does.
+func signTestCommit(key ed25519.PrivateKey, archiveHash string) string {
+	var (
+		headers = "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+			"author gophr <gophr.pm@gmail.com> 1468717200 +0000\n" +
+			"committer gophr <gophr.pm@gmail.com> 1468717200 +0000\n"
+		message = "\ngophr versioned repo of github.com/a/b @ 1234\n\n" +
+			ArchiveHashTrailer + ": " + archiveHash + "\n"
+		signature = SignCommit(key, []byte(headers+message))
+	)
+
+	return headers +
+		CommitSignatureField + " " +
+		strings.Replace(signature, "\n", "\n ", -1) + "\n" +
+		message
+}
+
+func TestParseSigningKey(t *testing.T) {
+	seed := testSigningKey.Seed()
+
+	key, err := ParseSigningKey(base64.StdEncoding.EncodeToString(seed))
+	assert.Nil(t, err)
+	assert.Equal(t, testSigningKey, key)
+
+	key, err = ParseSigningKey(base64.StdEncoding.EncodeToString(testSigningKey) + "\n")
+	assert.Nil(t, err)
+	assert.Equal(t, testSigningKey, key)
+
+	_, err = ParseSigningKey("not base64")
+	assert.NotNil(t, err)
+
+	_, err = ParseSigningKey(base64.StdEncoding.EncodeToString(seed[:10]))
+	assert.NotNil(t, err)
+}
+
+func TestPublicKeys(t *testing.T) {
+	publicKey := testSigningKey.Public().(ed25519.PublicKey)
+	formattedKey := FormatPublicKey(publicKey)
+	assert.True(t, strings.HasPrefix(formattedKey, "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI"))
+	assert.True(t, strings.HasSuffix(formattedKey, " gophr-depot"))
+
+	parsedKey, err := ParsePublicKey(formattedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, publicKey, parsedKey)
+
+	_, err = ParsePublicKey("ssh-rsa AAAA")
+	assert.NotNil(t, err)
+
+	_, err = ParsePublicKey("ssh-ed25519 !!!")
+	assert.NotNil(t, err)
+
+	_, err = ParsePublicKey("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5")
+	assert.NotNil(t, err)
+}
+
+func TestVerifyCommit(t *testing.T) {
+	publicKey := testSigningKey.Public().(ed25519.PublicKey)
+	commit := signTestCommit(testSigningKey, testArchiveHash)
+
+	assert.Nil(t, VerifyCommit(publicKey, []byte(commit), testArchiveHash))
+
+	// Commits vouch for one archive hash only.
+	assert.NotNil(t, VerifyCommit(publicKey, []byte(commit), "tree1:somethingelse"))
+
+	// Tampering with the commit should break the signature.
+	tampered := strings.Replace(commit, "github.com/a/b", "github.com/a/c", 1)
+	assert.NotNil(t, VerifyCommit(publicKey, []byte(tampered), testArchiveHash))
+
+	tampered = strings.Replace(commit, "4b825dc6", "4b825dc7", 1)
+	assert.NotNil(t, VerifyCommit(publicKey, []byte(tampered), testArchiveHash))
+
+	// Other keys should not be trusted.
+	otherKey := ed25519.NewKeyFromSeed([]byte("fedcba9876543210fedcba9876543210"))
+	commit = signTestCommit(otherKey, testArchiveHash)
+	assert.NotNil(t, VerifyCommit(publicKey, []byte(commit), testArchiveHash))
+
+	// Commits need to be signed.
+	unsigned := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n\nmessage\n"
+	assert.Equal(t, ErrUnsignedCommit, VerifyCommit(publicKey, []byte(unsigned), testArchiveHash))
+
+	// Signed commits without a trailer don't vouch for anything.
+	commit = signTestCommit(testSigningKey, testArchiveHash)
+	commit = commit[:strings.Index(commit, ArchiveHashTrailer)]
+	assert.NotNil(t, VerifyCommit(publicKey, []byte(commit), testArchiveHash))
+}
+
+func TestReadArchiveHash(t *testing.T) {
+	assert.Equal(t, "", ReadArchiveHash("Gophr versioned repo a/b@c"))
+	assert.Equal(
+		t,
+		"h1:abc=",
+		ReadArchiveHash("Gophr versioned repo a/b@c\n\nArchive-Hash: h1:abc=\n"))
+}
+
+func TestVerifyArchive(t *testing.T) {
+	publicKey := testSigningKey.Public().(ed25519.PublicKey)
+	commit := []byte(signTestCommit(testSigningKey, testArchiveHash))
+
+	newMockIO := func(readmeContents string) *io.MockIO {
+		mockIO := io.NewMockIO()
+		mockIO.On("ReadDir", "/repo").Return([]os.FileInfo{
+			io.NewFakeFileInfo(".git", 0, true),
+			io.NewFakeFileInfo("README.md", 4, false),
+			io.NewFakeFileInfo("b", 0, true),
+			io.NewFakeFileInfo("a.go", 10, false),
+		}, nil)
+		mockIO.On("ReadDir", "/repo/b").Return([]os.FileInfo{
+			io.NewFakeFileInfo("c.go", 10, false),
+		}, nil)
+		mockIO.On("ReadFile", "/repo/README.md").Return([]byte(readmeContents), nil)
+		mockIO.On("ReadFile", "/repo/a.go").Return([]byte("package a\n"), nil)
+		mockIO.On("ReadFile", "/repo/b/c.go").Return([]byte("package c\n"), nil)
+		return mockIO
+	}
+
+	assert.Nil(t, VerifyArchive(newMockIO("# a\n"), publicKey, "/repo", commit, testArchiveHash))
+
+	// Files that were changed on disk should not match the signed hash.
+	assert.NotNil(t, VerifyArchive(newMockIO("# b\n"), publicKey, "/repo", commit, testArchiveHash))
+
+	// Bad commits should be caught before the repo is read.
+	mockIO := io.NewMockIO()
+	assert.NotNil(t, VerifyArchive(mockIO, publicKey, "/repo", commit, "tree1:somethingelse"))
+	mockIO.AssertNotCalled(t, "ReadDir", "/repo")
+}
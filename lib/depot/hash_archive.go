@@ -0,0 +1,140 @@
+package depot
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gophr-pm/gophr/lib/io"
+)
+
+const (
+	archiveHashPrefix      = "tree1:"
+	archiveGitDirName      = ".git"
+	archiveHashFileLineFmt = "%x  %s\n"
+	archiveHashLinkLineFmt = "%x @ %s\n"
+)
+
+// archiveFile is a file or a symbolic link in an archive.
+type archiveFile struct {
+	// path is the slash-separated path of the file relative to the archive.
+	path   string
+	isLink bool
+}
+
+// archiveFiles is a list of archive files that sorts by path.
+type archiveFiles []archiveFile
+
+func (files archiveFiles) Len() int {
+	return len(files)
+}
+
+func (files archiveFiles) Less(i, j int) bool {
+	return files[i].path < files[j].path
+}
+
+func (files archiveFiles) Swap(i, j int) {
+	files[i], files[j] = files[j], files[i]
+}
+
+// HashArchive computes the tree hash of the archive of a package version: the
+// SHA-256 of a summary that lists every file and symbolic link in the archive,
+// ordered by path, one per line. Files are listed as the hex SHA-256 of their
+// contents, two spaces and their slash-separated path (like sha256sum does).
+// Symbolic links, such as the links of major version directories, are listed
+// as the hex SHA-256 of their target, " @ " and their path. Git metadata is
+// left out, so the hash of an archive is the same before and after it is
+// pushed to depot. The hash covers the depot archive that go get is served,
+// whose imports have been rewritten, so it is not the go.sum hash of any
+// module zip; that is why it carries a "tree1:" prefix instead of "h1:".
+func HashArchive(io io.IO, archiveDirPath string) (string, error) {
+	files, err := listArchiveFiles(io, archiveDirPath, "")
+	if err != nil {
+		return "", err
+	}
+
+	sort.Sort(archiveFiles(files))
+
+	summary := sha256.New()
+	for _, file := range files {
+		if strings.Contains(file.path, "\n") {
+			return "", fmt.Errorf(
+				"Could not hash archive file %q since its name has a newline",
+				file.path)
+		}
+
+		filePath := filepath.Join(archiveDirPath, filepath.FromSlash(file.path))
+		if file.isLink {
+			target, err := io.Readlink(filePath)
+			if err != nil {
+				return "", fmt.Errorf(
+					"Could not hash archive link %s: %v",
+					file.path,
+					err)
+			}
+
+			fmt.Fprintf(
+				summary,
+				archiveHashLinkLineFmt,
+				sha256.Sum256([]byte(filepath.ToSlash(target))),
+				file.path)
+			continue
+		}
+
+		data, err := io.ReadFile(filePath)
+		if err != nil {
+			return "", fmt.Errorf(
+				"Could not hash archive file %s: %v",
+				file.path,
+				err)
+		}
+
+		fmt.Fprintf(summary, archiveHashFileLineFmt, sha256.Sum256(data), file.path)
+	}
+
+	return archiveHashPrefix +
+		base64.StdEncoding.EncodeToString(summary.Sum(nil)), nil
+}
+
+// listArchiveFiles lists the files and symbolic links in the sub-directory of
+// an archive. Symbolic links are not followed.
+func listArchiveFiles(
+	io io.IO,
+	archiveDirPath string,
+	subDirPath string,
+) ([]archiveFile, error) {
+	entries, err := io.ReadDir(filepath.Join(
+		archiveDirPath,
+		filepath.FromSlash(subDirPath)))
+	if err != nil {
+		return nil, fmt.Errorf("Could not list archive files: %v", err)
+	}
+
+	var files []archiveFile
+	for _, entry := range entries {
+		entryPath := path.Join(subDirPath, entry.Name())
+		if entry.Mode()&os.ModeSymlink != 0 {
+			files = append(files, archiveFile{path: entryPath, isLink: true})
+		} else if entry.IsDir() {
+			if entry.Name() == archiveGitDirName {
+				continue
+			}
+
+			subDirFiles, err := listArchiveFiles(io, archiveDirPath, entryPath)
+			if err != nil {
+				return nil, err
+			}
+
+			files = append(files, subDirFiles...)
+		} else {
+			files = append(files, archiveFile{path: entryPath})
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,78 @@
+package depot
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashArchive(t *testing.T) {
+	// newArchiveIO mocks an archive with a few files, git metadata, and a major
+	// version link that points at the specified target.
+	newArchiveIO := func(linkTarget string) *io.MockIO {
+		mockIO := io.NewMockIO()
+		mockIO.On("ReadDir", "/archive").Return([]os.FileInfo{
+			io.NewFakeFileInfo(".git", 0, true),
+			io.NewFakeFileInfo("README.md", 4, false),
+			io.NewFakeFileInfo("b", 0, true),
+			io.NewFakeFileInfo("a.go", 10, false),
+			io.FakeFileInfo{NameProp: "v2", FileModeProp: os.ModeSymlink},
+		}, nil)
+		mockIO.On("ReadDir", "/archive/b").Return([]os.FileInfo{
+			io.NewFakeFileInfo("c.go", 10, false),
+		}, nil)
+		mockIO.On("ReadFile", "/archive/README.md").Return([]byte("# a\n"), nil)
+		mockIO.On("ReadFile", "/archive/a.go").Return([]byte("package a\n"), nil)
+		mockIO.On("ReadFile", "/archive/b/c.go").Return([]byte("package c\n"), nil)
+		mockIO.On("Readlink", "/archive/v2").Return(linkTarget, nil)
+		return mockIO
+	}
+
+	mockIO := newArchiveIO(".")
+	hash, err := HashArchive(mockIO, "/archive")
+	assert.Nil(t, err)
+	assert.Equal(t, "tree1:opw8AIecCPnZz8r33njldR7bb6H8Lh8iHxE1VXE1ngI=", hash)
+	mockIO.AssertNotCalled(t, "ReadDir", "/archive/.git")
+	mockIO.AssertNotCalled(t, "ReadDir", "/archive/v2")
+
+	// Symbolic links are hashed by where they point.
+	otherHash, err := HashArchive(newArchiveIO("b"), "/archive")
+	assert.Nil(t, err)
+	assert.NotEqual(t, hash, otherHash)
+
+	// Empty archives have the hash of an empty summary.
+	mockIO = io.NewMockIO()
+	mockIO.On("ReadDir", "/archive").Return([]os.FileInfo{}, nil)
+
+	hash, err = HashArchive(mockIO, "/archive")
+	assert.Nil(t, err)
+	assert.Equal(t, "tree1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", hash)
+
+	// Unreadable archives cannot be hashed.
+	mockIO = io.NewMockIO()
+	mockIO.On("ReadDir", "/archive").Return([]os.FileInfo{
+		io.NewFakeFileInfo("a.go", 10, false),
+	}, nil)
+	mockIO.On("ReadFile", "/archive/a.go").Return([]byte(nil), errors.New("this is an error"))
+
+	_, err = HashArchive(mockIO, "/archive")
+	assert.NotNil(t, err)
+
+	mockIO = io.NewMockIO()
+	mockIO.On("ReadDir", "/archive").Return([]os.FileInfo{
+		io.FakeFileInfo{NameProp: "v2", FileModeProp: os.ModeSymlink},
+	}, nil)
+	mockIO.On("Readlink", "/archive/v2").Return("", errors.New("this is an error"))
+
+	_, err = HashArchive(mockIO, "/archive")
+	assert.NotNil(t, err)
+
+	mockIO = io.NewMockIO()
+	mockIO.On("ReadDir", "/archive").Return([]os.FileInfo(nil), errors.New("this is an error"))
+
+	_, err = HashArchive(mockIO, "/archive")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,18 @@
+package dtos
+
+//go:generate ffjson $GOFILE
+
+// PackageArchive is the DTO for the archive of a package version. Hash is the
+// tree hash of the depot archive (see depot.HashArchive), with which clients
+// can verify the integrity of what go get fetches from depot. It is not a
+// go.sum hash. A hash like "tree1:<base64>" is the base64 of the SHA-256 of a
+// summary with a line per file or symbolic link of the archive outside of
+// .git, ordered by slash-separated path. Files are listed as
+// "<hex SHA-256 of contents>  <path>", and symbolic links as
+// "<hex SHA-256 of target> @ <path>".
+type PackageArchive struct {
+	SHA    string `json:"sha"`
+	Repo   string `json:"repo"`
+	Hash   string `json:"hash,omitempty"`
+	Author string `json:"author"`
+}
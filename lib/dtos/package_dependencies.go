@@ -0,0 +1,49 @@
+package dtos
+
+//go:generate ffjson $GOFILE
+
+// PackageDependency is the DTO for a package version that another package
+// version depends on.
+type PackageDependency struct {
+	SHA      string   `json:"sha"`
+	Repo     string   `json:"repo"`
+	Author   string   `json:"author"`
+	Strategy string   `json:"strategy"`
+	Subpaths []string `json:"subpaths"`
+}
+
+// PackageDependencies is the DTO for the direct dependencies of a package
+// version.
+type PackageDependencies struct {
+	SHA          string              `json:"sha"`
+	Repo         string              `json:"repo"`
+	Author       string              `json:"author"`
+	Dependencies []PackageDependency `json:"dependencies"`
+}
+
+// PackageDependencyGraph is the DTO for the transitive dependencies of a
+// package version.
+type PackageDependencyGraph struct {
+	Root      string                       `json:"root"`
+	Nodes     []PackageDependencyGraphNode `json:"nodes"`
+	Edges     []PackageDependencyGraphEdge `json:"edges"`
+	Truncated bool                         `json:"truncated"`
+}
+
+// PackageDependencyGraphNode is a package version in a PackageDependencyGraph.
+type PackageDependencyGraphNode struct {
+	ID       string `json:"id"`
+	SHA      string `json:"sha"`
+	Repo     string `json:"repo"`
+	Author   string `json:"author"`
+	Recorded bool   `json:"recorded"`
+}
+
+// PackageDependencyGraphEdge is a dependency of one package version on another
+// in a PackageDependencyGraph.
+type PackageDependencyGraphEdge struct {
+	To       string   `json:"to"`
+	From     string   `json:"from"`
+	Strategy string   `json:"strategy"`
+	Subpaths []string `json:"subpaths"`
+}
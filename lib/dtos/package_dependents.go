@@ -0,0 +1,21 @@
+package dtos
+
+//go:generate ffjson $GOFILE
+
+// PackageDependent is the DTO for a package version that depends on another
+// package.
+type PackageDependent struct {
+	SHA           string   `json:"sha"`
+	Repo          string   `json:"repo"`
+	Author        string   `json:"author"`
+	Subpaths      []string `json:"subpaths"`
+	DependencySHA string   `json:"dependencySHA"`
+}
+
+// PackageDependents is the DTO for a page of the dependents of a package.
+type PackageDependents struct {
+	Next       string             `json:"next,omitempty"`
+	Repo       string             `json:"repo"`
+	Author     string             `json:"author"`
+	Dependents []PackageDependent `json:"dependents"`
+}
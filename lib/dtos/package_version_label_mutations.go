@@ -0,0 +1,23 @@
+package dtos
+
+import "time"
+
+//go:generate ffjson $GOFILE
+
+// PackageVersionLabelMutation is the DTO for an event in which a pinned version
+// label of a package was moved upstream. SHA is what the label still resolves
+// to, and UpstreamSHA is what the label was moved to.
+type PackageVersionLabelMutation struct {
+	SHA          string    `json:"sha"`
+	Label        string    `json:"label"`
+	UpstreamSHA  string    `json:"upstreamSHA"`
+	DateDetected time.Time `json:"dateDetected"`
+}
+
+// PackageVersionLabelMutations is the DTO for the version label mutations of a
+// package.
+type PackageVersionLabelMutations struct {
+	Repo      string                        `json:"repo"`
+	Author    string                        `json:"author"`
+	Mutations []PackageVersionLabelMutation `json:"mutations"`
+}
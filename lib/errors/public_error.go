@@ -2,12 +2,16 @@ package errors
 
 import (
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 const (
 	unexpectedErrorMessage    = "An unexpected internal server error occurred."
 	nonPublicLogMessageFormat = "Could not repsond to request with non-public error: %v"
+	httpRetryAfterHeader      = "Retry-After"
 )
 
 // PublicError is an error that has an outside-friendly error message, and a
@@ -24,12 +28,25 @@ type ResultingError interface {
 	Causes() []error
 }
 
+// RetryableError is an error that is expected to go away after a while.
+type RetryableError interface {
+	// RetryAfter returns how long it is until the error should go away.
+	RetryAfter() time.Duration
+}
+
 // RespondWithError responds, using the supplied response writer, with an error.
 // If the error is a PublicError, then its message is sent in the response.
-// Otherwise, a generic internal server error message is sent.
+// Otherwise, a generic internal server error message is sent. If the error is
+// also a RetryableError, clients are told when to retry via the Retry-After
+// header.
 func RespondWithError(w http.ResponseWriter, err error) {
 	switch e := err.(type) {
 	case PublicError:
+		if retryable, ok := err.(RetryableError); ok {
+			w.Header().Set(httpRetryAfterHeader, strconv.Itoa(
+				int(math.Ceil(retryable.RetryAfter().Seconds()))))
+		}
+
 		statusCode, errorMessage := e.PublicError()
 		w.WriteHeader(statusCode)
 		w.Write([]byte(errorMessage))
@@ -60,6 +60,41 @@ func (gc *client) CreateCommit(
 	return err
 }
 
+// CreateSignedCommit creates a commit that is signed by sign, and points the
+// ref at it.
+func (gc *client) CreateSignedCommit(
+	repo *git.Repository,
+	refname string,
+	author *git.Signature,
+	committer *git.Signature,
+	message string,
+	tree *git.Tree,
+	sign CommitSigner,
+) error {
+	commit, err := repo.CreateCommitBuffer(
+		author,
+		committer,
+		git.MessageEncodingUTF8,
+		message,
+		tree,
+	)
+	if err != nil {
+		return err
+	}
+
+	commitID, err := repo.CreateCommitWithSignature(
+		string(commit),
+		sign(commit),
+		"gpgsig",
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.References.Create(refname, commitID, true, message)
+	return err
+}
+
 func (gc *client) CreateRef(
 	repo *git.Repository,
 	name string,
@@ -2,6 +2,10 @@ package git
 
 import git "github.com/libgit2/git2go"
 
+// CommitSigner signs the contents of a commit object, and returns the
+// signature to store in the commit.
+type CommitSigner func(commit []byte) string
+
 // Client is the external interface of client.
 type Client interface {
 	InitRepo(archiveDirPath string, bare bool) (*git.Repository, error)
@@ -18,6 +22,15 @@ type Client interface {
 		message string,
 		tree *git.Tree,
 	) error
+	CreateSignedCommit(
+		repo *git.Repository,
+		refname string,
+		author *git.Signature,
+		committer *git.Signature,
+		message string,
+		tree *git.Tree,
+		sign CommitSigner,
+	) error
 	CreateRef(
 		repo *git.Repository,
 		name string,
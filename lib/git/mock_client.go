@@ -64,6 +64,20 @@ func (m *MockClient) CreateCommit(
 	return args.Error(0)
 }
 
+// CreateSignedCommit mocks GitClint#CreateSignedCommit.
+func (m *MockClient) CreateSignedCommit(
+	repo *git.Repository,
+	refname string,
+	author *git.Signature,
+	committer *git.Signature,
+	message string,
+	tree *git.Tree,
+	sign CommitSigner,
+) error {
+	args := m.Called(repo, refname, author, committer, message, tree, sign)
+	return args.Error(0)
+}
+
 // CreateRef mocks GitClint#CreateRef.
 func (m *MockClient) CreateRef(
 	repo *git.Repository,
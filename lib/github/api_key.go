@@ -100,24 +100,23 @@ func (key *apiKey) update(header http.Header) *apiKey {
 	return key
 }
 
-// canBeUsed returns true if this key has remaining usages.
+// canBeUsed returns true if this key has remaining usages, or if its rate
+// limit has been reset since it was last used.
 func (key *apiKey) canBeUsed() bool {
 	key.dataLock.RLock()
-	hasRemainingRequests := key.remainingUses > 0
+	canBeUsed := key.remainingUses > 0 ||
+		!time.Now().Before(key.rateLimitResetTime)
 	key.dataLock.RUnlock()
 
-	return hasRemainingRequests
+	return canBeUsed
 }
 
-// waitUntilUseful blocks until this key can be used.
-func (key *apiKey) waitUntilUseful() {
+// usage returns the remaining usages of this key, and when they are reset.
+func (key *apiKey) usage() (int, time.Time) {
 	key.dataLock.RLock()
-	resetTime := key.rateLimitResetTime
-	sleepTime := resetTime.Sub(time.Now())
-	key.dataLock.RUnlock()
+	defer key.dataLock.RUnlock()
 
-	log.Printf("Github API Key is sleeping until %s.\n", sleepTime.String())
-	time.Sleep(sleepTime)
+	return key.remainingUses, key.rateLimitResetTime
 }
 
 // updateByRequest updates usage metadata by calling the Github API.
@@ -143,8 +143,9 @@ func (chain *apiKeyChain) refreshKeys() error {
 }
 
 // acquireKey employs a round-robin policy to find the next Github API key. If
-// no usable keys are found, it blocks until a key is available.
-func (chain *apiKeyChain) acquireKey() *apiKey {
+// no usable keys are found, a RateLimitExceededError is returned instead of
+// waiting for one, so that callers can decide how to wait.
+func (chain *apiKeyChain) acquireKey() (*apiKey, error) {
 	chain.lock.Lock()
 	keys := chain.keys
 	cursor := chain.cursor
@@ -152,20 +153,37 @@ func (chain *apiKeyChain) acquireKey() *apiKey {
 	chain.lock.Unlock()
 
 	if key := keys[cursor%len(keys)]; key.canBeUsed() {
-		return key
+		return key, nil
 	}
 
 	// This key is spent. Gotta find another one.
 	for i := 0; i < len(keys); i++ {
 		if key := keys[(i+cursor)%len(keys)]; key.canBeUsed() {
-			return key
+			return key, nil
 		}
 	}
 
-	// There are no keys presently available. Wait for the original one.
-	key := keys[cursor%len(keys)]
-	key.waitUntilUseful()
-	return key
+	// There are no keys presently available.
+	return nil, NewRateLimitExceededError(chain.quota().ResetTime)
+}
+
+// quota adds up how much of the rate limit the keys of the chain have left.
+func (chain *apiKeyChain) quota() Quota {
+	chain.lock.RLock()
+	keys := chain.keys
+	chain.lock.RUnlock()
+
+	quota := Quota{Keys: len(keys)}
+	for _, key := range keys {
+		remainingUses, resetTime := key.usage()
+		if key.canBeUsed() {
+			quota.Remaining += remainingUses
+		} else if quota.ResetTime.IsZero() || resetTime.Before(quota.ResetTime) {
+			quota.ResetTime = resetTime
+		}
+	}
+
+	return quota
 }
 
 // readGithubKeysFromSecret reads Github API keys from a secrets file.
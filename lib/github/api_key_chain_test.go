@@ -0,0 +1,66 @@
+package github
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyChainAcquireKey(t *testing.T) {
+	var (
+		now      = time.Now()
+		spentKey = &apiKey{token: "a", rateLimitResetTime: now.Add(time.Hour)}
+		laterKey = &apiKey{token: "b", rateLimitResetTime: now.Add(2 * time.Hour)}
+		freshKey = &apiKey{token: "c", remainingUses: 10, rateLimitResetTime: now.Add(time.Hour)}
+		chain    = &apiKeyChain{keys: []*apiKey{spentKey, laterKey, freshKey}}
+	)
+
+	// Spent keys are skipped.
+	for i := 0; i < 3; i++ {
+		key, err := chain.acquireKey()
+		assert.Nil(t, err)
+		assert.Equal(t, freshKey, key)
+	}
+
+	assert.Equal(t, Quota{Keys: 3, Remaining: 10, ResetTime: spentKey.rateLimitResetTime}, chain.quota())
+
+	// Once every key is spent, the earliest reset time is reported instead of
+	// waiting for it.
+	freshKey.remainingUses = 0
+	_, err := chain.acquireKey()
+	assert.Equal(t, NewRateLimitExceededError(spentKey.rateLimitResetTime), err)
+	assert.Equal(t, Quota{Keys: 3, Remaining: 0, ResetTime: spentKey.rateLimitResetTime}, chain.quota())
+
+	// Keys become usable again as soon as their rate limit is reset.
+	spentKey.rateLimitResetTime = now.Add(-time.Second)
+	key, err := chain.acquireKey()
+	assert.Nil(t, err)
+	assert.Equal(t, spentKey, key)
+}
+
+func TestRateLimitExceededError(t *testing.T) {
+	err := NewRateLimitExceededError(time.Now().Add(time.Hour))
+	assert.InDelta(t, time.Hour, err.RetryAfter(), float64(time.Minute))
+
+	statusCode, message := err.PublicError()
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.Contains(t, message, err.ResetTime.UTC().Format(time.RFC3339))
+
+	// Clients should never be asked to retry right away.
+	err = NewRateLimitExceededError(time.Now().Add(-time.Hour))
+	assert.Equal(t, minRetryAfter, err.RetryAfter())
+}
+
+func TestQuotaSetHeaders(t *testing.T) {
+	header := http.Header{}
+	Quota{Keys: 2, Remaining: 42}.SetHeaders(header)
+	assert.Equal(t, "2", header.Get(httpHeaderQuotaKeys))
+	assert.Equal(t, "42", header.Get(httpHeaderQuotaRemaining))
+	assert.Empty(t, header.Get(httpHeaderQuotaReset))
+
+	Quota{Keys: 2, ResetTime: time.Unix(1500000000, 0)}.SetHeaders(header)
+	assert.Equal(t, "0", header.Get(httpHeaderQuotaRemaining))
+	assert.Equal(t, "1500000000", header.Get(httpHeaderQuotaReset))
+}
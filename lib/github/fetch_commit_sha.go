@@ -21,7 +21,9 @@ import (
 const ddEventFetchCommitSHA = "github.fetch-commit-sha"
 
 // FetchCommitSHA fetches the commit SHA that is chronologically closest to a
-// given timestamp.
+// given timestamp. If the rate limit of every key has been exceeded, a
+// RateLimitExceededError is returned instead of settling for the latest
+// commit.
 func (svc *requestServiceImpl) FetchCommitSHA(
 	author string,
 	repo string,
@@ -58,6 +60,12 @@ func (svc *requestServiceImpl) FetchCommitSHA(
 		commitsUntilParameter)
 	if err == nil {
 		return commitSHA, nil
+	} else if _, rateLimited := err.(RateLimitExceededError); rateLimited {
+		// Falling back would only give up on the commit date for no reason, since
+		// the quota is spent either way.
+		trackingArgs.AlertType = datadog.Error
+		trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+		return "", err
 	}
 
 	// Fetch commits chronologically after the timestamp.
@@ -68,6 +76,10 @@ func (svc *requestServiceImpl) FetchCommitSHA(
 		commitsAfterParameter)
 	if err == nil {
 		return commitSHA, nil
+	} else if _, rateLimited := err.(RateLimitExceededError); rateLimited {
+		trackingArgs.AlertType = datadog.Error
+		trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+		return "", err
 	}
 
 	// Make sure that the anomaly is recorded in the datadog transaction.
@@ -75,14 +87,13 @@ func (svc *requestServiceImpl) FetchCommitSHA(
 		trackingArgs.EventInfo,
 		`Failed to get the commit SHA both before and after the timestamp.`)
 
-	// Before and after failed somehow. Just take the latest.
+	// Before and after failed somehow. Just take the latest commit of the
+	// default branch.
 	refs, err := lib.FetchRefs(author, repo)
-	if err != nil {
-		// Make sure that the error is recorded in the datadog transaction.
-		trackingArgs.AlertType = datadog.Error
-		trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
-
-		return refs.MasterRefHash, nil
+	if err == nil && len(refs.DefaultRefHash) > 0 {
+		return refs.DefaultRefHash, nil
+	} else if err == nil {
+		err = fmt.Errorf(`Could not find the default branch of "%s/%s".`, author, repo)
 	}
 
 	// Make sure that the error is recorded in the datadog transaction.
@@ -14,7 +14,12 @@ func (svc *requestServiceImpl) fetchCommitSHAByTimeSelector(
 	timeSelector string,
 ) (string, error) {
 	for attempts := 0; attempts < githubAPIAttemptsLimit; attempts++ {
-		resp, err := svc.keyChain.acquireKey().getFromGithub(
+		key, err := svc.keyChain.acquireKey()
+		if err != nil {
+			return "", err
+		}
+
+		resp, err := key.getFromGithub(
 			buildGitHubRepoCommitsFromTimestampAPIURL(
 				author,
 				repo,
@@ -0,0 +1,24 @@
+package github
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/datadog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchCommitSHARateLimited(t *testing.T) {
+	resetTime := time.Now().Add(time.Hour)
+	svc := &requestServiceImpl{
+		ddClient: datadog.NewFakeDataDogClient(),
+		keyChain: &apiKeyChain{keys: []*apiKey{
+			{token: "a", rateLimitResetTime: resetTime},
+		}},
+	}
+
+	// Spent keys don't send the search over to the default branch.
+	sha, err := svc.FetchCommitSHA("a", "b", time.Now())
+	assert.Empty(t, sha)
+	assert.Equal(t, NewRateLimitExceededError(resetTime), err)
+}
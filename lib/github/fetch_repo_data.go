@@ -47,7 +47,16 @@ func (svc *requestServiceImpl) FetchRepoData(
 `, author, repo)
 
 	for attempts := 0; attempts < githubAPIAttemptsLimit; attempts++ {
-		resp, err := svc.keyChain.acquireKey().getFromGithub(
+		key, err := svc.keyChain.acquireKey()
+		if err != nil {
+			// Make sure that the error is recorded in the datadog transaction.
+			trackingArgs.AlertType = datadog.Error
+			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+
+			return dtos.GithubRepo{}, err
+		}
+
+		resp, err := key.getFromGithub(
 			buildGitHubRepoDataAPIURL(
 				author,
 				repo))
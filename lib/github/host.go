@@ -0,0 +1,97 @@
+package github
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+const (
+	githubTreeURLTemplate    = "https://github.com/%s/%s/tree/%s{/dir}"
+	githubRawFileURLTemplate = "https://raw.githubusercontent.com/%s/%s/%s/%s"
+)
+
+// host is the vcs.Host implementation for Github. Every API request goes
+// through a RequestService.
+type host struct {
+	svc        RequestService
+	doHTTPHead HTTPHeadReq
+}
+
+// NewHost creates a new vcs.Host for Github that makes requests of the Github
+// API using svc.
+func NewHost(svc RequestService, doHTTPHead HTTPHeadReq) vcs.Host {
+	return &host{svc: svc, doHTTPHead: doHTTPHead}
+}
+
+// NewHosts creates the set of every vcs.Host supported by gophr, along with
+// the specified generic hosts (see vcs.NewGenericHosts). Requests of the
+// Github API go through svc.
+func NewHosts(svc RequestService, genericHosts ...vcs.Host) vcs.Hosts {
+	return vcs.NewHosts(append(
+		genericHosts,
+		NewHost(svc, DoHTTPHeadReq),
+		vcs.NewGitLabHost(),
+		vcs.NewBitbucketHost())...)
+}
+
+// Domain returns the domain of Github.
+func (h *host) Domain() string {
+	return vcs.GithubDomain
+}
+
+// ArchiveURL returns the URL of the zip archive of a repository at the
+// specified commit.
+func (h *host) ArchiveURL(author, repo, sha string) string {
+	return fmt.Sprintf(baseGithubArchiveURL, author, repo, sha)
+}
+
+// RawFileURL returns the URL of the contents of a file of a repository at the
+// specified commit.
+func (h *host) RawFileURL(author, repo, sha, path string) string {
+	return fmt.Sprintf(githubRawFileURLTemplate, author, repo, sha, path)
+}
+
+// TreeURLTemplate returns the go-source directory URL template of a
+// repository at the specified ref.
+func (h *host) TreeURLTemplate(author, repo, ref string) string {
+	if len(ref) < 1 {
+		ref = vcs.DefaultRef
+	}
+
+	return fmt.Sprintf(githubTreeURLTemplate, author, repo, ref)
+}
+
+// FetchCommitSHA fetches the commit SHA that is chronologically closest to a
+// given timestamp.
+func (h *host) FetchCommitSHA(
+	author string,
+	repo string,
+	timestamp time.Time,
+) (string, error) {
+	return h.svc.FetchCommitSHA(author, repo, timestamp)
+}
+
+// FetchCommitTimestamp fetches the timestamp of a commit.
+func (h *host) FetchCommitTimestamp(
+	author string,
+	repo string,
+	sha string,
+) (time.Time, error) {
+	return h.svc.FetchCommitTimestamp(author, repo, sha)
+}
+
+// ExpandPartialSHA fetches the full commit SHA that corresponds to a short SHA.
+func (h *host) ExpandPartialSHA(
+	author string,
+	repo string,
+	shortSHA string,
+) (string, error) {
+	return h.svc.ExpandPartialSHA(ExpandPartialSHAArgs{
+		Author:     author,
+		Repo:       repo,
+		ShortSHA:   shortSHA,
+		DoHTTPHead: h.doHTTPHead,
+	})
+}
@@ -0,0 +1,64 @@
+package github
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHost(t *testing.T) {
+	svc := NewMockRequestService()
+	h := NewHost(svc, nil)
+
+	assert.Equal(t, vcs.GithubDomain, h.Domain())
+	assert.Equal(t, "https://github.com/a/b/archive/c.zip", h.ArchiveURL("a", "b", "c"))
+	assert.Equal(
+		t,
+		"https://raw.githubusercontent.com/a/b/c/d/go.mod",
+		h.RawFileURL("a", "b", "c", "d/go.mod"))
+	assert.Equal(t, "https://github.com/a/b/tree/c{/dir}", h.TreeURLTemplate("a", "b", "c"))
+	assert.Equal(t, "https://github.com/a/b/tree/HEAD{/dir}", h.TreeURLTemplate("a", "b", ""))
+
+	now := time.Now()
+	svc.On("FetchCommitSHA", "a", "b", now).Return("sha", nil)
+	svc.On("FetchCommitTimestamp", "a", "b", "sha").Return(now, nil)
+	svc.On("ExpandPartialSHA", mock.AnythingOfType("github.ExpandPartialSHAArgs")).
+		Return("fullsha", nil)
+
+	sha, err := h.FetchCommitSHA("a", "b", now)
+	assert.Nil(t, err)
+	assert.Equal(t, "sha", sha)
+
+	timestamp, err := h.FetchCommitTimestamp("a", "b", "sha")
+	assert.Nil(t, err)
+	assert.Equal(t, now, timestamp)
+
+	sha, err = h.ExpandPartialSHA("a", "b", "short")
+	assert.Nil(t, err)
+	assert.Equal(t, "fullsha", sha)
+	svc.AssertExpectations(t)
+}
+
+func TestNewHosts(t *testing.T) {
+	hosts := NewHosts(NewMockRequestService())
+
+	host, _, _ := hosts.Of("a")
+	assert.Equal(t, vcs.GithubDomain, host.Domain())
+	host, _, _ = hosts.Of("gitlab.com:a")
+	assert.Equal(t, vcs.GitLabDomain, host.Domain())
+	host, _, _ = hosts.Of("bitbucket.org:a")
+	assert.Equal(t, vcs.BitbucketDomain, host.Domain())
+
+	// Generic hosts are only supported when they are asked for.
+	_, _, err := hosts.Of("git.example.com:a")
+	assert.NotNil(t, err)
+
+	hosts = NewHosts(NewMockRequestService(), vcs.NewGenericHost("git.example.com"))
+	host, _, _ = hosts.Of("git.example.com:a")
+	assert.Equal(t, "git.example.com", host.Domain())
+	host, _, _ = hosts.Of("a")
+	assert.Equal(t, vcs.GithubDomain, host.Domain())
+}
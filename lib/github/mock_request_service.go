@@ -53,3 +53,9 @@ func (m *MockRequestService) FetchCommitTimestamp(
 	args := m.Called(author, repo, sha)
 	return args.Get(0).(time.Time), args.Error(1)
 }
+
+// Quota mocks RequestService.Quota.
+func (m *MockRequestService) Quota() Quota {
+	args := m.Called()
+	return args.Get(0).(Quota)
+}
@@ -0,0 +1,74 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// minRetryAfter is the shortest time that clients are asked to wait for
+	// before retrying after the Github API rate limit is exceeded.
+	minRetryAfter = 1 * time.Second
+
+	httpHeaderQuotaKeys      = "X-Github-Quota-Keys"
+	httpHeaderQuotaReset     = "X-Github-Quota-Reset"
+	httpHeaderQuotaRemaining = "X-Github-Quota-Remaining"
+)
+
+// Quota is how much of the Github API rate limit a RequestService has left.
+type Quota struct {
+	// Keys is the number of Github API keys that the quota is spread across.
+	Keys int
+	// Remaining is the number of requests that can be made before every key is
+	// exhausted.
+	Remaining int
+	// ResetTime is when the first exhausted key becomes usable again. It is
+	// zero if none of the keys are exhausted.
+	ResetTime time.Time
+}
+
+// SetHeaders describes the quota in the headers of an HTTP response. The reset
+// time is only set if some of the keys are exhausted.
+func (quota Quota) SetHeaders(header http.Header) {
+	header.Set(httpHeaderQuotaKeys, strconv.Itoa(quota.Keys))
+	header.Set(httpHeaderQuotaRemaining, strconv.Itoa(quota.Remaining))
+	if !quota.ResetTime.IsZero() {
+		header.Set(httpHeaderQuotaReset, strconv.FormatInt(quota.ResetTime.Unix(), 10))
+	}
+}
+
+// RateLimitExceededError is an error that occurs when every Github API key of
+// a RequestService has been exhausted.
+type RateLimitExceededError struct {
+	ResetTime time.Time
+}
+
+// NewRateLimitExceededError creates a new RateLimitExceededError.
+func NewRateLimitExceededError(resetTime time.Time) RateLimitExceededError {
+	return RateLimitExceededError{ResetTime: resetTime}
+}
+
+func (err RateLimitExceededError) Error() string {
+	return fmt.Sprintf(
+		`Every Github API key has been exhausted until %s.`,
+		err.ResetTime.UTC().Format(time.RFC3339))
+}
+
+// RetryAfter returns how long it is until the Github API may be used again.
+func (err RateLimitExceededError) RetryAfter() time.Duration {
+	if retryAfter := err.ResetTime.Sub(time.Now()); retryAfter > minRetryAfter {
+		return retryAfter
+	}
+
+	return minRetryAfter
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err RateLimitExceededError) PublicError() (int, string) {
+	return http.StatusServiceUnavailable, fmt.Sprintf(
+		`Gophr is out of Github API quota until %s. Please try again then.`,
+		err.ResetTime.UTC().Format(time.RFC3339))
+}
@@ -44,6 +44,8 @@ type RequestService interface {
 		author string,
 		repo string,
 		sha string) (time.Time, error)
+	// Quota returns how much of the Github API rate limit is left.
+	Quota() Quota
 }
 
 // requestServiceImpl is the implementation of the RequestService.
@@ -73,3 +75,8 @@ func NewRequestService(args RequestServiceArgs) (RequestService, error) {
 	svc := &requestServiceImpl{keyChain: keyChain, ddClient: args.DDClient}
 	return svc, nil
 }
+
+// Quota returns how much of the Github API rate limit is left.
+func (svc *requestServiceImpl) Quota() Quota {
+	return svc.keyChain.quota()
+}
@@ -47,4 +47,10 @@ type IO interface {
 	// newpath are in different directories. If there is an error, it will be of
 	// type *LinkError.
 	Rename(oldpath, newpath string) error
+	// Symlink creates newname as a symbolic link to oldname. If there is an
+	// error, it will be of type *LinkError.
+	Symlink(oldname, newname string) error
+	// Readlink returns the destination of the named symbolic link. If there is
+	// an error, it will be of type *PathError.
+	Readlink(name string) (string, error)
 }
@@ -52,6 +52,16 @@ func (r *ioImpl) Rename(oldpath, newpath string) error {
 	return os.Rename(oldpath, newpath)
 }
 
+// Symlink calls os.Symlink.
+func (r *ioImpl) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+// Readlink calls os.Readlink.
+func (r *ioImpl) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
 // NewIO creates a new IO.
 func NewIO() IO {
 	return &ioImpl{}
@@ -64,6 +64,18 @@ func (m *MockIO) Rename(oldpath, newpath string) error {
 	return args.Error(0)
 }
 
+// Symlink mocks os.Symlink.
+func (m *MockIO) Symlink(oldname, newname string) error {
+	args := m.Called(oldname, newname)
+	return args.Error(0)
+}
+
+// Readlink mocks os.Readlink.
+func (m *MockIO) Readlink(name string) (string, error) {
+	args := m.Called(name)
+	return args.String(0), args.Error(1)
+}
+
 // NewMockIO creates a new io mock.
 func NewMockIO() *MockIO {
 	return &MockIO{}
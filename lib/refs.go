@@ -13,36 +13,40 @@ import (
 	"time"
 
 	"github.com/gophr-pm/gophr/lib/semver"
+	"github.com/gophr-pm/gophr/lib/vcs"
 )
 
 const (
-	errorRefsFetchNoSuchRepo       = "Could not find a Github repository at %s"
-	errorRefsFetchGithubError      = "Github responded with an error: %v"
-	errorRefsFetchGithubParseError = "Cannot read refs from Github: %v"
-	errorRefsFetchNetworkFailure   = "Could not reach Github at the moment; Please try again later"
-	errorRefsParseSizeFormat       = "Could not parse refs line size: %s"
-	errorRefsParseIncompleteRefs   = "Incomplete refs data received from GitHub"
+	errorRefsFetchNoSuchRepo      = "Could not find a repository at %s"
+	errorRefsFetchHostError       = "%s responded with an error: %v"
+	errorRefsFetchHostParseError  = "Cannot read refs from %s: %v"
+	errorRefsFetchNetworkFailure  = "Could not reach %s at the moment; Please try again later"
+	errorRefsFetchNonPublicDomain = "%s is not a public domain"
+	errorRefsParseSizeFormat      = "Could not parse refs line size: %s"
+	errorRefsParseIncompleteRefs  = "Incomplete refs data received from the package host"
 )
 
 const (
-	versionRefRegexIndexLabel             = 1
-	versionRefRegexIndexMajorVersion      = 2
-	versionRefRegexIndexMinorVersion      = 3
-	versionRefRegexIndexPatchVersion      = 4
-	versionRefRegexIndexPrereleaseLabel   = 5
-	versionRefRegexIndexPrereleaseVersion = 6
+	versionRefRegexIndexLabel         = 1
+	versionRefRegexIndexMajorVersion  = 2
+	versionRefRegexIndexMinorVersion  = 3
+	versionRefRegexIndexPatchVersion  = 4
+	versionRefRegexIndexPrerelease    = 5
+	versionRefRegexIndexBuildMetadata = 6
 )
 
 const (
 	refsHead                                  = "HEAD"
 	refsLineCap                               = "\n\x00"
 	refsSpaceChar                             = ' '
+	refsTagPrefix                             = "refs/tags/"
 	refsHeadPrefix                            = "refs/heads/"
 	refsLineFormat                            = "%04x%s"
 	refsHeadMaster                            = "refs/heads/master"
-	githubRootTemplate                        = "github.com/%s/%s"
-	refsMasterLineFormat                      = "%s refs/heads/master\n"
+	refsBranchLineFormat                      = "%s refs/heads/%s\n"
 	refsSymRefAssignment                      = "symref="
+	refsSymRefHeadAssignment                  = "symref=HEAD:"
+	refsCapabilitiesSeparator                 = "\x00"
 	refsOldRefAssignment                      = "oldref="
 	refsFetchURLTemplate                      = "https://%s.git/info/refs?service=git-upload-pack"
 	refsAugmentedHeadLineFormat               = "%s HEAD\n"
@@ -53,21 +57,33 @@ const (
 
 var (
 	httpClient      = &http.Client{Timeout: 10 * time.Second}
-	versionRefRegex = regexp.MustCompile(`^refs\/(?:tags|heads)\/(v?([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?(?:\-([a-zA-Z0-9\-_]+))?(?:\.([0-9]+))?)(?:\^\{\})?$`)
+	versionRefRegex = regexp.MustCompile(`^refs\/(?:tags|heads)\/(v?([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?(?:\-([0-9A-Za-z\-_]+(?:\.[0-9A-Za-z\-_]+)*))?(?:\+([0-9A-Za-z\-]+(?:\.[0-9A-Za-z\-]+)*))?)(?:\^\{\})?$`)
+	// moduleTagRefRegex matches the tags of go modules that live in
+	// sub-directories of the repository (e.g. "refs/tags/sub/module/v1.2.3").
+	// The first capture group is the directory, and the second is the version.
+	moduleTagRefRegex = regexp.MustCompile(`^refs/tags/((?:[^/]+/)*[^/]+)/(v[0-9][^/]*)$`)
 )
 
 // Refs collects information about git references for one specific repository.
 type Refs struct {
-	Data                 []byte
-	DataStr              string
-	DataLen              int
-	DataStrLen           int
-	Candidates           semver.SemverCandidateList
-	MasterRefHash        string
-	IndexHeadLineEnd     int
-	IndexHeadLineStart   int
-	IndexMasterLineEnd   int
-	IndexMasterLineStart int
+	Data               []byte
+	DataStr            string
+	DataLen            int
+	DataStrLen         int
+	Branches           map[string]string
+	Candidates         semver.SemverCandidateList
+	DefaultBranch      string
+	DefaultRefHash     string
+	IndexHeadLineEnd   int
+	IndexHeadLineStart int
+	// IndexDefaultBranchLineEnd and IndexDefaultBranchLineStart bound the line
+	// of the default branch. Both are zero if there is no such line.
+	IndexDefaultBranchLineEnd   int
+	IndexDefaultBranchLineStart int
+	// SubdirCandidates maps the directory of every go module in the repository
+	// that has tags of its own (e.g. "sub/module" for "sub/module/v1.2.3") to
+	// its version candidates. Those tags are left out of Candidates.
+	SubdirCandidates map[string]semver.SemverCandidateList
 }
 
 // NewRefs creates a new Refs instance from raw refs data fetched from Github
@@ -78,12 +94,14 @@ func NewRefs(data []byte) (Refs, error) {
 		dataLen    = len(data)
 		dataStrLen = len(dataStr)
 
-		masterRefHash                                 string
-		indexHashStart, indexHashEnd                  int
-		indexNameStart, indexNameEnd                  int
-		indexHeadLineStart, indexHeadLineEnd          int
-		indexMasterLineStart, indexMasterLineEnd      int
-		versionCandidates, sanitizedVersionCandidates []semver.SemverCandidate
+		headRefHash, headSymRef              string
+		branches                             = make(map[string]string)
+		indexHashStart, indexHashEnd         int
+		indexNameStart, indexNameEnd         int
+		indexHeadLineStart, indexHeadLineEnd int
+		branchLineBounds                     = make(map[string][2]int)
+		versionCandidates                    []semver.SemverCandidate
+		subdirVersionCandidates              map[string][]semver.SemverCandidate
 	)
 
 	for i, j := 0, 0; i < dataLen; i = j {
@@ -148,107 +166,304 @@ func NewRefs(data []byte) (Refs, error) {
 		hash := dataStr[indexHashStart:indexHashEnd]
 		name := dataStr[indexNameStart:indexNameEnd]
 
+		// Remember the head of every branch, so that branches can be selected by
+		// name.
+		if strings.HasPrefix(name, refsHeadPrefix) {
+			branches[name[len(refsHeadPrefix):]] = hash
+			branchLineBounds[name[len(refsHeadPrefix):]] = [2]int{i, j}
+		}
+
 		// Process the name and hash according to whether the name is relevant
 		if name == refsHead {
 			indexHeadLineStart = i
 			indexHeadLineEnd = j
-		} else if name == refsHeadMaster {
-			indexMasterLineStart = i
-			indexMasterLineEnd = j
-			masterRefHash = hash
+			headRefHash = hash
+			headSymRef = readHeadSymRef(dataStr[indexNameEnd:j])
 		} else if captureGroups := versionRefRegex.FindStringSubmatch(name); captureGroups != nil {
-			var (
-				gitRefLabel       = captureGroups[versionRefRegexIndexLabel]
-				majorVersion      = captureGroups[versionRefRegexIndexMajorVersion]
-				minorVersion      = captureGroups[versionRefRegexIndexMinorVersion]
-				patchVersion      = captureGroups[versionRefRegexIndexPatchVersion]
-				prereleaseLabel   = captureGroups[versionRefRegexIndexPrereleaseLabel]
-				prereleaseVersion = captureGroups[versionRefRegexIndexPrereleaseVersion]
-			)
-
-			// Annotated tag is peeled off and overrides the same version just parsed
-			if strings.HasSuffix(name, "^{}") {
-				name = name[:len(name)-3]
-			}
-
-			versionCandidate, err := semver.NewSemverCandidate(
+			if versionCandidate, err := newVersionCandidate(
 				hash,
 				name,
-				gitRefLabel,
-				majorVersion,
-				minorVersion,
-				patchVersion,
-				prereleaseLabel,
-				prereleaseVersion)
-			if err == nil {
-				versionCandidates = append(versionCandidates, versionCandidate)
+				captureGroups,
+			); err == nil {
+				versionCandidates = appendVersionCandidate(
+					versionCandidates,
+					versionCandidate,
+					name)
+			}
+		} else if moduleTagGroups := moduleTagRefRegex.FindStringSubmatch(name); moduleTagGroups != nil {
+			// Tags of go modules in sub-directories are versions of those modules
+			// alone.
+			subdir, version := moduleTagGroups[1], moduleTagGroups[2]
+			if captureGroups := versionRefRegex.FindStringSubmatch(
+				refsTagPrefix + version,
+			); captureGroups != nil {
+				if versionCandidate, err := newVersionCandidate(
+					hash,
+					name,
+					captureGroups,
+				); err == nil {
+					if subdirVersionCandidates == nil {
+						subdirVersionCandidates = make(map[string][]semver.SemverCandidate)
+					}
+
+					subdirVersionCandidates[subdir] = appendVersionCandidate(
+						subdirVersionCandidates[subdir],
+						versionCandidate,
+						name)
+				}
 			}
 		}
 	}
 
-	if versionCandidates != nil && len(versionCandidates) > 0 {
-		// First attach the sortable type to the slice of candidates.
-		versionCandidatesList := semver.SemverCandidateList(versionCandidates)
-		// Sort the list of candidates.
-		sort.Sort(versionCandidatesList)
-		// Remove duplicates by adding them to a new slice altogether.
-		var lastInsertedCandidate semver.SemverCandidate
-		for i, versionCandidate := range versionCandidatesList {
-			if i == 0 || versionCandidate.CompareTo(lastInsertedCandidate) != 0 {
-				sanitizedVersionCandidates = append(sanitizedVersionCandidates, versionCandidate)
-				lastInsertedCandidate = versionCandidate
-			}
+	var subdirCandidates map[string]semver.SemverCandidateList
+	if len(subdirVersionCandidates) > 0 {
+		subdirCandidates = make(map[string]semver.SemverCandidateList)
+		for subdir, candidates := range subdirVersionCandidates {
+			subdirCandidates[subdir] = sanitizeVersionCandidates(candidates)
 		}
 	}
 
+	// The default branch is the one that HEAD points to. Hosts that don't
+	// advertise where HEAD points are assumed to use master, if it exists.
+	defaultBranch := ""
+	if strings.HasPrefix(headSymRef, refsHeadPrefix) {
+		defaultBranch = headSymRef[len(refsHeadPrefix):]
+	} else if _, exists := branches[refsHeadMaster[len(refsHeadPrefix):]]; exists {
+		defaultBranch = refsHeadMaster[len(refsHeadPrefix):]
+	}
+
+	// Without a known default branch, HEAD itself is the best bet.
+	defaultRefHash, exists := branches[defaultBranch]
+	if !exists {
+		defaultRefHash = headRefHash
+	}
+
+	// Remember where the line of the default branch is, so that it can be
+	// swapped out when the refs are reserialized.
+	defaultBranchLineBounds := branchLineBounds[defaultBranch]
+
 	return Refs{
-		Data:                 data,
-		DataStr:              dataStr,
-		DataLen:              dataLen,
-		DataStrLen:           dataStrLen,
-		Candidates:           sanitizedVersionCandidates,
-		MasterRefHash:        masterRefHash,
-		IndexHeadLineEnd:     indexHeadLineEnd,
-		IndexMasterLineEnd:   indexMasterLineEnd,
-		IndexHeadLineStart:   indexHeadLineStart,
-		IndexMasterLineStart: indexMasterLineStart,
+		Data:                        data,
+		DataStr:                     dataStr,
+		DataLen:                     dataLen,
+		DataStrLen:                  dataStrLen,
+		Branches:                    branches,
+		Candidates:                  sanitizeVersionCandidates(versionCandidates),
+		DefaultBranch:               defaultBranch,
+		SubdirCandidates:            subdirCandidates,
+		DefaultRefHash:              defaultRefHash,
+		IndexHeadLineEnd:            indexHeadLineEnd,
+		IndexDefaultBranchLineEnd:   defaultBranchLineBounds[1],
+		IndexHeadLineStart:          indexHeadLineStart,
+		IndexDefaultBranchLineStart: defaultBranchLineBounds[0],
 	}, nil
 }
 
-// FetchRefs downloads and processes refs data from Github and ultimately
-// contructs a Refs instance with it.
+// newVersionCandidate creates a version candidate out of a ref that matched
+// versionRefRegex.
+func newVersionCandidate(
+	hash string,
+	name string,
+	captureGroups []string,
+) (semver.SemverCandidate, error) {
+	var (
+		gitRefLabel   = captureGroups[versionRefRegexIndexLabel]
+		majorVersion  = captureGroups[versionRefRegexIndexMajorVersion]
+		minorVersion  = captureGroups[versionRefRegexIndexMinorVersion]
+		patchVersion  = captureGroups[versionRefRegexIndexPatchVersion]
+		prerelease    = captureGroups[versionRefRegexIndexPrerelease]
+		buildMetadata = captureGroups[versionRefRegexIndexBuildMetadata]
+	)
+
+	// Annotated tag is peeled off and overrides the same version just parsed
+	if strings.HasSuffix(name, "^{}") {
+		name = name[:len(name)-3]
+	}
+
+	return semver.NewSemverCandidateWithBuildMetadata(
+		hash,
+		name,
+		gitRefLabel,
+		majorVersion,
+		minorVersion,
+		patchVersion,
+		prerelease,
+		buildMetadata)
+}
+
+// appendVersionCandidate appends the version candidate of the ref with the
+// specified name to candidates. Peeled annotated tags (e.g. "refs/tags/v1^{}")
+// point at the commit of the tag object that is listed right before them, so
+// they take the place of the tag object rather than being appended.
+func appendVersionCandidate(
+	candidates []semver.SemverCandidate,
+	candidate semver.SemverCandidate,
+	name string,
+) []semver.SemverCandidate {
+	if strings.HasSuffix(name, "^{}") {
+		for i := len(candidates) - 1; i >= 0; i-- {
+			if candidates[i].GitRefName == candidate.GitRefName {
+				candidates[i] = candidate
+				return candidates
+			}
+		}
+	}
+
+	return append(candidates, candidate)
+}
+
+// sanitizeVersionCandidates sorts version candidates, and removes duplicates.
+// Returns nil if there are no candidates.
+func sanitizeVersionCandidates(
+	versionCandidates []semver.SemverCandidate,
+) semver.SemverCandidateList {
+	if len(versionCandidates) < 1 {
+		return nil
+	}
+
+	// First attach the sortable type to the slice of candidates.
+	versionCandidatesList := semver.SemverCandidateList(versionCandidates)
+	// Sort the list of candidates. The sort is stable so that, of the
+	// candidates that are identical down to their build metadata, the one
+	// that was listed first is kept.
+	sort.Stable(versionCandidatesList)
+	// Remove duplicates by adding them to a new slice altogether. Candidates
+	// that differ only by build metadata are duplicates too: the one without
+	// build metadata (or else with the lowest build metadata) is kept.
+	var (
+		lastInsertedCandidate      semver.SemverCandidate
+		sanitizedVersionCandidates semver.SemverCandidateList
+	)
+	for i, versionCandidate := range versionCandidatesList {
+		if i == 0 || versionCandidate.CompareTo(lastInsertedCandidate) != 0 {
+			sanitizedVersionCandidates = append(sanitizedVersionCandidates, versionCandidate)
+			lastInsertedCandidate = versionCandidate
+		}
+	}
+
+	return sanitizedVersionCandidates
+}
+
+// ModuleDirOf returns the directory of the go module that the subpath (e.g.
+// "/sub/module/pkg") belongs to: the deepest directory with tags of its own
+// that contains the subpath (e.g. "sub/module"). Subpaths outside of such
+// directories belong to the root of the repository, whose directory is empty.
+func (refsData Refs) ModuleDirOf(subpath string) string {
+	for dir := strings.Trim(subpath, "/"); len(dir) > 0; {
+		if _, exists := refsData.SubdirCandidates[dir]; exists {
+			return dir
+		}
+
+		i := strings.LastIndexByte(dir, '/')
+		if i == -1 {
+			break
+		}
+
+		dir = dir[:i]
+	}
+
+	return ""
+}
+
+// CandidatesOf returns the version candidates of the go module that the
+// subpath (e.g. "/sub/module/pkg") belongs to (see ModuleDirOf).
+func (refsData Refs) CandidatesOf(subpath string) semver.SemverCandidateList {
+	if dir := refsData.ModuleDirOf(subpath); len(dir) > 0 {
+		return refsData.SubdirCandidates[dir]
+	}
+
+	return refsData.Candidates
+}
+
+// TagNameOf returns the name of the tag that a version candidate was read
+// from (e.g. "v1.2.3" or "sub/module/v1.2.3"). Returns an empty string if the
+// candidate was read from a branch.
+func TagNameOf(candidate semver.SemverCandidate) string {
+	if !strings.HasPrefix(candidate.GitRefName, refsTagPrefix) {
+		return ""
+	}
+
+	return candidate.GitRefName[len(refsTagPrefix):]
+}
+
+// VersionTags maps the name of every version tag of the repository, the tags
+// of go modules in sub-directories included, to the commit that it points to.
+func (refsData Refs) VersionTags() map[string]string {
+	tags := make(map[string]string)
+	addTags := func(candidates semver.SemverCandidateList) {
+		for _, candidate := range candidates {
+			if tagName := TagNameOf(candidate); len(tagName) > 0 {
+				tags[tagName] = candidate.GitRefHash
+			}
+		}
+	}
+
+	addTags(refsData.Candidates)
+	for _, candidates := range refsData.SubdirCandidates {
+		addTags(candidates)
+	}
+
+	return tags
+}
+
+// readHeadSymRef reads the ref that HEAD points to out of the remainder of the
+// HEAD line, which may list the capabilities of the host after a null byte.
+// Returns an empty string if the host did not advertise a symref for HEAD.
+func readHeadSymRef(headLineRemainder string) string {
+	indexNullByte := strings.Index(headLineRemainder, refsCapabilitiesSeparator)
+	if indexNullByte < 0 {
+		return ""
+	}
+
+	capabilities := strings.Fields(headLineRemainder[indexNullByte+1:])
+	for _, capability := range capabilities {
+		if strings.HasPrefix(capability, refsSymRefHeadAssignment) {
+			return capability[len(refsSymRefHeadAssignment):]
+		}
+	}
+
+	return ""
+}
+
+// FetchRefs downloads and processes refs data from the host of the repository
+// and ultimately contructs a Refs instance with it. Every host speaks the git
+// smart HTTP protocol, so the author may be qualified with any public domain
+// (see vcs.IsPublicDomain).
 func FetchRefs(author, repo string) (Refs, error) {
-	githubRoot := fmt.Sprintf(
-		githubRootTemplate,
-		author,
-		repo,
+	var (
+		repoRoot  = vcs.RepoPath(author, repo)
+		domain, _ = vcs.SplitAuthor(author)
 	)
 
-	res, err := httpClient.Get(fmt.Sprintf(refsFetchURLTemplate, githubRoot))
+	if !vcs.IsPublicDomain(domain) {
+		return Refs{}, fmt.Errorf(errorRefsFetchNonPublicDomain, domain)
+	}
+
+	res, err := httpClient.Get(fmt.Sprintf(refsFetchURLTemplate, repoRoot))
 	if err != nil {
-		return Refs{}, errors.New(errorRefsFetchNetworkFailure)
+		return Refs{}, fmt.Errorf(errorRefsFetchNetworkFailure, domain)
 	}
 
 	defer res.Body.Close()
 
 	if res.StatusCode >= 400 && res.StatusCode < 500 {
-		return Refs{}, fmt.Errorf(errorRefsFetchNoSuchRepo, githubRoot)
+		return Refs{}, fmt.Errorf(errorRefsFetchNoSuchRepo, repoRoot)
 	} else if res.StatusCode >= 500 {
 		// FYI no reliable way to get test coverage here; this never happens
-		return Refs{}, fmt.Errorf(errorRefsFetchGithubError, res.Status)
+		return Refs{}, fmt.Errorf(errorRefsFetchHostError, domain, res.Status)
 	}
 
 	data, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		// FYI no reliable way to get test coverage here; this never happens
-		return Refs{}, fmt.Errorf(errorRefsFetchGithubParseError, err)
+		return Refs{}, fmt.Errorf(errorRefsFetchHostParseError, domain, err)
 	}
 
 	return NewRefs(data)
 }
 
 // Reserialize changes the refs data to incorporate the selected version as
-// the HEAD instead of the default HEAD.
+// the HEAD, and as the head of the default branch, instead of the originals.
 //
 // This code was written by Gustavo Niemeyer, Nathan Youngman and
 // Geert-Johan Riemer.
@@ -261,8 +476,9 @@ func (refsData Refs) Reserialize(versionRefName, versionRefHash string) []byte {
 		dataStr              = refsData.DataStr
 		indexHeadLineEnd     = refsData.IndexHeadLineEnd
 		indexHeadLineStart   = refsData.IndexHeadLineStart
-		indexMasterLineEnd   = refsData.IndexMasterLineEnd
-		indexMasterLineStart = refsData.IndexMasterLineStart
+		defaultBranch        = refsData.DefaultBranch
+		indexBranchLineEnd   = refsData.IndexDefaultBranchLineEnd
+		indexBranchLineStart = refsData.IndexDefaultBranchLineStart
 	)
 
 	// Size the buffer to be a little bigger than
@@ -315,14 +531,18 @@ func (refsData Refs) Reserialize(versionRefName, versionRefHash string) []byte {
 	}
 	fmt.Fprintf(&buf, "%04x%s", 4+len(line), line)
 
-	// Insert the master reference line.
-	line = fmt.Sprintf(refsMasterLineFormat, versionRefHash)
+	// Insert the default branch reference line. Without a known default
+	// branch, go get falls back to master.
+	if len(defaultBranch) < 1 {
+		defaultBranch = refsHeadMaster[len(refsHeadPrefix):]
+	}
+	line = fmt.Sprintf(refsBranchLineFormat, versionRefHash, defaultBranch)
 	fmt.Fprintf(&buf, refsLineFormat, 4+len(line), line)
 
-	// Append the rest, dropping the original master line if necessary.
-	if indexMasterLineStart > 0 {
-		buf.Write(data[indexHeadLineEnd:indexMasterLineStart])
-		buf.Write(data[indexMasterLineEnd:])
+	// Append the rest, dropping the original default branch line if necessary.
+	if indexBranchLineStart > 0 {
+		buf.Write(data[indexHeadLineEnd:indexBranchLineStart])
+		buf.Write(data[indexBranchLineEnd:])
 	} else {
 		buf.Write(data[indexHeadLineEnd:])
 	}
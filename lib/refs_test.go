@@ -55,35 +55,19 @@ var refsTests = []refsTest{{
 	),
 	[]semver.SemverCandidate{
 		{
-			"00000000000000000000000000000000000hash2", // hash
-			"refs/heads/v0",                            // name
-			"v0",                                       // label
-			0,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:  "00000000000000000000000000000000000hash2",
+			GitRefName:  "refs/heads/v0",
+			GitRefLabel: "v0",
 		}, {
-			"00000000000000000000000000000000000hash3", // hash
-			"refs/heads/v1",                            // name
-			"v1",                                       // label
-			1,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash3",
+			GitRefName:   "refs/heads/v1",
+			GitRefLabel:  "v1",
+			MajorVersion: 1,
 		}, {
-			"00000000000000000000000000000000000hash4", // hash
-			"refs/heads/v2",                            // name
-			"v2",                                       // label
-			2,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash4",
+			GitRefName:   "refs/heads/v2",
+			GitRefLabel:  "v2",
+			MajorVersion: 2,
 		},
 	},
 }, {
@@ -105,35 +89,23 @@ var refsTests = []refsTest{{
 	// semver candidates are sorted in refs.go
 	[]semver.SemverCandidate{
 		{
-			"00000000000000000000000000000000000hash2", // hash
-			"refs/heads/v1.1",                          // name
-			"v1.1",                                     // label
-			1,                                          // major
-			1,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash2",
+			GitRefName:   "refs/heads/v1.1",
+			GitRefLabel:  "v1.1",
+			MajorVersion: 1,
+			MinorVersion: 1,
 		}, {
-			"00000000000000000000000000000000000hash4", // hash
-			"refs/heads/v1.2",                          // name
-			"v1.2",                                     // label
-			1,                                          // major
-			2,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash4",
+			GitRefName:   "refs/heads/v1.2",
+			GitRefLabel:  "v1.2",
+			MajorVersion: 1,
+			MinorVersion: 2,
 		}, {
-			"00000000000000000000000000000000000hash3", // hash
-			"refs/heads/v1.3",                          // name
-			"v1.3",                                     // label
-			1,                                          // major
-			3,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash3",
+			GitRefName:   "refs/heads/v1.3",
+			GitRefLabel:  "v1.3",
+			MajorVersion: 1,
+			MinorVersion: 3,
 		},
 	},
 }, {
@@ -150,15 +122,10 @@ var refsTests = []refsTest{{
 	),
 	[]semver.SemverCandidate{
 		{
-			"00000000000000000000000000000000000hash2", // hash
-			"refs/heads/v1",                            // name
-			"v1",                                       // label
-			1,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash2",
+			GitRefName:   "refs/heads/v1",
+			GitRefLabel:  "v1",
+			MajorVersion: 1,
 		},
 	},
 }, {
@@ -175,15 +142,10 @@ var refsTests = []refsTest{{
 	),
 	[]semver.SemverCandidate{
 		{
-			"00000000000000000000000000000000000hash2", // hash
-			"refs/tags/v1",                             // name
-			"v1",                                       // label
-			1,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash2",
+			GitRefName:   "refs/tags/v1",
+			GitRefLabel:  "v1",
+			MajorVersion: 1,
 		},
 	},
 }, {
@@ -201,15 +163,10 @@ var refsTests = []refsTest{{
 	),
 	[]semver.SemverCandidate{
 		{
-			"00000000000000000000000000000000000hash2", // hash
-			"refs/heads/v1",                            // name
-			"v1",                                       // label
-			1,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash2",
+			GitRefName:   "refs/heads/v1",
+			GitRefLabel:  "v1",
+			MajorVersion: 1,
 		},
 	},
 }, {
@@ -230,35 +187,19 @@ var refsTests = []refsTest{{
 	),
 	[]semver.SemverCandidate{
 		{
-			"00000000000000000000000000000000000hash2", // hash
-			"refs/tags/v0",                             // name
-			"v0",                                       // label
-			0,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:  "00000000000000000000000000000000000hash2",
+			GitRefName:  "refs/tags/v0",
+			GitRefLabel: "v0",
 		}, {
-			"00000000000000000000000000000000000hash3", // hash
-			"refs/tags/v1",                             // name
-			"v1",                                       // label
-			1,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash3",
+			GitRefName:   "refs/tags/v1",
+			GitRefLabel:  "v1",
+			MajorVersion: 1,
 		}, {
-			"00000000000000000000000000000000000hash4", // hash
-			"refs/tags/v2",                             // name
-			"v2",                                       // label
-			2,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash4",
+			GitRefName:   "refs/tags/v2",
+			GitRefLabel:  "v2",
+			MajorVersion: 2,
 		},
 	},
 }, {
@@ -278,28 +219,19 @@ var refsTests = []refsTest{{
 		"00000000000000000000000000000000000hash4 refs/tags/v1^{}",
 		"00000000000000000000000000000000000hash5 refs/tags/v2",
 	),
-	// Identical semver candidates are pruned from the semver candidate list in refs.go
+	// Peeled tags take the place of their tag objects, since they point at the
+	// commits.
 	[]semver.SemverCandidate{
 		{
-			"00000000000000000000000000000000000hash3", // hash
-			"refs/tags/v1",                             // name
-			"v1",                                       // label
-			1,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash4",
+			GitRefName:   "refs/tags/v1",
+			GitRefLabel:  "v1",
+			MajorVersion: 1,
 		}, {
-			"00000000000000000000000000000000000hash5", // hash
-			"refs/tags/v2",                             // name
-			"v2",                                       // label
-			2,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash5",
+			GitRefName:   "refs/tags/v2",
+			GitRefLabel:  "v2",
+			MajorVersion: 2,
 		},
 	},
 }, {
@@ -325,55 +257,42 @@ var refsTests = []refsTest{{
 	),
 	[]semver.SemverCandidate{
 		{
-			"00000000000000000000000000000000000hash3", // hash
-			"refs/heads/v1",                            // name
-			"v1",                                       // label
-			1,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash3",
+			GitRefName:   "refs/heads/v1",
+			GitRefLabel:  "v1",
+			MajorVersion: 1,
 		}, {
-			"00000000000000000000000000000000000hash4", // hash
-			"refs/heads/v1.1-unstable",                 // name
-			"v1.1-unstable",                            // label
-			1,                                          // major
-			1,                                          // minor
-			0,                                          // patch
-			"unstable",                                 // pre-release label
-			0,                                          // pre-release version
-			true,                                       // pre-release exists
+			GitRefHash:              "00000000000000000000000000000000000hash4",
+			GitRefName:              "refs/heads/v1.1-unstable",
+			GitRefLabel:             "v1.1-unstable",
+			MajorVersion:            1,
+			MinorVersion:            1,
+			PrereleaseLabel:         "unstable",
+			PrereleaseVersionExists: true,
+			Prerelease:              "unstable",
 		}, {
-			"00000000000000000000000000000000000hash6", // hash
-			"refs/heads/v1.2-unstable",                 // name
-			"v1.2-unstable",                            // label
-			1,                                          // major
-			2,                                          // minor
-			0,                                          // patch
-			"unstable",                                 // pre-release label
-			0,                                          // pre-release version
-			true,                                       // pre-release exists
+			GitRefHash:              "00000000000000000000000000000000000hash6",
+			GitRefName:              "refs/heads/v1.2-unstable",
+			GitRefLabel:             "v1.2-unstable",
+			MajorVersion:            1,
+			MinorVersion:            2,
+			PrereleaseLabel:         "unstable",
+			PrereleaseVersionExists: true,
+			Prerelease:              "unstable",
 		}, {
-			"00000000000000000000000000000000000hash5", // hash
-			"refs/heads/v1.3-unstable",                 // name
-			"v1.3-unstable",                            // label
-			1,                                          // major
-			3,                                          // minor
-			0,                                          // patch
-			"unstable",                                 // pre-release label
-			0,                                          // pre-release version
-			true,                                       // pre-release exists
+			GitRefHash:              "00000000000000000000000000000000000hash5",
+			GitRefName:              "refs/heads/v1.3-unstable",
+			GitRefLabel:             "v1.3-unstable",
+			MajorVersion:            1,
+			MinorVersion:            3,
+			PrereleaseLabel:         "unstable",
+			PrereleaseVersionExists: true,
+			Prerelease:              "unstable",
 		}, {
-			"00000000000000000000000000000000000hash7", // hash
-			"refs/heads/v2",                            // name
-			"v2",                                       // label
-			2,                                          // major
-			0,                                          // minor
-			0,                                          // patch
-			"",                                         // pre-release label
-			0,                                          // pre-release version
-			false,                                      // pre-release exists
+			GitRefHash:   "00000000000000000000000000000000000hash7",
+			GitRefName:   "refs/heads/v2",
+			GitRefLabel:  "v2",
+			MajorVersion: 2,
 		},
 	},
 }}
@@ -421,7 +340,9 @@ func candidatesMatch(t *testing.T, candidates1 []semver.SemverCandidate, candida
 			c1.PatchVersion == c2.PatchVersion &&
 			c1.PrereleaseLabel == c2.PrereleaseLabel &&
 			c1.PrereleaseVersion == c2.PrereleaseVersion &&
-			c1.PrereleaseVersionExists == c2.PrereleaseVersionExists) {
+			c1.PrereleaseVersionExists == c2.PrereleaseVersionExists &&
+			c1.Prerelease == c2.Prerelease &&
+			c1.BuildMetadata == c2.BuildMetadata) {
 			t.Logf("Candidate 1 (%v) was different than Candidate 2 (%v)", candidates1, candidates2)
 			return false
 		}
@@ -438,6 +359,19 @@ func TestGetRefs(t *testing.T) {
 	assert.Nil(t, err, "fetch should work for valid repos")
 }
 
+func TestFetchRefsNonPublicDomain(t *testing.T) {
+	for _, author := range []string{
+		"localhost:a",
+		"127.0.0.1:a",
+		"169.254.169.254:a",
+		"git.internal:a",
+	} {
+		_, err := FetchRefs(author, "b")
+		assert.NotNil(t, err, author)
+		assert.Contains(t, err.Error(), "is not a public domain", author)
+	}
+}
+
 func TestUseRefs(t *testing.T) {
 	refs, err := NewRefs([]byte(invalidSizeStringReflines()))
 	assert.NotNil(t, err, "refs parsing should have failed because the size wasn't a number")
@@ -459,3 +393,209 @@ func TestUseRefs(t *testing.T) {
 		}
 	}
 }
+
+func TestRefsBranches(t *testing.T) {
+	refs, err := NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/heads/develop",
+		"00000000000000000000000000000000000hash3 refs/heads/release-2.x",
+		"00000000000000000000000000000000000hash4 refs/heads/v1",
+		"00000000000000000000000000000000000hash5 refs/tags/v1.1",
+		"00000000000000000000000000000000000hash6 refs/pull/1/head",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+	assert.Equal(t, map[string]string{
+		"v1":          "00000000000000000000000000000000000hash4",
+		"master":      "00000000000000000000000000000000000hash1",
+		"develop":     "00000000000000000000000000000000000hash2",
+		"release-2.x": "00000000000000000000000000000000000hash3",
+	}, refs.Branches, "every branch head should have been recorded")
+	assert.Equal(t, 2, len(refs.Candidates), "version branches should still be candidates")
+}
+
+func TestRefsSemverCandidates(t *testing.T) {
+	refs, err := NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.0.0+build.2",
+		"00000000000000000000000000000000000hash3 refs/tags/v1.0.0-rc.1.beta",
+		"00000000000000000000000000000000000hash4 refs/tags/v1.0.0-beta.11",
+		"00000000000000000000000000000000000hash5 refs/tags/v1.0.0+build.1",
+		"00000000000000000000000000000000000hash6 refs/tags/v1.0.0-beta.2",
+		"00000000000000000000000000000000000hash7 refs/tags/v1.0.0-rc.01",
+		"00000000000000000000000000000000000hash8 refs/tags/v1.0.0-alpha+001",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+
+	var versions, hashes []string
+	for _, candidate := range refs.Candidates {
+		versions = append(versions, candidate.String())
+		hashes = append(hashes, candidate.GitRefHash)
+	}
+
+	assert.Equal(t, []string{
+		"1.0.0-alpha",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1.beta",
+		"1.0.0",
+	}, versions, "candidates should be ordered by precedence, and pre-releases with leading zeroes should be dropped")
+	assert.Equal(
+		t,
+		"00000000000000000000000000000000000hash5",
+		hashes[len(hashes)-1],
+		"of the candidates that differ only by build metadata, the lowest build metadata should be kept")
+
+	// The order of the refs should not change which duplicate is kept.
+	refs, err = NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash5 refs/tags/v1.0.0+build.1",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.0.0+build.2",
+		"00000000000000000000000000000000000hash3 refs/tags/v1.0.0",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+	assert.Equal(t, 1, len(refs.Candidates), "candidates that differ only by build metadata should be de-duplicated")
+	assert.Equal(
+		t,
+		"00000000000000000000000000000000000hash3",
+		refs.Candidates[0].GitRefHash,
+		"the candidate without build metadata should be kept")
+	assert.Equal(t, "", refs.Candidates[0].BuildMetadata, "the kept candidate should have no build metadata")
+
+	// Annotated tags resolve to the commits that they peel to.
+	refs, err = NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.0.0",
+		"00000000000000000000000000000000000hash3 refs/tags/v1.0.0^{}",
+		"00000000000000000000000000000000000hash4 refs/tags/v1.0.0+build.1",
+		"00000000000000000000000000000000000hash5 refs/tags/v1.1.0",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+	assert.Equal(t, 2, len(refs.Candidates))
+	assert.Equal(
+		t,
+		"00000000000000000000000000000000000hash3",
+		refs.Candidates[0].GitRefHash,
+		"the peeled tag should be kept rather than the tag object")
+	assert.Equal(t, "refs/tags/v1.0.0", refs.Candidates[0].GitRefName)
+	assert.Equal(t, "00000000000000000000000000000000000hash3", refs.VersionTags()["v1.0.0"])
+}
+
+func TestRefsSubdirCandidates(t *testing.T) {
+	refs, err := NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.0.0",
+		"00000000000000000000000000000000000hash3 refs/tags/sub/module/v1.1.0",
+		"00000000000000000000000000000000000hash4 refs/tags/sub/module/v1.0.0",
+		"00000000000000000000000000000000000hash5 refs/tags/sub/module/v1.0.0^{}",
+		"00000000000000000000000000000000000hash6 refs/tags/other/v0.1.0-beta.1",
+		"00000000000000000000000000000000000hash7 refs/tags/sub/not-a-version",
+		"00000000000000000000000000000000000hash8 refs/heads/sub/v2.0.0",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+
+	versionsOf := func(candidates semver.SemverCandidateList) []string {
+		var versions []string
+		for _, candidate := range candidates {
+			versions = append(versions, candidate.GitRefName+"@"+candidate.String())
+		}
+
+		return versions
+	}
+
+	assert.Equal(t, []string{"refs/tags/v1.0.0@1.0.0"}, versionsOf(refs.Candidates), "prefixed tags should not be root candidates")
+	assert.Equal(t, 2, len(refs.SubdirCandidates), "only the directories with version tags should have candidates")
+	assert.Equal(t, []string{
+		"refs/tags/sub/module/v1.0.0@1.0.0",
+		"refs/tags/sub/module/v1.1.0@1.1.0",
+	}, versionsOf(refs.SubdirCandidates["sub/module"]), "prefixed tags should be candidates of their directory")
+	assert.Equal(t, []string{
+		"refs/tags/other/v0.1.0-beta.1@0.1.0-beta.1",
+	}, versionsOf(refs.SubdirCandidates["other"]), "prefixed tags should be candidates of their directory")
+
+	assert.Equal(t, refs.SubdirCandidates["sub/module"], refs.CandidatesOf("/sub/module"))
+	assert.Equal(t, refs.SubdirCandidates["sub/module"], refs.CandidatesOf("/sub/module/pkg/"), "sub-packages should belong to the module that contains them")
+	assert.Equal(t, refs.SubdirCandidates["other"], refs.CandidatesOf("/other/sub/module"))
+	assert.Equal(t, refs.Candidates, refs.CandidatesOf("/sub"), "directories without tags should belong to the root module")
+	assert.Equal(t, refs.Candidates, refs.CandidatesOf(""), "the root should belong to the root module")
+	assert.Equal(t, "sub/module", refs.ModuleDirOf("/sub/module/pkg"))
+	assert.Equal(t, "", refs.ModuleDirOf("/sub"), "directories without tags should belong to the root module")
+
+	assert.Equal(t, "sub/module/v1.1.0", TagNameOf(refs.SubdirCandidates["sub/module"][1]))
+	assert.Equal(t, map[string]string{
+		"v1.0.0":              "00000000000000000000000000000000000hash2",
+		"sub/module/v1.0.0":   "00000000000000000000000000000000000hash5",
+		"sub/module/v1.1.0":   "00000000000000000000000000000000000hash3",
+		"other/v0.1.0-beta.1": "00000000000000000000000000000000000hash6",
+	}, refs.VersionTags(), "every version tag should be listed, branches aside")
+
+	// Without prefixed tags, there are no sub-directory candidates.
+	refs, err = NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.0.0",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+	assert.Nil(t, refs.SubdirCandidates)
+	assert.Equal(t, refs.Candidates, refs.CandidatesOf("/sub/module"))
+
+	// Versions read from branches are not tags.
+	refs, err = NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash2 refs/heads/v2",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+	assert.Equal(t, "", TagNameOf(refs.Candidates[0]))
+	assert.Empty(t, refs.VersionTags())
+}
+
+func TestRefsDefaultBranch(t *testing.T) {
+	// HEAD points to the default branch through the symref capability.
+	refs, err := NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD\x00multi_ack symref=HEAD:refs/heads/main agent=git/2.9.3",
+		"00000000000000000000000000000000000hash2 refs/heads/master",
+		"00000000000000000000000000000000000hash1 refs/heads/main",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+	assert.Equal(t, "main", refs.DefaultBranch, "the default branch should be read from the symref")
+	assert.Equal(t, "00000000000000000000000000000000000hash1", refs.DefaultRefHash, "the default ref hash should be the head of the default branch")
+
+	// Without a symref, master is assumed to be the default branch.
+	refs, err = NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD\x00multi_ack",
+		"00000000000000000000000000000000000hash2 refs/heads/develop",
+		"00000000000000000000000000000000000hash3 refs/heads/master",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+	assert.Equal(t, "master", refs.DefaultBranch, "master should be the fallback default branch")
+	assert.Equal(t, "00000000000000000000000000000000000hash3", refs.DefaultRefHash, "the default ref hash should be the head of master")
+
+	// Without a symref or master, HEAD is all there is.
+	refs, err = NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash2 refs/heads/develop",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+	assert.Equal(t, "", refs.DefaultBranch, "the default branch should be unknown")
+	assert.Equal(t, "00000000000000000000000000000000000hash1", refs.DefaultRefHash, "the default ref hash should be the hash of HEAD")
+}
+
+func TestRefsReserializeDefaultBranch(t *testing.T) {
+	refs, err := NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD\x00symref=HEAD:refs/heads/main",
+		"00000000000000000000000000000000000hash1 refs/heads/main",
+		"00000000000000000000000000000000000hash2 refs/heads/master",
+		"00000000000000000000000000000000000hash3 refs/tags/v1.0.0",
+	)))
+	assert.Nil(t, err, "refs should have been parsed correctly")
+
+	assert.Equal(t, reflines(
+		"00000000000000000000000000000000000hash3 HEAD\x00oldref=HEAD:refs/heads/main",
+		"00000000000000000000000000000000000hash3 refs/heads/main",
+		"00000000000000000000000000000000000hash2 refs/heads/master",
+		"00000000000000000000000000000000000hash3 refs/tags/v1.0.0",
+	), string(refs.Reserialize("refs/tags/v1.0.0", "00000000000000000000000000000000000hash3")), "the advertised default branch should be replaced, not master")
+}
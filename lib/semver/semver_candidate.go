@@ -3,10 +3,16 @@ package semver
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 )
 
+const (
+	errorSemverCandidateInvalidPrerelease    = "Invalid pre-release: %s"
+	errorSemverCandidateInvalidBuildMetadata = "Invalid build metadata: %s"
+)
+
 // SemverCandidate is a semver version that has been confirmed to exist for a
 // given package. It carries versioning metadata, but it also has git ref info
 // so that the commit of the version can be isolated.
@@ -20,6 +26,13 @@ type SemverCandidate struct {
 	PrereleaseLabel         string
 	PrereleaseVersion       int
 	PrereleaseVersionExists bool
+	// Prerelease is the complete, dot-separated pre-release of the version
+	// (e.g. "rc.1.beta"). PrereleaseLabel and PrereleaseVersion are derived
+	// from it so that selectors can match it.
+	Prerelease string
+	// BuildMetadata is everything after the "+" of the version. It has no
+	// bearing on precedence.
+	BuildMetadata string
 }
 
 // NewSemverCandidate creates a new instance of a SemverCandidate from a variety
@@ -84,6 +97,14 @@ func NewSemverCandidate(
 		prereleaseVersionNumber = 0
 	}
 
+	// Pre-release versions only exist alongside pre-release labels.
+	prerelease := prereleaseLabel
+	if len(prereleaseLabel) > 0 && len(prereleaseVersion) > 0 {
+		prerelease = prerelease +
+			string(SemverSelectorSeparatorChar) +
+			prereleaseVersion
+	}
+
 	return SemverCandidate{
 		GitRefHash:              gitRefHash,
 		GitRefName:              gitRefName,
@@ -94,9 +115,79 @@ func NewSemverCandidate(
 		PrereleaseLabel:         prereleaseLabel,
 		PrereleaseVersion:       prereleaseVersionNumber,
 		PrereleaseVersionExists: (len(prereleaseLabel) > 0),
+		Prerelease:              prerelease,
 	}, nil
 }
 
+// NewSemverCandidateWithBuildMetadata creates a new instance of a
+// SemverCandidate from a complete SemVer 2.0 pre-release (e.g. "rc.1.beta")
+// and build metadata (e.g. "20160101.sha"), either of which may be empty.
+// When the last of several pre-release identifiers is numeric, it becomes the
+// pre-release version and the rest become the pre-release label.
+func NewSemverCandidateWithBuildMetadata(
+	gitRefHash string,
+	gitRefName string,
+	gitRefLabel string,
+	majorVersion string,
+	minorVersion string,
+	patchVersion string,
+	prerelease string,
+	buildMetadata string,
+) (SemverCandidate, error) {
+	var (
+		prereleaseLabel   = prerelease
+		prereleaseVersion string
+	)
+
+	if len(prerelease) > 0 {
+		identifiers := strings.Split(prerelease, string(SemverSelectorSeparatorChar))
+		for _, identifier := range identifiers {
+			if len(identifier) == 0 {
+				return SemverCandidate{}, fmt.Errorf(errorSemverCandidateInvalidPrerelease, prerelease)
+			} else if len(identifier) > 1 && identifier[0] == '0' && isNumericIdentifier(identifier) {
+				// Numeric identifiers must not include leading zeroes.
+				return SemverCandidate{}, fmt.Errorf(errorSemverCandidateInvalidPrerelease, prerelease)
+			}
+		}
+
+		lastIdentifier := identifiers[len(identifiers)-1]
+		if len(identifiers) > 1 && isNumericIdentifier(lastIdentifier) {
+			// Only split off numbers that fit in a pre-release version.
+			if _, err := strconv.Atoi(lastIdentifier); err == nil {
+				prereleaseLabel = prerelease[:len(prerelease)-len(lastIdentifier)-1]
+				prereleaseVersion = lastIdentifier
+			}
+		}
+	}
+
+	if len(buildMetadata) > 0 {
+		identifiers := strings.Split(buildMetadata, string(SemverSelectorSeparatorChar))
+		for _, identifier := range identifiers {
+			if len(identifier) == 0 {
+				return SemverCandidate{}, fmt.Errorf(errorSemverCandidateInvalidBuildMetadata, buildMetadata)
+			}
+		}
+	}
+
+	candidate, err := NewSemverCandidate(
+		gitRefHash,
+		gitRefName,
+		gitRefLabel,
+		majorVersion,
+		minorVersion,
+		patchVersion,
+		prereleaseLabel,
+		prereleaseVersion)
+	if err != nil {
+		return SemverCandidate{}, err
+	}
+
+	candidate.Prerelease = prerelease
+	candidate.BuildMetadata = buildMetadata
+
+	return candidate, nil
+}
+
 // CompareTo compares the current candidate to another candidate and returns a
 // number indicating the relationship between the two. -1 means this candidate
 // is lower than the other. 1 implies the opposite. 0 means that the candidates
@@ -114,29 +205,99 @@ func (candidate SemverCandidate) CompareTo(other SemverCandidate) int {
 		return 1
 	} else if candidate.PatchVersion < other.PatchVersion {
 		return -1
-	} else if len(candidate.PrereleaseLabel) > 0 && len(other.PrereleaseLabel) == 0 {
-		// Prerelease immediately means that the version is lesser
-		return -1
-	} else if len(candidate.PrereleaseLabel) == 0 && len(other.PrereleaseLabel) > 0 {
+	}
+
+	return comparePrereleases(
+		candidate.prereleaseIdentifiers(),
+		other.prereleaseIdentifiers())
+}
+
+// prereleaseIdentifiers returns the dot-separated identifiers of the
+// pre-release of this candidate. Candidates that were put together by hand may
+// only have a pre-release label and version, so those are used as a fallback.
+func (candidate SemverCandidate) prereleaseIdentifiers() []string {
+	if len(candidate.Prerelease) > 0 {
+		return strings.Split(candidate.Prerelease, string(SemverSelectorSeparatorChar))
+	} else if len(candidate.PrereleaseLabel) == 0 {
+		return nil
+	}
+
+	identifiers := strings.Split(candidate.PrereleaseLabel, string(SemverSelectorSeparatorChar))
+	if candidate.PrereleaseVersion > 0 {
+		identifiers = append(identifiers, strconv.Itoa(candidate.PrereleaseVersion))
+	}
+
+	return identifiers
+}
+
+// comparePrereleases compares two lists of pre-release identifiers according
+// to SemVer 2.0: a version without a pre-release has a higher precedence than
+// one with a pre-release, identifiers are compared one by one, and if every
+// identifier is equal the longer list has a higher precedence.
+func comparePrereleases(identifiers, otherIdentifiers []string) int {
+	if len(identifiers) == 0 && len(otherIdentifiers) > 0 {
 		// Prerelease immediately means that the version is lesser
 		return 1
-	} else if candidate.PrereleaseLabel != other.PrereleaseLabel {
-		// Prerelease labels don't match, so return a comparison
-		return strings.Compare(candidate.PrereleaseLabel, other.PrereleaseLabel)
-	} else if candidate.PrereleaseVersion > other.PrereleaseVersion {
-		// Prerelease labels are identical, so continue comparison to versions
-		return 1
-	} else if candidate.PrereleaseVersion < other.PrereleaseVersion {
+	} else if len(identifiers) > 0 && len(otherIdentifiers) == 0 {
 		return -1
 	}
-	// If we got this far, then the versions are clearly identical
-	// (which is super weird)
-	return 0
+
+	for i := 0; i < len(identifiers) && i < len(otherIdentifiers); i++ {
+		if cmp := comparePrereleaseIdentifiers(
+			identifiers[i],
+			otherIdentifiers[i]); cmp != 0 {
+			return cmp
+		}
+	}
+
+	// If we got this far, then the shorter list is a prefix of the longer one.
+	return compareInts(len(identifiers), len(otherIdentifiers))
+}
+
+// comparePrereleaseIdentifiers compares two pre-release identifiers. Numeric
+// identifiers are compared numerically, and always have a lower precedence
+// than alphanumeric identifiers, which are compared lexically in ASCII order.
+func comparePrereleaseIdentifiers(identifier, otherIdentifier string) int {
+	var (
+		isNumeric      = isNumericIdentifier(identifier)
+		isOtherNumeric = isNumericIdentifier(otherIdentifier)
+	)
+
+	if isNumeric && !isOtherNumeric {
+		return -1
+	} else if !isNumeric && isOtherNumeric {
+		return 1
+	} else if isNumeric {
+		// Compare lengths first so that numbers of any size can be compared
+		// without being parsed. Leading zeroes are not allowed, so a longer
+		// number is a bigger number.
+		if cmp := compareInts(len(identifier), len(otherIdentifier)); cmp != 0 {
+			return cmp
+		}
+	}
+
+	return strings.Compare(identifier, otherIdentifier)
+}
+
+// isNumericIdentifier returns true if the identifier is made of only digits.
+func isNumericIdentifier(identifier string) bool {
+	if len(identifier) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(identifier); i++ {
+		if identifier[i] < '0' || identifier[i] > '9' {
+			return false
+		}
+	}
+
+	return true
 }
 
 // String returns a string-serialized version of the SemverCandidate. The git
 // ref metadata is excluded such that the output of this function resembles a
-// semver-compliant version string.
+// semver-compliant version string. Build metadata is excluded too, since it
+// does not tell versions apart.
 func (candidate SemverCandidate) String() string {
 	var buffer bytes.Buffer
 
@@ -146,7 +307,10 @@ func (candidate SemverCandidate) String() string {
 	buffer.WriteByte(SemverSelectorSeparatorChar)
 	buffer.WriteString(strconv.Itoa(candidate.PatchVersion))
 
-	if len(candidate.PrereleaseLabel) > 0 {
+	if len(candidate.Prerelease) > 0 {
+		buffer.WriteByte(SemverSelectorPrereleaseLabelPrefixChar)
+		buffer.WriteString(candidate.Prerelease)
+	} else if len(candidate.PrereleaseLabel) > 0 {
 		buffer.WriteByte(SemverSelectorPrereleaseLabelPrefixChar)
 		buffer.WriteString(candidate.PrereleaseLabel)
 
@@ -172,17 +336,50 @@ func (list SemverCandidateList) Swap(i, j int) {
 	list[i], list[j] = list[j], list[i]
 }
 
+// Less orders candidates by precedence. Candidates of equal precedence that
+// differ only by build metadata are ordered by it, candidates without build
+// metadata first, so that sorting is deterministic.
 func (list SemverCandidateList) Less(i, j int) bool {
-	return list[i].CompareTo(list[j]) < 0
+	if cmp := list[i].CompareTo(list[j]); cmp != 0 {
+		return cmp < 0
+	}
+
+	return compareBuildMetadata(list[i].BuildMetadata, list[j].BuildMetadata) < 0
+}
+
+// compareBuildMetadata compares build metadata lexically, except that empty
+// build metadata comes first.
+func compareBuildMetadata(buildMetadata, otherBuildMetadata string) int {
+	if len(buildMetadata) == 0 && len(otherBuildMetadata) > 0 {
+		return -1
+	} else if len(buildMetadata) > 0 && len(otherBuildMetadata) == 0 {
+		return 1
+	}
+
+	return strings.Compare(buildMetadata, otherBuildMetadata)
 }
 
 // Match returns a new SemverCandidateList with only candidates that match the
-// specified selector.
-func (list SemverCandidateList) Match(selector SemverSelector) SemverCandidateList {
+// specified selector or range.
+func (list SemverCandidateList) Match(constraint SemverConstraint) SemverCandidateList {
+	var newList []SemverCandidate
+
+	for _, candidate := range list {
+		if constraint.Matches(candidate) {
+			newList = append(newList, candidate)
+		}
+	}
+
+	return newList
+}
+
+// OfMajorVersion returns a new SemverCandidateList with only the candidates of
+// the specified major version.
+func (list SemverCandidateList) OfMajorVersion(majorVersion int) SemverCandidateList {
 	var newList []SemverCandidate
 
 	for _, candidate := range list {
-		if selector.Matches(candidate) {
+		if candidate.MajorVersion == majorVersion {
 			newList = append(newList, candidate)
 		}
 	}
@@ -213,10 +410,10 @@ func (list SemverCandidateList) Highest() *SemverCandidate {
 }
 
 // Best returns the best version available in the candidate list according to
-// the specified selector.
-func (list SemverCandidateList) Best(selector SemverSelector) *SemverCandidate {
+// the specified selector or range.
+func (list SemverCandidateList) Best(constraint SemverConstraint) *SemverCandidate {
 	var (
-		matches    = list.Match(selector)
+		matches    = list.Match(constraint)
 		matchesLen = len(matches)
 	)
 
@@ -227,16 +424,9 @@ func (list SemverCandidateList) Best(selector SemverSelector) *SemverCandidate {
 		// Hmm, I wonder which *one* is the best :P.
 		return &matches[0]
 	} else {
-		var (
-			selectorHasLessThan  = selector.Suffix == SemverSelectorSuffixLessThan
-			selectorHasWildcards = selector.MinorVersion.Type == SemverSegmentTypeWildcard ||
-				selector.PatchVersion.Type == SemverSegmentTypeWildcard ||
-				selector.PrereleaseVersion.Type == SemverSegmentTypeWildcard
-		)
-
 		// Get the most recent version available (adjusting what variation is
 		// possible).
-		if selectorHasWildcards || selectorHasLessThan {
+		if constraint.PrefersHighest() {
 			return matches.Highest()
 		}
 
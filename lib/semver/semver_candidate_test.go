@@ -2,6 +2,7 @@ package semver
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -262,3 +263,146 @@ func TestSemverCandidateList(t *testing.T) {
 	assert.Nil(t, list.Lowest(), "Lowset should return nil when there are no elements to sort")
 	assert.Nil(t, list.Highest(), "Highest should return nil when there are no elements to sort")
 }
+
+var specCandidateRegex = regexp.MustCompile(`^([0-9]+)\.([0-9]+)\.([0-9]+)(?:-([0-9A-Za-z\-\.]+))?(?:\+([0-9A-Za-z\-\.]+))?$`)
+
+func compileSpecCandidate(version string) SemverCandidate {
+	matches := specCandidateRegex.FindStringSubmatch(version)
+	if matches == nil {
+		panic(fmt.Sprint("A test candidate was invalid:", version))
+	}
+
+	candidate, err := NewSemverCandidateWithBuildMetadata(
+		"fakeHash",
+		"fakeName",
+		version,
+		matches[1],
+		matches[2],
+		matches[3],
+		matches[4],
+		matches[5])
+	if err != nil {
+		panic(fmt.Sprint("A test candidate could not be initialized:", version, "(", err, ")"))
+	}
+
+	return candidate
+}
+
+func TestNewSemverCandidateWithBuildMetadata(t *testing.T) {
+	candidate, err := NewSemverCandidateWithBuildMetadata("a", "b", "", "1", "2", "3", "rc.1.beta", "")
+	assert.Nil(t, err, "should not return an error for multi-identifier pre-releases")
+	assert.Equal(t, "rc.1.beta", candidate.Prerelease, "pre-release should match")
+	assert.Equal(t, "rc.1.beta", candidate.PrereleaseLabel, "non-numeric last identifiers should stay in the label")
+	assert.Equal(t, 0, candidate.PrereleaseVersion, "prerelease version should match")
+	assert.Equal(t, true, candidate.PrereleaseVersionExists, "prerelease version should exist")
+
+	candidate, err = NewSemverCandidateWithBuildMetadata("a", "b", "", "1", "2", "3", "beta.x.11", "exp.sha.5114f85")
+	assert.Nil(t, err, "should not return an error for build metadata")
+	assert.Equal(t, "beta.x", candidate.PrereleaseLabel, "numeric last identifiers should be split off the label")
+	assert.Equal(t, 11, candidate.PrereleaseVersion, "numeric last identifiers should become the prerelease version")
+	assert.Equal(t, "exp.sha.5114f85", candidate.BuildMetadata, "build metadata should match")
+	assert.Equal(t, "1.2.3-beta.x.11", candidate.String(), "build metadata should be left out of the string")
+
+	candidate, err = NewSemverCandidateWithBuildMetadata("a", "b", "", "1", "2", "3", "11", "")
+	assert.Nil(t, err, "should not return an error for numeric pre-releases")
+	assert.Equal(t, "11", candidate.PrereleaseLabel, "lone identifiers should stay in the label")
+
+	_, err = NewSemverCandidateWithBuildMetadata("a", "b", "", "1", "2", "3", "rc.01", "")
+	assert.NotNil(t, err, "should fail for numeric identifiers with leading zeroes")
+
+	_, err = NewSemverCandidateWithBuildMetadata("a", "b", "", "1", "2", "3", "rc..1", "")
+	assert.NotNil(t, err, "should fail for empty pre-release identifiers")
+
+	_, err = NewSemverCandidateWithBuildMetadata("a", "b", "", "1", "2", "3", "", "build.")
+	assert.NotNil(t, err, "should fail for empty build metadata identifiers")
+
+	_, err = NewSemverCandidateWithBuildMetadata("a", "b", "", "1", "2", "3", "", "001")
+	assert.Nil(t, err, "build metadata may have leading zeroes")
+}
+
+func TestSemverCandidateCompareToPrecedence(t *testing.T) {
+	// The precedence example from the SemVer 2.0 spec, in ascending order.
+	ascending := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1-0.3.7",
+		"1.0.1-x.7.z.92",
+		"1.0.1-x.7.z.92.1",
+		"1.0.1-x-y-z",
+		"1.0.1",
+		"1.0.2-99999999999999999999",
+		"1.0.2-100000000000000000000",
+	}
+
+	for i := range ascending {
+		for j := range ascending {
+			assert.Equal(
+				t,
+				compareInts(i, j),
+				compileSpecCandidate(ascending[i]).CompareTo(compileSpecCandidate(ascending[j])),
+				fmt.Sprintf(`"%s" compared to "%s"`, ascending[i], ascending[j]))
+		}
+	}
+
+	assert.Equal(
+		t,
+		0,
+		compileSpecCandidate("1.0.0-alpha+001").CompareTo(compileSpecCandidate("1.0.0-alpha+002")),
+		"build metadata should not affect precedence")
+	assert.Equal(
+		t,
+		-1,
+		compileSpecCandidate("1.0.0-beta").CompareTo(compileSpecCandidate("1.0.0-beta.0")),
+		"a longer pre-release should be greater when the rest is equal")
+}
+
+func TestSemverCandidateListBuildMetadata(t *testing.T) {
+	list := SemverCandidateList{
+		compileSpecCandidate("1.0.0+b"),
+		compileSpecCandidate("1.0.0-rc.1"),
+		compileSpecCandidate("1.0.0+a"),
+		compileSpecCandidate("1.0.0"),
+	}
+
+	sort.Sort(list)
+
+	var buildMetadata []string
+	for _, candidate := range list {
+		buildMetadata = append(buildMetadata, candidate.BuildMetadata)
+	}
+
+	assert.Equal(
+		t,
+		[]string{"", "", "a", "b"},
+		buildMetadata,
+		"candidates of equal precedence should be ordered by build metadata")
+	assert.Equal(t, "rc.1", list[0].Prerelease, "pre-releases should still come first")
+}
+
+func TestSemverCandidateListOfMajorVersion(t *testing.T) {
+	list := SemverCandidateList{
+		compileSpecCandidate("1.2.0"),
+		compileSpecCandidate("2.0.0-rc.1"),
+		compileSpecCandidate("2.0.0"),
+		compileSpecCandidate("2.1.0"),
+		compileSpecCandidate("3.0.0"),
+	}
+
+	var versions []string
+	for _, candidate := range list.OfMajorVersion(2) {
+		versions = append(versions, candidate.String())
+	}
+
+	assert.Equal(
+		t,
+		[]string{"2.0.0-rc.1", "2.0.0", "2.1.0"},
+		versions,
+		"only the candidates of the major version should be kept, in order")
+	assert.Empty(t, list.OfMajorVersion(4), "missing major versions should have no candidates")
+}
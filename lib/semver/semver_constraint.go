@@ -0,0 +1,13 @@
+package semver
+
+// SemverConstraint is anything that semver candidates can be matched against.
+// Both SemverSelector and SemverRange are SemverConstraints.
+type SemverConstraint interface {
+	// Matches determines whether the given candidate satisfies the constraint.
+	Matches(candidate SemverCandidate) bool
+	// PrefersHighest returns true if the highest matching candidate is the best
+	// one, and false if the lowest is.
+	PrefersHighest() bool
+	// String returns the string representation of the constraint.
+	String() string
+}
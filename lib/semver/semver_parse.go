@@ -0,0 +1,132 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	semverComparatorRegexTemplate = `(%s|%s|%s|%s)?([\%c\%c]?)([0-9]+)(?:\.([0-9]+|%c))?(?:\.([0-9]+|%c))?(?:\-([a-zA-Z0-9\-_]+[a-zA-Z0-9])(?:\.([0-9]+|%c))?)?([\%c\%c]?)`
+	semverSelectorRegexTemplate   = `^%s(?:[\%c\%c]%s)*$`
+)
+
+var (
+	// semverComparatorPattern matches one comparator of a semver range (e.g.
+	// "ge1.2.0"), or a plain semver selector (e.g. "^1.2").
+	semverComparatorPattern = fmt.Sprintf(
+		semverComparatorRegexTemplate,
+		SemverComparatorOperatorGreaterThan,
+		SemverComparatorOperatorGreaterThanOrEqual,
+		SemverComparatorOperatorLessThan,
+		SemverComparatorOperatorLessThanOrEqual,
+		SemverSelectorTildeChar,
+		SemverSelectorCaratChar,
+		SemverSelectorWildcardChar,
+		SemverSelectorWildcardChar,
+		SemverSelectorWildcardChar,
+		SemverSelectorLessThanChar,
+		SemverSelectorGreaterThanChar,
+	)
+	// semverComparatorRegex is the regular expression used to parse the
+	// comparators of semver selector strings.
+	semverComparatorRegex = regexp.MustCompile(
+		"^" + semverComparatorPattern + "$")
+	// semverSelectorRegex is the regular expression used to recognize semver
+	// selector strings. Selectors are made of comparators that are AND-ed with
+	// commas, and OR-ed with semicolons (e.g. "ge1.2.0,lt1.5.0;2.3.x").
+	semverSelectorRegex = regexp.MustCompile(fmt.Sprintf(
+		semverSelectorRegexTemplate,
+		semverComparatorPattern,
+		SemverRangeAndChar,
+		SemverRangeOrChar,
+		semverComparatorPattern,
+	))
+)
+
+// IsSemverSelectorString returns true if the string is shaped like a semver
+// selector (e.g. "^1.2") or a semver range (e.g. "ge1.2.0,lt1.5.0").
+func IsSemverSelectorString(selector string) bool {
+	return semverSelectorRegex.MatchString(selector)
+}
+
+// ReadSemverSelector converts a semver selector string into either a semver
+// selector, or a semver range if it is made of more than one comparator or
+// uses comparator operators.
+func ReadSemverSelector(
+	selector string,
+) (SemverSelector, SemverRange, error) {
+	var groups [][]SemverComparator
+	for _, groupString := range strings.Split(
+		selector,
+		string(SemverRangeOrChar)) {
+		var group []SemverComparator
+		for _, comparatorString := range strings.Split(
+			groupString,
+			string(SemverRangeAndChar)) {
+			comparator, err := readSemverComparator(comparatorString)
+			if err != nil {
+				return SemverSelector{}, SemverRange{}, err
+			}
+
+			group = append(group, comparator)
+		}
+
+		groups = append(groups, group)
+	}
+
+	// Plain selectors are kept as they are.
+	if len(groups) == 1 &&
+		len(groups[0]) == 1 &&
+		groups[0][0].Operator == SemverComparatorNone {
+		return groups[0][0].Selector, SemverRange{}, nil
+	}
+
+	semverRange, err := NewSemverRange(groups...)
+	if err != nil {
+		return SemverSelector{}, SemverRange{}, err
+	}
+
+	return SemverSelector{}, semverRange, nil
+}
+
+// ReadSemverConstraint converts a semver selector string into whichever
+// constraint it describes (see ReadSemverSelector).
+func ReadSemverConstraint(selector string) (SemverConstraint, error) {
+	semverSelector, semverRange, err := ReadSemverSelector(selector)
+	if err != nil {
+		return nil, err
+	} else if len(semverRange.Groups) > 0 {
+		return semverRange, nil
+	}
+
+	return semverSelector, nil
+}
+
+// readSemverComparator converts one comparator of a semver selector string
+// into a semver comparator.
+func readSemverComparator(comparator string) (SemverComparator, error) {
+	match := semverComparatorRegex.FindStringSubmatch(comparator)
+	if match == nil {
+		return SemverComparator{}, fmt.Errorf(
+			"Invalid version selector \"%s\"",
+			comparator)
+	}
+
+	semverSelector, err := NewSemverSelector(
+		match[2], // Prefix
+		match[3], // Major Version
+		match[4], // Minor Version
+		match[5], // Patch Version
+		match[6], // Pre-release Label
+		match[7], // Pre-release Version
+		match[8], // Suffix
+	)
+	if err != nil {
+		return SemverComparator{}, err
+	}
+
+	return NewSemverComparator(
+		match[1], // Operator
+		semverSelector)
+}
@@ -0,0 +1,35 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSemverSelectorString(t *testing.T) {
+	assert.True(t, IsSemverSelectorString("1"))
+	assert.True(t, IsSemverSelectorString("^1.2"))
+	assert.True(t, IsSemverSelectorString("1.2.x"))
+	assert.True(t, IsSemverSelectorString("ge1.2.0,lt1.5.0;2.3.x"))
+	assert.False(t, IsSemverSelectorString("master"))
+	assert.False(t, IsSemverSelectorString("v1.2"))
+	assert.False(t, IsSemverSelectorString(""))
+}
+
+func TestReadSemverConstraint(t *testing.T) {
+	constraint, err := ReadSemverConstraint("^1.2")
+	assert.Nil(t, err)
+	assert.IsType(t, SemverSelector{}, constraint)
+	assert.Equal(t, "^1.2", constraint.String())
+
+	constraint, err = ReadSemverConstraint("ge1.2.0,lt1.5.0")
+	assert.Nil(t, err)
+	assert.IsType(t, SemverRange{}, constraint)
+	assert.Equal(t, "ge1.2.0,lt1.5.0", constraint.String())
+
+	_, err = ReadSemverConstraint("1.x.3")
+	assert.NotNil(t, err)
+
+	_, err = ReadSemverConstraint("nope")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,271 @@
+package semver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// SemverRangeAndChar is the character that separates the comparators of a
+	// semver range that must all match.
+	SemverRangeAndChar = ','
+	// SemverRangeOrChar is the character that separates the groups of
+	// comparators of a semver range of which any may match.
+	SemverRangeOrChar = ';'
+)
+
+const (
+	// SemverComparatorOperatorGreaterThan is the operator string of the
+	// greater-than comparator.
+	SemverComparatorOperatorGreaterThan = "gt"
+	// SemverComparatorOperatorGreaterThanOrEqual is the operator string of the
+	// greater-than-or-equal comparator.
+	SemverComparatorOperatorGreaterThanOrEqual = "ge"
+	// SemverComparatorOperatorLessThan is the operator string of the less-than
+	// comparator.
+	SemverComparatorOperatorLessThan = "lt"
+	// SemverComparatorOperatorLessThanOrEqual is the operator string of the
+	// less-than-or-equal comparator.
+	SemverComparatorOperatorLessThanOrEqual = "le"
+)
+
+const (
+	errorSemverRangeEmpty                       = "Semver ranges need at least one comparator"
+	errorSemverComparatorInvalidOperator        = "Invalid semver comparator operator: %s"
+	errorSemverComparatorOperatorMixedWithRange = "Comparator operators cannot be mixed with version prefixes, suffixes or wildcards"
+)
+
+const (
+	// SemverComparatorNone is the operator enum value for a comparator that
+	// defers to its selector.
+	SemverComparatorNone = iota
+	// SemverComparatorGreaterThan is the operator enum value for greater-than.
+	SemverComparatorGreaterThan = iota
+	// SemverComparatorGreaterThanOrEqual is the operator enum value for
+	// greater-than-or-equal.
+	SemverComparatorGreaterThanOrEqual = iota
+	// SemverComparatorLessThan is the operator enum value for less-than.
+	SemverComparatorLessThan = iota
+	// SemverComparatorLessThanOrEqual is the operator enum value for
+	// less-than-or-equal.
+	SemverComparatorLessThanOrEqual = iota
+)
+
+// SemverComparator is one condition of a semver range. It either compares
+// candidates against a version with an operator, or, without an operator,
+// matches the candidates that its selector matches.
+type SemverComparator struct {
+	Operator int
+	Selector SemverSelector
+}
+
+// NewSemverComparator creates a new semver comparator from an operator string
+// (which may be empty) and the selector that follows it. Selectors that follow
+// an operator must be plain versions; partial versions are allowed (e.g. "lt2"
+// matches everything before 2.0.0, and "le1.2" everything up to 1.2.x).
+func NewSemverComparator(
+	operator string,
+	selector SemverSelector) (SemverComparator, error) {
+	comparator := SemverComparator{Selector: selector}
+
+	switch operator {
+	case "":
+		comparator.Operator = SemverComparatorNone
+		return comparator, nil
+	case SemverComparatorOperatorGreaterThan:
+		comparator.Operator = SemverComparatorGreaterThan
+	case SemverComparatorOperatorGreaterThanOrEqual:
+		comparator.Operator = SemverComparatorGreaterThanOrEqual
+	case SemverComparatorOperatorLessThan:
+		comparator.Operator = SemverComparatorLessThan
+	case SemverComparatorOperatorLessThanOrEqual:
+		comparator.Operator = SemverComparatorLessThanOrEqual
+	default:
+		return comparator, fmt.Errorf(errorSemverComparatorInvalidOperator, operator)
+	}
+
+	if selector.IsFlexible {
+		return comparator, errors.New(errorSemverComparatorOperatorMixedWithRange)
+	}
+
+	return comparator, nil
+}
+
+// Matches determines whether the given candidate satisfies this comparator.
+// Pre-release candidates are not considered here: see SemverRange.Matches.
+func (c SemverComparator) Matches(candidate SemverCandidate) bool {
+	switch c.Operator {
+	case SemverComparatorGreaterThan:
+		return c.compareTo(candidate) < 0
+	case SemverComparatorGreaterThanOrEqual:
+		return c.compareTo(candidate) <= 0
+	case SemverComparatorLessThan:
+		return c.compareTo(candidate) > 0
+	case SemverComparatorLessThanOrEqual:
+		return c.compareTo(candidate) >= 0
+	default:
+		return c.Selector.Matches(candidate)
+	}
+}
+
+// compareTo compares the version of this comparator to a candidate in the same
+// way that SemverCandidate.CompareTo does. Only the segments specified by the
+// comparator are compared, so the candidate 1.2.5 is equal to the version 1.2.
+func (c SemverComparator) compareTo(candidate SemverCandidate) int {
+	s := c.Selector
+
+	if cmp := compareInts(s.MajorVersion.Number, candidate.MajorVersion); cmp != 0 {
+		return cmp
+	} else if s.MinorVersion.Type == SemverSegmentTypeUnspecified {
+		return 0
+	} else if cmp = compareInts(s.MinorVersion.Number, candidate.MinorVersion); cmp != 0 {
+		return cmp
+	} else if s.PatchVersion.Type == SemverSegmentTypeUnspecified {
+		return 0
+	} else if cmp = compareInts(s.PatchVersion.Number, candidate.PatchVersion); cmp != 0 {
+		return cmp
+	}
+
+	var selectorIdentifiers []string
+	if len(s.PrereleaseLabel) > 0 {
+		selectorIdentifiers = strings.Split(s.PrereleaseLabel, string(SemverSelectorSeparatorChar))
+		if s.PrereleaseVersion.Type != SemverSegmentTypeUnspecified {
+			selectorIdentifiers = append(
+				selectorIdentifiers,
+				strconv.Itoa(s.PrereleaseVersion.Number))
+		}
+	}
+
+	// Pre-releases are compared by SemVer 2.0 precedence, just like candidates.
+	return comparePrereleases(selectorIdentifiers, candidate.prereleaseIdentifiers())
+}
+
+// admitsPrereleaseOf returns true if this comparator explicitly refers to a
+// pre-release of the same version as the candidate.
+func (c SemverComparator) admitsPrereleaseOf(candidate SemverCandidate) bool {
+	s := c.Selector
+	return len(s.PrereleaseLabel) > 0 &&
+		s.MajorVersion.Number == candidate.MajorVersion &&
+		s.MinorVersion.Number == candidate.MinorVersion &&
+		s.PatchVersion.Number == candidate.PatchVersion
+}
+
+func (c SemverComparator) String() string {
+	switch c.Operator {
+	case SemverComparatorGreaterThan:
+		return SemverComparatorOperatorGreaterThan + c.Selector.String()
+	case SemverComparatorGreaterThanOrEqual:
+		return SemverComparatorOperatorGreaterThanOrEqual + c.Selector.String()
+	case SemverComparatorLessThan:
+		return SemverComparatorOperatorLessThan + c.Selector.String()
+	case SemverComparatorLessThanOrEqual:
+		return SemverComparatorOperatorLessThanOrEqual + c.Selector.String()
+	default:
+		return c.Selector.String()
+	}
+}
+
+// SemverRange is a union of groups of comparators. A candidate is in the range
+// if it satisfies every comparator of at least one group. For instance,
+// "ge1.2.0,lt1.5.0;2.3.x" is made of the groups [ge1.2.0, lt1.5.0] and
+// [2.3.x].
+type SemverRange struct {
+	Groups [][]SemverComparator
+}
+
+// NewSemverRange creates a new semver range from groups of comparators. Empty
+// groups are not allowed.
+func NewSemverRange(groups ...[]SemverComparator) (SemverRange, error) {
+	if len(groups) < 1 {
+		return SemverRange{}, errors.New(errorSemverRangeEmpty)
+	}
+	for _, group := range groups {
+		if len(group) < 1 {
+			return SemverRange{}, errors.New(errorSemverRangeEmpty)
+		}
+	}
+
+	return SemverRange{Groups: groups}, nil
+}
+
+// Matches determines whether the given candidate is in this range. Like most
+// semver implementations, pre-release candidates are only matched by operator
+// comparators if a comparator of the same group refers to a pre-release of the
+// same version. Selectors without operators follow their own pre-release
+// rules.
+func (r SemverRange) Matches(candidate SemverCandidate) bool {
+	for _, group := range r.Groups {
+		if groupMatches(group, candidate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupMatches returns true if the candidate satisfies every comparator in the
+// group.
+func groupMatches(group []SemverComparator, candidate SemverCandidate) bool {
+	prereleaseAdmitted := len(candidate.PrereleaseLabel) == 0
+
+	for _, comparator := range group {
+		if !comparator.Matches(candidate) {
+			return false
+		}
+
+		if comparator.Operator == SemverComparatorNone ||
+			comparator.admitsPrereleaseOf(candidate) {
+			prereleaseAdmitted = true
+		}
+	}
+
+	return prereleaseAdmitted
+}
+
+// PrefersHighest returns true if the highest matching candidate is the best.
+// Ranges made of exactly one selector behave like the selector; every other
+// range prefers the highest candidate.
+func (r SemverRange) PrefersHighest() bool {
+	if len(r.Groups) == 1 &&
+		len(r.Groups[0]) == 1 &&
+		r.Groups[0][0].Operator == SemverComparatorNone {
+		return r.Groups[0][0].Selector.PrefersHighest()
+	}
+
+	return true
+}
+
+func (r SemverRange) String() string {
+	var buffer bytes.Buffer
+
+	for i, group := range r.Groups {
+		if i > 0 {
+			buffer.WriteByte(SemverRangeOrChar)
+		}
+
+		for j, comparator := range group {
+			if j > 0 {
+				buffer.WriteByte(SemverRangeAndChar)
+			}
+
+			buffer.WriteString(comparator.String())
+		}
+	}
+
+	return buffer.String()
+}
+
+// compareInts returns -1, 0 or 1 depending on whether a is less than, equal to
+// or greater than b.
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+
+	return 0
+}
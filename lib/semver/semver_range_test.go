@@ -0,0 +1,403 @@
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	comparatorRegex = regexp.MustCompile(
+		fmt.Sprintf(
+			`^(gt|ge|lt|le)?([%c%c]?)([0-9]+)(?:\.([0-9]+|%c))?(?:\.([0-9]+|%c))?(?:\-([a-zA-Z0-9\-_]+[a-zA-Z0-9])(?:\.([0-9]+|%c))?)?([%c%c]?)$`,
+			SemverSelectorTildeChar,
+			SemverSelectorCaratChar,
+			SemverSelectorWildcardChar,
+			SemverSelectorWildcardChar,
+			SemverSelectorWildcardChar,
+			SemverSelectorLessThanChar,
+			SemverSelectorGreaterThanChar,
+		),
+	)
+
+	rangeMatchTuples = []*semverRangeMatchTuple{
+		// Greater than
+		semverRange("gt1.2.3").bounds("1.2.4"),
+		semverRange("gt1.2.3").bounds("2.0.0"),
+		semverRange("gt1.2.3").doesntBound("1.2.3"),
+		semverRange("gt1.2.3").doesntBound("1.2.2"),
+		semverRange("gt1.2.3").doesntBound("1.3.0-beta"),
+		semverRange("gt1.2").bounds("1.3.0"),
+		semverRange("gt1.2").doesntBound("1.2.9"),
+		semverRange("gt1").bounds("2.0.0"),
+		semverRange("gt1").doesntBound("1.9.9"),
+		semverRange("gt1.2.3-beta").bounds("1.2.3-beta.1"),
+		semverRange("gt1.2.3-beta").bounds("1.2.3-rc"),
+		semverRange("gt1.2.3-beta").bounds("1.2.3"),
+		semverRange("gt1.2.3-beta.2").doesntBound("1.2.3-beta.2"),
+		semverRange("gt1.2.3-beta.2").doesntBound("1.2.3-alpha.9"),
+		semverRange("gt1.2.3-beta").doesntBound("1.2.4-beta"),
+		// Greater than or equal
+		semverRange("ge1.2.3").bounds("1.2.3"),
+		semverRange("ge1.2.3").bounds("1.10.0"),
+		semverRange("ge1.2.3").doesntBound("1.2.2"),
+		semverRange("ge1.2.3").doesntBound("1.2.3-rc"),
+		semverRange("ge1.2").bounds("1.2.0"),
+		semverRange("ge1.2").doesntBound("1.1.9"),
+		semverRange("ge1").bounds("1.0.0"),
+		semverRange("ge1").doesntBound("0.9.9"),
+		semverRange("ge1.2.3-beta").bounds("1.2.3-beta"),
+		semverRange("ge1.2.3-beta.2").bounds("1.2.3-beta.2"),
+		semverRange("ge1.2.3-beta.2").doesntBound("1.2.3-beta.1"),
+		// Less than
+		semverRange("lt1.2.3").bounds("1.2.2"),
+		semverRange("lt1.2.3").bounds("0.9.0"),
+		semverRange("lt1.2.3").doesntBound("1.2.3"),
+		semverRange("lt1.2.3").doesntBound("1.2.3-beta"),
+		semverRange("lt1.2").bounds("1.1.9"),
+		semverRange("lt1.2").doesntBound("1.2.0"),
+		semverRange("lt2").bounds("1.99.99"),
+		semverRange("lt2").doesntBound("2.0.0"),
+		semverRange("lt1.2.3-beta").bounds("1.2.3-alpha"),
+		semverRange("lt1.2.3-beta.3").bounds("1.2.3-beta.2"),
+		semverRange("lt1.2.3-beta").doesntBound("1.2.3"),
+		// Less than or equal
+		semverRange("le1.2.3").bounds("1.2.3"),
+		semverRange("le1.2.3").bounds("1.2.0"),
+		semverRange("le1.2.3").doesntBound("1.2.4"),
+		semverRange("le1.2").bounds("1.2.9"),
+		semverRange("le1.2").doesntBound("1.3.0"),
+		semverRange("le1").bounds("1.9.9"),
+		semverRange("le1").doesntBound("2.0.0"),
+		semverRange("le1.2.3-beta.2").bounds("1.2.3-beta.2"),
+		semverRange("le1.2.3-beta.2").doesntBound("1.2.3-beta.3"),
+		// Intersections
+		semverRange("ge1.2.0,lt1.5.0").bounds("1.2.0"),
+		semverRange("ge1.2.0,lt1.5.0").bounds("1.4.9"),
+		semverRange("ge1.2.0,lt1.5.0").doesntBound("1.5.0"),
+		semverRange("ge1.2.0,lt1.5.0").doesntBound("1.1.9"),
+		semverRange("ge1.2.0,lt1.5.0").doesntBound("1.3.0-beta"),
+		semverRange("gt1.2,le1.4").bounds("1.3.0"),
+		semverRange("gt1.2,le1.4").bounds("1.4.7"),
+		semverRange("gt1.2,le1.4").doesntBound("1.2.7"),
+		semverRange("gt1.2,le1.4").doesntBound("1.5.0"),
+		semverRange("ge1.2.0-beta,lt1.3.0").bounds("1.2.0-rc"),
+		semverRange("ge1.2.0-beta,lt1.3.0").bounds("1.2.5"),
+		semverRange("ge1.2.0-beta,lt1.3.0").doesntBound("1.2.5-rc"),
+		semverRange("ge1.2.0,lt1.5.0,1.3.x").bounds("1.3.4"),
+		semverRange("ge1.2.0,lt1.5.0,1.3.x").doesntBound("1.4.0"),
+		semverRange("1.x,lt1.5").bounds("1.4.2"),
+		semverRange("1.x,lt1.5").doesntBound("1.5.0"),
+		semverRange("^1.2.0,lt1.4").bounds("1.3.9"),
+		semverRange("^1.2.0,lt1.4").doesntBound("1.4.0"),
+		semverRange("ge2,lt1").doesntBound("1.5.0"),
+		// Unions
+		semverRange("1.x;2.3.x").bounds("1.0.0"),
+		semverRange("1.x;2.3.x").bounds("1.9.1"),
+		semverRange("1.x;2.3.x").bounds("2.3.7"),
+		semverRange("1.x;2.3.x").doesntBound("2.4.0"),
+		semverRange("1.x;2.3.x").doesntBound("3.0.0"),
+		semverRange("lt1;ge2").bounds("0.5.0"),
+		semverRange("lt1;ge2").bounds("2.0.0"),
+		semverRange("lt1;ge2").doesntBound("1.5.0"),
+		semverRange("ge1.2.0,lt1.3.0;ge1.5.0,lt1.6.0").bounds("1.2.5"),
+		semverRange("ge1.2.0,lt1.3.0;ge1.5.0,lt1.6.0").bounds("1.5.5"),
+		semverRange("ge1.2.0,lt1.3.0;ge1.5.0,lt1.6.0").doesntBound("1.4.0"),
+		semverRange("ge1.2.0,lt1.3.0;ge1.5.0,lt1.6.0").doesntBound("1.6.0"),
+		semverRange("~1.2.3;^2.0.0").bounds("1.2.9"),
+		semverRange("~1.2.3;^2.0.0").bounds("2.8.0"),
+		semverRange("~1.2.3;^2.0.0").doesntBound("1.3.0"),
+		semverRange("1.2.3-rc;ge2").bounds("1.2.3-rc"),
+		semverRange("1.2.3-rc;ge2").doesntBound("1.2.3"),
+		// Plain selectors behave exactly as they do outside of ranges
+		semverRange("~1.2.2").bounds("1.2.5"),
+		semverRange("1.2.2+").bounds("1.3.5"),
+		semverRange("1.2.2-").bounds("1.1.5"),
+		semverRange("1.2.3-alpha.x").bounds("1.2.3-alpha.5"),
+		semverRange("1.1.1").bounds("1.1.1"),
+		semverRange("1.1.1").doesntBound("1.1.2"),
+	}
+)
+
+type semverRangeMatchTuple struct {
+	correct           bool
+	semverRange       string
+	compiledRange     SemverRange
+	candidate         string
+	compiledCandidate SemverCandidate
+}
+
+func semverRange(semverRange string) *semverRangeMatchTuple {
+	return &semverRangeMatchTuple{semverRange: semverRange}
+}
+
+func (tuple *semverRangeMatchTuple) bounds(candidate string) *semverRangeMatchTuple {
+	tuple.correct = true
+	tuple.candidate = candidate
+	return tuple
+}
+
+func (tuple *semverRangeMatchTuple) doesntBound(candidate string) *semverRangeMatchTuple {
+	tuple.correct = false
+	tuple.candidate = candidate
+	return tuple
+}
+
+func (tuple *semverRangeMatchTuple) compile() {
+	compiledRange, err := compileRange(tuple.semverRange)
+	if err != nil {
+		panic(fmt.Sprint("A test range could not be initialized:", tuple.semverRange, "(", err, ")"))
+	}
+	tuple.compiledRange = compiledRange
+
+	tuple.compiledCandidate = compileCandidate(tuple.candidate)
+}
+
+func compileRange(semverRange string) (SemverRange, error) {
+	var groups [][]SemverComparator
+	for _, groupString := range strings.Split(semverRange, string(SemverRangeOrChar)) {
+		var group []SemverComparator
+		for _, comparatorString := range strings.Split(groupString, string(SemverRangeAndChar)) {
+			comparator, err := compileComparator(comparatorString)
+			if err != nil {
+				return SemverRange{}, err
+			}
+
+			group = append(group, comparator)
+		}
+
+		groups = append(groups, group)
+	}
+
+	return NewSemverRange(groups...)
+}
+
+func compileComparator(comparator string) (SemverComparator, error) {
+	matches := comparatorRegex.FindStringSubmatch(comparator)
+	if matches == nil {
+		return SemverComparator{}, fmt.Errorf("invalid comparator %s", comparator)
+	}
+
+	selector, err := NewSemverSelector(
+		matches[2],
+		matches[3],
+		matches[4],
+		matches[5],
+		matches[6],
+		matches[7],
+		matches[8],
+	)
+	if err != nil {
+		return SemverComparator{}, err
+	}
+
+	return NewSemverComparator(matches[1], selector)
+}
+
+func compileCandidate(candidate string) SemverCandidate {
+	matches := candidateRegex.FindStringSubmatch(candidate)
+	if matches == nil {
+		panic(fmt.Sprint("A test candidate was invalid:", candidate))
+	}
+
+	compiledCandidate, err := NewSemverCandidate(
+		"fakeHash",
+		"fakeName",
+		"fakeLabel",
+		matches[1],
+		matches[2],
+		matches[3],
+		matches[4],
+		matches[5],
+	)
+	if err != nil {
+		panic(fmt.Sprint("A test candidate could not be initialized:", candidate, "(", err, ")"))
+	}
+
+	return compiledCandidate
+}
+
+func TestNewSemverComparator(t *testing.T) {
+	var (
+		err        error
+		selector   SemverSelector
+		comparator SemverComparator
+	)
+
+	selector, _ = NewSemverSelector("", "1", "2", "3", "", "", "")
+	comparator, err = NewSemverComparator("", selector)
+	assert.Nil(t, err)
+	assert.Equal(t, SemverComparatorNone, comparator.Operator, "operator should be unspecified")
+
+	comparator, err = NewSemverComparator("gt", selector)
+	assert.Nil(t, err)
+	assert.Equal(t, SemverComparatorGreaterThan, comparator.Operator, "operator should be greater than")
+
+	comparator, err = NewSemverComparator("ge", selector)
+	assert.Nil(t, err)
+	assert.Equal(t, SemverComparatorGreaterThanOrEqual, comparator.Operator, "operator should be greater than or equal")
+
+	comparator, err = NewSemverComparator("lt", selector)
+	assert.Nil(t, err)
+	assert.Equal(t, SemverComparatorLessThan, comparator.Operator, "operator should be less than")
+
+	comparator, err = NewSemverComparator("le", selector)
+	assert.Nil(t, err)
+	assert.Equal(t, SemverComparatorLessThanOrEqual, comparator.Operator, "operator should be less than or equal")
+	assert.Equal(t, selector, comparator.Selector, "selector should be preserved")
+
+	comparator, err = NewSemverComparator("eq", selector)
+	assert.NotNil(t, err, "should fail on illegal operators")
+
+	selector, _ = NewSemverSelector("", "1", "x", "", "", "", "")
+	comparator, err = NewSemverComparator("", selector)
+	assert.Nil(t, err, "selectors without operators may be flexible")
+
+	comparator, err = NewSemverComparator("ge", selector)
+	assert.NotNil(t, err, "should fail when an operator is mixed with a wildcard")
+
+	selector, _ = NewSemverSelector("^", "1", "2", "", "", "", "")
+	comparator, err = NewSemverComparator("lt", selector)
+	assert.NotNil(t, err, "should fail when an operator is mixed with a prefix")
+
+	selector, _ = NewSemverSelector("", "1", "2", "", "", "", "+")
+	comparator, err = NewSemverComparator("gt", selector)
+	assert.NotNil(t, err, "should fail when an operator is mixed with a suffix")
+}
+
+func TestNewSemverRange(t *testing.T) {
+	selector, _ := NewSemverSelector("", "1", "2", "3", "", "", "")
+	comparator, _ := NewSemverComparator("ge", selector)
+
+	_, err := NewSemverRange()
+	assert.NotNil(t, err, "should fail without groups")
+
+	_, err = NewSemverRange([]SemverComparator{comparator}, []SemverComparator{})
+	assert.NotNil(t, err, "should fail with an empty group")
+
+	r, err := NewSemverRange([]SemverComparator{comparator})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]SemverComparator{{comparator}}, r.Groups, "groups should be preserved")
+}
+
+func TestSemverRangeMatches(t *testing.T) {
+	for _, tuple := range rangeMatchTuples {
+		tuple.compile()
+	}
+
+	for _, tuple := range rangeMatchTuples {
+		if tuple.correct {
+			assert.True(
+				t,
+				tuple.compiledRange.Matches(tuple.compiledCandidate),
+				fmt.Sprintf(`"%s" should match "%s"`, tuple.semverRange, tuple.candidate),
+			)
+		} else {
+			assert.False(
+				t,
+				tuple.compiledRange.Matches(tuple.compiledCandidate),
+				fmt.Sprintf(
+					`"%s" shouldn't match "%s"`,
+					tuple.semverRange,
+					tuple.candidate,
+				),
+			)
+		}
+	}
+}
+
+func TestSemverRangeString(t *testing.T) {
+	for _, rangeString := range []string{
+		"gt1",
+		"ge1.2",
+		"lt1.2.3",
+		"le1.2.3-beta.4",
+		"1.x",
+		"ge1.2.0,lt1.5.0",
+		"1.x;2.3.x",
+		"ge1.2.0,lt1.3.0;~1.5.2;2.x",
+	} {
+		r, err := compileRange(rangeString)
+		assert.Nil(t, err, rangeString)
+		assert.Equal(t, rangeString, r.String(), "serialized range should match expectations")
+	}
+}
+
+func TestSemverCandidateListBestInRange(t *testing.T) {
+	var list SemverCandidateList
+	for _, candidate := range []string{
+		"1.0.0",
+		"1.2.0",
+		"1.2.5",
+		"1.4.0",
+		"1.5.0",
+		"2.0.0-beta",
+		"2.3.1",
+		"2.3.4",
+		"2.4.0",
+	} {
+		list = append(list, compileCandidate(candidate))
+	}
+	sort.Sort(list)
+
+	for _, test := range []struct {
+		semverRange string
+		best        string
+	}{
+		// Ranges prefer the most recent version that they match.
+		{"ge1.2.0,lt1.5.0", "1.4.0"},
+		{"1.x;2.3.x", "2.3.4"},
+		{"lt2", "1.5.0"},
+		{"gt1.2,le1.4;1.0.0", "1.4.0"},
+		{"ge2.0.0-beta,lt2.1", "2.0.0-beta"},
+		// Ranges made of one selector behave like the selector.
+		{"1.2+", "1.2.0"},
+		{"^1.2.0", "1.2.0"},
+		{"1.x", "1.5.0"},
+		{"1.2.x", "1.2.5"},
+		// Ranges that match nothing have no best version.
+		{"ge3", ""},
+		{"gt1.5.0,lt2.3.0", ""},
+	} {
+		r, err := compileRange(test.semverRange)
+		assert.Nil(t, err, test.semverRange)
+
+		best := list.Best(r)
+		if len(test.best) == 0 {
+			assert.Nil(t, best, test.semverRange)
+		} else if assert.NotNil(t, best, test.semverRange) {
+			assert.Equal(t, test.best, best.String(), test.semverRange)
+		}
+	}
+}
+
+func TestSemverSelectorPrefersHighest(t *testing.T) {
+	for _, test := range []struct {
+		selector       SemverSelector
+		prefersHighest bool
+	}{
+		{compileSelector("1.x"), true},
+		{compileSelector("1.2.x"), true},
+		{compileSelector("1.2.3-beta.x"), true},
+		{compileSelector("1.2.3-"), true},
+		{compileSelector("1.2.3+"), false},
+		{compileSelector("^1.2.3"), false},
+		{compileSelector("~1.2.3"), false},
+		{compileSelector("1.2.3"), false},
+	} {
+		assert.Equal(t, test.prefersHighest, test.selector.PrefersHighest(), test.selector.String())
+	}
+}
+
+func compileSelector(selector string) SemverSelector {
+	comparator, err := compileComparator(selector)
+	if err != nil {
+		panic(fmt.Sprint("A test selector could not be initialized:", selector, "(", err, ")"))
+	}
+
+	return comparator.Selector
+}
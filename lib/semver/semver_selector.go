@@ -388,10 +388,16 @@ func (s SemverSelector) Matches(candidate SemverCandidate) bool {
 			case SemverSegmentTypeWildcard, SemverSegmentTypeUnspecified:
 				return true
 			}
+			if s.MinorVersion.Number != candidate.MinorVersion {
+				return false
+			}
 			switch s.PatchVersion.Type {
 			case SemverSegmentTypeWildcard, SemverSegmentTypeUnspecified:
 				return true
 			}
+			if s.PatchVersion.Number != candidate.PatchVersion {
+				return false
+			}
 
 			return s.PrereleaseLabel == candidate.PrereleaseLabel
 		}
@@ -419,6 +425,16 @@ func (s SemverSelector) Matches(candidate SemverCandidate) bool {
 	}
 }
 
+// PrefersHighest returns true if the highest matching candidate is the best.
+// Selectors with wildcards or a less-than suffix want the most recent version
+// that they match, while every other selector wants the least recent one.
+func (s SemverSelector) PrefersHighest() bool {
+	return s.Suffix == SemverSelectorSuffixLessThan ||
+		s.MinorVersion.Type == SemverSegmentTypeWildcard ||
+		s.PatchVersion.Type == SemverSegmentTypeWildcard ||
+		s.PrereleaseVersion.Type == SemverSegmentTypeWildcard
+}
+
 func (s SemverSelector) String() string {
 	var (
 		buffer                 bytes.Buffer
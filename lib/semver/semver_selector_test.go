@@ -86,6 +86,10 @@ var (
 		selector("1.2.x").bounds("1.2.1"),
 		selector("1.2.3-alpha.x").bounds("1.2.3-alpha.5"),
 		selector("1.x").doesntBound("2.11.9"),
+		selector("1.2.x").doesntBound("1.3.1"),
+		selector("2.3.x").doesntBound("2.4.0"),
+		selector("1.2.3-alpha.x").doesntBound("1.2.4-alpha.1"),
+		selector("1.2.3-alpha.x").doesntBound("1.2.3-beta.1"),
 		// Vanilla
 		selector("1.1.1").bounds("1.1.1"),
 		selector("1.1.1-beta").bounds("1.1.1-beta"),
@@ -0,0 +1,130 @@
+package vcs
+
+import (
+	"bytes"
+	"strings"
+)
+
+// internalDomainSuffixes are the suffixes of domains that only resolve inside
+// private networks.
+var internalDomainSuffixes = []string{
+	".localhost",
+	".local",
+	".localdomain",
+	".internal",
+	".intranet",
+	".lan",
+	".home.arpa",
+	".corp",
+}
+
+const (
+	// GithubDomain is the domain of Github.
+	GithubDomain = "github.com"
+	// GitLabDomain is the domain of GitLab.
+	GitLabDomain = "gitlab.com"
+	// BitbucketDomain is the domain of Bitbucket.
+	BitbucketDomain = "bitbucket.org"
+	// authorDomainSeparator separates the domain of a host from the author in a
+	// qualified author. Colons can't appear in import paths or usernames, so
+	// there is no ambiguity.
+	authorDomainSeparator = ':'
+)
+
+// QualifyAuthor combines the domain of a host and an author into the form that
+// gophr uses to identify authors everywhere (the database, depot etc.).
+// Github authors are left as-is so that they keep their original identities.
+// Every other author is prefixed with its domain (e.g. "gitlab.com:author").
+func QualifyAuthor(domain, author string) string {
+	if len(domain) == 0 || domain == GithubDomain {
+		return author
+	}
+
+	return domain + string(authorDomainSeparator) + author
+}
+
+// SplitAuthor splits a qualified author into the domain of its host and the
+// bare author.
+func SplitAuthor(author string) (domain string, bareAuthor string) {
+	if i := strings.IndexByte(author, authorDomainSeparator); i != -1 {
+		return author[:i], author[i+1:]
+	}
+
+	return GithubDomain, author
+}
+
+// AuthorPath returns the import path form of a qualified author. Github authors
+// are not prefixed by a domain (e.g. "author" or "gitlab.com/author").
+func AuthorPath(author string) string {
+	if i := strings.IndexByte(author, authorDomainSeparator); i != -1 {
+		return author[:i] + "/" + author[i+1:]
+	}
+
+	return author
+}
+
+// RepoPath returns the fully qualified path of a repository, including the
+// domain of its host (e.g. "github.com/author/repo").
+func RepoPath(author, repo string) string {
+	domain, bareAuthor := SplitAuthor(author)
+
+	var buffer bytes.Buffer
+	buffer.WriteString(domain)
+	buffer.WriteByte('/')
+	buffer.WriteString(bareAuthor)
+	buffer.WriteByte('/')
+	buffer.WriteString(repo)
+
+	return buffer.String()
+}
+
+// IsWellKnownDomain returns true if the domain belongs to a host that gophr
+// understands well enough to version dependencies against.
+func IsWellKnownDomain(domain string) bool {
+	return domain == GithubDomain ||
+		domain == GitLabDomain ||
+		domain == BitbucketDomain
+}
+
+// IsPublicDomain returns true if the domain is the name of a host on the public
+// internet. IP literals, ports, localhost, single-label names and names that
+// only resolve inside private networks (e.g. "git.internal") are not.
+func IsPublicDomain(domain string) bool {
+	domain = strings.ToLower(domain)
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+
+	for _, label := range labels {
+		if len(label) < 1 ||
+			len(label) > 63 ||
+			label[0] == '-' ||
+			label[len(label)-1] == '-' {
+			return false
+		}
+
+		for i := 0; i < len(label); i++ {
+			if c := label[i]; !(c >= 'a' && c <= 'z') &&
+				!(c >= '0' && c <= '9') &&
+				c != '-' {
+				return false
+			}
+		}
+	}
+
+	// Top-level domains are never numeric, so this rules out IPv4 literals.
+	tld := labels[len(labels)-1]
+	if strings.Trim(tld, "0123456789") == "" {
+		return false
+	}
+
+	for _, suffix := range internalDomainSuffixes {
+		if strings.HasSuffix("."+domain, suffix) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,64 @@
+package vcs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQualifyAuthor(t *testing.T) {
+	assert.Equal(t, "a", QualifyAuthor("", "a"))
+	assert.Equal(t, "a", QualifyAuthor(GithubDomain, "a"))
+	assert.Equal(t, "gitlab.com:a", QualifyAuthor(GitLabDomain, "a"))
+	assert.Equal(t, "git.example.com:a", QualifyAuthor("git.example.com", "a"))
+}
+
+func TestSplitAuthor(t *testing.T) {
+	domain, bareAuthor := SplitAuthor("a")
+	assert.Equal(t, GithubDomain, domain)
+	assert.Equal(t, "a", bareAuthor)
+
+	domain, bareAuthor = SplitAuthor("bitbucket.org:a")
+	assert.Equal(t, BitbucketDomain, domain)
+	assert.Equal(t, "a", bareAuthor)
+}
+
+func TestAuthorPath(t *testing.T) {
+	assert.Equal(t, "a", AuthorPath("a"))
+	assert.Equal(t, "gitlab.com/a", AuthorPath("gitlab.com:a"))
+}
+
+func TestRepoPath(t *testing.T) {
+	assert.Equal(t, "github.com/a/b", RepoPath("a", "b"))
+	assert.Equal(t, "gitlab.com/a/b", RepoPath("gitlab.com:a", "b"))
+}
+
+func TestIsWellKnownDomain(t *testing.T) {
+	assert.True(t, IsWellKnownDomain(GithubDomain))
+	assert.True(t, IsWellKnownDomain(GitLabDomain))
+	assert.True(t, IsWellKnownDomain(BitbucketDomain))
+	assert.False(t, IsWellKnownDomain("golang.org"))
+}
+
+func TestIsPublicDomain(t *testing.T) {
+	assert.True(t, IsPublicDomain(GitLabDomain))
+	assert.True(t, IsPublicDomain("git.example.com"))
+	assert.True(t, IsPublicDomain("Git.Example.com"))
+	assert.True(t, IsPublicDomain("git-1.example.io"))
+
+	assert.False(t, IsPublicDomain(""))
+	assert.False(t, IsPublicDomain("localhost"))
+	assert.False(t, IsPublicDomain("git"), "single-label names should be refused")
+	assert.False(t, IsPublicDomain("a.localhost"))
+	assert.False(t, IsPublicDomain("git.local"))
+	assert.False(t, IsPublicDomain("git.internal"))
+	assert.False(t, IsPublicDomain("git.corp"))
+	assert.False(t, IsPublicDomain("127.0.0.1"))
+	assert.False(t, IsPublicDomain("169.254.169.254"))
+	assert.False(t, IsPublicDomain("0x7f.1"))
+	assert.False(t, IsPublicDomain("[::1]"))
+	assert.False(t, IsPublicDomain("::1"))
+	assert.False(t, IsPublicDomain("example.com:8080"))
+	assert.False(t, IsPublicDomain("example.com."))
+	assert.False(t, IsPublicDomain("-a.example.com"))
+}
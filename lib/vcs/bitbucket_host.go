@@ -0,0 +1,180 @@
+package vcs
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	bitbucketAPIBaseURL            = "https://api.bitbucket.org/2.0"
+	bitbucketWebBaseURL            = "https://bitbucket.org"
+	bitbucketCommitURLTemplate     = "%s/repositories/%s/%s/commit/%s"
+	bitbucketCommitsURLTemplate    = "%s/repositories/%s/%s/commits?pagelen=100"
+	bitbucketArchiveURLTemplate    = "%s/%s/%s/get/%s.zip"
+	bitbucketRawFileURLTemplate    = "%s/%s/%s/raw/%s/%s"
+	bitbucketTreeURLTemplateFormat = "%s/%s/%s/src/%s{/dir}"
+	// bitbucketCommitPagesLimit caps how many pages of commits are read while
+	// looking for a commit by date.
+	bitbucketCommitPagesLimit = 10
+)
+
+// bitbucketCommit is the subset of a Bitbucket API commit that gophr cares
+// about.
+type bitbucketCommit struct {
+	Hash string    `json:"hash"`
+	Date time.Time `json:"date"`
+}
+
+// bitbucketCommitsPage is a single page of Bitbucket API commits.
+type bitbucketCommitsPage struct {
+	Next   string            `json:"next"`
+	Values []bitbucketCommit `json:"values"`
+}
+
+// bitbucketHost is the Host implementation for Bitbucket.
+type bitbucketHost struct {
+	apiBaseURL string
+	webBaseURL string
+}
+
+// NewBitbucketHost creates a new host for bitbucket.org.
+func NewBitbucketHost() Host {
+	return &bitbucketHost{
+		apiBaseURL: bitbucketAPIBaseURL,
+		webBaseURL: bitbucketWebBaseURL,
+	}
+}
+
+// Domain returns the domain of the host.
+func (host *bitbucketHost) Domain() string {
+	return BitbucketDomain
+}
+
+// ArchiveURL returns the URL of the zip archive of a repository at the
+// specified commit.
+func (host *bitbucketHost) ArchiveURL(author, repo, sha string) string {
+	return fmt.Sprintf(
+		bitbucketArchiveURLTemplate,
+		host.webBaseURL,
+		author,
+		repo,
+		sha)
+}
+
+// RawFileURL returns the URL of the contents of a file of a repository at the
+// specified commit.
+func (host *bitbucketHost) RawFileURL(author, repo, sha, path string) string {
+	return fmt.Sprintf(
+		bitbucketRawFileURLTemplate,
+		host.webBaseURL,
+		author,
+		repo,
+		sha,
+		path)
+}
+
+// TreeURLTemplate returns the go-source directory URL template of a
+// repository at the specified ref.
+func (host *bitbucketHost) TreeURLTemplate(author, repo, ref string) string {
+	if len(ref) < 1 {
+		ref = DefaultRef
+	}
+
+	return fmt.Sprintf(
+		bitbucketTreeURLTemplateFormat,
+		host.webBaseURL,
+		author,
+		repo,
+		ref)
+}
+
+// FetchCommitSHA fetches the commit SHA that is chronologically closest to a
+// given timestamp. Bitbucket can't filter commits by date, so pages of commits
+// (newest first) are read until one old enough turns up. If none do, the oldest
+// commit read is used instead.
+func (host *bitbucketHost) FetchCommitSHA(
+	author string,
+	repo string,
+	timestamp time.Time,
+) (string, error) {
+	var (
+		oldestSHA string
+		nextURL   = fmt.Sprintf(
+			bitbucketCommitsURLTemplate,
+			host.apiBaseURL,
+			author,
+			repo)
+	)
+
+	for pages := 0; pages < bitbucketCommitPagesLimit && len(nextURL) > 0; pages++ {
+		var page bitbucketCommitsPage
+		if err := getJSON(nextURL, &page); err != nil {
+			return "", err
+		}
+
+		for _, commit := range page.Values {
+			if !commit.Date.After(timestamp) {
+				return commit.Hash, nil
+			}
+
+			oldestSHA = commit.Hash
+		}
+
+		nextURL = page.Next
+	}
+
+	if len(oldestSHA) > 0 {
+		return oldestSHA, nil
+	}
+
+	return "", errors.New("No commit SHAs available for timestamp given")
+}
+
+// FetchCommitTimestamp fetches the timestamp of a commit.
+func (host *bitbucketHost) FetchCommitTimestamp(
+	author string,
+	repo string,
+	sha string,
+) (time.Time, error) {
+	commit, err := host.fetchCommit(author, repo, sha)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return commit.Date, nil
+}
+
+// ExpandPartialSHA fetches the full commit SHA that corresponds to a short SHA.
+func (host *bitbucketHost) ExpandPartialSHA(
+	author string,
+	repo string,
+	shortSHA string,
+) (string, error) {
+	commit, err := host.fetchCommit(author, repo, shortSHA)
+	if err != nil {
+		return "", err
+	}
+
+	return commit.Hash, nil
+}
+
+// fetchCommit fetches a single commit from the Bitbucket API. Bitbucket
+// accepts both short and full SHAs.
+func (host *bitbucketHost) fetchCommit(
+	author string,
+	repo string,
+	sha string,
+) (bitbucketCommit, error) {
+	var commit bitbucketCommit
+	if err := getJSON(fmt.Sprintf(
+		bitbucketCommitURLTemplate,
+		host.apiBaseURL,
+		author,
+		repo,
+		sha), &commit); err != nil {
+		return bitbucketCommit{}, err
+	}
+
+	return commit, nil
+}
@@ -0,0 +1,84 @@
+package vcs
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	genericArchiveURLTemplate    = "https://%s/%s/%s/archive/%s.zip"
+	genericTreeURLTemplateFormat = "https://%s/%s/%s/src/%s{/dir}"
+	genericRawFileURLTemplate    = "https://%s/%s/%s/raw/%s/%s"
+)
+
+// genericHost is a Host that only speaks git over HTTP. Archives and trees are
+// located using the conventions of Gitea and Gogs.
+type genericHost struct {
+	domain string
+}
+
+// NewGenericHost creates a new host for a domain that gophr knows nothing
+// about.
+func NewGenericHost(domain string) Host {
+	return &genericHost{domain: domain}
+}
+
+// Domain returns the domain of the host.
+func (host *genericHost) Domain() string {
+	return host.domain
+}
+
+// ArchiveURL returns the URL of the zip archive of a repository at the
+// specified commit.
+func (host *genericHost) ArchiveURL(author, repo, sha string) string {
+	return fmt.Sprintf(genericArchiveURLTemplate, host.domain, author, repo, sha)
+}
+
+// RawFileURL returns the URL of the contents of a file of a repository at the
+// specified commit.
+func (host *genericHost) RawFileURL(author, repo, sha, path string) string {
+	return fmt.Sprintf(
+		genericRawFileURLTemplate,
+		host.domain,
+		author,
+		repo,
+		sha,
+		path)
+}
+
+// TreeURLTemplate returns the go-source directory URL template of a
+// repository at the specified ref.
+func (host *genericHost) TreeURLTemplate(author, repo, ref string) string {
+	if len(ref) < 1 {
+		ref = DefaultRef
+	}
+
+	return fmt.Sprintf(genericTreeURLTemplateFormat, host.domain, author, repo, ref)
+}
+
+// FetchCommitSHA is not supported by generic hosts.
+func (host *genericHost) FetchCommitSHA(
+	author string,
+	repo string,
+	timestamp time.Time,
+) (string, error) {
+	return "", ErrUnsupported
+}
+
+// FetchCommitTimestamp is not supported by generic hosts.
+func (host *genericHost) FetchCommitTimestamp(
+	author string,
+	repo string,
+	sha string,
+) (time.Time, error) {
+	return time.Time{}, ErrUnsupported
+}
+
+// ExpandPartialSHA is not supported by generic hosts.
+func (host *genericHost) ExpandPartialSHA(
+	author string,
+	repo string,
+	shortSHA string,
+) (string, error) {
+	return "", ErrUnsupported
+}
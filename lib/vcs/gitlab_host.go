@@ -0,0 +1,169 @@
+package vcs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	gitlabAPIBaseURL               = "https://gitlab.com/api/v4"
+	gitlabWebBaseURL               = "https://gitlab.com"
+	gitlabCommitURLTemplate        = "%s/projects/%s/repository/commits/%s"
+	gitlabArchiveURLTemplate       = "%s/%s/%s/-/archive/%s/%s-%s.zip"
+	gitlabRawFileURLTemplate       = "%s/%s/%s/-/raw/%s/%s"
+	gitlabTreeURLTemplateFormat    = "%s/%s/%s/tree/%s{/dir}"
+	gitlabCommitsUntilURLTemplate  = "%s/projects/%s/repository/commits?until=%s&per_page=1"
+	gitlabCommitsOldestURLTemplate = "%s/projects/%s/repository/commits?since=%s&per_page=100"
+)
+
+// gitlabCommit is the subset of a GitLab API commit that gophr cares about.
+type gitlabCommit struct {
+	ID            string    `json:"id"`
+	CommittedDate time.Time `json:"committed_date"`
+}
+
+// gitlabHost is the Host implementation for GitLab.
+type gitlabHost struct {
+	apiBaseURL string
+	webBaseURL string
+}
+
+// NewGitLabHost creates a new host for gitlab.com.
+func NewGitLabHost() Host {
+	return &gitlabHost{apiBaseURL: gitlabAPIBaseURL, webBaseURL: gitlabWebBaseURL}
+}
+
+// Domain returns the domain of the host.
+func (host *gitlabHost) Domain() string {
+	return GitLabDomain
+}
+
+// ArchiveURL returns the URL of the zip archive of a repository at the
+// specified commit.
+func (host *gitlabHost) ArchiveURL(author, repo, sha string) string {
+	return fmt.Sprintf(
+		gitlabArchiveURLTemplate,
+		host.webBaseURL,
+		author,
+		repo,
+		sha,
+		repo,
+		sha)
+}
+
+// RawFileURL returns the URL of the contents of a file of a repository at the
+// specified commit.
+func (host *gitlabHost) RawFileURL(author, repo, sha, path string) string {
+	return fmt.Sprintf(
+		gitlabRawFileURLTemplate,
+		host.webBaseURL,
+		author,
+		repo,
+		sha,
+		path)
+}
+
+// TreeURLTemplate returns the go-source directory URL template of a
+// repository at the specified ref.
+func (host *gitlabHost) TreeURLTemplate(author, repo, ref string) string {
+	if len(ref) < 1 {
+		ref = DefaultRef
+	}
+
+	return fmt.Sprintf(
+		gitlabTreeURLTemplateFormat,
+		host.webBaseURL,
+		author,
+		repo,
+		ref)
+}
+
+// FetchCommitSHA fetches the commit SHA that is chronologically closest to a
+// given timestamp. Commits before the timestamp are preferred; if there are
+// none, the oldest commit after the timestamp is used instead.
+func (host *gitlabHost) FetchCommitSHA(
+	author string,
+	repo string,
+	timestamp time.Time,
+) (string, error) {
+	var commits []gitlabCommit
+	if err := getJSON(fmt.Sprintf(
+		gitlabCommitsUntilURLTemplate,
+		host.apiBaseURL,
+		gitlabProjectID(author, repo),
+		url.QueryEscape(timestamp.UTC().Format(time.RFC3339))), &commits); err != nil {
+		return "", err
+	}
+	if len(commits) > 0 {
+		return commits[0].ID, nil
+	}
+
+	// Commits come back newest first, so the last one is the closest.
+	if err := getJSON(fmt.Sprintf(
+		gitlabCommitsOldestURLTemplate,
+		host.apiBaseURL,
+		gitlabProjectID(author, repo),
+		url.QueryEscape(timestamp.UTC().Format(time.RFC3339))), &commits); err != nil {
+		return "", err
+	}
+	if len(commits) > 0 {
+		return commits[len(commits)-1].ID, nil
+	}
+
+	return "", errors.New("No commit SHAs available for timestamp given")
+}
+
+// FetchCommitTimestamp fetches the timestamp of a commit.
+func (host *gitlabHost) FetchCommitTimestamp(
+	author string,
+	repo string,
+	sha string,
+) (time.Time, error) {
+	commit, err := host.fetchCommit(author, repo, sha)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return commit.CommittedDate, nil
+}
+
+// ExpandPartialSHA fetches the full commit SHA that corresponds to a short SHA.
+func (host *gitlabHost) ExpandPartialSHA(
+	author string,
+	repo string,
+	shortSHA string,
+) (string, error) {
+	commit, err := host.fetchCommit(author, repo, shortSHA)
+	if err != nil {
+		return "", err
+	}
+
+	return commit.ID, nil
+}
+
+// fetchCommit fetches a single commit from the GitLab API. GitLab accepts both
+// short and full SHAs.
+func (host *gitlabHost) fetchCommit(
+	author string,
+	repo string,
+	sha string,
+) (gitlabCommit, error) {
+	var commit gitlabCommit
+	if err := getJSON(fmt.Sprintf(
+		gitlabCommitURLTemplate,
+		host.apiBaseURL,
+		gitlabProjectID(author, repo),
+		sha), &commit); err != nil {
+		return gitlabCommit{}, err
+	}
+
+	return commit, nil
+}
+
+// gitlabProjectID returns the URL-encoded path of a project, which GitLab
+// accepts in place of the numeric project ID.
+func gitlabProjectID(author, repo string) string {
+	return url.QueryEscape(author + "/" + repo)
+}
@@ -0,0 +1,92 @@
+package vcs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrUnsupported is returned by hosts that cannot perform a particular
+// operation (e.g. generic git hosts cannot look up commits by date).
+var ErrUnsupported = errors.New("This operation is not supported by the host")
+
+// DefaultRef is the ref that refers to the default branch of a repository,
+// whatever it happens to be called.
+const DefaultRef = "HEAD"
+
+// Host is an abstraction over a service that hosts git repositories (e.g.
+// Github or GitLab). Authors passed to a Host are always bare - they are never
+// qualified with the domain of the host.
+type Host interface {
+	// Domain returns the domain of the host (e.g. "gitlab.com").
+	Domain() string
+	// ArchiveURL returns the URL of the zip archive of a repository at the
+	// specified commit.
+	ArchiveURL(author, repo, sha string) string
+	// RawFileURL returns the URL of the contents of a file of a repository at
+	// the specified commit. The path is relative to the root of the repository.
+	RawFileURL(author, repo, sha, path string) string
+	// TreeURLTemplate returns the go-source directory URL template of a
+	// repository at the specified ref. An empty ref refers to the default
+	// branch.
+	TreeURLTemplate(author, repo, ref string) string
+	// FetchCommitSHA fetches the commit SHA that is chronologically closest to
+	// a given timestamp.
+	FetchCommitSHA(author, repo string, timestamp time.Time) (string, error)
+	// FetchCommitTimestamp fetches the timestamp of a commit.
+	FetchCommitTimestamp(author, repo, sha string) (time.Time, error)
+	// ExpandPartialSHA fetches the full commit SHA that corresponds to a short
+	// SHA.
+	ExpandPartialSHA(author, repo, shortSHA string) (string, error)
+}
+
+// Hosts is a set of hosts keyed by domain.
+type Hosts map[string]Host
+
+// NewHosts creates a new set of hosts.
+func NewHosts(hosts ...Host) Hosts {
+	set := make(Hosts)
+	for _, host := range hosts {
+		set[host.Domain()] = host
+	}
+
+	return set
+}
+
+// NewGenericHosts creates a generic host for each of the specified domains.
+// Gophr fetches whatever the domains point at, so domains that don't belong to
+// the public internet are refused (see IsPublicDomain).
+func NewGenericHosts(domains []string) ([]Host, error) {
+	var hosts []Host
+	for _, domain := range domains {
+		if !IsPublicDomain(domain) {
+			return nil, fmt.Errorf(
+				"%s cannot be a package host since it is not a public domain.",
+				domain)
+		}
+
+		hosts = append(hosts, NewGenericHost(strings.ToLower(domain)))
+	}
+
+	return hosts, nil
+}
+
+// Supports returns true if the domain of the specified author is in the set.
+func (hosts Hosts) Supports(author string) bool {
+	domain, _ := SplitAuthor(author)
+	_, exists := hosts[domain]
+	return exists
+}
+
+// Of returns the host of the specified author along with the bare author. If
+// the domain of the author is not in the set, an error is returned instead;
+// generic hosts have to be added to the set explicitly (see NewGenericHosts).
+func (hosts Hosts) Of(author string) (Host, string, error) {
+	domain, bareAuthor := SplitAuthor(author)
+	if host, exists := hosts[domain]; exists {
+		return host, bareAuthor, nil
+	}
+
+	return nil, "", fmt.Errorf("%s is not a supported package host.", domain)
+}
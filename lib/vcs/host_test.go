@@ -0,0 +1,175 @@
+package vcs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostsOf(t *testing.T) {
+	genericHosts, err := NewGenericHosts([]string{"git.example.com"})
+	assert.Nil(t, err)
+	hosts := NewHosts(append(genericHosts, NewGitLabHost(), NewBitbucketHost())...)
+
+	host, bareAuthor, err := hosts.Of("gitlab.com:a")
+	assert.Nil(t, err)
+	assert.Equal(t, GitLabDomain, host.Domain())
+	assert.Equal(t, "a", bareAuthor)
+
+	host, bareAuthor, err = hosts.Of("git.example.com:a")
+	assert.Nil(t, err)
+	assert.Equal(t, "git.example.com", host.Domain())
+	assert.Equal(t, "a", bareAuthor)
+	assert.True(t, hosts.Supports("git.example.com:a"))
+
+	// Domains that weren't added to the set should not be reached.
+	_, _, err = hosts.Of("git.elsewhere.com:a")
+	assert.NotNil(t, err)
+	assert.False(t, hosts.Supports("git.elsewhere.com:a"))
+
+	// Neither should Github, since it isn't in the set either.
+	_, _, err = hosts.Of("a")
+	assert.NotNil(t, err)
+	assert.False(t, hosts.Supports("a"))
+}
+
+func TestNewGenericHosts(t *testing.T) {
+	hosts, err := NewGenericHosts([]string{"git.example.com", "Git.Example.org"})
+	assert.Nil(t, err)
+	assert.Len(t, hosts, 2)
+	assert.Equal(t, "git.example.com", hosts[0].Domain())
+	assert.Equal(t, "git.example.org", hosts[1].Domain())
+
+	for _, domain := range []string{
+		"localhost",
+		"git",
+		"git.internal",
+		"10.0.0.1",
+		"[::1]",
+	} {
+		_, err = NewGenericHosts([]string{"git.example.com", domain})
+		assert.NotNil(t, err, domain)
+	}
+}
+
+func TestGenericHost(t *testing.T) {
+	host := NewGenericHost("git.example.com")
+
+	assert.Equal(
+		t,
+		"https://git.example.com/a/b/archive/c.zip",
+		host.ArchiveURL("a", "b", "c"))
+	assert.Equal(
+		t,
+		"https://git.example.com/a/b/raw/c/d/go.mod",
+		host.RawFileURL("a", "b", "c", "d/go.mod"))
+	assert.Equal(
+		t,
+		"https://git.example.com/a/b/src/HEAD{/dir}",
+		host.TreeURLTemplate("a", "b", ""))
+
+	_, err := host.FetchCommitSHA("a", "b", time.Now())
+	assert.Equal(t, ErrUnsupported, err)
+	_, err = host.FetchCommitTimestamp("a", "b", "c")
+	assert.Equal(t, ErrUnsupported, err)
+	_, err = host.ExpandPartialSHA("a", "b", "c")
+	assert.Equal(t, ErrUnsupported, err)
+}
+
+func TestGitLabHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.RawQuery {
+		case "":
+			assert.Equal(t, "/projects/a%2Fb/repository/commits/abcdef", r.URL.EscapedPath())
+			w.Write([]byte(`{"id":"abcdef1234","committed_date":"2017-03-14T15:09:26Z"}`))
+		case "until=2017-01-01T00%3A00%3A00Z&per_page=1":
+			w.Write([]byte(`[]`))
+		default:
+			assert.Equal(t, "since=2017-01-01T00%3A00%3A00Z&per_page=100", r.URL.RawQuery)
+			w.Write([]byte(`[{"id":"newer"},{"id":"older"}]`))
+		}
+	}))
+	defer server.Close()
+
+	host := &gitlabHost{apiBaseURL: server.URL, webBaseURL: gitlabWebBaseURL}
+
+	assert.Equal(t, GitLabDomain, host.Domain())
+	assert.Equal(
+		t,
+		"https://gitlab.com/a/b/-/archive/c/b-c.zip",
+		host.ArchiveURL("a", "b", "c"))
+	assert.Equal(
+		t,
+		"https://gitlab.com/a/b/-/raw/c/d/go.mod",
+		host.RawFileURL("a", "b", "c", "d/go.mod"))
+	assert.Equal(
+		t,
+		"https://gitlab.com/a/b/tree/c{/dir}",
+		host.TreeURLTemplate("a", "b", "c"))
+
+	sha, err := host.ExpandPartialSHA("a", "b", "abcdef")
+	assert.Nil(t, err)
+	assert.Equal(t, "abcdef1234", sha)
+
+	timestamp, err := host.FetchCommitTimestamp("a", "b", "abcdef")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2017, 3, 14, 15, 9, 26, 0, time.UTC), timestamp.UTC())
+
+	sha, err = host.FetchCommitSHA("a", "b", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, "older", sha)
+}
+
+func TestBitbucketHost(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repositories/a/b/commit/abcdef":
+			w.Write([]byte(`{"hash":"abcdef1234","date":"2017-03-14T15:09:26+00:00"}`))
+		case "/repositories/a/b/commits":
+			w.Write([]byte(`{"next":"` + server.URL + `/page2","values":[` +
+				`{"hash":"newest","date":"2017-03-14T00:00:00+00:00"}]}`))
+		case "/page2":
+			w.Write([]byte(`{"values":[` +
+				`{"hash":"newer","date":"2017-02-01T00:00:00+00:00"},` +
+				`{"hash":"older","date":"2016-12-01T00:00:00+00:00"}]}`))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+
+	host := &bitbucketHost{apiBaseURL: server.URL, webBaseURL: bitbucketWebBaseURL}
+
+	assert.Equal(t, BitbucketDomain, host.Domain())
+	assert.Equal(
+		t,
+		"https://bitbucket.org/a/b/get/c.zip",
+		host.ArchiveURL("a", "b", "c"))
+	assert.Equal(
+		t,
+		"https://bitbucket.org/a/b/raw/c/d/go.mod",
+		host.RawFileURL("a", "b", "c", "d/go.mod"))
+	assert.Equal(
+		t,
+		"https://bitbucket.org/a/b/src/HEAD{/dir}",
+		host.TreeURLTemplate("a", "b", ""))
+
+	sha, err := host.ExpandPartialSHA("a", "b", "abcdef")
+	assert.Nil(t, err)
+	assert.Equal(t, "abcdef1234", sha)
+
+	timestamp, err := host.FetchCommitTimestamp("a", "b", "abcdef")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2017, 3, 14, 15, 9, 26, 0, time.UTC), timestamp.UTC())
+
+	sha, err = host.FetchCommitSHA("a", "b", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, "older", sha)
+
+	_, err = host.FetchCommitTimestamp("x", "y", "z")
+	assert.NotNil(t, err)
+}
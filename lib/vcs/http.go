@@ -0,0 +1,38 @@
+package vcs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	httpClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// getJSON issues an HTTP GET request against the specified URL, and then reads
+// the JSON response body into output.
+func getJSON(url string, output interface{}) error {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return fmt.Errorf("Could not reach %s: %v.", url, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return fmt.Errorf("Could not find %s.", url)
+	} else if resp.StatusCode != 200 {
+		return fmt.Errorf(
+			"Bumped into a status code %d while fetching %s.",
+			resp.StatusCode,
+			url)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(output); err != nil {
+		return fmt.Errorf("Could not read response from %s: %v.", url, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package vcs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+const (
+	importMetaURLTemplate = "https://%s?go-get=1"
+	importMetaNameImport  = "go-import"
+	importMetaNameSource  = "go-source"
+	importMetaVCSGit      = "git"
+	importMetaGitSuffix   = ".git"
+)
+
+// ImportMeta is what a vanity import path (e.g. "go.uber.org/zap") advertises
+// to "go get" via its go-import and go-source meta tags.
+type ImportMeta struct {
+	// Root is the import path that corresponds to the root of the repository.
+	Root string
+	// VCS is the version control system of the repository (e.g. "git").
+	VCS string
+	// RepoURL is the URL of the repository.
+	RepoURL string
+	// HomeURL is the home page of the repository as told by go-source. It is
+	// empty when there is no go-source meta tag.
+	HomeURL string
+}
+
+// FetchImportMeta fetches the go-import (and go-source) meta tags of an import
+// path the same way that "go get" does.
+func FetchImportMeta(importPath string) (ImportMeta, error) {
+	metaURL := fmt.Sprintf(importMetaURLTemplate, importPath)
+	resp, err := httpClient.Get(metaURL)
+	if err != nil {
+		return ImportMeta{}, fmt.Errorf("Could not reach %s: %v.", metaURL, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return ImportMeta{}, fmt.Errorf(
+			"Bumped into a status code %d while fetching %s.",
+			resp.StatusCode,
+			metaURL)
+	}
+
+	return ReadImportMeta(importPath, resp.Body)
+}
+
+// ReadImportMeta reads the go-import meta tag that matches the import path out
+// of an HTML document. The go-source meta tag with the same root is read too,
+// if there is one.
+func ReadImportMeta(importPath string, r io.Reader) (ImportMeta, error) {
+	var (
+		meta    ImportMeta
+		found   bool
+		sources = make(map[string]string)
+		decoder = xml.NewDecoder(r)
+	)
+
+	// HTML is not XML, so be as lenient as "go get" is.
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			// Meta tags are only allowed in the head, but read until the end anyway.
+			break
+		}
+
+		if element, ok := token.(xml.EndElement); ok &&
+			strings.EqualFold(element.Name.Local, "head") {
+			break
+		}
+
+		element, ok := token.(xml.StartElement)
+		if !ok || !strings.EqualFold(element.Name.Local, "meta") {
+			continue
+		}
+
+		var name, content string
+		for _, attr := range element.Attr {
+			switch strings.ToLower(attr.Name.Local) {
+			case "name":
+				name = attr.Value
+			case "content":
+				content = attr.Value
+			}
+		}
+
+		fields := strings.Fields(content)
+		if len(fields) < 1 || !isImportPathInRoot(importPath, fields[0]) {
+			continue
+		}
+
+		switch name {
+		case importMetaNameImport:
+			if len(fields) != 3 {
+				continue
+			} else if found && meta.Root != fields[0] {
+				return ImportMeta{}, fmt.Errorf(
+					"Found conflicting go-import meta tags for %s.",
+					importPath)
+			}
+
+			found = true
+			meta.Root, meta.VCS, meta.RepoURL = fields[0], fields[1], fields[2]
+		case importMetaNameSource:
+			if len(fields) > 1 {
+				sources[fields[0]] = fields[1]
+			}
+		}
+	}
+
+	if !found {
+		return ImportMeta{}, fmt.Errorf(
+			"Could not find a go-import meta tag for %s.",
+			importPath)
+	}
+
+	meta.HomeURL = sources[meta.Root]
+
+	return meta, nil
+}
+
+// isImportPathInRoot returns true if the import path is the root or a
+// sub-package of the root.
+func isImportPathInRoot(importPath, root string) bool {
+	return importPath == root || strings.HasPrefix(importPath, root+"/")
+}
+
+// ReadRepoURL reads the qualified author (see QualifyAuthor) and the repo of a
+// repository out of its URL (e.g. "https://github.com/a/b.git"). Only
+// repositories that live on well-known hosts can be read.
+func ReadRepoURL(repoURL string) (author string, repo string, ok bool) {
+	parsed, err := url.Parse(repoURL)
+	if err != nil || !IsWellKnownDomain(parsed.Host) {
+		return "", "", false
+	}
+
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) < 2 || len(parts[0]) < 1 || len(parts[1]) < 1 {
+		return "", "", false
+	}
+
+	return QualifyAuthor(parsed.Host, parts[0]),
+		strings.TrimSuffix(parts[1], importMetaGitSuffix),
+		true
+}
+
+// Repo returns the qualified author and the repo of the repository behind the
+// import meta. When the repository does not live on a well-known host (e.g.
+// go.googlesource.com), the go-source home page is used instead, since it
+// often points at a mirror that does.
+func (meta ImportMeta) Repo() (author string, repo string, ok bool) {
+	if meta.VCS != importMetaVCSGit {
+		return "", "", false
+	}
+
+	if author, repo, ok = ReadRepoURL(meta.RepoURL); ok {
+		return author, repo, true
+	}
+
+	return ReadRepoURL(meta.HomeURL)
+}
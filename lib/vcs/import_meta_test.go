@@ -0,0 +1,77 @@
+package vcs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadImportMeta(t *testing.T) {
+	meta, err := ReadImportMeta("go.uber.org/zap/zapcore", strings.NewReader(`
+<!DOCTYPE html>
+<html>
+	<head>
+		<meta name="go-import" content="go.uber.org/zap git https://github.com/uber-go/zap">
+		<meta name="go-source" content="go.uber.org/zap https://github.com/uber-go/zap https://github.com/uber-go/zap/tree/master{/dir} https://github.com/uber-go/zap/tree/master{/dir}/{file}#L{line}">
+		<meta name="go-import" content="go.uber.org/atomic git https://github.com/uber-go/atomic">
+	</head>
+	<body>Nothing to see here.</body>
+</html>`))
+	assert.Nil(t, err)
+	assert.Equal(t, ImportMeta{
+		Root:    "go.uber.org/zap",
+		VCS:     "git",
+		RepoURL: "https://github.com/uber-go/zap",
+		HomeURL: "https://github.com/uber-go/zap",
+	}, meta)
+
+	_, err = ReadImportMeta("go.uber.org/zap", strings.NewReader(`
+<html><head>
+	<meta name="go-import" content="go.uber.org/zapper git https://github.com/uber-go/zapper">
+</head></html>`))
+	assert.NotNil(t, err, "roots only match whole path elements")
+
+	_, err = ReadImportMeta("example.com/a/b", strings.NewReader(`
+<html><head>
+	<meta name="go-import" content="example.com/a git https://github.com/a/a">
+	<meta name="go-import" content="example.com/a/b git https://github.com/a/b">
+</head></html>`))
+	assert.NotNil(t, err, "conflicting roots should be rejected")
+
+	_, err = ReadImportMeta("example.com/a", strings.NewReader(`<html></html>`))
+	assert.NotNil(t, err)
+}
+
+func TestImportMetaRepo(t *testing.T) {
+	author, repo, ok := ImportMeta{
+		VCS:     "git",
+		RepoURL: "https://gitlab.com/a/b.git",
+	}.Repo()
+	assert.True(t, ok)
+	assert.Equal(t, "gitlab.com:a", author)
+	assert.Equal(t, "b", repo)
+
+	// Repositories that aren't on well-known hosts fall back to the go-source
+	// home page.
+	author, repo, ok = ImportMeta{
+		VCS:     "git",
+		RepoURL: "https://go.googlesource.com/net",
+		HomeURL: "https://github.com/golang/net/",
+	}.Repo()
+	assert.True(t, ok)
+	assert.Equal(t, "golang", author)
+	assert.Equal(t, "net", repo)
+
+	_, _, ok = ImportMeta{
+		VCS:     "git",
+		RepoURL: "https://go.googlesource.com/net",
+	}.Repo()
+	assert.False(t, ok)
+
+	_, _, ok = ImportMeta{
+		VCS:     "hg",
+		RepoURL: "https://bitbucket.org/a/b",
+	}.Repo()
+	assert.False(t, ok)
+}
@@ -1,9 +1,12 @@
 package verdeps
 
 const (
-	gophrPrefix                    = "\"gophr.pm/"
+	gophrDomain                    = "gophr.pm"
+	gophrPrefix                    = "\"" + gophrDomain + "/"
 	goFileSuffix                   = ".go"
 	githubPrefix                   = "\"github.com/"
+	gopkgInDomain                  = "gopkg.in"
+	refsTagPrefix                  = "refs/tags/"
 	vendorDirName                  = "vendor"
 	internalDirName                = "internal"
 	vendorSrcDirName               = "src"
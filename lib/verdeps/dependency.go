@@ -0,0 +1,119 @@
+package verdeps
+
+import "sort"
+
+// Dependency is a specific version of a package that the package being
+// versioned depends on.
+type Dependency struct {
+	// SHA is the commit SHA that the dependency was pinned to.
+	SHA string
+	// Repo is the repo of the dependency.
+	Repo string
+	// Author is the qualified author of the dependency.
+	Author string
+	// Strategy is how the SHA was picked (e.g. PinStrategyLockFile).
+	Strategy string
+	// Subpaths are the sub-packages of the dependency that are imported,
+	// relative to its root. The root itself is the empty string.
+	Subpaths []string
+}
+
+// DependencyRecorder is told about every dependency of the package being
+// versioned once all of its imports have been pinned. If it returns an error,
+// the error is logged and versioning carries on.
+type DependencyRecorder func(deps []Dependency) error
+
+// ignoreDependencies is the DependencyRecorder used by default: it does
+// nothing.
+func ignoreDependencies(deps []Dependency) error {
+	return nil
+}
+
+// dependencyList collects the dependencies of the package being versioned as
+// its imports get revised. It is not safe for concurrent use.
+type dependencyList struct {
+	deps     map[string]*Dependency
+	pins     map[string]Pin
+	subpaths map[string]map[string]bool
+}
+
+// newDependencyList creates a new, empty dependencyList.
+func newDependencyList() *dependencyList {
+	return &dependencyList{
+		deps:     make(map[string]*Dependency),
+		pins:     make(map[string]Pin),
+		subpaths: make(map[string]map[string]bool),
+	}
+}
+
+// pinned tells the list how the imports with the specified import path hash
+// were pinned.
+func (list *dependencyList) pinned(importPathHash string, pin Pin) {
+	list.pins[importPathHash] = pin
+}
+
+// add adds the sub-package of a dependency that a pinned import spec imports.
+// Imports of the package being versioned are left out.
+func (list *dependencyList) add(
+	spec *importSpec,
+	author string,
+	repo string,
+	subpath string,
+) {
+	pin, exists := list.pins[spec.importPathHash()]
+	if !exists || pin.Strategy == PinStrategySubPackage {
+		return
+	}
+
+	sha, strategy := pin.SHA, pin.Strategy
+
+	key := author + "/" + repo + "@" + sha
+	if _, exists := list.deps[key]; !exists {
+		list.deps[key] = &Dependency{
+			SHA:      sha,
+			Repo:     repo,
+			Author:   author,
+			Strategy: strategy,
+		}
+		list.subpaths[key] = make(map[string]bool)
+	}
+
+	if !list.subpaths[key][subpath] {
+		list.subpaths[key][subpath] = true
+		list.deps[key].Subpaths = append(list.deps[key].Subpaths, subpath)
+	}
+}
+
+// get returns every dependency in the list, ordered by author, repo and SHA.
+// Subpaths are sorted too.
+func (list *dependencyList) get() []Dependency {
+	deps := make([]Dependency, 0, len(list.deps))
+	for _, dep := range list.deps {
+		sort.Strings(dep.Subpaths)
+		deps = append(deps, *dep)
+	}
+
+	sort.Sort(byDependencyID(deps))
+	return deps
+}
+
+// byDependencyID orders dependencies by author, repo and SHA.
+type byDependencyID []Dependency
+
+func (deps byDependencyID) Len() int {
+	return len(deps)
+}
+
+func (deps byDependencyID) Swap(i, j int) {
+	deps[i], deps[j] = deps[j], deps[i]
+}
+
+func (deps byDependencyID) Less(i, j int) bool {
+	if deps[i].Author != deps[j].Author {
+		return deps[i].Author < deps[j].Author
+	} else if deps[i].Repo != deps[j].Repo {
+		return deps[i].Repo < deps[j].Repo
+	}
+
+	return deps[i].SHA < deps[j].SHA
+}
@@ -2,52 +2,404 @@ package verdeps
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
+	"github.com/gophr-pm/gophr/lib"
 	"github.com/gophr-pm/gophr/lib/github"
+	"github.com/gophr-pm/gophr/lib/semver"
+	"github.com/gophr-pm/gophr/lib/vcs"
 )
 
+// refsDownloader is a function type that de-couples verdeps.fetchSHA from
+// lib.FetchRefs.
+type refsDownloader func(author, repo string) (lib.Refs, error)
+
 type fetchSHAArgs struct {
-	ghSvc              github.RequestService
+	hosts              vcs.Hosts
+	importPathHash     string
+	policy             *dependencyPolicy
 	outputChan         chan *fetchSHAResult
 	importPath         string
 	packageSHA         string
 	packageRepo        string
+	downloadRefs       refsDownloader
 	packageAuthor      string
+	gophrImport        *gophrImport
+	vanityImport       *vanityImport
+	lockedRevision     *lockedRevision
 	packageVersionDate time.Time
 }
 
 func fetchSHA(args fetchSHAArgs) {
 	var (
-		err    error
-		sha    string
-		repo   string
-		author string
+		err          error
+		sha          string
+		host         vcs.Host
+		repo         string
+		label        string
+		author       string
+		subpath      string
+		lockFile     string
+		strategy     string
+		bareAuthor   string
+		majorVersion int
 	)
 
-	// Parse out the author and the repo.
-	author, repo, _ = parseImportPath(args.importPath)
+	// Parse out the author and the repo. Gophr and vanity imports have already
+	// been resolved to theirs, and gophr imports to the go module they belong
+	// to. Module-style imports may select a major version too (e.g.
+	// "github.com/a/b/v3").
+	if args.gophrImport != nil {
+		author, repo = args.gophrImport.author, args.gophrImport.repo
+		subpath = args.gophrImport.moduleDir
+	} else if args.vanityImport != nil {
+		author, repo = args.vanityImport.author, args.vanityImport.repo
+	} else {
+		author, repo, subpath = parseImportPath(args.importPath)
+		majorVersion, _ = readMajorVersionSuffix(subpath)
+	}
 
 	// If the dep is a sub-package. If it is, don't fetch the commit sha.
 	if isSubPackage(author, args.packageAuthor, repo, args.packageRepo) {
 		sha = args.packageSHA
+		strategy = PinStrategySubPackage
 	} else {
-		// Fetch the most appropriate commit sha for this package given the time
-		// constraint.
-		if sha, err = args.ghSvc.FetchCommitSHA(
-			author,
-			repo,
-			args.packageVersionDate,
-		); err != nil {
+		if host, bareAuthor, err = args.hosts.Of(author); err != nil {
 			args.outputChan <- newFetchSHAFailure(err)
 			return
-		} else if len(sha) == 0 {
-			args.outputChan <- newFetchSHAFailure(
-				errors.New("Commit SHA it came back empty"))
-			return
+		}
+
+		// Policies that pin the dependency to a SHA have the last word.
+		if args.policy != nil {
+			switch args.policy.kind {
+			case dependencyPolicyKindSHA:
+				sha, strategy = args.policy.value, PinStrategyPolicy
+			case dependencyPolicyKindShortSHA:
+				if sha, err = host.ExpandPartialSHA(
+					bareAuthor,
+					repo,
+					args.policy.value,
+				); err != nil {
+					args.outputChan <- newFetchSHAPolicyFailure(fmt.Errorf(
+						"Could not expand the SHA that %s pins %s to: %v",
+						args.policy.policyFile,
+						args.policy.importPath,
+						err))
+					return
+				}
+
+				strategy = PinStrategyPolicy
+			}
+		}
+
+		// Prefer the revision that the author locked the dependency to.
+		if len(strategy) == 0 && args.lockedRevision != nil {
+			if sha, err = resolveLockedRevision(
+				host,
+				bareAuthor,
+				author,
+				repo,
+				*args.lockedRevision,
+				args.downloadRefs,
+			); err == nil {
+				strategy = PinStrategyLockFile
+				lockFile = args.lockedRevision.lockFile
+			} else if isRateLimited(err) {
+				args.outputChan <- newFetchSHAFailure(err)
+				return
+			} else {
+				log.Printf(
+					"Could not use the revision of %s/%s locked in %s, so falling "+
+						"back to the commit date: %v\n",
+					author,
+					repo,
+					args.lockedRevision.lockFile,
+					err)
+			}
+		}
+
+		// Otherwise, selector policies hold the dependency to the versions that
+		// they match. Locked revisions have to be among them.
+		if args.policy != nil &&
+			args.policy.kind == dependencyPolicyKindSelector {
+			if strategy == PinStrategyLockFile {
+				if err = checkPolicySelector(
+					*args.policy,
+					author,
+					repo,
+					subpath,
+					sha,
+					args.downloadRefs,
+				); err != nil {
+					args.outputChan <- newFetchSHAPolicyFailure(err)
+					return
+				}
+			} else if len(strategy) == 0 {
+				if sha, err = resolveSemverSelector(
+					host,
+					bareAuthor,
+					author,
+					repo,
+					subpath,
+					args.policy.value,
+					args.downloadRefs,
+					args.packageVersionDate,
+				); err != nil {
+					args.outputChan <- newFetchSHAPolicyFailure(fmt.Errorf(
+						"Could not resolve the policy that %s has for %s: %v",
+						args.policy.policyFile,
+						args.policy.importPath,
+						err))
+					return
+				}
+
+				strategy = PinStrategyPolicy
+			}
+		}
+
+		// Gophr imports are bound to what their selectors resolved to back then.
+		if len(strategy) == 0 &&
+			args.gophrImport != nil &&
+			len(args.gophrImport.selector) > 0 {
+			if sha, err = resolveGophrSelector(
+				host,
+				bareAuthor,
+				*args.gophrImport,
+				args.downloadRefs,
+				args.packageVersionDate,
+			); err != nil {
+				args.outputChan <- newFetchSHAFailure(err)
+				return
+			}
+
+			strategy = PinStrategyGophrSelector
+		}
+
+		// gopkg.in imports are bound to the versions that gopkg.in selects.
+		if len(strategy) == 0 &&
+			args.vanityImport != nil &&
+			args.vanityImport.isGopkgIn() {
+			if sha, err = resolveGopkgInVersion(
+				host,
+				bareAuthor,
+				author,
+				repo,
+				*args.vanityImport,
+				args.downloadRefs,
+				args.packageVersionDate,
+			); err != nil {
+				args.outputChan <- newFetchSHAFailure(err)
+				return
+			}
+
+			strategy = PinStrategyGopkgIn
+		}
+
+		// Imports with a major version suffix have to stay within that major
+		// version, so only its releases will do.
+		if len(strategy) == 0 && majorVersion > 1 {
+			if sha, label, err = resolveTaggedRelease(
+				host,
+				bareAuthor,
+				author,
+				repo,
+				subpath,
+				majorVersion,
+				args.downloadRefs,
+				args.packageVersionDate,
+			); err != nil {
+				args.outputChan <- newFetchSHAFailure(err)
+				return
+			} else if len(sha) < 1 {
+				args.outputChan <- newFetchSHAFailure(fmt.Errorf(
+					"No release of major version %d of %s predates the package",
+					majorVersion,
+					vcs.RepoPath(author, repo)))
+				return
+			}
+
+			strategy = PinStrategyTaggedRelease
+		}
+
+		// Prefer the highest release that had already been tagged back then.
+		if len(strategy) == 0 {
+			if sha, label, err = resolveTaggedRelease(
+				host,
+				bareAuthor,
+				author,
+				repo,
+				subpath,
+				0,
+				args.downloadRefs,
+				args.packageVersionDate,
+			); isRateLimited(err) {
+				args.outputChan <- newFetchSHAFailure(err)
+				return
+			} else if err != nil {
+				log.Printf(
+					"Could not look for a tagged release of %s/%s, so falling back "+
+						"to the commit date: %v\n",
+					author,
+					repo,
+					err)
+			} else if len(sha) > 0 {
+				strategy = PinStrategyTaggedRelease
+			}
+		}
+
+		// Otherwise, fetch the most appropriate commit sha for this package given
+		// the time constraint from whichever host it lives on.
+		if len(strategy) == 0 {
+			if sha, err = host.FetchCommitSHA(
+				bareAuthor,
+				repo,
+				args.packageVersionDate,
+			); err != nil {
+				args.outputChan <- newFetchSHAFailure(err)
+				return
+			} else if len(sha) == 0 {
+				args.outputChan <- newFetchSHAFailure(
+					errors.New("Commit SHA it came back empty"))
+				return
+			}
+
+			strategy = PinStrategyCommitDate
 		}
 	}
 
 	// Put a new mapping struct into the output chan.
-	args.outputChan <- newFetchSHASuccess(args.importPath, sha)
+	args.outputChan <- newFetchSHASuccess(
+		args.importPath,
+		args.importPathHash,
+		sha,
+		strategy,
+		lockFile,
+		label)
+}
+
+// isRateLimited returns true if err means that the API quota of the package
+// host has been spent. Falling back to another strategy is pointless then,
+// and would pin the dependency to something other than what the quota would
+// have allowed for.
+func isRateLimited(err error) bool {
+	_, rateLimited := err.(github.RateLimitExceededError)
+	return rateLimited
+}
+
+// resolveLockedRevision turns a locked revision into a full commit SHA.
+func resolveLockedRevision(
+	host vcs.Host,
+	bareAuthor string,
+	author string,
+	repo string,
+	revision lockedRevision,
+	downloadRefs refsDownloader,
+) (string, error) {
+	switch revision.kind {
+	case lockedRevisionKindSHA:
+		return revision.revision, nil
+	case lockedRevisionKindShortSHA:
+		return host.ExpandPartialSHA(bareAuthor, repo, revision.revision)
+	}
+
+	// Versions are tags, so look them up in the refs of the dependency.
+	refs, err := downloadRefs(author, repo)
+	if err != nil {
+		return "", err
+	}
+
+	tagRefName := refsTagPrefix + revision.revision
+	for _, candidate := range refs.Candidates {
+		if candidate.GitRefName == tagRefName {
+			return candidate.GitRefHash, nil
+		}
+	}
+
+	return "", fmt.Errorf("Could not find tag %s", revision.revision)
+}
+
+// checkPolicySelector makes sure that the SHA that a dependency was pinned to is
+// one of the versions that the selector policy of the dependency matches. Only
+// the versions of the go module that the subpath belongs to are considered.
+func checkPolicySelector(
+	policy dependencyPolicy,
+	author string,
+	repo string,
+	subpath string,
+	sha string,
+	downloadRefs refsDownloader,
+) error {
+	constraint, err := semver.ReadSemverConstraint(policy.value)
+	if err != nil {
+		return err
+	}
+
+	refs, err := downloadRefs(author, repo)
+	if err != nil {
+		return err
+	}
+
+	for _, candidate := range refs.CandidatesOf(subpath).Match(constraint) {
+		if candidate.GitRefHash == sha {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"%s is locked to %s, which violates the policy \"%s\" in %s",
+		vcs.RepoPath(author, repo),
+		sha,
+		policy.value,
+		policy.policyFile)
+}
+
+// resolveGopkgInVersion finds the commit SHA of the version that gopkg.in
+// selected for a gopkg.in import when the package being versioned was
+// committed: the highest tag or branch of the major version (e.g. "v2", "v2.1"
+// or "v2.1.3" for ".v2") that predates the package. Like resolveTaggedRelease,
+// it gives up after maxTaggedReleaseLookups commit lookups.
+func resolveGopkgInVersion(
+	host vcs.Host,
+	bareAuthor string,
+	author string,
+	repo string,
+	vanity vanityImport,
+	downloadRefs refsDownloader,
+	packageVersionDate time.Time,
+) (string, error) {
+	refs, err := downloadRefs(author, repo)
+	if err != nil {
+		return "", err
+	}
+
+	// Candidates are sorted from lowest to highest.
+	lookups := 0
+	for i := len(refs.Candidates) - 1; i >= 0 &&
+		lookups < maxTaggedReleaseLookups; i-- {
+		candidate := refs.Candidates[i]
+		if candidate.MajorVersion != vanity.majorVersion ||
+			(!vanity.allowsPrerelease && len(candidate.Prerelease) > 0) {
+			continue
+		}
+
+		lookups++
+		commitDate, err := host.FetchCommitTimestamp(
+			bareAuthor,
+			repo,
+			candidate.GitRefHash)
+		if err != nil {
+			return "", err
+		} else if commitDate.After(packageVersionDate) {
+			continue
+		}
+
+		return candidate.GitRefHash, nil
+	}
+
+	return "", fmt.Errorf(
+		"Could not find a version of %s that matches %s and predates the package",
+		vcs.RepoPath(author, repo),
+		vanity.root)
 }
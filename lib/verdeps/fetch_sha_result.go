@@ -2,29 +2,82 @@ package verdeps
 
 // fetchSHAResult is the result of a call to fetchSHA. When successful, it is a
 // mapping between an import path and the sha it is paired with. When
-// unsuccessful, it carries its error.
+// unsuccessful, it carries its error. Fatal errors fail the versioning of the
+// package.
 type fetchSHAResult struct {
-	sha        string
-	err        error
-	successful bool
-	importPath string
+	sha            string
+	err            error
+	fatal          bool
+	label          string
+	strategy       string
+	lockFile       string
+	successful     bool
+	importPath     string
+	importPathHash string
 }
 
 // newFetchSHASuccess creates a new fetchSHAResult, but specifies that fetchSHA
-// completed successfully.
-func newFetchSHASuccess(importPath, sha string) *fetchSHAResult {
+// completed successfully using the specified pin strategy. The lock file is
+// only relevant to PinStrategyLockFile, and the label to
+// PinStrategyTaggedRelease.
+func newFetchSHASuccess(
+	importPath string,
+	importPathHash string,
+	sha string,
+	strategy string,
+	lockFile string,
+	label string,
+) *fetchSHAResult {
 	return &fetchSHAResult{
-		sha:        sha,
-		importPath: importPath,
-		successful: true,
+		sha:            sha,
+		label:          label,
+		strategy:       strategy,
+		lockFile:       lockFile,
+		importPath:     importPath,
+		successful:     true,
+		importPathHash: importPathHash,
+	}
+}
+
+// pin returns the Pin that this result describes.
+func (result *fetchSHAResult) pin() Pin {
+	return Pin{
+		SHA:        result.sha,
+		Label:      result.label,
+		Strategy:   result.strategy,
+		LockFile:   result.lockFile,
+		ImportPath: result.importPath,
 	}
 }
 
 // newFetchSHAFailure creates a new fetchSHAResult, but specifies that fetchSHA
-// completed unsuccessfully.
+// completed unsuccessfully. Running out of API quota is fatal, since the
+// dependency could have been versioned with quota to spare.
 func newFetchSHAFailure(err error) *fetchSHAResult {
 	return &fetchSHAResult{
 		err:        err,
+		fatal:      isRateLimited(err),
 		successful: false,
 	}
 }
+
+// newFetchSHAPolicyFailure creates a new fetchSHAResult, but specifies that
+// fetchSHA could not honor the policy of the package. Since the policy cannot be
+// ignored, the failure is fatal.
+func newFetchSHAPolicyFailure(err error) *fetchSHAResult {
+	return &fetchSHAResult{
+		err:        err,
+		fatal:      true,
+		successful: false,
+	}
+}
+
+// version returns what the import should select in place of its SHA: the
+// label if there is one.
+func (result *fetchSHAResult) version() string {
+	if len(result.label) > 0 {
+		return result.label
+	}
+
+	return result.sha
+}
@@ -1,11 +1,15 @@
 package verdeps
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/gophr-pm/gophr/lib"
 	"github.com/gophr-pm/gophr/lib/github"
+	"github.com/gophr-pm/gophr/lib/vcs"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -35,7 +39,7 @@ func TestFetchSHA(t *testing.T) {
 			// despite the fact that fetch sha writes to an output channel, since the
 			// channel is buffered (for test purposes).
 			fetchSHA(fetchSHAArgs{
-				ghSvc:              mockGhSvc,
+				hosts:              vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
 				outputChan:         outputChan,
 				importPath:         expectedOutputImportPath,
 				packageSHA:         packageSHA,
@@ -80,11 +84,12 @@ func TestFetchSHA(t *testing.T) {
 			// despite the fact that fetch sha writes to an output channel, since the
 			// channel is buffered (for test purposes).
 			fetchSHA(fetchSHAArgs{
-				ghSvc:              mockGhSvc,
+				hosts:              vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
 				outputChan:         outputChan,
 				importPath:         importPath,
 				packageSHA:         packageSHA,
 				packageRepo:        packageRepo,
+				downloadRefs:       downloadNoRefs,
 				packageAuthor:      packageAuthor,
 				packageVersionDate: packageVersionDate,
 			})
@@ -116,11 +121,12 @@ func TestFetchSHA(t *testing.T) {
 			// despite the fact that fetch sha writes to an output channel, since the
 			// channel is buffered (for test purposes).
 			fetchSHA(fetchSHAArgs{
-				ghSvc:              mockGhSvc,
+				hosts:              vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
 				outputChan:         outputChan,
 				importPath:         importPath,
 				packageSHA:         packageSHA,
 				packageRepo:        packageRepo,
+				downloadRefs:       downloadNoRefs,
 				packageAuthor:      packageAuthor,
 				packageVersionDate: packageVersionDate,
 			})
@@ -157,11 +163,12 @@ func TestFetchSHA(t *testing.T) {
 			// despite the fact that fetch sha writes to an output channel, since the
 			// channel is buffered (for test purposes).
 			fetchSHA(fetchSHAArgs{
-				ghSvc:              mockGhSvc,
+				hosts:              vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
 				outputChan:         outputChan,
 				importPath:         importPath,
 				packageSHA:         packageSHA,
 				packageRepo:        packageRepo,
+				downloadRefs:       downloadNoRefs,
 				packageAuthor:      packageAuthor,
 				packageVersionDate: packageVersionDate,
 			})
@@ -178,5 +185,632 @@ func TestFetchSHA(t *testing.T) {
 			So(actualOutputSHA, ShouldEqual, expectedOutputSHA)
 			So(actualOutputImportPath, ShouldEqual, expectedOutputImportPath)
 		})
+
+		Convey("When the import is locked to a full SHA, that SHA should be enqueued", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				lockedSHA  = "5555555555555555555555555555555555555555"
+			)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:         vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:    outputChan,
+				importPath:    importPath,
+				packageSHA:    packageSHA,
+				packageRepo:   packageRepo,
+				packageAuthor: packageAuthor,
+				lockedRevision: &lockedRevision{
+					kind:     lockedRevisionKindSHA,
+					lockFile: "Gopkg.lock",
+					revision: lockedSHA,
+				},
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			// The commit date heuristic should not have been used.
+			mockGhSvc.AssertNotCalled(t, "FetchCommitSHA", "x", "y", packageVersionDate)
+
+			So(result.successful, ShouldBeTrue)
+			So(result.sha, ShouldEqual, lockedSHA)
+			So(result.pin(), ShouldResemble, Pin{
+				SHA:        lockedSHA,
+				Strategy:   PinStrategyLockFile,
+				LockFile:   "Gopkg.lock",
+				ImportPath: importPath,
+			})
+		})
+
+		Convey("When the import is locked to a tag, the SHA of the tag should be enqueued", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				refs, _    = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"2222222222222222222222222222222222222222 refs/tags/v1.2.3",
+				)))
+			)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:       vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:  outputChan,
+				importPath:  importPath,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					So(author, ShouldEqual, "x")
+					So(repo, ShouldEqual, "y")
+					return refs, nil
+				},
+				packageAuthor: packageAuthor,
+				lockedRevision: &lockedRevision{
+					kind:     lockedRevisionKindVersion,
+					lockFile: "go.sum",
+					revision: "v1.2.3",
+				},
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeTrue)
+			So(result.sha, ShouldEqual, "2222222222222222222222222222222222222222")
+			So(result.strategy, ShouldEqual, PinStrategyLockFile)
+			So(result.lockFile, ShouldEqual, "go.sum")
+		})
+
+		Convey("When the locked revision cannot be resolved, the commit date should be used", func() {
+			var (
+				mockGhSvc         = github.NewMockRequestService()
+				outputChan        = make(chan *fetchSHAResult, 1)
+				expectedOutputSHA = "thisistheoutputshathisistheoutputsha!!!!"
+			)
+
+			mockGhSvc.On(
+				"FetchCommitSHA",
+				"x",
+				"y",
+				packageVersionDate,
+			).Return(expectedOutputSHA, nil)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:       vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:  outputChan,
+				importPath:  importPath,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return lib.Refs{}, errors.New("this is an error")
+				},
+				packageAuthor: packageAuthor,
+				lockedRevision: &lockedRevision{
+					kind:     lockedRevisionKindVersion,
+					lockFile: "go.sum",
+					revision: "v1.2.3",
+				},
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeTrue)
+			So(result.sha, ShouldEqual, expectedOutputSHA)
+			So(result.strategy, ShouldEqual, PinStrategyCommitDate)
+			So(result.lockFile, ShouldEqual, "")
+		})
+
+		Convey("When the import is a gopkg.in import, the version that gopkg.in selected back then should be enqueued", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				refs, _    = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"2222222222222222222222222222222222222222 refs/heads/v2",
+					"3333333333333333333333333333333333333333 refs/tags/v2.1.0",
+					"4444444444444444444444444444444444444444 refs/tags/v2.2.0-rc.1",
+					"6666666666666666666666666666666666666666 refs/tags/v2.3.0",
+					"5555555555555555555555555555555555555555 refs/tags/v3.0.0",
+				)))
+			)
+
+			mockGhSvc.
+				On("FetchCommitTimestamp", "go-yaml", "yaml", "6666666666666666666666666666666666666666").
+				Return(packageVersionDate.Add(time.Hour), nil)
+			mockGhSvc.
+				On("FetchCommitTimestamp", "go-yaml", "yaml", "3333333333333333333333333333333333333333").
+				Return(packageVersionDate.Add(-time.Hour), nil)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:       vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:  outputChan,
+				importPath:  `"gopkg.in/yaml.v2"`,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					So(author, ShouldEqual, "go-yaml")
+					So(repo, ShouldEqual, "yaml")
+					return refs, nil
+				},
+				packageAuthor: packageAuthor,
+				vanityImport: &vanityImport{
+					root:         "gopkg.in/yaml.v2",
+					repo:         "yaml",
+					author:       "go-yaml",
+					majorVersion: 2,
+				},
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeTrue)
+			So(result.sha, ShouldEqual, "3333333333333333333333333333333333333333")
+			So(result.strategy, ShouldEqual, PinStrategyGopkgIn)
+			So(result.importPath, ShouldEqual, `"gopkg.in/yaml.v2"`)
+			mockGhSvc.AssertNotCalled(t, "FetchCommitTimestamp", "go-yaml", "yaml", "4444444444444444444444444444444444444444")
+		})
+
+		Convey("When no version of a gopkg.in import predates the package, fetching the sha should fail", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				refs, _    = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"3333333333333333333333333333333333333333 refs/tags/v2.1.0",
+				)))
+			)
+
+			mockGhSvc.
+				On("FetchCommitTimestamp", "go-yaml", "yaml", "3333333333333333333333333333333333333333").
+				Return(packageVersionDate.Add(time.Hour), nil)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:       vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:  outputChan,
+				importPath:  `"gopkg.in/yaml.v2"`,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return refs, nil
+				},
+				packageAuthor: packageAuthor,
+				vanityImport: &vanityImport{
+					root:         "gopkg.in/yaml.v2",
+					repo:         "yaml",
+					author:       "go-yaml",
+					majorVersion: 2,
+				},
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeFalse)
+			So(result.err, ShouldNotBeNil)
+		})
+
+		Convey("When the dependency has releases, the highest one that predates the package should be enqueued", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				refs, _    = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"2222222222222222222222222222222222222222 refs/tags/v1.2.0",
+					"3333333333333333333333333333333333333333 refs/tags/v1.3.0",
+					"4444444444444444444444444444444444444444 refs/tags/v1.4.0-rc.1",
+					"5555555555555555555555555555555555555555 refs/tags/v2.0.0",
+				)))
+			)
+
+			mockGhSvc.
+				On("FetchCommitTimestamp", "x", "y", "5555555555555555555555555555555555555555").
+				Return(packageVersionDate.Add(time.Hour), nil)
+			mockGhSvc.
+				On("FetchCommitTimestamp", "x", "y", "3333333333333333333333333333333333333333").
+				Return(packageVersionDate.Add(-time.Hour), nil)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:       vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:  outputChan,
+				importPath:  importPath,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return refs, nil
+				},
+				packageAuthor:      packageAuthor,
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			// The commit date heuristic should not have been used.
+			mockGhSvc.AssertNotCalled(t, "FetchCommitSHA", "x", "y", packageVersionDate)
+
+			So(result.successful, ShouldBeTrue)
+			So(result.version(), ShouldEqual, "1.3.0")
+			So(result.pin(), ShouldResemble, Pin{
+				SHA:        "3333333333333333333333333333333333333333",
+				Label:      "1.3.0",
+				Strategy:   PinStrategyTaggedRelease,
+				ImportPath: importPath,
+			})
+		})
+
+		Convey("When the import belongs to a sub-module, the highest release of the sub-module should be enqueued", func() {
+			var (
+				mockGhSvc     = github.NewMockRequestService()
+				outputChan    = make(chan *fetchSHAResult, 1)
+				subImportPath = `"github.com/x/y/c/d"`
+				refs, _       = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"2222222222222222222222222222222222222222 refs/tags/v1.2.0",
+					"3333333333333333333333333333333333333333 refs/tags/v1.3.0",
+					"6666666666666666666666666666666666666666 refs/tags/c/v0.1.0",
+				)))
+			)
+
+			mockGhSvc.
+				On("FetchCommitTimestamp", "x", "y", "6666666666666666666666666666666666666666").
+				Return(packageVersionDate.Add(-time.Hour), nil)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:       vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:  outputChan,
+				importPath:  subImportPath,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return refs, nil
+				},
+				packageAuthor:      packageAuthor,
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeTrue)
+			So(result.pin(), ShouldResemble, Pin{
+				SHA:        "6666666666666666666666666666666666666666",
+				Label:      "0.1.0",
+				Strategy:   PinStrategyTaggedRelease,
+				ImportPath: subImportPath,
+			})
+		})
+
+		Convey("When the quota runs out while looking for a release, the failure should be fatal", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				quotaErr   = github.NewRateLimitExceededError(time.Now().Add(time.Hour))
+				refs, _    = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"3333333333333333333333333333333333333333 refs/tags/v1.0.0",
+				)))
+			)
+
+			mockGhSvc.
+				On("FetchCommitTimestamp", "x", "y", "3333333333333333333333333333333333333333").
+				Return(time.Time{}, quotaErr)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:       vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:  outputChan,
+				importPath:  importPath,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return refs, nil
+				},
+				packageAuthor:      packageAuthor,
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			// The commit date heuristic should not have been used.
+			mockGhSvc.AssertNotCalled(t, "FetchCommitSHA", "x", "y", packageVersionDate)
+
+			So(result.successful, ShouldBeFalse)
+			So(result.fatal, ShouldBeTrue)
+			So(result.err, ShouldResemble, quotaErr)
+		})
+
+		Convey("When no release predates the package, the commit date should be used", func() {
+			var (
+				mockGhSvc         = github.NewMockRequestService()
+				outputChan        = make(chan *fetchSHAResult, 1)
+				expectedOutputSHA = "thisistheoutputshathisistheoutputsha!!!!"
+				refs, _           = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"2222222222222222222222222222222222222222 refs/heads/v1",
+					"3333333333333333333333333333333333333333 refs/tags/v1.0.0",
+				)))
+			)
+
+			mockGhSvc.
+				On("FetchCommitTimestamp", "x", "y", "3333333333333333333333333333333333333333").
+				Return(packageVersionDate.Add(time.Hour), nil)
+			mockGhSvc.
+				On("FetchCommitSHA", "x", "y", packageVersionDate).
+				Return(expectedOutputSHA, nil)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:       vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:  outputChan,
+				importPath:  importPath,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return refs, nil
+				},
+				packageAuthor:      packageAuthor,
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeTrue)
+			So(result.version(), ShouldEqual, expectedOutputSHA)
+			So(result.strategy, ShouldEqual, PinStrategyCommitDate)
+		})
+
+		Convey("When the import has a major version suffix, the highest release of that major version should be enqueued", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				refs, _    = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"2222222222222222222222222222222222222222 refs/tags/v1.9.0",
+					"3333333333333333333333333333333333333333 refs/tags/v3.1.0",
+					"4444444444444444444444444444444444444444 refs/tags/v3.2.0",
+					"5555555555555555555555555555555555555555 refs/tags/v4.0.0",
+				)))
+			)
+
+			mockGhSvc.
+				On("FetchCommitTimestamp", "x", "y", "4444444444444444444444444444444444444444").
+				Return(packageVersionDate.Add(time.Hour), nil)
+			mockGhSvc.
+				On("FetchCommitTimestamp", "x", "y", "3333333333333333333333333333333333333333").
+				Return(packageVersionDate.Add(-time.Hour), nil)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:       vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:  outputChan,
+				importPath:  `"github.com/x/y/v3/z"`,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return refs, nil
+				},
+				packageAuthor:      packageAuthor,
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeTrue)
+			So(result.sha, ShouldEqual, "3333333333333333333333333333333333333333")
+			So(result.version(), ShouldEqual, "3.1.0")
+			So(result.strategy, ShouldEqual, PinStrategyTaggedRelease)
+		})
+
+		Convey("When the major version of the import has no release that predates the package, no SHA should be enqueued", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				refs, _    = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"2222222222222222222222222222222222222222 refs/tags/v1.9.0",
+				)))
+			)
+
+			fetchSHA(fetchSHAArgs{
+				hosts:       vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				outputChan:  outputChan,
+				importPath:  `"github.com/x/y/v3"`,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return refs, nil
+				},
+				packageAuthor:      packageAuthor,
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeFalse)
+			So(result.fatal, ShouldBeFalse)
+			So(result.err, ShouldNotBeNil)
+		})
+
+		Convey("When the policy pins the import to a SHA, that SHA should be enqueued", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+			)
+
+			fetchSHA(fetchSHAArgs{
+				hosts: vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				policy: &dependencyPolicy{
+					kind:       dependencyPolicyKindSHA,
+					value:      "2222222222222222222222222222222222222222",
+					policyFile: policyYAMLFileName,
+					importPath: "github.com/x/y",
+				},
+				outputChan:    outputChan,
+				importPath:    importPath,
+				packageSHA:    packageSHA,
+				packageRepo:   packageRepo,
+				downloadRefs:  downloadNoRefs,
+				packageAuthor: packageAuthor,
+				lockedRevision: &lockedRevision{
+					kind:     lockedRevisionKindSHA,
+					lockFile: "Gopkg.lock",
+					revision: "1111111111111111111111111111111111111111",
+				},
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeTrue)
+			So(result.sha, ShouldEqual, "2222222222222222222222222222222222222222")
+			So(result.strategy, ShouldEqual, PinStrategyPolicy)
+		})
+
+		Convey("When the policy holds the import to a range, the best version in range that predates the package should be enqueued", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				refs, _    = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"2222222222222222222222222222222222222222 refs/tags/v1.2.0",
+					"3333333333333333333333333333333333333333 refs/tags/v1.3.0",
+					"4444444444444444444444444444444444444444 refs/tags/v2.0.0",
+				)))
+			)
+
+			mockGhSvc.
+				On("FetchCommitTimestamp", "x", "y", "3333333333333333333333333333333333333333").
+				Return(packageVersionDate, nil)
+
+			fetchSHA(fetchSHAArgs{
+				hosts: vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				policy: &dependencyPolicy{
+					kind:       dependencyPolicyKindSelector,
+					value:      "lt2.0",
+					policyFile: policyYAMLFileName,
+					importPath: "github.com/x/y",
+				},
+				outputChan:  outputChan,
+				importPath:  importPath,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return refs, nil
+				},
+				packageAuthor:      packageAuthor,
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeTrue)
+			So(result.sha, ShouldEqual, "3333333333333333333333333333333333333333")
+			So(result.strategy, ShouldEqual, PinStrategyPolicy)
+		})
+
+		Convey("When the locked revision violates the policy, the failure should be fatal", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				refs, _    = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"2222222222222222222222222222222222222222 refs/tags/v1.2.0",
+					"4444444444444444444444444444444444444444 refs/tags/v2.0.0",
+				)))
+			)
+
+			fetchSHA(fetchSHAArgs{
+				hosts: vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				policy: &dependencyPolicy{
+					kind:       dependencyPolicyKindSelector,
+					value:      "lt2.0",
+					policyFile: policyYAMLFileName,
+					importPath: "github.com/x/y",
+				},
+				outputChan:  outputChan,
+				importPath:  importPath,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return refs, nil
+				},
+				packageAuthor: packageAuthor,
+				lockedRevision: &lockedRevision{
+					kind:     lockedRevisionKindVersion,
+					lockFile: "go.sum",
+					revision: "v2.0.0",
+				},
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeFalse)
+			So(result.fatal, ShouldBeTrue)
+			So(result.err, ShouldNotBeNil)
+		})
+
+		Convey("When nothing in range predates the package, the failure should be fatal", func() {
+			var (
+				mockGhSvc  = github.NewMockRequestService()
+				outputChan = make(chan *fetchSHAResult, 1)
+				refs, _    = lib.NewRefs([]byte(testRefsLines(
+					"1111111111111111111111111111111111111111 HEAD",
+					"4444444444444444444444444444444444444444 refs/tags/v2.0.0",
+				)))
+			)
+
+			fetchSHA(fetchSHAArgs{
+				hosts: vcs.NewHosts(github.NewHost(mockGhSvc, nil)),
+				policy: &dependencyPolicy{
+					kind:       dependencyPolicyKindSelector,
+					value:      "lt2.0",
+					policyFile: policyYAMLFileName,
+					importPath: "github.com/x/y",
+				},
+				outputChan:  outputChan,
+				importPath:  importPath,
+				packageSHA:  packageSHA,
+				packageRepo: packageRepo,
+				downloadRefs: func(author, repo string) (lib.Refs, error) {
+					return refs, nil
+				},
+				packageAuthor:      packageAuthor,
+				packageVersionDate: packageVersionDate,
+			})
+
+			result := <-outputChan
+			close(outputChan)
+
+			So(result.successful, ShouldBeFalse)
+			So(result.fatal, ShouldBeTrue)
+			So(result.err, ShouldNotBeNil)
+		})
 	})
 }
+
+// testRefsLines formats refs lines the way that hosts send them.
+func testRefsLines(lines ...string) string {
+	var buffer bytes.Buffer
+	for _, line := range lines {
+		fmt.Fprintf(&buffer, "%04x%s\n", len(line)+5, line)
+	}
+
+	return buffer.String()
+}
+
+// downloadNoRefs is a refsDownloader for dependencies without any versions.
+func downloadNoRefs(author, repo string) (lib.Refs, error) {
+	return lib.Refs{}, nil
+}
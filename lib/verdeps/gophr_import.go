@@ -0,0 +1,207 @@
+package verdeps
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/semver"
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+const (
+	gophrSelectorPrefix   = '@'
+	gophrImportPathPrefix = gophrDomain + "/"
+)
+
+var (
+	// shortSHARegex matches abbreviated commit SHAs.
+	shortSHARegex = regexp.MustCompile(`^[0-9a-f]{6,39}$`)
+	// dateSelectorRegex matches selectors shaped like dates or timestamps.
+	dateSelectorRegex = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}(?:T.*)?$`)
+)
+
+// gophrImport is an import of a package that is served by gophr (e.g.
+// "gophr.pm/a/b@^1.2/c"). Unless it selects a full SHA, the version that it
+// resolves to can change over time.
+type gophrImport struct {
+	// root is the import path of the root of the package, selector included
+	// (e.g. "gophr.pm/a/b@^1.2").
+	root string
+	// repo is the repo of the package.
+	repo string
+	// author is the qualified author of the package.
+	author string
+	// selector is the version selector of the import. It is empty if the
+	// import doesn't have one.
+	selector string
+	// moduleDir is the directory of the go module that the import belongs to,
+	// if the repository has more than one (e.g. "sub/module"). It is empty for
+	// the root module.
+	moduleDir string
+}
+
+// readGophrImport reads the gophr import out of an unquoted import path. It
+// returns nil if the import path is not a gophr import path, or if it selects
+// a version that cannot change (e.g. a full SHA).
+func readGophrImport(importPath string) *gophrImport {
+	if !strings.HasPrefix(importPath, gophrImportPathPrefix) {
+		return nil
+	}
+
+	var (
+		domain string
+		parts  = strings.SplitN(
+			importPath[len(gophrImportPathPrefix):],
+			"/",
+			3)
+	)
+
+	// Packages that aren't on Github are prefixed by their domain.
+	if len(parts) > 0 && strings.IndexByte(parts[0], '.') != -1 {
+		domain = parts[0]
+		parts = strings.SplitN(
+			importPath[len(gophrImportPathPrefix)+len(domain)+1:],
+			"/",
+			3)
+	}
+
+	if len(parts) < 2 || len(parts[0]) < 1 || len(parts[1]) < 1 {
+		return nil
+	}
+
+	// The selector is attached to the repo.
+	repo, selector := parts[1], ""
+	if i := strings.IndexByte(repo, gophrSelectorPrefix); i != -1 {
+		repo, selector = repo[:i], repo[i+1:]
+	}
+
+	if len(repo) < 1 || !isFloatingGophrSelector(selector) {
+		return nil
+	}
+
+	root := gophrImportPathPrefix + parts[0] + "/" + parts[1]
+	if len(domain) > 0 {
+		root = gophrImportPathPrefix + domain + "/" + parts[0] + "/" + parts[1]
+	}
+
+	return &gophrImport{
+		root:     root,
+		repo:     repo,
+		author:   vcs.QualifyAuthor(domain, parts[0]),
+		selector: selector,
+	}
+}
+
+// isFloatingGophrSelector returns true if the selector is missing, a short SHA
+// or a semver selector. Full SHAs already can't change. Dates always select the
+// same commit, and branches have no history to look back on, so they are left
+// as they are too. Selectors are told apart in the same order that the router
+// uses.
+func isFloatingGophrSelector(selector string) bool {
+	if len(selector) == 0 || shortSHARegex.MatchString(selector) {
+		return true
+	} else if fullSHARegex.MatchString(selector) ||
+		dateSelectorRegex.MatchString(selector) {
+		return false
+	}
+
+	return semver.IsSemverSelectorString(selector)
+}
+
+// key returns the root of the gophr import, followed by the directory of its
+// go module if that is not the root module. Imports of the same module share
+// a key, and so get pinned together.
+func (gophr *gophrImport) key() string {
+	if len(gophr.moduleDir) > 0 {
+		return gophr.root + "/" + gophr.moduleDir
+	}
+
+	return gophr.root
+}
+
+// subpath returns what comes after the root of the gophr import in the
+// specified import path.
+func (gophr *gophrImport) subpath(importPath string) string {
+	return strings.TrimPrefix(strings.Trim(importPath, `"`), gophr.root)
+}
+
+// resolveGophrSelector finds the full SHA that the selector of a gophr import
+// resolved to when the package being versioned was committed. Short SHAs are
+// expanded. Semver selectors resolve to the best version of the go module of
+// the import that had already been committed at the time.
+func resolveGophrSelector(
+	host vcs.Host,
+	bareAuthor string,
+	gophr gophrImport,
+	downloadRefs refsDownloader,
+	packageVersionDate time.Time,
+) (string, error) {
+	if shortSHARegex.MatchString(gophr.selector) {
+		return host.ExpandPartialSHA(bareAuthor, gophr.repo, gophr.selector)
+	}
+
+	return resolveSemverSelector(
+		host,
+		bareAuthor,
+		gophr.author,
+		gophr.repo,
+		gophr.moduleDir,
+		gophr.selector,
+		downloadRefs,
+		packageVersionDate)
+}
+
+// resolveSemverSelector finds the full SHA of the best version of a dependency
+// that matches the semver selector, and that had already been committed when
+// the package being versioned was. Only the versions of the go module that the
+// subpath belongs to are considered (see lib.Refs.CandidatesOf). Like
+// resolveTaggedRelease, it gives up after maxTaggedReleaseLookups commit
+// lookups.
+func resolveSemverSelector(
+	host vcs.Host,
+	bareAuthor string,
+	author string,
+	repo string,
+	subpath string,
+	selector string,
+	downloadRefs refsDownloader,
+	packageVersionDate time.Time,
+) (string, error) {
+	constraint, err := semver.ReadSemverConstraint(selector)
+	if err != nil {
+		return "", err
+	}
+
+	refs, err := downloadRefs(author, repo)
+	if err != nil {
+		return "", err
+	}
+
+	// Walk the matches from the best to the worst until one of them predates the
+	// package.
+	matches := refs.CandidatesOf(subpath).Match(constraint)
+	for i := 0; i < len(matches) && i < maxTaggedReleaseLookups; i++ {
+		candidate := matches[i]
+		if constraint.PrefersHighest() {
+			candidate = matches[len(matches)-1-i]
+		}
+
+		commitDate, err := host.FetchCommitTimestamp(
+			bareAuthor,
+			repo,
+			candidate.GitRefHash)
+		if err != nil {
+			return "", err
+		} else if !commitDate.After(packageVersionDate) {
+			return candidate.GitRefHash, nil
+		}
+	}
+
+	return "", fmt.Errorf(
+		"No version of %s matched %s at %s",
+		vcs.RepoPath(author, repo),
+		selector,
+		packageVersionDate.Format(time.RFC3339))
+}
@@ -0,0 +1,219 @@
+package verdeps
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/github"
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReadGophrImport(t *testing.T) {
+	Convey("Given an import path", t, func() {
+		Convey("Gophr imports with floating selectors should be read", func() {
+			So(readGophrImport("gophr.pm/a/b@^1.2/c/d"), ShouldResemble, &gophrImport{
+				root:     "gophr.pm/a/b@^1.2",
+				repo:     "b",
+				author:   "a",
+				selector: "^1.2",
+			})
+			So(readGophrImport("gophr.pm/a/b@abcdef1"), ShouldResemble, &gophrImport{
+				root:     "gophr.pm/a/b@abcdef1",
+				repo:     "b",
+				author:   "a",
+				selector: "abcdef1",
+			})
+			So(readGophrImport("gophr.pm/gitlab.com/a/b@ge1.0.0,lt2.0.0/c"), ShouldResemble, &gophrImport{
+				root:     "gophr.pm/gitlab.com/a/b@ge1.0.0,lt2.0.0",
+				repo:     "b",
+				author:   "gitlab.com:a",
+				selector: "ge1.0.0,lt2.0.0",
+			})
+			So(readGophrImport("gophr.pm/a/b/c"), ShouldResemble, &gophrImport{
+				root:   "gophr.pm/a/b",
+				repo:   "b",
+				author: "a",
+			})
+		})
+
+		Convey("Gophr imports that can't change should not be read", func() {
+			So(readGophrImport("gophr.pm/a/b@0123456789012345678901234567890123456789"), ShouldBeNil)
+			So(readGophrImport("gophr.pm/a/b@2017-03-14"), ShouldBeNil)
+			So(readGophrImport("gophr.pm/a/b@2017-03-14T15:09:26Z"), ShouldBeNil)
+			So(readGophrImport("gophr.pm/a/b@branch:master"), ShouldBeNil)
+			So(readGophrImport("gophr.pm/a"), ShouldBeNil)
+		})
+
+		Convey("Other import paths should not be read", func() {
+			So(readGophrImport("github.com/a/b@^1.2"), ShouldBeNil)
+			So(readGophrImport("fmt"), ShouldBeNil)
+		})
+
+		Convey("The subpath should be everything after the root", func() {
+			gophr := readGophrImport("gophr.pm/a/b@^1.2/c/d")
+			So(gophr.subpath(`"gophr.pm/a/b@^1.2/c/d"`), ShouldEqual, "/c/d")
+			So(gophr.subpath(`"gophr.pm/a/b@^1.2"`), ShouldEqual, "")
+		})
+
+		Convey("Imports of different go modules should not share a key", func() {
+			gophr := readGophrImport("gophr.pm/a/b@^1.2/c/d")
+			So(gophr.key(), ShouldEqual, "gophr.pm/a/b@^1.2")
+
+			gophr.moduleDir = "c"
+			So(gophr.key(), ShouldEqual, "gophr.pm/a/b@^1.2/c")
+		})
+	})
+}
+
+func TestResolveGophrSelector(t *testing.T) {
+	Convey("Given a gophr import with a floating selector", t, func() {
+		var (
+			packageVersionDate = time.Date(2017, time.March, 14, 0, 0, 0, 0, time.UTC)
+			refs, _            = lib.NewRefs([]byte(testRefsLines(
+				"1111111111111111111111111111111111111111 HEAD",
+				"2222222222222222222222222222222222222222 refs/tags/v1.2.0",
+				"3333333333333333333333333333333333333333 refs/tags/v1.3.0",
+				"4444444444444444444444444444444444444444 refs/tags/v1.4.0",
+				"5555555555555555555555555555555555555555 refs/tags/v2.0.0",
+			)))
+			downloadRefs = func(author, repo string) (lib.Refs, error) {
+				So(author, ShouldEqual, "a")
+				So(repo, ShouldEqual, "b")
+				return refs, nil
+			}
+		)
+
+		Convey("Semver selectors should resolve to the best version that predates the package", func() {
+			ghSvc := github.NewMockRequestService()
+			ghSvc.
+				On("FetchCommitTimestamp", "a", "b", "4444444444444444444444444444444444444444").
+				Return(packageVersionDate.Add(time.Hour), nil)
+			ghSvc.
+				On("FetchCommitTimestamp", "a", "b", "3333333333333333333333333333333333333333").
+				Return(packageVersionDate, nil)
+
+			sha, err := resolveGophrSelector(
+				github.NewHost(ghSvc, nil),
+				"a",
+				*readGophrImport("gophr.pm/a/b@1.x"),
+				downloadRefs,
+				packageVersionDate)
+
+			So(err, ShouldBeNil)
+			So(sha, ShouldEqual, "3333333333333333333333333333333333333333")
+		})
+
+		Convey("Semver selectors without versions that predate the package should fail", func() {
+			ghSvc := github.NewMockRequestService()
+			ghSvc.
+				On("FetchCommitTimestamp", "a", "b", "5555555555555555555555555555555555555555").
+				Return(packageVersionDate.Add(time.Hour), nil)
+
+			_, err := resolveGophrSelector(
+				github.NewHost(ghSvc, nil),
+				"a",
+				*readGophrImport("gophr.pm/a/b@2"),
+				downloadRefs,
+				packageVersionDate)
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Semver selectors should give up after so many commit lookups", func() {
+			var (
+				ghSvc     = github.NewMockRequestService()
+				refsLines = []string{"1111111111111111111111111111111111111111 HEAD"}
+			)
+
+			for i := 0; i <= maxTaggedReleaseLookups; i++ {
+				refsLines = append(
+					refsLines,
+					fmt.Sprintf("%040d refs/tags/v1.%d.0", i, i))
+			}
+
+			manyRefs, _ := lib.NewRefs([]byte(testRefsLines(refsLines...)))
+			ghSvc.
+				On("FetchCommitTimestamp", "a", "b", mock.AnythingOfType("string")).
+				Return(packageVersionDate.Add(time.Hour), nil)
+
+			_, err := resolveGophrSelector(
+				github.NewHost(ghSvc, nil),
+				"a",
+				*readGophrImport("gophr.pm/a/b@1.x"),
+				func(author, repo string) (lib.Refs, error) {
+					return manyRefs, nil
+				},
+				packageVersionDate)
+
+			So(err, ShouldNotBeNil)
+			ghSvc.AssertNumberOfCalls(t, "FetchCommitTimestamp", maxTaggedReleaseLookups)
+		})
+
+		Convey("Commit lookup failures should fail", func() {
+			ghSvc := github.NewMockRequestService()
+			ghSvc.
+				On("FetchCommitTimestamp", "a", "b", "5555555555555555555555555555555555555555").
+				Return(time.Time{}, errors.New("this is an error"))
+
+			_, err := resolveGophrSelector(
+				github.NewHost(ghSvc, nil),
+				"a",
+				*readGophrImport("gophr.pm/a/b@2"),
+				downloadRefs,
+				packageVersionDate)
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Semver selectors of sub-modules should resolve against the tags of the sub-module", func() {
+			refs, _ := lib.NewRefs([]byte(testRefsLines(
+				"1111111111111111111111111111111111111111 HEAD",
+				"2222222222222222222222222222222222222222 refs/tags/v1.2.0",
+				"6666666666666666666666666666666666666666 refs/tags/c/v1.1.0",
+				"7777777777777777777777777777777777777777 refs/tags/c/v1.5.0",
+			)))
+			ghSvc := github.NewMockRequestService()
+			ghSvc.
+				On("FetchCommitTimestamp", "a", "b", "7777777777777777777777777777777777777777").
+				Return(packageVersionDate, nil)
+
+			gophr := *readGophrImport("gophr.pm/a/b@1.x/c/d")
+			gophr.moduleDir = refs.ModuleDirOf("/c/d")
+
+			sha, err := resolveGophrSelector(
+				github.NewHost(ghSvc, nil),
+				"a",
+				gophr,
+				func(author, repo string) (lib.Refs, error) { return refs, nil },
+				packageVersionDate)
+
+			So(err, ShouldBeNil)
+			So(sha, ShouldEqual, "7777777777777777777777777777777777777777")
+		})
+
+		Convey("Short SHAs should be expanded", func() {
+			ghSvc := github.NewMockRequestService()
+			ghSvc.
+				On("ExpandPartialSHA", github.ExpandPartialSHAArgs{
+					Author:   "a",
+					Repo:     "b",
+					ShortSHA: "abcdef1",
+				}).
+				Return("abcdef1234567890abcdef1234567890abcdef12", nil)
+
+			sha, err := resolveGophrSelector(
+				github.NewHost(ghSvc, nil),
+				"a",
+				*readGophrImport("gophr.pm/a/b@abcdef1"),
+				downloadRefs,
+				packageVersionDate)
+
+			So(err, ShouldBeNil)
+			So(sha, ShouldEqual, "abcdef1234567890abcdef1234567890abcdef12")
+		})
+	})
+}
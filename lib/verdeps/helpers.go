@@ -2,42 +2,72 @@ package verdeps
 
 import (
 	"bytes"
-	"math/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/vcs"
 )
 
+// majorVersionSuffixRegex matches subpaths that start with a go module major
+// version suffix (e.g. "/v3/c"). The suffix is the first capture group, and
+// the major version is the second.
+var majorVersionSuffixRegex = regexp.MustCompile(`^(/v([2-9]|[1-9][0-9]+))(?:/|$)`)
+
 func isSubPackage(depAuthor, packageAuthor, depRepo, packageRepo string) bool {
 	return depAuthor == packageAuthor && depRepo == packageRepo
 }
 
-// parseImportPath splits a github import path into author, repo and subpath.
+// parseImportPath splits an import path into author, repo and subpath. The
+// author is qualified with the domain of its host (see vcs.QualifyAuthor).
 func parseImportPath(
 	importPath string,
 ) (author string, repo string, subpath string) {
 	var (
 		i                 int
+		domain            string
 		repoStartIndex    int
 		subpathStartIndex int
 
 		importPathLength = len(importPath)
-		authorStartIndex = len(githubPrefix)
+		domainStartIndex = 0
 	)
 
+	// Skip the opening quote if there is one.
+	if importPathLength > 0 && importPath[0] == '"' {
+		domainStartIndex = 1
+	}
+
+	// Advance to the end of the domain.
+	for i = domainStartIndex; i < importPathLength && importPath[i] != '/'; i++ {
+	}
+
+	// Exit if there is nothing after the domain.
+	if i == importPathLength {
+		return "", "", ""
+	}
+
+	domain = importPath[domainStartIndex:i]
+	authorStartIndex := i + 1
+
 	// Advance to the next slash.
 	for i = authorStartIndex; i < importPathLength && importPath[i] != '/'; i++ {
 	}
 
 	// Exit if we reached the end of the import path.
 	if i == importPathLength {
-		return importPath[authorStartIndex : importPathLength-1], "", ""
+		return vcs.QualifyAuthor(
+			domain,
+			importPath[authorStartIndex:importPathLength-1]), "", ""
 	}
 
-	author = importPath[authorStartIndex:i]
+	author = vcs.QualifyAuthor(domain, importPath[authorStartIndex:i])
 	repoStartIndex = i + 1
 
 	// Advance past the current slash to the next one (or the end of the string).
@@ -60,21 +90,47 @@ func parseImportPath(
 	return author, repo, subpath
 }
 
-// composeNewImportPath assembles a new import path given package metadata.
+// readMajorVersionSuffix reads the go module major version suffix (e.g. "/v3")
+// at the start of a subpath. Only major versions greater than one have
+// suffixes, so if the subpath doesn't start with one, the major version is 0
+// and the suffix is empty.
+func readMajorVersionSuffix(subpath string) (majorVersion int, suffix string) {
+	match := majorVersionSuffixRegex.FindStringSubmatch(subpath)
+	if match == nil {
+		return 0, ""
+	}
+
+	majorVersion, err := strconv.Atoi(match[2])
+	if err != nil {
+		return 0, ""
+	}
+
+	return majorVersion, match[1]
+}
+
+// isVersionableImportPath returns true if the unquoted import path belongs to a
+// host that dependencies can be versioned against.
+func isVersionableImportPath(importPath string) bool {
+	i := strings.IndexByte(importPath, '/')
+	return i != -1 && vcs.IsWellKnownDomain(importPath[:i])
+}
+
+// composeNewImportPath assembles a new import path given package metadata. The
+// version is either a commit SHA or a version label.
 func composeNewImportPath(
 	author string,
 	repo string,
-	sha string,
+	version string,
 	subpath string,
 	generatedInternalDirName string,
 ) []byte {
 	var buffer bytes.Buffer
 	buffer.WriteString(gophrPrefix)
-	buffer.WriteString(author)
+	buffer.WriteString(vcs.AuthorPath(author))
 	buffer.WriteByte('/')
 	buffer.WriteString(repo)
 	buffer.WriteByte('@')
-	buffer.WriteString(sha)
+	buffer.WriteString(version)
 
 	if len(subpath) > 0 {
 		// Check for "internal". If it is in the sub-path, replace it. Otherwise,
@@ -99,14 +155,27 @@ func composeNewImportPath(
 	return buffer.Bytes()
 }
 
+// importPathHashOf returns the key that every import path of the same
+// repository shares. The roots of vanity and gophr imports are their own keys.
 func importPathHashOf(importPath string) string {
-	author, repo, _ := parseImportPath(importPath)
+	unquoted := strings.Trim(importPath, `"`)
+	if gophr := readGophrImport(unquoted); gophr != nil {
+		return gophr.root
+	} else if isVanityImportPath(unquoted) {
+		return unquoted
+	}
+
+	author, repo, subpath := parseImportPath(importPath)
 
 	buffer := bytes.Buffer{}
 	buffer.WriteString(author)
 	buffer.WriteByte('/')
 	buffer.WriteString(repo)
 
+	// Every major version of a module is pinned on its own.
+	_, majorVersionSuffix := readMajorVersionSuffix(subpath)
+	buffer.WriteString(majorVersionSuffix)
+
 	return buffer.String()
 }
 
@@ -119,7 +188,8 @@ type getPackageDirPathsArgs struct {
 
 // getPackageDirPaths gets the vendor directory path (if one exists), all the
 // sub-directories names, and the go-file paths of the supplied package
-// directory path.
+// directory path. Names and paths are sorted so that traversal is the same
+// every time.
 func getPackageDirPaths(
 	args getPackageDirPathsArgs,
 ) (vendorDirPath string, subDirNames []string, goFilePaths []string) {
@@ -147,6 +217,9 @@ func getPackageDirPaths(
 		}
 	}
 
+	sort.Strings(subDirNames)
+	sort.Strings(goFilePaths)
+
 	return vendorDirPath, subDirNames, goFilePaths
 }
 
@@ -164,17 +237,10 @@ func subDirExists(dirPath, subDirName string) (bool, error) {
 	return stat.IsDir(), err
 }
 
-// generatedInternalDirNameRunes is the set of eligible characters for a
-// generateInternalDirName.
-var generatedInternalDirNameRunes = []rune("abcdef0123456789")
-
 // generateInternalDirName generates a 16 character directory name for Internal
-// package files.
-func generateInternalDirName() string {
-	rand.Seed(time.Now().UnixNano())
-	b := make([]rune, generatedInternalDirNameLength)
-	for i := range b {
-		b[i] = generatedInternalDirNameRunes[rand.Intn(len(generatedInternalDirNameRunes))]
-	}
-	return string(b)
+// package files. The name is derived from the SHA of the package being
+// versioned so that every archive of the same package version is identical.
+func generateInternalDirName(packageSHA string) string {
+	sum := sha256.Sum256([]byte(packageSHA))
+	return hex.EncodeToString(sum[:])[:generatedInternalDirNameLength]
 }
@@ -3,6 +3,8 @@ package verdeps
 import (
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"testing"
 
 	"github.com/gophr-pm/gophr/lib/io"
@@ -11,7 +13,7 @@ import (
 )
 
 var validInternalDirnameRegex = regexp.MustCompile(`\b[0-9a-f]{16}\b`)
-var generatedInternalDirName = generateInternalDirName()
+var generatedInternalDirName = generateInternalDirName("mysha")
 
 const author = "raymondChandler"
 const repo = "theLongGoodBye"
@@ -69,14 +71,67 @@ func TestParseImportPath_validSubpath(t *testing.T) {
 	assert.Equal(t, expectedSubpath, actualSubpath)
 }
 
+func TestParseImportPath_otherHost(t *testing.T) {
+	t.Parallel()
+	expectedAuthor, expectedRepo, expectedSubpath := "gitlab.com:"+author, repo, subpath
+	actualAuthor, actualRepo, actualSubpath := parseImportPath(`"gitlab.com/` + author + "/" + repo + subpath + `"`)
+	assert.Equal(t, expectedAuthor, actualAuthor)
+	assert.Equal(t, expectedRepo, actualRepo)
+	assert.Equal(t, expectedSubpath, actualSubpath)
+}
+
+func TestIsVersionableImportPath(t *testing.T) {
+	t.Parallel()
+	assert.True(t, isVersionableImportPath("github.com/a/b"))
+	assert.True(t, isVersionableImportPath("gitlab.com/a/b"))
+	assert.True(t, isVersionableImportPath("bitbucket.org/a/b/c"))
+	assert.False(t, isVersionableImportPath("golang.org/x/net"))
+	assert.False(t, isVersionableImportPath("fmt"))
+}
+
+func TestImportPathHashOf(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "a/b", importPathHashOf(`"github.com/a/b/c"`))
+	assert.Equal(t, "gitlab.com:a/b", importPathHashOf("gitlab.com/a/b"))
+	assert.Equal(t, "gopkg.in/yaml.v2", importPathHashOf(`"gopkg.in/yaml.v2"`))
+	assert.Equal(t, "gophr.pm/a/b@^1.2", importPathHashOf(`"gophr.pm/a/b@^1.2/c"`))
+	assert.Equal(t, "a/b/v3", importPathHashOf(`"github.com/a/b/v3/c"`))
+	assert.Equal(t, "a/b/v3", importPathHashOf("github.com/a/b/v3"))
+	assert.Equal(t, "a/b", importPathHashOf("github.com/a/b/v1/c"))
+}
+
+func TestReadMajorVersionSuffix(t *testing.T) {
+	t.Parallel()
+
+	majorVersion, suffix := readMajorVersionSuffix("/v3/c")
+	assert.Equal(t, 3, majorVersion)
+	assert.Equal(t, "/v3", suffix)
+
+	majorVersion, suffix = readMajorVersionSuffix("/v12")
+	assert.Equal(t, 12, majorVersion)
+	assert.Equal(t, "/v12", suffix)
+
+	for _, subpath := range []string{"", "/c", "/v1", "/v0/c", "/v02", "/v3c", "/c/v3"} {
+		majorVersion, suffix = readMajorVersionSuffix(subpath)
+		assert.Equal(t, 0, majorVersion, subpath)
+		assert.Equal(t, "", suffix, subpath)
+	}
+}
+
 func TestGenerateInternalDirName_hasProperLengthAndAcceptedCharacters(t *testing.T) {
 	t.Parallel()
 	for i := 0; i < 10; i++ {
-		internalDirName := generateInternalDirName()
+		internalDirName := generateInternalDirName(strconv.Itoa(i))
 		assert.True(t, validInternalDirnameRegex.MatchString(internalDirName))
 	}
 }
 
+func TestGenerateInternalDirName_isDerivedFromSHA(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, generateInternalDirName("mysha"), generateInternalDirName("mysha"))
+	assert.NotEqual(t, generateInternalDirName("mysha"), generateInternalDirName("othersha"))
+}
+
 func TestComposeNewImportPath_noSubpath(t *testing.T) {
 	t.Parallel()
 	expectedComposedPath := gophrPrefix + author + "/" + repo + "@" + sixCharSha + "\""
@@ -84,6 +139,13 @@ func TestComposeNewImportPath_noSubpath(t *testing.T) {
 	assert.Equal(t, expectedComposedPath, actualComposedPath)
 }
 
+func TestComposeNewImportPath_otherHost(t *testing.T) {
+	t.Parallel()
+	expectedComposedPath := gophrPrefix + "gitlab.com/" + author + "/" + repo + "@" + sixCharSha + "\""
+	actualComposedPath := string(composeNewImportPath("gitlab.com:"+author, repo, sixCharSha, "", generatedInternalDirName)[:])
+	assert.Equal(t, expectedComposedPath, actualComposedPath)
+}
+
 func TestComposeNewImportPath_withInternalSubpath(t *testing.T) {
 	t.Parallel()
 	expectedComposedPath := gophrPrefix + author + "/" + repo + "@" + sixCharSha + "/" + generatedInternalDirName + "/" + "\""
@@ -105,6 +167,13 @@ func TestComposeNewImportPath_withRegularSubpath(t *testing.T) {
 	assert.Equal(t, expectedComposedPath, actualComposedPath)
 }
 
+func TestComposeNewImportPath_withMajorVersionSuffix(t *testing.T) {
+	t.Parallel()
+	expectedComposedPath := gophrPrefix + author + "/" + repo + "@3.1.0/v3" + subpath + "\""
+	actualComposedPath := string(composeNewImportPath(author, repo, "3.1.0", "/v3"+subpath, generatedInternalDirName)[:])
+	assert.Equal(t, expectedComposedPath, actualComposedPath)
+}
+
 func TestGetPackageDirPaths_onlyVendorDir(t *testing.T) {
 	t.Parallel()
 	var fakeFiles []os.FileInfo
@@ -173,6 +242,9 @@ func TestGetPackageDirPaths_vendorDirAndGoFiles(t *testing.T) {
 			packageDirPath: packageDirPath,
 		})
 
+	// Go files are listed in order.
+	sort.Strings(expectedGoFileNames)
+
 	assert.Equal(t, packageDirPath+"/vendor/src", vendorDirPath)
 	assert.Equal(t, expectedSubDirs, subDirNames)
 	assert.Equal(t, expectedGoFileNames, goFilePaths)
@@ -208,6 +280,10 @@ func TestGetPackageDirPaths_vendorDirAndGoFilesAndSubdirs(t *testing.T) {
 			packageDirPath: packageDirPath,
 		})
 
+	// Sub-directories and go files are listed in order.
+	sort.Strings(expectedSubDirNames)
+	sort.Strings(expectedGoFileNames)
+
 	assert.Equal(t, packageDirPath+"/vendor/src", vendorDirPath)
 	assert.Equal(t, expectedSubDirNames, subDirNames)
 	assert.Equal(t, expectedGoFileNames, goFilePaths)
@@ -216,8 +292,8 @@ func TestGetPackageDirPaths_vendorDirAndGoFilesAndSubdirs(t *testing.T) {
 func makeRandomMockFiles(numberOfFiles int, extension string, isDir bool) []os.FileInfo {
 	var mockFileInfos []os.FileInfo
 	for x := 0; x < numberOfFiles; x++ {
-		// conveniently, generateInternalDirName is a random string generator
-		mockFileInfos = append(mockFileInfos, io.FakeFileInfo{NameProp: generateInternalDirName() + extension, IsDirProp: isDir})
+		// conveniently, generateInternalDirName is a hex string generator
+		mockFileInfos = append(mockFileInfos, io.FakeFileInfo{NameProp: generateInternalDirName(strconv.Itoa(x)) + extension, IsDirProp: isDir})
 	}
 	return mockFileInfos
 }
@@ -2,9 +2,41 @@ package verdeps
 
 import (
 	"go/ast"
+	"strconv"
 )
 
 type importSpec struct {
 	imports  *ast.ImportSpec
 	filePath string
+	// gophrImport is set if the import is a gophr import that has to be pinned.
+	gophrImport *gophrImport
+	// vanityImport is set once a vanity import has been resolved.
+	vanityImport *vanityImport
+}
+
+// rootImportPath returns the quoted import path of the root of the repository
+// that the import spec belongs to, or of its go module for gophr imports. Only
+// gophr and vanity imports have roots that can't be derived from the import
+// path itself, so every other import path is returned as-is.
+func (spec *importSpec) rootImportPath() string {
+	if spec.gophrImport != nil {
+		return strconv.Quote(spec.gophrImport.key())
+	} else if spec.vanityImport != nil {
+		return strconv.Quote(spec.vanityImport.root)
+	}
+
+	return spec.imports.Path.Value
+}
+
+// importPathHash returns the key that the import spec shares with the other
+// import specs of the same repository (see importPathHashOf). Gophr imports
+// share it with the imports of the same go module instead.
+func (spec *importSpec) importPathHash() string {
+	if spec.gophrImport != nil {
+		return spec.gophrImport.key()
+	} else if spec.vanityImport != nil {
+		return spec.vanityImport.root
+	}
+
+	return importPathHashOf(spec.imports.Path.Value)
 }
@@ -57,13 +57,19 @@ func parseGoFile(args parseGoFileArgs) {
 		// Ignore the surrounding quotes.
 		importString := strings.Trim(spec.Path.Value, "\"")
 
-		// Only pursue a dependency if it has a github prefix and is not vendored.
+		// Only pursue a dependency if it belongs to a well-known host (or might be
+		// a vanity import of one), or is a gophr import that isn't pinned yet, and
+		// is not vendored.
+		gophr := readGophrImport(importString)
 		if !args.vendorContext.contains(importString) &&
-			strings.HasPrefix(importString, "github.com/") {
+			(gophr != nil ||
+				isVersionableImportPath(importString) ||
+				isVanityImportPath(importString)) {
 			// Both conditions were met, so add this import spec to the list.
 			specs = append(specs, &importSpec{
-				imports:  spec,
-				filePath: args.filePath,
+				imports:     spec,
+				filePath:    args.filePath,
+				gophrImport: gophr,
 			})
 		}
 	}
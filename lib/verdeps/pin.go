@@ -0,0 +1,76 @@
+package verdeps
+
+import "log"
+
+const (
+	// PinStrategySubPackage is the strategy used to pin imports of the package
+	// being versioned to the SHA of the package itself.
+	PinStrategySubPackage = "sub-package"
+	// PinStrategyLockFile is the strategy used to pin imports to the revisions
+	// recorded in the lock files of the package being versioned.
+	PinStrategyLockFile = "lock-file"
+	// PinStrategyCommitDate is the strategy used to pin imports to the commits
+	// that were the latest when the package being versioned was committed.
+	PinStrategyCommitDate = "commit-date"
+	// PinStrategyGopkgIn is the strategy used to pin gopkg.in imports to the
+	// versions that gopkg.in selected for them when the package was committed.
+	PinStrategyGopkgIn = "gopkg.in"
+	// PinStrategyTaggedRelease is the strategy used to pin imports to the
+	// highest tagged releases that were committed before the package being
+	// versioned was.
+	PinStrategyTaggedRelease = "tagged-release"
+	// PinStrategyGophrSelector is the strategy used to pin gophr imports to the
+	// versions that their selectors resolved to when the package being versioned
+	// was committed.
+	PinStrategyGophrSelector = "gophr-selector"
+	// PinStrategyPolicy is the strategy used to pin imports to what the policy
+	// file of the package being versioned says about them.
+	PinStrategyPolicy = "policy"
+)
+
+// Pin describes how an import of the package being versioned was pinned to a
+// specific commit.
+type Pin struct {
+	// SHA is the commit SHA that the import was pinned to.
+	SHA string
+	// Label is the version label that the import selects instead of the SHA
+	// (e.g. "1.4.2"). It is only set for PinStrategyTaggedRelease, and only if
+	// the label selects the SHA and nothing else.
+	Label string
+	// Strategy is how the SHA was picked (e.g. PinStrategyLockFile).
+	Strategy string
+	// LockFile is the path of the lock file that the SHA came from, relative to
+	// the package. It is only set for PinStrategyLockFile.
+	LockFile string
+	// ImportPath is the quoted import path that was pinned.
+	ImportPath string
+}
+
+// PinRecorder is told about every import of the package being versioned that
+// gets pinned.
+type PinRecorder func(pin Pin)
+
+// logPin is the PinRecorder used by default: it just logs the pin.
+func logPin(pin Pin) {
+	if len(pin.Label) > 0 {
+		log.Printf(
+			"Pinned %s to %s (%s) using %s.\n",
+			pin.ImportPath,
+			pin.Label,
+			pin.SHA,
+			pin.Strategy)
+	} else if len(pin.LockFile) > 0 {
+		log.Printf(
+			"Pinned %s to %s using %s (%s).\n",
+			pin.ImportPath,
+			pin.SHA,
+			pin.Strategy,
+			pin.LockFile)
+	} else {
+		log.Printf(
+			"Pinned %s to %s using %s.\n",
+			pin.ImportPath,
+			pin.SHA,
+			pin.Strategy)
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/gophr-pm/gophr/lib/github"
+	"github.com/gophr-pm/gophr/lib"
 	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/vcs"
 )
 
 // shaFetcher is a function type that de-couples verdeps.processDeps from
@@ -42,36 +44,212 @@ type syncedWaitingListMapCreator func() syncedWaitingListMap
 // processDepsArgs is the arguments struct for processDeps.
 type processDepsArgs struct {
 	io                      io.IO
-	ghSvc                   github.RequestService
+	hosts                   vcs.Hosts
 	fetchSHA                shaFetcher
+	recordPin               PinRecorder
 	reviseDeps              depsReviser
 	packageSHA              string
 	packagePath             string
 	packageRepo             string
+	downloadRefs            refsDownloader
 	packageAuthor           string
+	readLockFiles           lockFilesReader
+	readPolicyFile          policyFileReader
 	readPackageDir          packageDirReader
 	packageVersionDate      time.Time
+	recordDependencies      DependencyRecorder
+	resolveVanityImport     vanityImportResolver
 	newSpecWaitingList      specWaitingListCreator
 	newSyncedStringMap      syncedStringMapCreator
 	newSyncedWaitingListMap syncedWaitingListMapCreator
 }
 
-// TODO(skeswa): add a descriptive comment.
+// processDeps pins every versionable import in a package to a specific commit
+// SHA, and revises the source files of the package accordingly. Imports that
+// are locked by a lock file of the package are pinned to the locked revision.
+// Everything else is pinned to the commit that was the latest when the package
+// was committed. Vanity imports (e.g. "gopkg.in/yaml.v2") are resolved to the
+// repositories that they stand for first. Gophr imports with selectors that
+// can change (e.g. "gophr.pm/a/b@^1.2") are pinned to what their selectors
+// resolved to when the package was committed. Module-style imports with major
+// version suffixes (e.g. "github.com/a/b/v3") are pinned to releases of that
+// major version, and keep their suffixes. The policy file of the package
+// overrides all of the above, and any import that cannot honor it fails the
+// package. Once every import has been pinned, the resulting dependencies of the
+// package are recorded.
 func processDeps(args processDepsArgs) error {
+	policies, err := args.readPolicyFile(args.io, args.packagePath)
+	if err != nil {
+		return err
+	}
+
 	var (
+		revisionsLockedByFiles   = args.readLockFiles(args.io, args.packagePath)
 		revisionChan             = make(chan *revision)
+		waitingVanitySpecs       = make(map[string][]*importSpec)
+		vanityResolutions        = make(map[string]*vanityImportResolution)
 		waitingSpecs             = args.newSyncedWaitingListMap()
 		fetchSHAResults          = args.newSyncedStringMap()
 		importSpecChan           = make(chan *importSpec)
 		packageSpecChan          = make(chan *packageSpec)
 		fetchSHAResultChan       = make(chan *fetchSHAResult)
+		vanityResolutionChan     = make(chan *vanityImportResolution)
 		accumulatedErrors        = newSyncedErrors()
+		dependencies             = newDependencyList()
 		revisionWaitGroup        = &sync.WaitGroup{}
 		syncedImportCounts       = newSyncedImportCounts()
 		processedImportsCount    = newSyncedInt()
-		generatedInternalDirName = generateInternalDirName()
+		generatedInternalDirName = generateInternalDirName(args.packageSHA)
 	)
 
+	// gophrModuleDirOf finds the directory of the go module that a gophr import
+	// belongs to. Imports of the root of a repository belong to its root
+	// module, so its refs are only downloaded for imports of sub-packages, and
+	// only once per repository, and never from hosts that aren't supported. If
+	// they can't be, the import is assumed to belong to the root module.
+	gophrRefs := make(map[string]lib.Refs)
+	gophrModuleDirOf := func(spec *importSpec) string {
+		gophr := spec.gophrImport
+		subpath := gophr.subpath(spec.imports.Path.Value)
+		if len(subpath) == 0 || !args.hosts.Supports(gophr.author) {
+			return ""
+		}
+
+		repoPath := vcs.RepoPath(gophr.author, gophr.repo)
+		refs, exists := gophrRefs[repoPath]
+		if !exists {
+			var err error
+			if refs, err = args.downloadRefs(gophr.author, gophr.repo); err != nil {
+				log.Printf(
+					"Could not tell which module of %s %s belongs to: %v\n",
+					repoPath,
+					spec.imports.Path.Value,
+					err)
+				return ""
+			}
+
+			gophrRefs[repoPath] = refs
+		}
+
+		return refs.ModuleDirOf(subpath)
+	}
+
+	// processImportSpec pins an import spec to the SHA of its repository. The SHA
+	// is fetched if no other import spec of the same repository has asked for
+	// it yet.
+	processImportSpec := func(spec *importSpec) {
+		// Gophr imports are pinned per go module, so find out which one they
+		// belong to first.
+		if spec.gophrImport != nil {
+			spec.gophrImport.moduleDir = gophrModuleDirOf(spec)
+		}
+
+		// For each incoming spec, make it wait keyed on the import path hash.
+		importPath := spec.imports.Path.Value
+		importPathHash := spec.importPathHash()
+
+		// Imports that the policy file says not to rewrite are left as they are.
+		policy, hasPolicy := policies[importPathHash]
+		if hasPolicy && policy.kind == dependencyPolicyKindNoRewrite {
+			processedImportsCount.increment()
+			return
+		}
+
+		if version, exists := fetchSHAResults.get(importPathHash); !exists {
+			// If we don't presently have the sha, then we have to go out and get
+			// it.
+			if specs, exists := waitingSpecs.get(importPathHash); !exists {
+				// Create a new waiting list for this import path since it does not
+				// yet exist.
+				waitingSpecs.setIfAbsent(
+					importPathHash,
+					args.newSpecWaitingList(spec))
+
+				// Use the locked revision of the import if there is one.
+				var locked *lockedRevision
+				if revision, exists := revisionsLockedByFiles.of(
+					importPathHash,
+					spec.vanityImport); exists {
+					locked = &revision
+				}
+
+				// Honor the policy of the import if there is one.
+				var honored *dependencyPolicy
+				if hasPolicy {
+					honored = &policy
+				}
+
+				// Start the request itself.
+				go args.fetchSHA(fetchSHAArgs{
+					hosts:              args.hosts,
+					importPathHash:     importPathHash,
+					policy:             honored,
+					outputChan:         fetchSHAResultChan,
+					importPath:         spec.rootImportPath(),
+					packageSHA:         args.packageSHA,
+					packageRepo:        args.packageRepo,
+					downloadRefs:       args.downloadRefs,
+					packageAuthor:      args.packageAuthor,
+					gophrImport:        spec.gophrImport,
+					vanityImport:       spec.vanityImport,
+					lockedRevision:     locked,
+					packageVersionDate: args.packageVersionDate,
+				})
+			} else {
+				if ok := specs.add(spec); !ok {
+					// If the add failed, assume that it is because the the sha was
+					// obtained after we last checked.
+					if version, exists = fetchSHAResults.get(importPathHash); !exists {
+						accumulatedErrors.add(fmt.Errorf(
+							"Could not version dependency %s"+
+								" because the SHA did not yet exist.",
+							importPath))
+					} else {
+						enqueueImportRevision(
+							revisionChan,
+							importPath,
+							version,
+							dependencies,
+							generatedInternalDirName,
+							spec)
+					}
+				}
+
+				// Count this import as processed.
+				processedImportsCount.increment()
+			}
+		} else {
+			// If we got here, it means that the sha has already been obtained, so
+			// the new import path exists.
+			enqueueImportRevision(
+				revisionChan,
+				importPath,
+				version,
+				dependencies,
+				generatedInternalDirName,
+				spec)
+
+			// Count this import as processed.
+			processedImportsCount.increment()
+		}
+	}
+
+	// processVanityImportSpec processes an import spec once its vanity import has
+	// been resolved. Import specs that could not be resolved are left as they
+	// are.
+	processVanityImportSpec := func(
+		spec *importSpec,
+		resolution *vanityImportResolution,
+	) {
+		if resolution.err != nil {
+			processedImportsCount.increment()
+			return
+		}
+
+		spec.vanityImport = resolution.vanityImport
+		processImportSpec(spec)
+	}
+
 	// Read the package looking for import and package metadata.
 	go args.readPackageDir(readPackageDirArgs{
 		io:                       args.io,
@@ -105,8 +283,12 @@ func processDeps(args processDepsArgs) error {
 			// Only continue if an actual importPath-sha mapping came through.
 			if result.successful {
 				// Create an entry in the map.
-				importPathHash := importPathHashOf(result.importPath)
-				fetchSHAResults.set(importPathHash, result.sha)
+				importPathHash := result.importPathHash
+				fetchSHAResults.set(importPathHash, result.version())
+
+				// Record how the import was pinned.
+				args.recordPin(result.pin())
+				dependencies.pinned(importPathHash, result.pin())
 
 				// Clear away the waiting specs.
 				if waitingList, exists := waitingSpecs.get(importPathHash); exists {
@@ -116,12 +298,15 @@ func processDeps(args processDepsArgs) error {
 							enqueueImportRevision(
 								revisionChan,
 								spec.imports.Path.Value,
-								result.sha,
+								result.version(),
+								dependencies,
 								generatedInternalDirName,
 								spec)
 						}
 					}
 				}
+			} else if result.fatal {
+				accumulatedErrors.add(result.err)
 			} else {
 				// If not successful, log the error (don't return it since it isn't
 				// fatal).
@@ -148,63 +333,41 @@ func processDeps(args processDepsArgs) error {
 				break
 			}
 
-			// For each incoming spec, make it wait keyed on the import path hash.
-			importPath := spec.imports.Path.Value
-			importPathHash := importPathHashOf(spec.imports.Path.Value)
-			if sha, exists := fetchSHAResults.get(importPathHash); !exists {
-				// If we don't presently have the sha, then we have to go out and get
-				// it.
-				if specs, exists := waitingSpecs.get(importPathHash); !exists {
-					// Create a new waiting list for this import path since it does not
-					// yet exist.
-					waitingSpecs.setIfAbsent(
-						importPathHash,
-						args.newSpecWaitingList(spec))
-
-					// Start the request itself.
-					go args.fetchSHA(fetchSHAArgs{
-						ghSvc:              args.ghSvc,
-						outputChan:         fetchSHAResultChan,
-						importPath:         importPath,
-						packageSHA:         args.packageSHA,
-						packageRepo:        args.packageRepo,
-						packageAuthor:      args.packageAuthor,
-						packageVersionDate: args.packageVersionDate,
-					})
-				} else {
-					if ok := specs.add(spec); !ok {
-						// If the add failed, assume that it is because the the sha was
-						// obtained after we last checked.
-						if sha, exists = fetchSHAResults.get(importPathHash); !exists {
-							accumulatedErrors.add(fmt.Errorf(
-								"Could not version dependency %s"+
-									" because the SHA did not yet exist.",
-								importPath))
-						} else {
-							enqueueImportRevision(
-								revisionChan,
-								importPath,
-								sha,
-								generatedInternalDirName,
-								spec)
-						}
-					}
-
-					// Count this import as processed.
-					processedImportsCount.increment()
+			// Vanity imports have to be resolved before they can be versioned.
+			importPath := strings.Trim(spec.imports.Path.Value, `"`)
+			if resolution, exists := vanityResolutions[importPath]; exists {
+				processVanityImportSpec(spec, resolution)
+				break
+			} else if isVanityImportPath(importPath) {
+				specs, exists := waitingVanitySpecs[importPath]
+				waitingVanitySpecs[importPath] = append(specs, spec)
+				if !exists {
+					go resolveVanityImportSpecs(
+						importPath,
+						args.resolveVanityImport,
+						vanityResolutionChan)
 				}
-			} else {
-				// If we got here, it means that the sha has already been obtained, so
-				// the new import path exists.
-				enqueueImportRevision(
-					revisionChan,
-					importPath,
-					sha,
-					generatedInternalDirName,
-					spec)
 
-				// Count this import as processed.
-				processedImportsCount.increment()
+				break
+			}
+
+			processImportSpec(spec)
+
+		case resolution := <-vanityResolutionChan:
+			// Remember the resolution for the import specs yet to come.
+			vanityResolutions[resolution.importPath] = resolution
+			specs := waitingVanitySpecs[resolution.importPath]
+			delete(waitingVanitySpecs, resolution.importPath)
+
+			if resolution.err != nil {
+				log.Printf(
+					`Failed to resolve vanity import path "%s": %v`,
+					resolution.importPath,
+					resolution.err)
+			}
+
+			for _, spec := range specs {
+				processVanityImportSpec(spec, resolution)
 			}
 		}
 
@@ -252,30 +415,79 @@ func processDeps(args processDepsArgs) error {
 		return concatErrors(accumulatedErrors)
 	}
 
-	// Otherwise, return without a hitch.
+	// Otherwise, record what the package depends on. The record is only an
+	// index of what was versioned, so failing to write it doesn't fail
+	// versioning.
+	if err := args.recordDependencies(dependencies.get()); err != nil {
+		log.Printf(
+			"Could not record the dependencies of %s/%s@%s: %v\n",
+			args.packageAuthor,
+			args.packageRepo,
+			args.packageSHA,
+			err)
+	}
+
 	return nil
 }
 
 // enqueueImportRevision is a helper function that puts a revision into the
-// revision channel that revises an import statement.
+// revision channel that revises an import statement. The import is added to the
+// dependencies of the package too.
 func enqueueImportRevision(
 	revisionChan chan *revision,
 	importPath string,
-	sha string,
+	version string,
+	dependencies *dependencyList,
 	generatedInternalDirName string,
 	spec *importSpec,
 ) {
-	author, repo, subpath := parseImportPath(importPath)
+	var author, repo, subpath string
+	if spec.gophrImport != nil {
+		author = spec.gophrImport.author
+		repo = spec.gophrImport.repo
+		subpath = spec.gophrImport.subpath(importPath)
+	} else if spec.vanityImport != nil {
+		author = spec.vanityImport.author
+		repo = spec.vanityImport.repo
+		subpath = spec.vanityImport.subpath(importPath)
+	} else {
+		author, repo, subpath = parseImportPath(importPath)
+	}
+
+	dependencies.add(spec, author, repo, subpath)
+
 	newImportPath := composeNewImportPath(
 		author,
 		repo,
-		sha,
+		version,
 		subpath,
 		generatedInternalDirName)
 
 	revisionChan <- newImportRevision(spec, newImportPath)
 }
 
+// vanityImportResolution is the outcome of resolving a vanity import path.
+type vanityImportResolution struct {
+	err          error
+	importPath   string
+	vanityImport *vanityImport
+}
+
+// resolveVanityImportSpecs resolves a vanity import path, and puts the outcome
+// into the resolution channel.
+func resolveVanityImportSpecs(
+	importPath string,
+	resolveVanityImport vanityImportResolver,
+	resolutionChan chan *vanityImportResolution,
+) {
+	vanity, err := resolveVanityImport(importPath)
+	resolutionChan <- &vanityImportResolution{
+		err:          err,
+		importPath:   importPath,
+		vanityImport: vanity,
+	}
+}
+
 // enqueuePackageRevision is a helper function that puts a revision into the
 // revision channel that (potentially) revises a package statement.
 func enqueuePackageRevision(revisionChan chan *revision, spec *packageSpec) {
@@ -283,11 +495,18 @@ func enqueuePackageRevision(revisionChan chan *revision, spec *packageSpec) {
 }
 
 // concatErrors joins all the accumulated individual errors into one combined
-// error.
+// error. If the API quota ran out, that error is returned as it is instead, so
+// that callers can tell when to try again.
 func concatErrors(accumulatedErrors *syncedErrors) error {
 	errs := accumulatedErrors.get()
 	buffer := bytes.Buffer{}
 
+	for _, err := range errs {
+		if isRateLimited(err) {
+			return err
+		}
+	}
+
 	buffer.WriteString("Failed to process dependencies. Bumped into ")
 	buffer.WriteString(strconv.Itoa(len(errs)))
 	buffer.WriteString(" problems: [ ")
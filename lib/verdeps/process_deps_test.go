@@ -13,6 +13,7 @@ import (
 
 	"github.com/gophr-pm/gophr/lib/github"
 	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/vcs"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -59,7 +60,11 @@ func TestProcessDeps(t *testing.T) {
 
 				args.outputChan <- newFetchSHASuccess(
 					args.importPath,
-					"thisistheshafor"+args.importPath)
+					args.importPathHash,
+					"thisistheshafor"+args.importPath,
+					PinStrategyCommitDate,
+					"",
+					"")
 			}
 			reviseDeps = func(args reviseDepsArgs) {
 				// Stow the received args for later assertions.
@@ -113,7 +118,7 @@ func TestProcessDeps(t *testing.T) {
 			// Execute synchronously to make life easier.
 			err := processDeps(processDepsArgs{
 				io:                      io,
-				ghSvc:                   ghSvc,
+				hosts:                   vcs.NewHosts(github.NewHost(ghSvc, nil)),
 				fetchSHA:                fetchSHA,
 				reviseDeps:              reviseDeps,
 				packageSHA:              packageSHA,
@@ -125,6 +130,10 @@ func TestProcessDeps(t *testing.T) {
 				newSpecWaitingList:      newSpecWaitingList,
 				newSyncedStringMap:      newSyncedStringMap,
 				newSyncedWaitingListMap: newSyncedWaitingListMap,
+				readLockFiles:           readNoLockFiles,
+				readPolicyFile:          readNoPolicyFile,
+				recordPin:               func(pin Pin) {},
+				recordDependencies:      ignoreDependencies,
 			})
 
 			// Assert up a storm starting with fetchSHA.
@@ -135,7 +144,7 @@ func TestProcessDeps(t *testing.T) {
 			So(shaRequests[`"github.com/a/b"`], ShouldNotBeNil)
 			So(shaRequests[`"github.com/h/i/j/k"`], ShouldNotBeNil)
 			for _, args := range shaRequests {
-				So(args.ghSvc, ShouldNotBeNil)
+				So(args.hosts, ShouldNotBeNil)
 				So(args.outputChan, ShouldNotBeNil)
 				So(args.importPath, ShouldStartWith, `"github.com/`)
 				So(args.packageSHA, ShouldEqual, packageSHA)
@@ -184,6 +193,7 @@ func TestProcessDeps(t *testing.T) {
 				allFetchSHAArgsLock      sync.RWMutex
 				actualReviseDepsArgs     reviseDepsArgs
 				actualReadPackageDirArgs readPackageDirArgs
+				recordedDependencies     []Dependency
 
 				io                 = io.NewMockIO()
 				ghSvc              = github.NewMockRequestService()
@@ -220,7 +230,11 @@ func TestProcessDeps(t *testing.T) {
 
 				args.outputChan <- newFetchSHASuccess(
 					args.importPath,
-					"thisistheshafor"+args.importPath)
+					args.importPathHash,
+					"thisistheshafor"+args.importPath,
+					PinStrategyCommitDate,
+					"",
+					"")
 			}
 			reviseDeps = func(args reviseDepsArgs) {
 				// Stow the received args for later assertions.
@@ -272,7 +286,7 @@ func TestProcessDeps(t *testing.T) {
 			// Execute synchronously to make life easier.
 			err := processDeps(processDepsArgs{
 				io:                      io,
-				ghSvc:                   ghSvc,
+				hosts:                   vcs.NewHosts(github.NewHost(ghSvc, nil)),
 				fetchSHA:                fetchSHA,
 				reviseDeps:              reviseDeps,
 				packageSHA:              packageSHA,
@@ -284,12 +298,20 @@ func TestProcessDeps(t *testing.T) {
 				newSpecWaitingList:      newSpecWaitingList,
 				newSyncedStringMap:      newSyncedStringMap,
 				newSyncedWaitingListMap: newSyncedWaitingListMap,
+				readLockFiles:           readNoLockFiles,
+				readPolicyFile:          readNoPolicyFile,
+				recordPin:               func(pin Pin) {},
+				recordDependencies: func(deps []Dependency) error {
+					recordedDependencies = deps
+					// Failing to record the dependencies should not fail versioning.
+					return errors.New("this is an error")
+				},
 			})
 
 			// Assert up a storm starting with fetchSHA.
 			So(len(allFetchSHAArgs), ShouldEqual, 2)
 			for _, args := range allFetchSHAArgs {
-				So(args.ghSvc, ShouldNotBeNil)
+				So(args.hosts, ShouldNotBeNil)
 				So(args.outputChan, ShouldNotBeNil)
 				So(args.importPath, ShouldStartWith, `"github.com/`)
 				So(args.packageSHA, ShouldEqual, packageSHA)
@@ -325,6 +347,24 @@ func TestProcessDeps(t *testing.T) {
 			So(actualReadPackageDirArgs.traversePackageDir, ShouldNotBeNil)
 
 			// Finally, perform asserts for general outputs.
+			// Every sub-package of a dependency should be recorded once.
+			So(recordedDependencies, ShouldResemble, []Dependency{
+				{
+					SHA:      `thisistheshafor"github.com/a/b"`,
+					Repo:     "b",
+					Author:   "a",
+					Strategy: PinStrategyCommitDate,
+					Subpaths: []string{"", "/c", "/d/e", "/x/y/z"},
+				},
+				{
+					SHA:      `thisistheshafor"github.com/h/i/j/k"`,
+					Repo:     "i",
+					Author:   "h",
+					Strategy: PinStrategyCommitDate,
+					Subpaths: []string{"/j/k"},
+				},
+			})
+
 			So(err, ShouldBeNil)
 		})
 
@@ -397,7 +437,7 @@ func TestProcessDeps(t *testing.T) {
 			// Execute synchronously to make life easier.
 			err := processDeps(processDepsArgs{
 				io:                      nil,
-				ghSvc:                   nil,
+				hosts:                   nil,
 				fetchSHA:                nil,
 				reviseDeps:              reviseDeps,
 				packageSHA:              "",
@@ -409,6 +449,10 @@ func TestProcessDeps(t *testing.T) {
 				newSpecWaitingList:      specWaitingList.creator(),
 				newSyncedStringMap:      fetchSHAResults.creator(),
 				newSyncedWaitingListMap: waitingSpecs.creator(),
+				readLockFiles:           readNoLockFiles,
+				readPolicyFile:          readNoPolicyFile,
+				recordPin:               func(pin Pin) {},
+				recordDependencies:      ignoreDependencies,
 			})
 
 			// The error should bubble up.
@@ -490,7 +534,7 @@ func TestProcessDeps(t *testing.T) {
 			// Execute synchronously to make life easier.
 			err := processDeps(processDepsArgs{
 				io:                      nil,
-				ghSvc:                   nil,
+				hosts:                   nil,
 				fetchSHA:                nil,
 				reviseDeps:              reviseDeps,
 				packageSHA:              "",
@@ -502,6 +546,10 @@ func TestProcessDeps(t *testing.T) {
 				newSpecWaitingList:      specWaitingList.creator(),
 				newSyncedStringMap:      fetchSHAResults.creator(),
 				newSyncedWaitingListMap: waitingSpecs.creator(),
+				readLockFiles:           readNoLockFiles,
+				readPolicyFile:          readNoPolicyFile,
+				recordPin:               func(pin Pin) {},
+				recordDependencies:      ignoreDependencies,
 			})
 
 			// There is no error since the SHA ends up paired with the import.
@@ -559,7 +607,7 @@ func TestProcessDeps(t *testing.T) {
 			// Execute synchronously to make life easier.
 			err := processDeps(processDepsArgs{
 				io:                      nil,
-				ghSvc:                   nil,
+				hosts:                   nil,
 				fetchSHA:                fetchSHA,
 				reviseDeps:              reviseDeps,
 				packageSHA:              "",
@@ -571,11 +619,237 @@ func TestProcessDeps(t *testing.T) {
 				newSpecWaitingList:      newSpecWaitingList,
 				newSyncedStringMap:      newSyncedStringMap,
 				newSyncedWaitingListMap: newSyncedWaitingListMap,
+				readLockFiles:           readNoLockFiles,
+				readPolicyFile:          readNoPolicyFile,
+				recordPin:               func(pin Pin) {},
+				recordDependencies:      ignoreDependencies,
 			})
 
 			// The error should bubble up.
 			So(err, ShouldBeNil)
 		})
+
+		Convey("Policy files should be honored, and policy failures should be raised", func() {
+			var (
+				fetchSHA           shaFetcher
+				reviseDeps         depsReviser
+				readPackageDir     packageDirReader
+				readPolicyFile     policyFileReader
+				revisedImports     int
+				fetchedPolicies    []*dependencyPolicy
+				fetchedImportPaths []string
+				packageVersionDate = time.Date(
+					2016,
+					time.April,
+					8,
+					14,
+					12,
+					0,
+					0,
+					time.Local)
+			)
+
+			// Create fakes of the worker functions passed into processDeps.
+			fetchSHA = func(args fetchSHAArgs) {
+				introduceRandomLag(0.5, 30)
+				fetchedImportPaths = append(fetchedImportPaths, args.importPath)
+				fetchedPolicies = append(fetchedPolicies, args.policy)
+				args.outputChan <- newFetchSHAPolicyFailure(errors.New("this is an error"))
+			}
+			reviseDeps = func(args reviseDepsArgs) {
+				// Nothing should be revised.
+				for range args.inputChan {
+					revisedImports++
+				}
+
+				args.revisionWaitGroup.Done()
+			}
+			readPackageDir = func(args readPackageDirArgs) {
+				args.importCounts.setImportCount("filepath1", 2)
+				introduceRandomLag(0.4, 15)
+				args.importSpecChan <- generateTestImportSpecWithPos(
+					101,
+					"filepath1",
+					`"github.com/a/b"`)
+				introduceRandomLag(0.4, 15)
+				args.importSpecChan <- generateTestImportSpecWithPos(
+					102,
+					"filepath1",
+					`"github.com/c/d/e"`)
+
+				// Close both channels once we're done.
+				close(args.importSpecChan)
+				close(args.packageSpecChan)
+			}
+			readPolicyFile = func(io io.IO, packagePath string) (dependencyPolicies, error) {
+				return dependencyPolicies{
+					"a/b": {kind: dependencyPolicyKindSelector, value: "lt2.0"},
+					"c/d": {kind: dependencyPolicyKindNoRewrite, value: "norewrite"},
+				}, nil
+			}
+
+			// Execute synchronously to make life easier.
+			err := processDeps(processDepsArgs{
+				io:                      nil,
+				hosts:                   nil,
+				fetchSHA:                fetchSHA,
+				reviseDeps:              reviseDeps,
+				packageSHA:              "",
+				packagePath:             "",
+				packageRepo:             "",
+				packageAuthor:           "",
+				readPackageDir:          readPackageDir,
+				packageVersionDate:      packageVersionDate,
+				newSpecWaitingList:      newSpecWaitingList,
+				newSyncedStringMap:      newSyncedStringMap,
+				newSyncedWaitingListMap: newSyncedWaitingListMap,
+				readLockFiles:           readNoLockFiles,
+				readPolicyFile:          readPolicyFile,
+				recordPin:               func(pin Pin) {},
+				recordDependencies:      ignoreDependencies,
+			})
+
+			// Only the import with a range should have been fetched, and its
+			// failure should bubble up.
+			So(err, ShouldNotBeNil)
+			So(revisedImports, ShouldEqual, 0)
+			So(fetchedImportPaths, ShouldResemble, []string{`"github.com/a/b"`})
+			So(fetchedPolicies, ShouldHaveLength, 1)
+			So(fetchedPolicies[0].value, ShouldEqual, "lt2.0")
+		})
+
+		Convey("An error should be raised if the policy file cannot be read", func() {
+			err := processDeps(processDepsArgs{
+				readPolicyFile: func(io io.IO, packagePath string) (dependencyPolicies, error) {
+					return nil, errors.New("this is an error")
+				},
+			})
+
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Vanity imports should be resolved before they are versioned", func() {
+			var (
+				fetchSHA         shaFetcher
+				reviseDeps       depsReviser
+				readPackageDir   packageDirReader
+				shaRequests      = make(map[string]fetchSHAArgs)
+				shaRequestsLock  sync.Mutex
+				resolutions      = make(map[string]int)
+				resolutionsLock  sync.Mutex
+				importRevStrings = make(map[string]bool)
+			)
+
+			// Create fakes of the worker functions passed into processDeps.
+			fetchSHA = func(args fetchSHAArgs) {
+				shaRequestsLock.Lock()
+				shaRequests[args.importPath] = args
+				shaRequestsLock.Unlock()
+
+				introduceRandomLag(0.5, 30)
+				args.outputChan <- newFetchSHASuccess(
+					args.importPath,
+					args.importPathHash,
+					"sha",
+					PinStrategyCommitDate,
+					"",
+					"")
+			}
+			reviseDeps = func(args reviseDepsArgs) {
+				for input := range args.inputChan {
+					if input.revisesImport {
+						importRevStrings[stringifyRevision(input)] = true
+					}
+				}
+
+				args.revisionWaitGroup.Done()
+			}
+			readPackageDir = func(args readPackageDirArgs) {
+				args.importCounts.setImportCount("filepath1", 3)
+				args.importCounts.setImportCount("filepath2", 1)
+				args.importSpecChan <- generateTestImportSpecWithPos(
+					101,
+					"filepath1",
+					`"go.uber.org/zap"`)
+				introduceRandomLag(0.4, 15)
+				args.importSpecChan <- generateTestImportSpecWithPos(
+					102,
+					"filepath1",
+					`"go.uber.org/zap/zapcore"`)
+				introduceRandomLag(0.4, 15)
+				args.importSpecChan <- generateTestImportSpecWithPos(
+					103,
+					"filepath1",
+					`"example.com/nope"`)
+				introduceRandomLag(0.4, 15)
+				args.importSpecChan <- generateTestImportSpecWithPos(
+					104,
+					"filepath2",
+					`"go.uber.org/zap"`)
+				introduceRandomLag(0.4, 15)
+				args.packageSpecChan <- generateTestPackageSpec("filepath1", 1)
+				args.packageSpecChan <- generateTestPackageSpec("filepath2", 2)
+
+				// Close both channels once we're done.
+				close(args.importSpecChan)
+				close(args.packageSpecChan)
+			}
+
+			err := processDeps(processDepsArgs{
+				io:                      nil,
+				hosts:                   nil,
+				fetchSHA:                fetchSHA,
+				reviseDeps:              reviseDeps,
+				packageSHA:              "",
+				packagePath:             "",
+				packageRepo:             "",
+				packageAuthor:           "",
+				readPackageDir:          readPackageDir,
+				packageVersionDate:      time.Now(),
+				newSpecWaitingList:      newSpecWaitingList,
+				newSyncedStringMap:      newSyncedStringMap,
+				newSyncedWaitingListMap: newSyncedWaitingListMap,
+				readLockFiles:           readNoLockFiles,
+				readPolicyFile:          readNoPolicyFile,
+				recordPin:               func(pin Pin) {},
+				recordDependencies:      ignoreDependencies,
+				resolveVanityImport: func(importPath string) (*vanityImport, error) {
+					resolutionsLock.Lock()
+					resolutions[importPath]++
+					resolutionsLock.Unlock()
+
+					if importPath == "example.com/nope" {
+						return nil, errors.New("this is an error")
+					}
+
+					return &vanityImport{
+						root:   "go.uber.org/zap",
+						repo:   "zap",
+						author: "uber-go",
+					}, nil
+				},
+			})
+
+			So(err, ShouldBeNil)
+
+			// Every vanity import path should only be resolved once.
+			So(resolutions, ShouldResemble, map[string]int{
+				"go.uber.org/zap":         1,
+				"go.uber.org/zap/zapcore": 1,
+				"example.com/nope":        1,
+			})
+
+			// Vanity imports of the same root should share one request.
+			So(len(shaRequests), ShouldEqual, 1)
+			So(shaRequests[`"go.uber.org/zap"`].vanityImport, ShouldNotBeNil)
+			So(shaRequests[`"go.uber.org/zap"`].vanityImport.author, ShouldEqual, "uber-go")
+
+			So(importRevStrings, ShouldResemble, map[string]bool{
+				`filepath1:101:118:"gophr.pm/uber-go/zap@sha"`:         true,
+				`filepath1:102:127:"gophr.pm/uber-go/zap@sha/zapcore"`: true,
+				`filepath2:104:121:"gophr.pm/uber-go/zap@sha"`:         true,
+			})
+		})
 	})
 }
 
@@ -591,6 +865,16 @@ func stringifyRevision(rev *revision) string {
 		string(rev.gophrURL[:]))
 }
 
+// readNoLockFiles is a lockFilesReader for packages without lock files.
+func readNoLockFiles(io io.IO, packagePath string) lockedRevisions {
+	return nil
+}
+
+// readNoPolicyFile is a policyFileReader for packages without policy files.
+func readNoPolicyFile(io io.IO, packagePath string) (dependencyPolicies, error) {
+	return nil, nil
+}
+
 // introduceRandomLag conditionally pauses briefly. The goal here is to throw
 // some fuzz into every test to catch race conditions.
 func introduceRandomLag(chanceOfLag float32, maxMS int) {
@@ -0,0 +1,432 @@
+package verdeps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/semver"
+)
+
+const (
+	// lockedRevisionKindSHA is the kind of locked revision that is a full
+	// commit SHA.
+	lockedRevisionKindSHA = iota
+	// lockedRevisionKindShortSHA is the kind of locked revision that is an
+	// abbreviated commit SHA (e.g. the one at the end of a pseudo-version).
+	lockedRevisionKindShortSHA = iota
+	// lockedRevisionKindVersion is the kind of locked revision that is a
+	// tagged version (e.g. "v1.2.3").
+	lockedRevisionKindVersion = iota
+)
+
+const (
+	goSumFileName           = "go.sum"
+	gopkgLockFileName       = "Gopkg.lock"
+	glideLockFileName       = "glide.lock"
+	goSumModFileSuffix      = "/go.mod"
+	goSumIncompatibleSuffix = "+incompatible"
+	glideLockNamePrefix     = "- name:"
+	glideLockVersionPrefix  = "version:"
+	gopkgLockNameKey        = "name"
+	gopkgLockRevisionKey    = "revision"
+)
+
+var (
+	govendorFileName = filepath.Join("vendor", "vendor.json")
+	godepsFileName   = filepath.Join("Godeps", "Godeps.json")
+
+	fullSHARegex       = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	goSumVersionRegex  = regexp.MustCompile(`^v([0-9]+)\.([0-9]+)\.([0-9]+)(?:-([0-9A-Za-z\-\.]+))?$`)
+	pseudoVersionRegex = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+-(?:.*\.)?[0-9]{14}-([0-9a-f]{12})$`)
+)
+
+// lockedRevision is the revision of a dependency that was recorded in a lock
+// file of the package being versioned.
+type lockedRevision struct {
+	kind     int
+	lockFile string
+	revision string
+}
+
+// lockedRevisions maps the import path hash of every locked dependency (see
+// importPathHashOf) to its locked revision. Vanity dependencies are mapped by
+// their roots instead (see lockedVanityRootOf).
+type lockedRevisions map[string]lockedRevision
+
+// lockFileEntry is one dependency read from a lock file.
+type lockFileEntry struct {
+	kind       int
+	revision   string
+	importPath string
+}
+
+// lockFileParser reads the dependencies out of a lock file.
+type lockFileParser func(data []byte) ([]lockFileEntry, error)
+
+// lockFile describes a lock file that verdeps knows how to read.
+type lockFile struct {
+	path  string
+	parse lockFileParser
+}
+
+// lockFilesReader is a function type that de-couples verdeps.processDeps from
+// verdeps.readLockFiles.
+type lockFilesReader func(io io.IO, packagePath string) lockedRevisions
+
+// lockFiles is every lock file that verdeps reads, from the most to least
+// authoritative. The lock files of newer tools come first since packages that
+// migrate between tools tend to leave the old lock files behind.
+var lockFiles = []lockFile{
+	{path: goSumFileName, parse: parseGoSum},
+	{path: gopkgLockFileName, parse: parseGopkgLock},
+	{path: glideLockFileName, parse: parseGlideLock},
+	{path: govendorFileName, parse: parseGovendorJSON},
+	{path: godepsFileName, parse: parseGodepsJSON},
+}
+
+// readLockFiles reads the revisions that the lock files in the root of the
+// package lock its dependencies to. Lock files that are missing or unreadable
+// are skipped, since the commit date of the package can always be used to pin
+// dependencies instead.
+func readLockFiles(io io.IO, packagePath string) lockedRevisions {
+	revisions := make(lockedRevisions)
+
+	for _, file := range lockFiles {
+		data, err := io.ReadFile(filepath.Join(packagePath, file.path))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Printf("Could not read lock file %s: %v\n", file.path, err)
+			}
+
+			continue
+		}
+
+		entries, err := file.parse(data)
+		if err != nil {
+			log.Printf("Could not parse lock file %s: %v\n", file.path, err)
+			continue
+		}
+
+		for _, entry := range entries {
+			var importPathHash string
+			if isVanityImportPath(entry.importPath) {
+				importPathHash = lockedVanityRootOf(entry.importPath)
+			} else if isVersionableImportPath(entry.importPath) {
+				// Only repositories can be locked, so skip anything shorter.
+				if _, repo, _ := parseImportPath(entry.importPath); len(repo) < 1 {
+					continue
+				}
+
+				importPathHash = importPathHashOf(entry.importPath)
+			} else {
+				continue
+			}
+
+			// More authoritative lock files take precedence.
+			if _, exists := revisions[importPathHash]; !exists {
+				revisions[importPathHash] = lockedRevision{
+					kind:     entry.kind,
+					lockFile: file.path,
+					revision: entry.revision,
+				}
+			}
+		}
+	}
+
+	return revisions
+}
+
+// lockedVanityRootOf returns what a vanity import path listed in a lock file
+// is keyed by. Vanity imports are keyed by the roots that they resolve to, which
+// gopkg.in paths spell out. Lock files otherwise list the roots themselves,
+// except for vendor.json and Godeps.json, which may list sub-packages instead
+// (see lockedRevisions.of).
+func lockedVanityRootOf(importPath string) string {
+	if matches := gopkgInRegex.FindStringSubmatch(importPath); matches != nil {
+		return matches[gopkgInRegexIndexRoot]
+	}
+
+	return importPath
+}
+
+// of returns the locked revision of the import with the specified import path
+// hash. Vanity imports are also locked by the lock file entries of their
+// sub-packages, since their roots are unknown until they are resolved.
+func (revisions lockedRevisions) of(
+	importPathHash string,
+	vanity *vanityImport,
+) (lockedRevision, bool) {
+	if revision, exists := revisions[importPathHash]; exists || vanity == nil {
+		return revision, exists
+	}
+
+	var (
+		found      bool
+		foundPath  string
+		foundEntry lockedRevision
+	)
+
+	for importPath, revision := range revisions {
+		subpath := vanity.subpath(importPath)
+		if subpath == importPath || !strings.HasPrefix(subpath, "/") {
+			continue
+		}
+
+		// Other major versions of the root are locked on their own.
+		if _, suffix := readMajorVersionSuffix(subpath); len(suffix) > 0 {
+			continue
+		}
+
+		// Sub-packages of the same root should agree, but pick one that doesn't
+		// depend on the order of the map if they don't.
+		if !found || importPath < foundPath {
+			found, foundPath, foundEntry = true, importPath, revision
+		}
+	}
+
+	return foundEntry, found
+}
+
+// parseGoSum reads the module versions out of a go.sum file. When several
+// versions of a module are listed, the highest one is what the build uses.
+func parseGoSum(data []byte) ([]lockFileEntry, error) {
+	var (
+		entries        []lockFileEntry
+		modules        []string
+		bestCandidates = make(map[string]semver.SemverCandidate)
+		bestVersions   = make(map[string]string)
+		scanner        = bufio.NewScanner(bytes.NewReader(data))
+	)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || strings.HasSuffix(fields[1], goSumModFileSuffix) {
+			// Modules that only have their go.mod listed are not built.
+			continue
+		}
+
+		module := fields[0]
+		version := strings.TrimSuffix(fields[1], goSumIncompatibleSuffix)
+		matches := goSumVersionRegex.FindStringSubmatch(version)
+		if matches == nil {
+			continue
+		}
+
+		candidate, err := semver.NewSemverCandidateWithBuildMetadata(
+			version,
+			version,
+			version,
+			matches[1],
+			matches[2],
+			matches[3],
+			matches[4],
+			"")
+		if err != nil {
+			continue
+		}
+
+		if best, exists := bestCandidates[module]; !exists {
+			modules = append(modules, module)
+			bestCandidates[module] = candidate
+			bestVersions[module] = version
+		} else if candidate.CompareTo(best) > 0 {
+			bestCandidates[module] = candidate
+			bestVersions[module] = version
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, module := range modules {
+		version := bestVersions[module]
+
+		// Pseudo-versions end with the abbreviated SHA of an untagged commit.
+		if matches := pseudoVersionRegex.FindStringSubmatch(version); matches != nil {
+			entries = append(entries, lockFileEntry{
+				kind:       lockedRevisionKindShortSHA,
+				revision:   matches[1],
+				importPath: module,
+			})
+		} else {
+			entries = append(entries, lockFileEntry{
+				kind:       lockedRevisionKindVersion,
+				revision:   version,
+				importPath: module,
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// parseGopkgLock reads the project revisions out of a dep Gopkg.lock file.
+func parseGopkgLock(data []byte) ([]lockFileEntry, error) {
+	var (
+		entries  []lockFileEntry
+		name     string
+		revision string
+		scanner  = bufio.NewScanner(bytes.NewReader(data))
+	)
+
+	flush := func() {
+		if len(name) > 0 && fullSHARegex.MatchString(revision) {
+			entries = append(entries, lockFileEntry{
+				kind:       lockedRevisionKindSHA,
+				revision:   revision,
+				importPath: name,
+			})
+		}
+
+		name, revision = "", ""
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			// Every table ends the project before it.
+			flush()
+			continue
+		}
+
+		if key, value, ok := readTOMLStringAssignment(line); ok {
+			switch key {
+			case gopkgLockNameKey:
+				name = value
+			case gopkgLockRevisionKey:
+				revision = value
+			}
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// readTOMLStringAssignment reads a key and a string value out of a line of
+// TOML like `name = "github.com/a/b"`.
+func readTOMLStringAssignment(line string) (key, value string, ok bool) {
+	i := strings.IndexByte(line, '=')
+	if i < 0 {
+		return "", "", false
+	}
+
+	key = strings.TrimSpace(line[:i])
+	value = strings.TrimSpace(line[i+1:])
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return "", "", false
+	}
+
+	return key, value[1 : len(value)-1], true
+}
+
+// parseGlideLock reads the import versions out of a glide.lock file.
+func parseGlideLock(data []byte) ([]lockFileEntry, error) {
+	var (
+		entries []lockFileEntry
+		name    string
+		scanner = bufio.NewScanner(bytes.NewReader(data))
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, glideLockNamePrefix) {
+			name = strings.TrimSpace(line[len(glideLockNamePrefix):])
+		} else if strings.HasPrefix(line, glideLockVersionPrefix) && len(name) > 0 {
+			version := strings.TrimSpace(line[len(glideLockVersionPrefix):])
+			if fullSHARegex.MatchString(version) {
+				entries = append(entries, lockFileEntry{
+					kind:       lockedRevisionKindSHA,
+					revision:   version,
+					importPath: name,
+				})
+			}
+
+			name = ""
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// govendorJSON is the JSON structure of a govendor vendor/vendor.json file.
+type govendorJSON struct {
+	Package []struct {
+		Path     string `json:"path"`
+		Revision string `json:"revision"`
+	} `json:"package"`
+}
+
+// parseGovendorJSON reads the package revisions out of a govendor
+// vendor/vendor.json file.
+func parseGovendorJSON(data []byte) ([]lockFileEntry, error) {
+	var (
+		file    govendorJSON
+		entries []lockFileEntry
+	)
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	for _, pkg := range file.Package {
+		if fullSHARegex.MatchString(pkg.Revision) {
+			entries = append(entries, lockFileEntry{
+				kind:       lockedRevisionKindSHA,
+				revision:   pkg.Revision,
+				importPath: pkg.Path,
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// godepsJSON is the JSON structure of a godep Godeps/Godeps.json file.
+type godepsJSON struct {
+	Deps []struct {
+		Rev        string
+		ImportPath string
+	}
+}
+
+// parseGodepsJSON reads the dependency revisions out of a godep
+// Godeps/Godeps.json file.
+func parseGodepsJSON(data []byte) ([]lockFileEntry, error) {
+	var (
+		file    godepsJSON
+		entries []lockFileEntry
+	)
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	for _, dep := range file.Deps {
+		if fullSHARegex.MatchString(dep.Rev) {
+			entries = append(entries, lockFileEntry{
+				kind:       lockedRevisionKindSHA,
+				revision:   dep.Rev,
+				importPath: dep.ImportPath,
+			})
+		}
+	}
+
+	return entries, nil
+}
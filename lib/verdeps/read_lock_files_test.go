@@ -0,0 +1,164 @@
+package verdeps
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/io"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	testLockSHA1 = "1111111111111111111111111111111111111111"
+	testLockSHA2 = "2222222222222222222222222222222222222222"
+	testLockSHA3 = "3333333333333333333333333333333333333333"
+)
+
+func TestReadLockFiles(t *testing.T) {
+	Convey("Given a package with lock files", t, func() {
+		Convey("go.sum should yield the highest version of every built module", func() {
+			entries, err := parseGoSum([]byte(
+				"github.com/a/b v1.2.0 h1:x=\n" +
+					"github.com/a/b v1.2.0/go.mod h1:x=\n" +
+					"github.com/a/b v1.10.0 h1:x=\n" +
+					"github.com/c/d/v2 v2.0.0-20170915032832-14c0d48ead0c h1:x=\n" +
+					"github.com/e/f v1.0.0+incompatible h1:x=\n" +
+					"github.com/g/h v3.0.0/go.mod h1:x=\n"))
+
+			So(err, ShouldBeNil)
+			So(entries, ShouldResemble, []lockFileEntry{
+				{kind: lockedRevisionKindVersion, revision: "v1.10.0", importPath: "github.com/a/b"},
+				{kind: lockedRevisionKindShortSHA, revision: "14c0d48ead0c", importPath: "github.com/c/d/v2"},
+				{kind: lockedRevisionKindVersion, revision: "v1.0.0", importPath: "github.com/e/f"},
+			})
+		})
+
+		Convey("Gopkg.lock should yield the revision of every project", func() {
+			entries, err := parseGopkgLock([]byte(`
+[[projects]]
+  name = "github.com/a/b"
+  packages = ["."]
+  revision = "` + testLockSHA1 + `"
+  version = "v1.0.0"
+
+[[projects]]
+  branch = "master"
+  name = "github.com/c/d"
+  revision = "` + testLockSHA2 + `"
+
+[solve-meta]
+  inputs-digest = "abc"
+`))
+
+			So(err, ShouldBeNil)
+			So(entries, ShouldResemble, []lockFileEntry{
+				{kind: lockedRevisionKindSHA, revision: testLockSHA1, importPath: "github.com/a/b"},
+				{kind: lockedRevisionKindSHA, revision: testLockSHA2, importPath: "github.com/c/d"},
+			})
+		})
+
+		Convey("glide.lock should yield the version of every import", func() {
+			entries, err := parseGlideLock([]byte(`hash: abc
+updated: 2017-01-01T00:00:00Z
+imports:
+- name: github.com/a/b
+  version: ` + testLockSHA1 + `
+  subpackages:
+  - c
+testImports:
+- name: github.com/c/d
+  version: ` + testLockSHA2 + `
+`))
+
+			So(err, ShouldBeNil)
+			So(entries, ShouldResemble, []lockFileEntry{
+				{kind: lockedRevisionKindSHA, revision: testLockSHA1, importPath: "github.com/a/b"},
+				{kind: lockedRevisionKindSHA, revision: testLockSHA2, importPath: "github.com/c/d"},
+			})
+		})
+
+		Convey("vendor.json and Godeps.json should yield every locked revision", func() {
+			entries, err := parseGovendorJSON([]byte(`{"package":[
+				{"path":"github.com/a/b/c","revision":"` + testLockSHA1 + `"},
+				{"path":"github.com/c/d","revision":"notasha"}]}`))
+
+			So(err, ShouldBeNil)
+			So(entries, ShouldResemble, []lockFileEntry{
+				{kind: lockedRevisionKindSHA, revision: testLockSHA1, importPath: "github.com/a/b/c"},
+			})
+
+			entries, err = parseGodepsJSON([]byte(`{"Deps":[
+				{"ImportPath":"github.com/a/b","Rev":"` + testLockSHA2 + `"}]}`))
+
+			So(err, ShouldBeNil)
+			So(entries, ShouldResemble, []lockFileEntry{
+				{kind: lockedRevisionKindSHA, revision: testLockSHA2, importPath: "github.com/a/b"},
+			})
+
+			_, err = parseGodepsJSON([]byte(`{`))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("More authoritative lock files should take precedence", func() {
+			mockIO := io.NewMockIO()
+			notExist := &os.PathError{Op: "open", Err: os.ErrNotExist}
+
+			mockIO.On("ReadFile", filepath.Join("pkg", goSumFileName)).Return([]byte(nil), notExist)
+			mockIO.On("ReadFile", filepath.Join("pkg", gopkgLockFileName)).Return([]byte(`
+[[projects]]
+  name = "github.com/a/b"
+  revision = "`+testLockSHA1+`"
+`), nil)
+			mockIO.On("ReadFile", filepath.Join("pkg", glideLockFileName)).Return([]byte(nil), errors.New("this is an error"))
+			mockIO.On("ReadFile", filepath.Join("pkg", govendorFileName)).Return([]byte(`{`), nil)
+			mockIO.On("ReadFile", filepath.Join("pkg", godepsFileName)).Return([]byte(`{"Deps":[
+				{"ImportPath":"github.com/a/b/c","Rev":"`+testLockSHA2+`"},
+				{"ImportPath":"github.com/x/y","Rev":"`+testLockSHA3+`"},
+				{"ImportPath":"golang.org/x/net","Rev":"`+testLockSHA3+`"},
+				{"ImportPath":"gopkg.in/yaml.v2/internal","Rev":"`+testLockSHA2+`"},
+				{"ImportPath":"git.internal/a/b","Rev":"`+testLockSHA2+`"}]}`), nil)
+
+			revisions := readLockFiles(mockIO, "pkg")
+
+			So(revisions, ShouldResemble, lockedRevisions{
+				"a/b":              {kind: lockedRevisionKindSHA, lockFile: gopkgLockFileName, revision: testLockSHA1},
+				"x/y":              {kind: lockedRevisionKindSHA, lockFile: godepsFileName, revision: testLockSHA3},
+				"golang.org/x/net": {kind: lockedRevisionKindSHA, lockFile: godepsFileName, revision: testLockSHA3},
+				"gopkg.in/yaml.v2": {kind: lockedRevisionKindSHA, lockFile: godepsFileName, revision: testLockSHA2},
+			})
+		})
+
+		Convey("Vanity imports should be locked by their roots or sub-packages", func() {
+			revisions := lockedRevisions{
+				"a/b":                        {revision: testLockSHA1},
+				"golang.org/x/net":           {revision: testLockSHA1},
+				"go.uber.org/zap/zapcore":    {revision: testLockSHA2},
+				"go.uber.org/zap/v2/zapcore": {revision: testLockSHA3},
+			}
+
+			revision, exists := revisions.of("a/b", nil)
+			So(exists, ShouldBeTrue)
+			So(revision.revision, ShouldEqual, testLockSHA1)
+
+			revision, exists = revisions.of("golang.org/x/net", &vanityImport{root: "golang.org/x/net"})
+			So(exists, ShouldBeTrue)
+			So(revision.revision, ShouldEqual, testLockSHA1)
+
+			revision, exists = revisions.of("go.uber.org/zap", &vanityImport{root: "go.uber.org/zap"})
+			So(exists, ShouldBeTrue)
+			So(revision.revision, ShouldEqual, testLockSHA2)
+
+			_, exists = revisions.of("go.uber.org/za", &vanityImport{root: "go.uber.org/za"})
+			So(exists, ShouldBeFalse)
+
+			_, exists = revisions.of("golang.org/x/text", &vanityImport{root: "golang.org/x/text"})
+			So(exists, ShouldBeFalse)
+
+			// Only vanity imports are looked up by their sub-packages.
+			_, exists = revisions.of("go.uber.org/zap", nil)
+			So(exists, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,304 @@
+package verdeps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/semver"
+)
+
+const (
+	// dependencyPolicyKindSelector is the kind of dependency policy that holds
+	// a dependency to the versions that a semver selector matches.
+	dependencyPolicyKindSelector = iota
+	// dependencyPolicyKindSHA is the kind of dependency policy that pins a
+	// dependency to a full commit SHA.
+	dependencyPolicyKindSHA
+	// dependencyPolicyKindShortSHA is the kind of dependency policy that pins a
+	// dependency to an abbreviated commit SHA.
+	dependencyPolicyKindShortSHA
+	// dependencyPolicyKindNoRewrite is the kind of dependency policy that leaves
+	// the imports of a dependency exactly as they are.
+	dependencyPolicyKindNoRewrite
+)
+
+const (
+	policyYAMLFileName      = ".gophr.yml"
+	policyJSONFileName      = ".gophr.json"
+	policyDependenciesKey   = "dependencies"
+	policyNoRewriteKeyword  = "norewrite"
+	policyYAMLCommentChar   = '#'
+	policyYAMLKeyValueSplit = ":"
+)
+
+// policySelectorOperators maps the comparison operators that policy files may
+// use to the comparator operators of gophr semver selectors. Longer operators
+// come first so that they are matched before their prefixes.
+var policySelectorOperators = []struct {
+	operator   string
+	comparator string
+}{
+	{operator: ">=", comparator: semver.SemverComparatorOperatorGreaterThanOrEqual},
+	{operator: "<=", comparator: semver.SemverComparatorOperatorLessThanOrEqual},
+	{operator: ">", comparator: semver.SemverComparatorOperatorGreaterThan},
+	{operator: "<", comparator: semver.SemverComparatorOperatorLessThan},
+	{operator: "=", comparator: ""},
+}
+
+// dependencyPolicy is what the policy file of the package being versioned says
+// about one of its dependencies.
+type dependencyPolicy struct {
+	kind       int
+	value      string
+	policyFile string
+	importPath string
+}
+
+// dependencyPolicies maps the import path hash of every dependency that has a
+// policy (see importPathHashOf) to its policy.
+type dependencyPolicies map[string]dependencyPolicy
+
+// policyFileParser reads the policy of every dependency out of a policy file.
+// The policies are keyed by import path, and are yet to be validated.
+type policyFileParser func(data []byte) (map[string]string, error)
+
+// policyFile describes a policy file that verdeps knows how to read.
+type policyFile struct {
+	path  string
+	parse policyFileParser
+}
+
+// policyFileReader is a function type that de-couples verdeps.processDeps from
+// verdeps.readPolicyFile.
+type policyFileReader func(
+	io io.IO,
+	packagePath string,
+) (dependencyPolicies, error)
+
+// policyFiles is every policy file that verdeps reads, in order of preference.
+var policyFiles = []policyFile{
+	{path: policyYAMLFileName, parse: parsePolicyYAML},
+	{path: policyJSONFileName, parse: parsePolicyJSON},
+}
+
+// readPolicyFile reads the dependency policies from the policy file in the
+// root of the package. Policy files are optional, but unlike lock files, a
+// policy file that is unreadable or invalid is an error: the author asked for
+// the dependencies to be held back, so guessing is not an option.
+func readPolicyFile(io io.IO, packagePath string) (dependencyPolicies, error) {
+	for _, file := range policyFiles {
+		data, err := io.ReadFile(filepath.Join(packagePath, file.path))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf(
+				"Could not read policy file %s: %v",
+				file.path,
+				err)
+		}
+
+		entries, err := file.parse(data)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Could not parse policy file %s: %v",
+				file.path,
+				err)
+		}
+
+		policies := make(dependencyPolicies)
+		for importPath, value := range entries {
+			policy, err := readDependencyPolicy(file.path, importPath, value)
+			if err != nil {
+				return nil, err
+			}
+
+			policies[importPathHashOf(importPath)] = policy
+		}
+
+		return policies, nil
+	}
+
+	return nil, nil
+}
+
+// readDependencyPolicy turns the value that a policy file maps an import path
+// to into a dependency policy.
+func readDependencyPolicy(
+	policyFile string,
+	importPath string,
+	value string,
+) (dependencyPolicy, error) {
+	policy := dependencyPolicy{
+		value:      value,
+		policyFile: policyFile,
+		importPath: importPath,
+	}
+
+	if len(importPath) < 1 {
+		return dependencyPolicy{}, fmt.Errorf(
+			"Policy file %s has a policy without an import path",
+			policyFile)
+	}
+
+	switch {
+	case value == policyNoRewriteKeyword:
+		policy.kind = dependencyPolicyKindNoRewrite
+	case fullSHARegex.MatchString(value):
+		policy.kind = dependencyPolicyKindSHA
+	case shortSHARegex.MatchString(value):
+		policy.kind = dependencyPolicyKindShortSHA
+	default:
+		selector := normalizePolicySelector(value)
+		if !semver.IsSemverSelectorString(selector) {
+			return dependencyPolicy{}, fmt.Errorf(
+				"Policy file %s has an invalid policy for %s: \"%s\"",
+				policyFile,
+				importPath,
+				value)
+		}
+
+		policy.kind = dependencyPolicyKindSelector
+		policy.value = selector
+	}
+
+	return policy, nil
+}
+
+// normalizePolicySelector turns the selectors of policy files into gophr
+// semver selectors. Whitespace is dropped, and comparison operators (e.g.
+// "<2.0") are swapped for their gophr equivalents (e.g. "lt2.0").
+func normalizePolicySelector(selector string) string {
+	selector = strings.Join(strings.Fields(selector), "")
+
+	var groups []string
+	for _, groupString := range strings.Split(
+		selector,
+		string(semver.SemverRangeOrChar)) {
+		var comparators []string
+		for _, comparator := range strings.Split(
+			groupString,
+			string(semver.SemverRangeAndChar)) {
+			for _, op := range policySelectorOperators {
+				if strings.HasPrefix(comparator, op.operator) {
+					comparator = op.comparator + comparator[len(op.operator):]
+					break
+				}
+			}
+
+			comparators = append(comparators, comparator)
+		}
+
+		groups = append(groups, strings.Join(
+			comparators,
+			string(semver.SemverRangeAndChar)))
+	}
+
+	return strings.Join(groups, string(semver.SemverRangeOrChar))
+}
+
+// parsePolicyJSON reads the policies out of a .gophr.json file, which looks
+// like {"dependencies": {"github.com/a/b": "<2.0"}}.
+func parsePolicyJSON(data []byte) (map[string]string, error) {
+	var policyJSON struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+
+	if err := json.Unmarshal(data, &policyJSON); err != nil {
+		return nil, err
+	}
+
+	return policyJSON.Dependencies, nil
+}
+
+// parsePolicyYAML reads the policies out of a .gophr.yml file. Only the subset
+// of YAML that policies need is supported: top-level keys, and the flat map of
+// import paths to policies under the "dependencies" key. Other top-level keys
+// are skipped along with everything nested under them.
+func parsePolicyYAML(data []byte) (map[string]string, error) {
+	var (
+		entries        = make(map[string]string)
+		scanner        = bufio.NewScanner(bytes.NewReader(data))
+		lineNumber     = 0
+		inDependencies = false
+	)
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := stripPolicyYAMLComment(scanner.Text())
+		if len(strings.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		i := strings.Index(line, policyYAMLKeyValueSplit)
+		if i == -1 {
+			return nil, fmt.Errorf(
+				"Line %d is not a \"key: value\" pair",
+				lineNumber)
+		}
+
+		var (
+			key      = unquotePolicyYAMLString(line[:i])
+			value    = unquotePolicyYAMLString(line[i+1:])
+			isNested = line[0] == ' ' || line[0] == '\t'
+		)
+
+		if !isNested {
+			inDependencies = key == policyDependenciesKey
+			if inDependencies && len(value) > 0 {
+				return nil, fmt.Errorf(
+					"Line %d should start the map of dependencies",
+					lineNumber)
+			}
+
+			continue
+		} else if !inDependencies {
+			continue
+		} else if len(value) < 1 {
+			return nil, fmt.Errorf(
+				"Line %d has no policy for %s",
+				lineNumber,
+				key)
+		}
+
+		entries[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// stripPolicyYAMLComment removes the comment at the end of a YAML line, if
+// there is one.
+func stripPolicyYAMLComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] == policyYAMLCommentChar &&
+			(i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+			return line[:i]
+		}
+	}
+
+	return line
+}
+
+// unquotePolicyYAMLString trims the whitespace and quotes around a YAML key or
+// value.
+func unquotePolicyYAMLString(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > 1 &&
+		(s[0] == '"' || s[0] == '\'') &&
+		s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+
+	return s
+}
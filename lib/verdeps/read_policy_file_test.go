@@ -0,0 +1,144 @@
+package verdeps
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/io"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadPolicyFile(t *testing.T) {
+	Convey("Given a package with a policy file", t, func() {
+		notExist := &os.PathError{Op: "open", Err: os.ErrNotExist}
+
+		Convey(".gophr.yml should yield the policy of every dependency", func() {
+			entries, err := parsePolicyYAML([]byte(`
+# Hold back the dependencies that broke us.
+name: my-package
+dependencies:
+  github.com/a/b: <2.0 # 2.x changed the API.
+  "github.com/c/d": '>= 1.2, < 1.5'
+  github.com/e/f: ` + testLockSHA1 + `
+
+  gopkg.in/yaml.v2: norewrite
+other:
+  github.com/g/h: 1.x
+`))
+
+			So(err, ShouldBeNil)
+			So(entries, ShouldResemble, map[string]string{
+				"github.com/a/b":   "<2.0",
+				"github.com/c/d":   ">= 1.2, < 1.5",
+				"github.com/e/f":   testLockSHA1,
+				"gopkg.in/yaml.v2": "norewrite",
+			})
+		})
+
+		Convey("Invalid .gophr.yml files should fail to parse", func() {
+			_, err := parsePolicyYAML([]byte("dependencies:\n  github.com/a/b\n"))
+			So(err, ShouldNotBeNil)
+
+			_, err = parsePolicyYAML([]byte("dependencies:\n  github.com/a/b:\n"))
+			So(err, ShouldNotBeNil)
+
+			_, err = parsePolicyYAML([]byte("dependencies: github.com/a/b\n"))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey(".gophr.json should yield the policy of every dependency", func() {
+			entries, err := parsePolicyJSON([]byte(`{"dependencies":{"github.com/a/b":"<2.0"}}`))
+
+			So(err, ShouldBeNil)
+			So(entries, ShouldResemble, map[string]string{"github.com/a/b": "<2.0"})
+
+			_, err = parsePolicyJSON([]byte(`{`))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Policies should be told apart and keyed by import path hash", func() {
+			mockIO := io.NewMockIO()
+			mockIO.On("ReadFile", filepath.Join("pkg", policyYAMLFileName)).Return([]byte(nil), notExist)
+			mockIO.On("ReadFile", filepath.Join("pkg", policyJSONFileName)).Return([]byte(`{"dependencies":{
+				"github.com/a/b/c": "<2.0",
+				"github.com/c/d": ">=1.2,<1.5;^3",
+				"github.com/e/f": "`+testLockSHA1+`",
+				"github.com/g/h": "abcdef1",
+				"gopkg.in/yaml.v2": "norewrite"}}`), nil)
+
+			policies, err := readPolicyFile(mockIO, "pkg")
+
+			So(err, ShouldBeNil)
+			So(policies, ShouldResemble, dependencyPolicies{
+				"a/b": {
+					kind:       dependencyPolicyKindSelector,
+					value:      "lt2.0",
+					policyFile: policyJSONFileName,
+					importPath: "github.com/a/b/c",
+				},
+				"c/d": {
+					kind:       dependencyPolicyKindSelector,
+					value:      "ge1.2,lt1.5;^3",
+					policyFile: policyJSONFileName,
+					importPath: "github.com/c/d",
+				},
+				"e/f": {
+					kind:       dependencyPolicyKindSHA,
+					value:      testLockSHA1,
+					policyFile: policyJSONFileName,
+					importPath: "github.com/e/f",
+				},
+				"g/h": {
+					kind:       dependencyPolicyKindShortSHA,
+					value:      "abcdef1",
+					policyFile: policyJSONFileName,
+					importPath: "github.com/g/h",
+				},
+				"gopkg.in/yaml.v2": {
+					kind:       dependencyPolicyKindNoRewrite,
+					value:      "norewrite",
+					policyFile: policyJSONFileName,
+					importPath: "gopkg.in/yaml.v2",
+				},
+			})
+		})
+
+		Convey(".gophr.yml should take precedence over .gophr.json", func() {
+			mockIO := io.NewMockIO()
+			mockIO.On("ReadFile", filepath.Join("pkg", policyYAMLFileName)).Return([]byte("dependencies:\n  github.com/a/b: 1.x\n"), nil)
+
+			policies, err := readPolicyFile(mockIO, "pkg")
+
+			So(err, ShouldBeNil)
+			So(policies, ShouldHaveLength, 1)
+			So(policies["a/b"].value, ShouldEqual, "1.x")
+		})
+
+		Convey("Packages without policy files should have no policies", func() {
+			mockIO := io.NewMockIO()
+			mockIO.On("ReadFile", filepath.Join("pkg", policyYAMLFileName)).Return([]byte(nil), notExist)
+			mockIO.On("ReadFile", filepath.Join("pkg", policyJSONFileName)).Return([]byte(nil), notExist)
+
+			policies, err := readPolicyFile(mockIO, "pkg")
+
+			So(err, ShouldBeNil)
+			So(policies, ShouldBeEmpty)
+		})
+
+		Convey("Unreadable policy files and invalid policies should fail", func() {
+			mockIO := io.NewMockIO()
+			mockIO.On("ReadFile", filepath.Join("pkg", policyYAMLFileName)).Return([]byte(nil), errors.New("this is an error"))
+
+			_, err := readPolicyFile(mockIO, "pkg")
+			So(err, ShouldNotBeNil)
+
+			mockIO = io.NewMockIO()
+			mockIO.On("ReadFile", filepath.Join("pkg", policyYAMLFileName)).Return([]byte("dependencies:\n  github.com/a/b: latest\n"), nil)
+
+			_, err = readPolicyFile(mockIO, "pkg")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package verdeps
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/semver"
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+// maxTaggedReleaseLookups is the most commit lookups that are spent looking for
+// a tagged release of a dependency before settling for the commit date.
+const maxTaggedReleaseLookups = 10
+
+// resolveTaggedRelease finds the highest tagged release of a dependency that
+// was committed before the package being versioned was. Pre-releases are left
+// out. Besides the commit SHA of the release, it returns the version label that
+// selects the release and nothing else, or an empty label if there is no such
+// label. Only the releases of the go module that the subpath belongs to are
+// considered (see lib.Refs.CandidatesOf). If the major version is greater
+// than zero, only releases of that major version are considered. If the
+// dependency has no suitable release, the SHA is empty.
+func resolveTaggedRelease(
+	host vcs.Host,
+	bareAuthor string,
+	author string,
+	repo string,
+	subpath string,
+	majorVersion int,
+	downloadRefs refsDownloader,
+	packageVersionDate time.Time,
+) (sha string, label string, err error) {
+	refs, err := downloadRefs(author, repo)
+	if err != nil {
+		return "", "", err
+	}
+
+	moduleCandidates := refs.CandidatesOf(subpath)
+
+	candidates := moduleCandidates
+	if majorVersion > 0 {
+		candidates = candidates.OfMajorVersion(majorVersion)
+	}
+
+	// Candidates are sorted from lowest to highest.
+	lookups := 0
+	for i := len(candidates) - 1; i >= 0 &&
+		lookups < maxTaggedReleaseLookups; i-- {
+		candidate := candidates[i]
+		if !strings.HasPrefix(candidate.GitRefName, refsTagPrefix) ||
+			len(candidate.Prerelease) > 0 ||
+			len(candidate.PrereleaseLabel) > 0 {
+			continue
+		}
+
+		lookups++
+		commitDate, err := host.FetchCommitTimestamp(
+			bareAuthor,
+			repo,
+			candidate.GitRefHash)
+		if err != nil {
+			return "", "", err
+		} else if commitDate.After(packageVersionDate) {
+			continue
+		}
+
+		// Only use the label if it can't be mistaken for another version.
+		label = candidate.String()
+		if constraint, err := semver.ReadSemverConstraint(label); err != nil {
+			label = ""
+		} else if matches := moduleCandidates.Match(constraint); len(matches) != 1 ||
+			matches[0].GitRefHash != candidate.GitRefHash {
+			label = ""
+		}
+
+		return candidate.GitRefHash, label, nil
+	}
+
+	return "", "", nil
+}
@@ -410,6 +410,12 @@ func (fio *fakeIO) WriteFile(
 ) error {
 	return nil
 }
+func (fio *fakeIO) Symlink(oldname, newname string) error {
+	return nil
+}
+func (fio *fakeIO) Readlink(name string) (string, error) {
+	return "", nil
+}
 func (fio *fakeIO) Rename(oldPath, newPath string) error {
 	fio.lock.Lock()
 	defer fio.lock.Unlock()
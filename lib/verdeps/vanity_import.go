@@ -0,0 +1,155 @@
+package verdeps
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+const (
+	gopkgInAuthorPrefix       = "go-"
+	gopkgInRegexIndexRoot     = 1
+	gopkgInRegexIndexAuthor   = 2
+	gopkgInRegexIndexRepo     = 3
+	gopkgInRegexIndexMajor    = 4
+	gopkgInRegexIndexUnstable = 5
+)
+
+// gopkgInRegex matches the import paths of gopkg.in as documented at
+// https://gopkg.in (e.g. "gopkg.in/yaml.v2" or "gopkg.in/user/pkg.v3").
+var gopkgInRegex = regexp.MustCompile(`^(gopkg\.in/(?:([a-zA-Z0-9][-a-zA-Z0-9]*)/)?([a-zA-Z][-.a-zA-Z0-9]*)\.v([0-9]+)(-unstable)?)(?:/|$)`)
+
+// vanityImport is an import path that does not name a repository on a
+// well-known host directly (e.g. "gopkg.in/yaml.v2" or "golang.org/x/net"),
+// resolved to the repository that it stands for.
+type vanityImport struct {
+	// root is the import path of the root of the repository.
+	root string
+	// repo is the repo of the repository.
+	repo string
+	// author is the qualified author of the repository.
+	author string
+	// majorVersion is the major version that gopkg.in selects. It is only set
+	// for gopkg.in imports.
+	majorVersion int
+	// allowsPrerelease is true if gopkg.in may select pre-release versions.
+	allowsPrerelease bool
+}
+
+// isGopkgIn returns true if the vanity import was resolved through gopkg.in's
+// version mapping.
+func (vanity *vanityImport) isGopkgIn() bool {
+	return strings.HasPrefix(vanity.root, gopkgInDomain+"/")
+}
+
+// subpath returns what comes after the root of the vanity import in the
+// specified import path.
+func (vanity *vanityImport) subpath(importPath string) string {
+	return strings.TrimPrefix(strings.Trim(importPath, `"`), vanity.root)
+}
+
+// importMetaFetcher is a function type that de-couples verdeps from
+// vcs.FetchImportMeta.
+type importMetaFetcher func(importPath string) (vcs.ImportMeta, error)
+
+// vanityImportResolver is a function type that de-couples verdeps.processDeps
+// from the resolver returned by newVanityImportResolver.
+type vanityImportResolver func(importPath string) (*vanityImport, error)
+
+// isVanityImportPath returns true if the unquoted import path could be a
+// vanity import path. Only paths that start with a public domain qualify, which
+// leaves out the standard library and hosts on private networks.
+func isVanityImportPath(importPath string) bool {
+	i := strings.IndexByte(importPath, '/')
+	return i != -1 &&
+		vcs.IsPublicDomain(importPath[:i]) &&
+		!vcs.IsWellKnownDomain(importPath[:i]) &&
+		importPath[:i] != gophrDomain
+}
+
+// newVanityImportResolver creates a resolver of vanity imports. It remembers
+// every root it resolves so that the sub-packages of a root don't each cost a
+// round trip.
+func newVanityImportResolver(
+	fetchImportMeta importMetaFetcher,
+) vanityImportResolver {
+	var (
+		lock     sync.RWMutex
+		resolved = make(map[string]*vanityImport)
+	)
+
+	return func(importPath string) (*vanityImport, error) {
+		// Check whether the root of the import path has already been resolved.
+		lock.RLock()
+		for root, vanity := range resolved {
+			if importPath == root || strings.HasPrefix(importPath, root+"/") {
+				lock.RUnlock()
+				return vanity, nil
+			}
+		}
+		lock.RUnlock()
+
+		vanity, err := resolveVanityImport(importPath, fetchImportMeta)
+		if err != nil {
+			return nil, err
+		}
+
+		lock.Lock()
+		resolved[vanity.root] = vanity
+		lock.Unlock()
+
+		return vanity, nil
+	}
+}
+
+// resolveVanityImport figures out which repository a vanity import path stands
+// for. gopkg.in imports are resolved using its documented mapping to Github.
+// Every other import is resolved using its go-import meta tags.
+func resolveVanityImport(
+	importPath string,
+	fetchImportMeta importMetaFetcher,
+) (*vanityImport, error) {
+	if matches := gopkgInRegex.FindStringSubmatch(importPath); matches != nil {
+		majorVersion, err := strconv.Atoi(matches[gopkgInRegexIndexMajor])
+		if err != nil {
+			return nil, err
+		}
+
+		// "gopkg.in/pkg.vN" stands for "github.com/go-pkg/pkg".
+		author := matches[gopkgInRegexIndexAuthor]
+		if len(author) < 1 {
+			author = gopkgInAuthorPrefix + matches[gopkgInRegexIndexRepo]
+		}
+
+		return &vanityImport{
+			root:             matches[gopkgInRegexIndexRoot],
+			repo:             matches[gopkgInRegexIndexRepo],
+			author:           author,
+			majorVersion:     majorVersion,
+			allowsPrerelease: len(matches[gopkgInRegexIndexUnstable]) > 0,
+		}, nil
+	}
+
+	meta, err := fetchImportMeta(importPath)
+	if err != nil {
+		return nil, err
+	}
+
+	author, repo, ok := meta.Repo()
+	if !ok {
+		return nil, fmt.Errorf(
+			"%s is served from %s, which is not a well-known host",
+			meta.Root,
+			meta.RepoURL)
+	}
+
+	return &vanityImport{
+		root:   meta.Root,
+		repo:   repo,
+		author: author,
+	}, nil
+}
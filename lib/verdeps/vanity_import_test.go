@@ -0,0 +1,125 @@
+package verdeps
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/vcs"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIsVanityImportPath(t *testing.T) {
+	Convey("Given an import path", t, func() {
+		Convey("Paths that start with an unknown domain should be vanity import paths", func() {
+			So(isVanityImportPath("gopkg.in/yaml.v2"), ShouldBeTrue)
+			So(isVanityImportPath("golang.org/x/net/context"), ShouldBeTrue)
+			So(isVanityImportPath("go.uber.org/zap"), ShouldBeTrue)
+		})
+
+		Convey("Well-known hosts, gophr and the standard library should not be vanity import paths", func() {
+			So(isVanityImportPath("github.com/a/b"), ShouldBeFalse)
+			So(isVanityImportPath("gitlab.com/a/b"), ShouldBeFalse)
+			So(isVanityImportPath("gophr.pm/a/b@1.0"), ShouldBeFalse)
+			So(isVanityImportPath("net/http"), ShouldBeFalse)
+			So(isVanityImportPath("fmt"), ShouldBeFalse)
+		})
+
+		Convey("Import paths on private networks should not be vanity import paths", func() {
+			So(isVanityImportPath("127.0.0.1/a/b"), ShouldBeFalse)
+			So(isVanityImportPath("localhost.localdomain/a/b"), ShouldBeFalse)
+			So(isVanityImportPath("git.internal/a/b"), ShouldBeFalse)
+		})
+	})
+}
+
+func TestResolveVanityImport(t *testing.T) {
+	Convey("Given a vanity import path", t, func() {
+		failToFetchImportMeta := func(importPath string) (vcs.ImportMeta, error) {
+			return vcs.ImportMeta{}, errors.New("this is an error")
+		}
+
+		Convey("gopkg.in import paths should be mapped to Github without a round trip", func() {
+			vanity, err := resolveVanityImport("gopkg.in/yaml.v2", failToFetchImportMeta)
+			So(err, ShouldBeNil)
+			So(vanity, ShouldResemble, &vanityImport{
+				root:         "gopkg.in/yaml.v2",
+				repo:         "yaml",
+				author:       "go-yaml",
+				majorVersion: 2,
+			})
+			So(vanity.isGopkgIn(), ShouldBeTrue)
+
+			vanity, err = resolveVanityImport(
+				"gopkg.in/user/pkg.v3-unstable/sub/pkg",
+				failToFetchImportMeta)
+			So(err, ShouldBeNil)
+			So(vanity, ShouldResemble, &vanityImport{
+				root:             "gopkg.in/user/pkg.v3-unstable",
+				repo:             "pkg",
+				author:           "user",
+				majorVersion:     3,
+				allowsPrerelease: true,
+			})
+			So(vanity.subpath(`"gopkg.in/user/pkg.v3-unstable/sub/pkg"`), ShouldEqual, "/sub/pkg")
+		})
+
+		Convey("Other import paths should be resolved using their go-import meta tags", func() {
+			vanity, err := resolveVanityImport(
+				"golang.org/x/net/context",
+				func(importPath string) (vcs.ImportMeta, error) {
+					So(importPath, ShouldEqual, "golang.org/x/net/context")
+					return vcs.ImportMeta{
+						Root:    "golang.org/x/net",
+						VCS:     "git",
+						RepoURL: "https://go.googlesource.com/net",
+						HomeURL: "https://github.com/golang/net/",
+					}, nil
+				})
+			So(err, ShouldBeNil)
+			So(vanity, ShouldResemble, &vanityImport{
+				root:   "golang.org/x/net",
+				repo:   "net",
+				author: "golang",
+			})
+			So(vanity.isGopkgIn(), ShouldBeFalse)
+			So(vanity.subpath(`"golang.org/x/net/context"`), ShouldEqual, "/context")
+		})
+
+		Convey("Import paths of repositories that aren't on well-known hosts should fail", func() {
+			_, err := resolveVanityImport(
+				"example.com/a",
+				func(importPath string) (vcs.ImportMeta, error) {
+					return vcs.ImportMeta{
+						Root:    "example.com/a",
+						VCS:     "git",
+						RepoURL: "https://git.example.com/a",
+					}, nil
+				})
+			So(err, ShouldNotBeNil)
+
+			_, err = resolveVanityImport("example.com/a", failToFetchImportMeta)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Resolvers should only fetch the meta tags of a root once", func() {
+			fetches := 0
+			resolve := newVanityImportResolver(
+				func(importPath string) (vcs.ImportMeta, error) {
+					fetches++
+					return vcs.ImportMeta{
+						Root:    "go.uber.org/zap",
+						VCS:     "git",
+						RepoURL: "https://github.com/uber-go/zap",
+					}, nil
+				})
+
+			first, err := resolve("go.uber.org/zap")
+			So(err, ShouldBeNil)
+			second, err := resolve("go.uber.org/zap/zapcore")
+			So(err, ShouldBeNil)
+
+			So(fetches, ShouldEqual, 1)
+			So(second, ShouldEqual, first)
+		})
+	})
+}
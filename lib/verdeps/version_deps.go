@@ -3,9 +3,12 @@ package verdeps
 import (
 	"errors"
 	"fmt"
+	"log"
 
+	"github.com/gophr-pm/gophr/lib"
 	"github.com/gophr-pm/gophr/lib/github"
 	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/vcs"
 )
 
 // depsProcessor is a function type that de-couples verdeps.VersionDeps from
@@ -22,19 +25,29 @@ type VersionDepsArgs struct {
 	Repo string
 	// SHA is the path to the package source code to be versioned.
 	Path string
-	// Author is the author of the package being versioned.
+	// Author is the author of the package being versioned. It may be qualified
+	// with the domain of its host (see vcs.QualifyAuthor).
 	Author string
+	// Hosts is the set of hosts that the package and its dependencies are
+	// fetched from. If unspecified, github.NewHosts(GithubService) will be used.
+	Hosts vcs.Hosts
 	// processDeps version-locks all appropriate dependencies in a package
 	// directory, while keeping in mind chronological accuracy. If unspecified,
 	// verdeps.processDeps will be used.
 	processDeps depsProcessor
+	// RecordPin is told how every import of the package was pinned. If
+	// unspecified, pins are logged.
+	RecordPin PinRecorder
+	// RecordDependencies is told about every dependency of the package once
+	// they have all been pinned. If unspecified, dependencies are not recorded.
+	RecordDependencies DependencyRecorder
 	// GithubService is the service, with which, requests can be made of the
 	// Github API.
 	GithubService github.RequestService
 }
 
-// VersionDeps version locks all of the Github-based Go dependencies referenced
-// in the source code of a package. It takes a variety of package metadata and
+// VersionDeps version locks all of the Go dependencies referenced in the source
+// code of a package that live on well-known hosts. It takes a variety of package metadata and
 // the path to the source code, and changes its dependencies accordingly.
 func VersionDeps(args VersionDepsArgs) error {
 	if args.IO == nil {
@@ -56,27 +69,66 @@ func VersionDeps(args VersionDepsArgs) error {
 		args.processDeps = processDeps
 	}
 
+	// Fallback to logging pins if no override is supplied.
+	if args.RecordPin == nil {
+		args.RecordPin = logPin
+	}
+
+	// Fallback to ignoring dependencies if no override is supplied.
+	if args.RecordDependencies == nil {
+		args.RecordDependencies = ignoreDependencies
+	}
+
+	// Fallback to every supported host if no override is supplied.
+	if args.Hosts == nil {
+		args.Hosts = github.NewHosts(args.GithubService)
+	}
+
 	// Fetch the timestamp of the commit SHA.
-	commitDate, err := args.GithubService.FetchCommitTimestamp(
-		args.Author,
+	host, bareAuthor, err := args.Hosts.Of(args.Author)
+	if err != nil {
+		return err
+	}
+
+	commitDate, err := host.FetchCommitTimestamp(
+		bareAuthor,
 		args.Repo,
 		args.SHA,
 	)
-	if err != nil {
+	if err == vcs.ErrUnsupported {
+		// Without a timestamp, there is no way to version the dependencies
+		// chronologically. So, leave them be.
+		log.Printf(
+			"Skipping dependency versioning of %s/%s@%s since %s does not "+
+				"support commit lookups.\n",
+			args.Author,
+			args.Repo,
+			args.SHA,
+			host.Domain())
+		return nil
+	} else if isRateLimited(err) {
+		return err
+	} else if err != nil {
 		return fmt.Errorf("Could not fetch commit timestamp: %v.", err)
 	}
 
 	return args.processDeps(processDepsArgs{
 		io:                      args.IO,
-		ghSvc:                   args.GithubService,
+		hosts:                   args.Hosts,
 		fetchSHA:                fetchSHA,
+		recordPin:               args.RecordPin,
 		reviseDeps:              reviseDeps,
 		packageSHA:              args.SHA,
 		packagePath:             args.Path,
 		packageRepo:             args.Repo,
+		downloadRefs:            lib.FetchRefs,
 		packageAuthor:           args.Author,
+		readLockFiles:           readLockFiles,
+		readPolicyFile:          readPolicyFile,
 		readPackageDir:          readPackageDir,
 		packageVersionDate:      commitDate,
+		recordDependencies:      args.RecordDependencies,
+		resolveVanityImport:     newVanityImportResolver(vcs.FetchImportMeta),
 		newSpecWaitingList:      newSpecWaitingList,
 		newSyncedStringMap:      newSyncedStringMap,
 		newSyncedWaitingListMap: newSyncedWaitingListMap,
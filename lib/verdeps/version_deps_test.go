@@ -160,7 +160,7 @@ func TestVersionDeps(t *testing.T) {
 
 			So(err, ShouldBeNil)
 			So(actualProcessDepsArgs.fetchSHA, ShouldNotBeNil)
-			So(actualProcessDepsArgs.ghSvc, ShouldNotBeNil)
+			So(actualProcessDepsArgs.hosts, ShouldNotBeNil)
 			So(actualProcessDepsArgs.io, ShouldNotBeNil)
 			So(actualProcessDepsArgs.newSpecWaitingList, ShouldNotBeNil)
 			So(actualProcessDepsArgs.newSyncedStringMap, ShouldNotBeNil)
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/config"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archival"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archive"
+	"github.com/gophr-pm/gophr/lib/depot"
+	"github.com/gophr-pm/gophr/lib/github"
+	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/vcs"
+	"github.com/gophr-pm/gophr/lib/verdeps"
+)
+
+const (
+	// archivalJobAttemptsLimit is the number of times a job is attempted before
+	// it is marked as failed.
+	archivalJobAttemptsLimit = 3
+	// archivalJobRetryDelay is how long a job waits before being retried for
+	// every attempt that it has already used up.
+	archivalJobRetryDelay = 30 * time.Second
+	// archivalJobGracePeriod is how long a running job may go without holding
+	// the archival lock of its package version before it is assumed that its
+	// worker died.
+	archivalJobGracePeriod = 2 * archiveLockTTL
+	// archivalQueuePollInterval is how often idle workers check the queue for
+	// jobs enqueued by other replicas.
+	archivalQueuePollInterval = 5 * time.Second
+	// archivalAwaitPollInterval is how often a job is checked while it is being
+	// awaited.
+	archivalAwaitPollInterval = 1 * time.Second
+	// defaultArchivalWorkers is the number of workers used when the config does
+	// not specify one.
+	defaultArchivalWorkers = 4
+)
+
+// archivalQueueArgs is the arguments struct for newArchivalQueue.
+type archivalQueueArgs struct {
+	io                    io.IO
+	db                    db.Client
+	conf                  *config.Config
+	creds                 *config.Credentials
+	ghSvc                 github.RequestService
+	hosts                 vcs.Hosts
+	signingKey            ed25519.PrivateKey
+	downloadRefs          refsDownloader
+	versionPackage        packageVersioner
+	isPackageArchived     packageArchivalChecker
+	recordPackageArchival packageArchivalRecorder
+}
+
+// archivalQueue is a durable queue of package versions that need to be
+// sub-versioned and archived in depot. Jobs are stored in the database, so
+// every router replica shares the same queue. Each replica runs a bounded
+// pool of workers that claim jobs from it.
+type archivalQueue struct {
+	args archivalQueueArgs
+	wake chan struct{}
+	// enqueues and awaits coalesce the enqueues and awaits of the same package
+	// version that are in flight at the same time.
+	enqueues flightGroup
+	awaits   flightGroup
+}
+
+// newArchivalQueue creates a new archivalQueue. No jobs are worked on until
+// the queue is started.
+func newArchivalQueue(args archivalQueueArgs) *archivalQueue {
+	return &archivalQueue{args: args}
+}
+
+// start spins up the specified number of workers.
+func (aq *archivalQueue) start(workers int) {
+	if workers < 1 {
+		workers = defaultArchivalWorkers
+	}
+
+	aq.wake = make(chan struct{}, workers)
+	for i := 0; i < workers; i++ {
+		go aq.work()
+	}
+
+	log.Printf("Started %d archival workers.\n", workers)
+}
+
+// enqueue queues a package version for archival, and returns the resulting
+// job.
+func (aq *archivalQueue) enqueue(
+	args packageArchivalEnqueuerArgs,
+) (*archival.Job, error) {
+	job, err := aq.enqueues.do(
+		packageVersionKey(args.author, args.repo, args.sha),
+		func() (interface{}, error) {
+			return aq.enqueueJob(args)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return job.(*archival.Job), nil
+}
+
+// enqueueJob is enqueue without the coalescing.
+func (aq *archivalQueue) enqueueJob(
+	args packageArchivalEnqueuerArgs,
+) (*archival.Job, error) {
+	queued, err := archival.Enqueue(
+		aq.args.db,
+		args.author,
+		args.repo,
+		args.sha,
+		args.selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// Let an idle worker know that there is something to do.
+	if queued {
+		select {
+		case aq.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	job, err := archival.Get(aq.args.db, args.author, args.repo, args.sha)
+	if err != nil {
+		return nil, err
+	} else if job == nil {
+		return nil, fmt.Errorf(
+			"Archival job for %s/%s@%s disappeared after being enqueued.",
+			args.author,
+			args.repo,
+			args.sha)
+	}
+
+	return job, nil
+}
+
+// await waits for the archival job of a package version to be done, or to be
+// deferred. Returns the job as it was last seen, whether it was done or not. Callers that await
+// the same package version at the same time share one poll of the database.
+func (aq *archivalQueue) await(
+	args packageArchivalAwaiterArgs,
+) (*archival.Job, error) {
+	job, err := aq.awaits.do(
+		packageVersionKey(args.author, args.repo, args.sha),
+		func() (interface{}, error) {
+			return aq.awaitJob(args)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return job.(*archival.Job), nil
+}
+
+// awaitJob is await without the coalescing.
+func (aq *archivalQueue) awaitJob(
+	args packageArchivalAwaiterArgs,
+) (*archival.Job, error) {
+	deadline := time.Now().Add(args.timeout)
+
+	for {
+		job, err := archival.Get(aq.args.db, args.author, args.repo, args.sha)
+		if err != nil {
+			return nil, err
+		} else if job == nil {
+			return nil, NewNoSuchArchivalJobError(args.author, args.repo, args.sha)
+		}
+
+		if job.IsDone() ||
+			job.IsDeferred() ||
+			!time.Now().Add(archivalAwaitPollInterval).Before(deadline) {
+			return job, nil
+		}
+
+		time.Sleep(archivalAwaitPollInterval)
+	}
+}
+
+// work is the loop that every worker runs until the process exits.
+func (aq *archivalQueue) work() {
+	for {
+		// Keep going while there is work to be done.
+		for aq.workOnNextJob() {
+		}
+
+		select {
+		case <-aq.wake:
+		case <-time.After(archivalQueuePollInterval):
+		}
+	}
+}
+
+// workOnNextJob claims a job that is ready to be worked on, and works on it.
+// Returns false if there was no such job.
+func (aq *archivalQueue) workOnNextJob() bool {
+	jobs, err := aq.readyJobs()
+	if err != nil {
+		log.Println("Failed to read the archival queue:", err)
+		return false
+	}
+
+	for _, job := range jobs {
+		claimed, err := archival.Claim(aq.args.db, job)
+		if err != nil {
+			log.Println(err)
+			continue
+		} else if !claimed {
+			// Another worker got to it first.
+			continue
+		}
+
+		job.Status = archival.StatusRunning
+		job.Attempts++
+		aq.process(job)
+		return true
+	}
+
+	return false
+}
+
+// readyJobs returns up to twice as many jobs as there are workers that are
+// ready to be worked on: the queued jobs that are not waiting to be retried or
+// deferred, and the running jobs whose workers appear to have died. Since
+// workers hold the archival lock for as long as they are alive, a running job
+// without a held lock has been abandoned. Every job of a status is looked at
+// if need be, so jobs that are waiting can't starve the ones that are ready.
+func (aq *archivalQueue) readyJobs() ([]archival.Job, error) {
+	var (
+		now       = time.Now()
+		limit     = cap(aq.wake) * 2
+		readyJobs []archival.Job
+		hasQuota  = func(job archival.Job) bool {
+			return job.Attempts < archivalJobAttemptsLimit
+		}
+	)
+
+	if err := archival.ForEachByStatus(
+		aq.args.db,
+		archival.StatusQueued,
+		func(job archival.Job) bool {
+			retryTime := job.DateUpdated.Add(
+				time.Duration(job.Attempts) * archivalJobRetryDelay)
+			if !now.Before(retryTime) && !job.IsDeferred() {
+				readyJobs = append(readyJobs, job)
+			}
+
+			return len(readyJobs) < limit
+		}); err != nil {
+		return nil, err
+	} else if len(readyJobs) >= limit {
+		return readyJobs, nil
+	}
+
+	var lockErr error
+	if err := archival.ForEachByStatus(
+		aq.args.db,
+		archival.StatusRunning,
+		func(job archival.Job) bool {
+			if now.Sub(job.DateUpdated) <= archivalJobGracePeriod {
+				return true
+			}
+
+			lock, err := archives.GetLock(aq.args.db, job.Author, job.Repo, job.SHA)
+			if err != nil {
+				lockErr = err
+				return false
+			} else if lock == nil || !lock.IsHeld() {
+				if hasQuota(job) {
+					readyJobs = append(readyJobs, job)
+				} else if _, err := archival.Fail(
+					aq.args.db,
+					job,
+					fmt.Errorf("Gave up after %d interrupted attempts.", job.Attempts),
+				); err != nil {
+					log.Println(err)
+				}
+			}
+
+			return len(readyJobs) < limit
+		}); err != nil {
+		return nil, err
+	} else if lockErr != nil {
+		return nil, lockErr
+	}
+
+	return readyJobs, nil
+}
+
+// process archives the package version of a claimed job, and records the
+// outcome.
+func (aq *archivalQueue) process(job archival.Job) {
+	log.Printf(
+		"Archiving %s/%s@%s (attempt %d of %d).\n",
+		job.Author,
+		job.Repo,
+		job.SHA,
+		job.Attempts,
+		archivalJobAttemptsLimit)
+
+	err := aq.args.versionPackage(packageVersionerArgs{
+		io:                     aq.args.io,
+		db:                     aq.args.db,
+		sha:                    job.SHA,
+		repo:                   job.Repo,
+		conf:                   aq.args.conf,
+		creds:                  aq.args.creds,
+		ghSvc:                  aq.args.ghSvc,
+		hosts:                  aq.args.hosts,
+		author:                 job.Author,
+		signingKey:             aq.args.signingKey,
+		hashArchive:            depot.HashArchive,
+		pushToDepot:            pushToDepot,
+		downloadRefs:           aq.args.downloadRefs,
+		lockArchival:           lockPackageArchivalInDB,
+		versionDeps:            verdeps.VersionDeps,
+		downloadPackage:        downloadPackage,
+		createDepotRepo:        createRepoInDepot,
+		destroyDepotRepo:       deleteRepoInDepot,
+		fetchCommitDate:        fetchCommitDate,
+		isPackageArchived:      aq.args.isPackageArchived,
+		constructionZonePath:   aq.args.conf.ConstructionZonePath,
+		recordPackageArchival:  aq.args.recordPackageArchival,
+		attemptWorkDirDeletion: deleteFolder,
+	})
+
+	var transitioned bool
+	if rateLimitErr, rateLimited := err.(github.RateLimitExceededError); rateLimited {
+		// Running out of quota says nothing about the job, so try again once the
+		// quota is reset without counting this attempt.
+		log.Printf(
+			"Deferring archival of %s/%s@%s until %s: %v\n",
+			job.Author,
+			job.Repo,
+			job.SHA,
+			rateLimitErr.ResetTime,
+			err)
+
+		transitioned, err = archival.Defer(
+			aq.args.db,
+			job,
+			rateLimitErr.ResetTime,
+			err)
+	} else if err == nil {
+		transitioned, err = archival.Succeed(aq.args.db, job)
+	} else {
+		// Report the sub-versioning failure to the logs.
+		log.Printf(
+			"Sub-versioning failed for package %s/%s@%s: %v\n",
+			job.Author,
+			job.Repo,
+			job.SHA,
+			err)
+
+		if job.Attempts < archivalJobAttemptsLimit {
+			transitioned, err = archival.Retry(aq.args.db, job, err)
+		} else {
+			transitioned, err = archival.Fail(aq.args.db, job, err)
+		}
+	}
+
+	if err != nil {
+		log.Println(err)
+	} else if !transitioned {
+		// Another worker took over the job after this one went quiet for too
+		// long, so the outcome is theirs to record.
+		log.Printf(
+			"Archival job for %s/%s@%s was taken over by another worker.\n",
+			job.Author,
+			job.Repo,
+			job.SHA)
+	}
+}
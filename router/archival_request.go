@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archival"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archive"
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+const (
+	// archivalRequestPathPrefix prefixes the path of every archival request.
+	// Since no author may be named "-", it can't be mistaken for a package.
+	archivalRequestPathPrefix = "/-/archivals"
+)
+
+// archivalStatus is the JSON-serializable archival status of a package
+// version.
+type archivalStatus struct {
+	SHA               string     `json:"sha"`
+	Repo              string     `json:"repo"`
+	Author            string     `json:"author"`
+	Status            string     `json:"status"`
+	Selector          string     `json:"selector,omitempty"`
+	Attempts          int        `json:"attempts"`
+	LastError         string     `json:"lastError,omitempty"`
+	DateUpdated       *time.Time `json:"dateUpdated,omitempty"`
+	DateEnqueued      *time.Time `json:"dateEnqueued,omitempty"`
+	DateDeferredUntil *time.Time `json:"dateDeferredUntil,omitempty"`
+}
+
+// archivalRequest is a request to either queue a package version for
+// archival (POST), or to check on its archival status (GET).
+type archivalRequest struct {
+	req   *http.Request
+	parts *packageRequestParts
+}
+
+// isArchivalRequest returns true if the request was made against the
+// archival endpoints.
+func isArchivalRequest(req *http.Request) bool {
+	return strings.HasPrefix(req.URL.Path, archivalRequestPathPrefix+"/")
+}
+
+// readArchivalRequest reads the package version out of the URL of an archival
+// request. Archival request paths look like go get paths without a subpath:
+// "/-/archivals/author/repo@selector".
+func readArchivalRequest(req *http.Request) (*archivalRequest, error) {
+	url := strings.TrimSpace(req.URL.Path)
+	parts, err := parsePackageRequestPath(
+		strings.TrimPrefix(url, archivalRequestPathPrefix))
+	if err != nil {
+		return nil, NewInvalidArchivalRequestURLError(url, err)
+	} else if len(parts.subpath) > 0 {
+		return nil, NewInvalidArchivalRequestURLError(url)
+	}
+
+	return &archivalRequest{req: req, parts: parts}, nil
+}
+
+// respondToArchivalRequestArgs is the arguments struct for
+// archivalRequest#respond.
+type respondToArchivalRequestArgs struct {
+	db                    db.Queryable
+	res                   http.ResponseWriter
+	hosts                 vcs.Hosts
+	downloadRefs          refsDownloader
+	getArchivalJob        archivalJobGetter
+	pinVersionLabel       versionLabelPinner
+	enqueueArchival       packageArchivalEnqueuer
+	isPackageArchived     packageArchivalChecker
+	recordPackageArchival packageArchivalRecorder
+}
+
+// respond queues the package version for archival, or reports its archival
+// status, depending on the request method.
+func (ar *archivalRequest) respond(args respondToArchivalRequestArgs) error {
+	if ar.req.Method != http.MethodGet && ar.req.Method != http.MethodPost {
+		return NewUnsupportedArchivalRequestMethodError(ar.req.Method)
+	} else if err := assertSupportedHost(args.hosts, ar.parts.author); err != nil {
+		return err
+	}
+
+	sha, _, err := resolvePackageVersion(resolvePackageVersionArgs{
+		db:              args.db,
+		parts:           ar.parts,
+		hosts:           args.hosts,
+		downloadRefs:    args.downloadRefs,
+		pinVersionLabel: args.pinVersionLabel,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Package versions that were archived before the archival queue existed do
+	// not have jobs, so check for the archive itself first.
+	archived, err := args.isPackageArchived(packageArchivalCheckerArgs{
+		db:                    args.db,
+		sha:                   sha,
+		repo:                  ar.parts.repo,
+		author:                ar.parts.author,
+		readDepotArchiveHash:  readDepotArchiveHash,
+		recordPackageArchival: args.recordPackageArchival,
+		isPackageArchivedInDB: archives.Exists,
+	})
+	if err != nil {
+		return err
+	}
+
+	var (
+		job        *archival.Job
+		statusCode = http.StatusOK
+	)
+
+	if archived {
+		job = &archival.Job{
+			SHA:    sha,
+			Repo:   ar.parts.repo,
+			Author: ar.parts.author,
+			Status: archival.StatusSucceeded,
+		}
+	} else if ar.req.Method == http.MethodPost {
+		if job, err = args.enqueueArchival(packageArchivalEnqueuerArgs{
+			sha:      sha,
+			repo:     ar.parts.repo,
+			author:   ar.parts.author,
+			selector: ar.parts.selector,
+		}); err != nil {
+			return err
+		}
+
+		statusCode = http.StatusAccepted
+	} else {
+		if job, err = args.getArchivalJob(
+			args.db,
+			ar.parts.author,
+			ar.parts.repo,
+			sha); err != nil {
+			return err
+		} else if job == nil {
+			return NewNoSuchArchivalJobError(ar.parts.author, ar.parts.repo, sha)
+		}
+	}
+
+	body, err := json.Marshal(newArchivalStatus(*job))
+	if err != nil {
+		return err
+	}
+
+	args.res.Header().Set(httpContentTypeHeader, contentTypeJSON)
+	args.res.WriteHeader(statusCode)
+	args.res.Write(body)
+	return nil
+}
+
+// newArchivalStatus turns an archival job into an archivalStatus.
+func newArchivalStatus(job archival.Job) archivalStatus {
+	status := archivalStatus{
+		SHA:       job.SHA,
+		Repo:      job.Repo,
+		Author:    vcs.AuthorPath(job.Author),
+		Status:    job.Status,
+		Selector:  job.Selector,
+		Attempts:  job.Attempts,
+		LastError: job.LastError,
+	}
+
+	// Jobs synthesized for packages that were already archived have no dates.
+	if !job.DateUpdated.IsZero() {
+		dateUpdated := job.DateUpdated.UTC()
+		status.DateUpdated = &dateUpdated
+	}
+	if !job.DateEnqueued.IsZero() {
+		dateEnqueued := job.DateEnqueued.UTC()
+		status.DateEnqueued = &dateEnqueued
+	}
+	if job.IsDeferred() {
+		dateDeferredUntil := job.DateDeferredUntil.UTC()
+		status.DateDeferredUntil = &dateDeferredUntil
+	}
+
+	return status
+}
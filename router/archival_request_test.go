@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archival"
+	"github.com/gophr-pm/gophr/lib/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsArchivalRequest(t *testing.T) {
+	assert.True(t, isArchivalRequest(fakeHTTPRequest("gophr.pm", "/-/archivals/a/b@1.0", false)))
+	assert.False(t, isArchivalRequest(fakeHTTPRequest("gophr.pm", "/-/archivals", false)))
+	assert.False(t, isArchivalRequest(fakeHTTPRequest("gophr.pm", "/a/b@1.0", true)))
+}
+
+func TestReadArchivalRequest(t *testing.T) {
+	ar, err := readArchivalRequest(fakeHTTPRequest("gophr.pm", "/-/archivals/ab/cd@1.0", false))
+	assert.Nil(t, err)
+	assert.Equal(t, "ab", ar.parts.author)
+	assert.Equal(t, "cd", ar.parts.repo)
+	assert.Equal(t, "1.0", ar.parts.selector)
+
+	ar, err = readArchivalRequest(fakeHTTPRequest("gophr.pm", "/-/archivals/gitlab.com/ab/cd", false))
+	assert.Nil(t, err)
+	assert.Equal(t, "gitlab.com:ab", ar.parts.author)
+	assert.Equal(t, "cd", ar.parts.repo)
+
+	for _, path := range []string{
+		"/-/archivals/",
+		"/-/archivals/ab",
+		"/-/archivals/ab/cd/ef",
+		"/-/archivals/ab/cd@1.0/ef",
+	} {
+		_, err = readArchivalRequest(fakeHTTPRequest("gophr.pm", path, false))
+		assert.NotNil(t, err, path)
+	}
+}
+
+func TestRespondToArchivalRequest(t *testing.T) {
+	var (
+		sha   = "0000000000000000000000000000000000000sha"
+		now   = time.Date(2017, 3, 14, 15, 9, 26, 0, time.UTC)
+		parts = &packageRequestParts{
+			repo:               "cd",
+			author:             "ab",
+			selector:           sha,
+			shaSelector:        sha,
+			hasFullSHASelector: true,
+		}
+		archivalRequestOf = func(method string) *archivalRequest {
+			return &archivalRequest{req: &http.Request{Method: method}, parts: parts}
+		}
+		hosts       = vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain))
+		notArchived = func(args packageArchivalCheckerArgs) (bool, error) {
+			assert.Equal(t, sha, args.sha)
+			return false, nil
+		}
+	)
+
+	// Unsupported method.
+	w := httptest.NewRecorder()
+	err := archivalRequestOf(http.MethodDelete).respond(respondToArchivalRequestArgs{res: w})
+	assert.Equal(t, NewUnsupportedArchivalRequestMethodError(http.MethodDelete), err)
+
+	// Unsupported host.
+	w = httptest.NewRecorder()
+	err = (&archivalRequest{
+		req:   &http.Request{Method: http.MethodPost},
+		parts: &packageRequestParts{author: "git.example.com:ab", repo: "cd"},
+	}).respond(respondToArchivalRequestArgs{
+		res:   w,
+		hosts: hosts,
+		isPackageArchived: func(args packageArchivalCheckerArgs) (bool, error) {
+			t.Fatal("the archival of packages on unsupported hosts should not be checked")
+			return false, nil
+		},
+	})
+	assert.Equal(t, NewUnsupportedPackageHostError("git.example.com"), err)
+
+	// Archival check fails.
+	w = httptest.NewRecorder()
+	err = archivalRequestOf(http.MethodGet).respond(respondToArchivalRequestArgs{
+		res:   w,
+		hosts: hosts,
+		isPackageArchived: func(args packageArchivalCheckerArgs) (bool, error) {
+			return false, errors.New("this is an error")
+		},
+	})
+	assert.NotNil(t, err)
+
+	// Already archived.
+	w = httptest.NewRecorder()
+	err = archivalRequestOf(http.MethodPost).respond(respondToArchivalRequestArgs{
+		res:   w,
+		hosts: hosts,
+		isPackageArchived: func(args packageArchivalCheckerArgs) (bool, error) {
+			return true, nil
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(
+		t,
+		`{"sha":"`+sha+`","repo":"cd","author":"ab","status":"succeeded","attempts":0}`,
+		w.Body.String())
+
+	// Never enqueued.
+	w = httptest.NewRecorder()
+	err = archivalRequestOf(http.MethodGet).respond(respondToArchivalRequestArgs{
+		res:               w,
+		hosts:             hosts,
+		isPackageArchived: notArchived,
+		getArchivalJob: func(q db.Queryable, author, repo, sha string) (*archival.Job, error) {
+			return nil, nil
+		},
+	})
+	assert.Equal(t, NewNoSuchArchivalJobError("ab", "cd", sha), err)
+
+	// Running.
+	w = httptest.NewRecorder()
+	err = archivalRequestOf(http.MethodGet).respond(respondToArchivalRequestArgs{
+		res:               w,
+		hosts:             hosts,
+		isPackageArchived: notArchived,
+		getArchivalJob: func(q db.Queryable, author, repo, sha string) (*archival.Job, error) {
+			assert.Equal(t, "ab", author)
+			assert.Equal(t, "cd", repo)
+			return &archival.Job{
+				SHA:          sha,
+				Repo:         repo,
+				Author:       author,
+				Status:       archival.StatusRunning,
+				Selector:     sha,
+				Attempts:     2,
+				LastError:    "oops",
+				DateUpdated:  now,
+				DateEnqueued: now,
+			}, nil
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, contentTypeJSON, w.Header().Get(httpContentTypeHeader))
+	assert.Equal(
+		t,
+		`{"sha":"`+sha+`","repo":"cd","author":"ab","status":"running","selector":"`+sha+`",`+
+			`"attempts":2,"lastError":"oops","dateUpdated":"2017-03-14T15:09:26Z",`+
+			`"dateEnqueued":"2017-03-14T15:09:26Z"}`,
+		w.Body.String())
+
+	// Enqueued.
+	w = httptest.NewRecorder()
+	err = archivalRequestOf(http.MethodPost).respond(respondToArchivalRequestArgs{
+		res:               w,
+		hosts:             hosts,
+		isPackageArchived: notArchived,
+		enqueueArchival: func(args packageArchivalEnqueuerArgs) (*archival.Job, error) {
+			assert.Equal(t, "ab", args.author)
+			assert.Equal(t, "cd", args.repo)
+			assert.Equal(t, sha, args.sha)
+			assert.Equal(t, sha, args.selector)
+			return &archival.Job{
+				SHA:          args.sha,
+				Repo:         args.repo,
+				Author:       args.author,
+				Status:       archival.StatusQueued,
+				DateUpdated:  now,
+				DateEnqueued: now,
+			}, nil
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"queued"`)
+
+	// Semver selectors are resolved to the SHAs that their tags were pinned to,
+	// even if the tags have since moved upstream.
+	refs, _ := lib.NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.2.0")))
+	semverParts, err := parsePackageRequestPath("/ab/cd@1.2.0")
+	assert.Nil(t, err)
+
+	w = httptest.NewRecorder()
+	err = (&archivalRequest{
+		req:   &http.Request{Method: http.MethodPost},
+		parts: semverParts,
+	}).respond(respondToArchivalRequestArgs{
+		res:          w,
+		hosts:        hosts,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+		pinVersionLabel: fakeVersionLabelPinner(map[string]string{
+			"v1.2.0": "00000000000000000000000000000000000hash9",
+		}),
+		isPackageArchived: func(args packageArchivalCheckerArgs) (bool, error) {
+			assert.Equal(t, "00000000000000000000000000000000000hash9", args.sha)
+			return false, nil
+		},
+		enqueueArchival: func(args packageArchivalEnqueuerArgs) (*archival.Job, error) {
+			assert.Equal(t, "00000000000000000000000000000000000hash9", args.sha)
+			assert.Equal(t, "1.2.0", args.selector)
+			return &archival.Job{
+				SHA:          args.sha,
+				Repo:         args.repo,
+				Author:       args.author,
+				Status:       archival.StatusQueued,
+				DateUpdated:  now,
+				DateEnqueued: now,
+			}, nil
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"sha":"00000000000000000000000000000000000hash9"`)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// archivedPackageCacheCapacity is how many package versions the archived
+// package cache of a router remembers.
+const archivedPackageCacheCapacity = 10000
+
+// archivedPackageCache is an in-memory LRU cache of the package versions that
+// are known to have been archived. Archives of package versions don't go
+// away, so only positive archival checks are cached, and they never expire.
+type archivedPackageCache struct {
+	lock     sync.Mutex
+	keys     *list.List
+	elements map[string]*list.Element
+	capacity int
+}
+
+// newArchivedPackageCache creates a new archivedPackageCache that holds up to
+// capacity package versions.
+func newArchivedPackageCache(capacity int) *archivedPackageCache {
+	return &archivedPackageCache{
+		keys:     list.New(),
+		elements: make(map[string]*list.Element),
+		capacity: capacity,
+	}
+}
+
+// contains returns true if the package version is known to have been
+// archived.
+func (c *archivedPackageCache) contains(author, repo, sha string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, exists := c.elements[packageVersionKey(author, repo, sha)]
+	if exists {
+		c.keys.MoveToFront(element)
+	}
+
+	return exists
+}
+
+// add remembers that the package version has been archived. The package
+// version that was used the least recently is forgotten if the cache is full.
+func (c *archivedPackageCache) add(author, repo, sha string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := packageVersionKey(author, repo, sha)
+	if element, exists := c.elements[key]; exists {
+		c.keys.MoveToFront(element)
+		return
+	}
+
+	c.elements[key] = c.keys.PushFront(key)
+	if c.keys.Len() > c.capacity {
+		oldest := c.keys.Back()
+		c.keys.Remove(oldest)
+		delete(c.elements, oldest.Value.(string))
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchivedPackageCache(t *testing.T) {
+	cache := newArchivedPackageCache(2)
+	assert.False(t, cache.contains("a", "b", "sha1"))
+
+	cache.add("a", "b", "sha1")
+	cache.add("a", "b", "sha2")
+	assert.True(t, cache.contains("a", "b", "sha1"))
+	assert.True(t, cache.contains("a", "b", "sha2"))
+	assert.False(t, cache.contains("a", "c", "sha1"))
+
+	// sha1 was used less recently than sha2, so it goes first.
+	cache.add("a", "b", "sha1")
+	assert.True(t, cache.contains("a", "b", "sha2"))
+	cache.add("a", "b", "sha3")
+	assert.False(t, cache.contains("a", "b", "sha1"))
+	assert.True(t, cache.contains("a", "b", "sha2"))
+	assert.True(t, cache.contains("a", "b", "sha3"))
+	assert.Equal(t, 2, cache.keys.Len())
+	assert.Len(t, cache.elements, 2)
+}
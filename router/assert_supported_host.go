@@ -0,0 +1,16 @@
+package main
+
+import "github.com/gophr-pm/gophr/lib/vcs"
+
+// assertSupportedHost makes sure that the host of a package is one that gophr
+// has been configured to reach. Everything that gophr fetches on behalf of a
+// request is fetched from the host of the requested package, so requests for
+// packages on any other host are turned away before anything is fetched.
+func assertSupportedHost(hosts vcs.Hosts, author string) error {
+	if !hosts.Supports(author) {
+		domain, _ := vcs.SplitAuthor(author)
+		return NewUnsupportedPackageHostError(domain)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+// packageKey identifies a package in coalesced calls.
+func packageKey(author, repo string) string {
+	return author + "/" + repo
+}
+
+// packageVersionKey identifies a package version in coalesced calls.
+func packageVersionKey(author, repo, sha string) string {
+	return author + "/" + repo + "@" + sha
+}
+
+// newCoalescingRefsDownloader creates a refsDownloader that coalesces
+// downloads of the refs of the same package that are in flight at the same
+// time into one.
+func newCoalescingRefsDownloader(downloadRefs refsDownloader) refsDownloader {
+	var flights flightGroup
+	return func(author, repo string) (lib.Refs, error) {
+		refs, err := flights.do(packageKey(author, repo), func() (interface{}, error) {
+			return downloadRefs(author, repo)
+		})
+		if err != nil {
+			return lib.Refs{}, err
+		}
+
+		return refs.(lib.Refs), nil
+	}
+}
+
+// newCachingPackageArchivalChecker creates a packageArchivalChecker that
+// coalesces checks of the same package version that are in flight at the same
+// time into one, and that remembers the package versions that were found to
+// be archived.
+func newCachingPackageArchivalChecker(
+	isPackageArchived packageArchivalChecker,
+	capacity int,
+) packageArchivalChecker {
+	var (
+		cache   = newArchivedPackageCache(capacity)
+		flights flightGroup
+	)
+
+	return func(args packageArchivalCheckerArgs) (bool, error) {
+		if cache.contains(args.author, args.repo, args.sha) {
+			return true, nil
+		}
+
+		archived, err := flights.do(
+			packageVersionKey(args.author, args.repo, args.sha),
+			func() (interface{}, error) {
+				return isPackageArchived(args)
+			})
+		if err != nil {
+			return false, err
+		} else if archived.(bool) {
+			cache.add(args.author, args.repo, args.sha)
+		}
+
+		return archived.(bool), nil
+	}
+}
+
+// newCachingUpstreamFileFetcher wraps an upstreamArchiveFetcher or a
+// goModFetcher such that fetches of the same package version that are in
+// flight at the same time are coalesced into one, and such that up to
+// capacity bytes of the files that were fetched are remembered.
+func newCachingUpstreamFileFetcher(
+	fetchFile func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error),
+	capacity int,
+) func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+	var (
+		cache   = newUpstreamFileCache(capacity)
+		flights flightGroup
+	)
+
+	return func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+		if file, exists := cache.get(author, repo, sha); exists {
+			return file, nil
+		}
+
+		file, err := flights.do(
+			packageVersionKey(author, repo, sha),
+			func() (interface{}, error) {
+				return fetchFile(hosts, author, repo, sha)
+			})
+		if err != nil {
+			return nil, err
+		}
+
+		cache.add(author, repo, sha, file.([]byte))
+		return file.([]byte), nil
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalescingRefsDownloader(t *testing.T) {
+	refs, _ := lib.NewRefs([]byte(reflines("00000000000000000000000000000000000hash1 HEAD")))
+	downloadRefs := newCoalescingRefsDownloader(func(author, repo string) (lib.Refs, error) {
+		if repo == "broken" {
+			return lib.Refs{}, errors.New("this is an error")
+		}
+
+		assert.Equal(t, "myauthor", author)
+		assert.Equal(t, "myrepo", repo)
+		return refs, nil
+	})
+
+	actualRefs, err := downloadRefs("myauthor", "myrepo")
+	assert.Nil(t, err)
+	assert.Equal(t, refs, actualRefs)
+
+	_, err = downloadRefs("myauthor", "broken")
+	assert.NotNil(t, err)
+}
+
+func TestCachingPackageArchivalChecker(t *testing.T) {
+	var (
+		checks   int
+		archived = map[string]bool{"sha1": true}
+	)
+
+	isArchived := newCachingPackageArchivalChecker(
+		func(args packageArchivalCheckerArgs) (bool, error) {
+			checks++
+			if args.sha == "broken" {
+				return false, errors.New("this is an error")
+			}
+
+			return archived[args.sha], nil
+		},
+		10)
+
+	check := func(sha string) (bool, error) {
+		return isArchived(packageArchivalCheckerArgs{
+			author: "myauthor",
+			repo:   "myrepo",
+			sha:    sha,
+		})
+	}
+
+	// Positive checks are remembered.
+	for i := 0; i < 2; i++ {
+		ok, err := check("sha1")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, 1, checks)
+
+	// Negative checks and failures are not.
+	for i := 0; i < 2; i++ {
+		ok, err := check("sha2")
+		assert.Nil(t, err)
+		assert.False(t, ok)
+
+		_, err = check("broken")
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, 5, checks)
+
+	// Once sha2 is archived, that sticks.
+	archived["sha2"] = true
+	ok, err := check("sha2")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 6, checks)
+}
+
+func TestCachingUpstreamFileFetcher(t *testing.T) {
+	var (
+		hosts   = vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain))
+		fetches int
+	)
+
+	fetchArchive := newCachingUpstreamFileFetcher(
+		func(actualHosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+			fetches++
+			assert.Equal(t, hosts, actualHosts)
+			if sha == "broken" {
+				return nil, errors.New("this is an error")
+			}
+
+			return []byte(author + "/" + repo + "@" + sha), nil
+		},
+		10)
+
+	// Archives are remembered.
+	for i := 0; i < 2; i++ {
+		archive, err := fetchArchive(hosts, "a", "b", "sha1")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("a/b@sha1"), archive)
+	}
+	assert.Equal(t, 1, fetches)
+
+	// Failures are not.
+	for i := 0; i < 2; i++ {
+		_, err := fetchArchive(hosts, "a", "b", "broken")
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, 3, fetches)
+
+	// Archives that don't fit are fetched every time.
+	for i := 0; i < 2; i++ {
+		archive, err := fetchArchive(hosts, "a", "bcdef", "sha1")
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("a/bcdef@sha1"), archive)
+	}
+	assert.Equal(t, 5, fetches)
+}
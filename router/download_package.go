@@ -7,15 +7,22 @@ import (
 )
 
 const (
-	packageZipFileName   = "archive.zip"
-	githubZipURLTemplate = "https://github.com/%s/%s/archive/%s.zip"
+	packageZipFileName = "archive.zip"
 )
 
-// downloadPackage downloads a go package repository from Github into the
-// construction zone and returns the created directories.
+// downloadPackage downloads a go package repository from its host into the
+// construction zone and returns the created directories. Packages that keep
+// their major version on a branch get a directory for it (see
+// linkMajorVersionDir).
 func downloadPackage(args packageDownloaderArgs) (packageDownloadPaths, error) {
 	downloadPaths := packageDownloadPaths{}
 
+	// Only hosts that gophr supports may be downloaded from.
+	host, bareAuthor, err := args.hosts.Of(args.author)
+	if err != nil {
+		return downloadPaths, err
+	}
+
 	// Create the working directory. Everything else in this functions happens in
 	// here.
 	workDirPath := filepath.Join(args.constructionZonePath, generateWorkDirName())
@@ -23,9 +30,9 @@ func downloadPackage(args packageDownloaderArgs) (packageDownloadPaths, error) {
 		return downloadPaths, fmt.Errorf("Could not create workDir %s: %v.", workDirPath, err)
 	}
 
-	// Use a zip strategy to download from Github in order to save of data
+	// Use a zip strategy to download from the host in order to save of data
 	// transfer and on-disk storage needs.
-	zipURL := fmt.Sprintf(githubZipURLTemplate, args.author, args.repo, args.sha)
+	zipURL := host.ArchiveURL(bareAuthor, args.repo, args.sha)
 	zipResp, err := args.doHTTPGet(zipURL)
 	defer zipResp.Body.Close()
 	if err != nil || zipResp.StatusCode == 404 {
@@ -61,8 +68,17 @@ func downloadPackage(args packageDownloaderArgs) (packageDownloadPaths, error) {
 	for _, f := range files {
 		fileName := f.Name()
 		if fileName != packageZipFileName {
+			archiveDirPath := filepath.Join(workDirPath, fileName)
+
+			// Keep module-style imports of the major version of the package
+			// working.
+			if err = args.linkMajorVersionDir(args.io, archiveDirPath); err != nil {
+				args.deleteWorkDir(workDirPath)
+				return downloadPaths, fmt.Errorf("Could not link the major version directory: %v.", err)
+			}
+
 			downloadPaths.workDirPath = workDirPath
-			downloadPaths.archiveDirPath = filepath.Join(workDirPath, fileName)
+			downloadPaths.archiveDirPath = archiveDirPath
 			return downloadPaths, nil
 		}
 	}
@@ -10,23 +10,40 @@ import (
 
 	"github.com/gophr-pm/gophr/lib"
 	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/vcs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 func TestDownloadPackage(t *testing.T) {
+	// Nothing should be fetched from hosts that aren't supported.
+	_, err := downloadPackage(packageDownloaderArgs{
+		io:                   io.NewMockIO(),
+		hosts:                vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain)),
+		author:               "git.example.com:myauthor",
+		repo:                 "myrepo",
+		sha:                  "mysha",
+		constructionZonePath: "/my/cons/zone",
+		doHTTPGet: func(url string) (*http.Response, error) {
+			t.Fatalf("%s should not have been fetched", url)
+			return nil, nil
+		},
+	})
+	assert.NotNil(t, err)
+
 	mockIO := io.NewMockIO()
 	mockIO.
 		On("Mkdir", mock.AnythingOfType("string"), os.FileMode(0644)).
 		Return(errors.New("this is an error"))
 	args := packageDownloaderArgs{
 		io:                   mockIO,
+		hosts:                vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain)),
 		author:               "myauthor",
 		repo:                 "myrepo",
 		sha:                  "mysha",
 		constructionZonePath: "/my/cons/zone",
 	}
-	_, err := downloadPackage(args)
+	_, err = downloadPackage(args)
 	assert.NotNil(t, err)
 	mockIO.AssertExpectations(t)
 
@@ -41,6 +58,7 @@ func TestDownloadPackage(t *testing.T) {
 	deleteWorkDirCalled := false
 	args = packageDownloaderArgs{
 		io:                   mockIO,
+		hosts:                vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain)),
 		author:               "myauthor",
 		repo:                 "myrepo",
 		sha:                  "mysha",
@@ -71,6 +89,7 @@ func TestDownloadPackage(t *testing.T) {
 	deleteWorkDirCalled = false
 	args = packageDownloaderArgs{
 		io:                   mockIO,
+		hosts:                vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain)),
 		author:               "myauthor",
 		repo:                 "myrepo",
 		sha:                  "mysha",
@@ -104,6 +123,7 @@ func TestDownloadPackage(t *testing.T) {
 	deleteWorkDirCalled = false
 	args = packageDownloaderArgs{
 		io:                   mockIO,
+		hosts:                vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain)),
 		author:               "myauthor",
 		repo:                 "myrepo",
 		sha:                  "mysha",
@@ -142,6 +162,7 @@ func TestDownloadPackage(t *testing.T) {
 	deleteWorkDirCalled = false
 	args = packageDownloaderArgs{
 		io:                   mockIO,
+		hosts:                vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain)),
 		author:               "myauthor",
 		repo:                 "myrepo",
 		sha:                  "mysha",
@@ -181,6 +202,7 @@ func TestDownloadPackage(t *testing.T) {
 	deleteWorkDirCalled = false
 	args = packageDownloaderArgs{
 		io:                   mockIO,
+		hosts:                vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain)),
 		author:               "myauthor",
 		repo:                 "myrepo",
 		sha:                  "mysha",
@@ -230,6 +252,7 @@ func TestDownloadPackage(t *testing.T) {
 	deleteWorkDirCalled = false
 	args = packageDownloaderArgs{
 		io:                   mockIO,
+		hosts:                vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain)),
 		author:               "myauthor",
 		repo:                 "myrepo",
 		sha:                  "mysha",
@@ -281,6 +304,7 @@ func TestDownloadPackage(t *testing.T) {
 	deleteWorkDirCalled = false
 	args = packageDownloaderArgs{
 		io:                   mockIO,
+		hosts:                vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain)),
 		author:               "myauthor",
 		repo:                 "myrepo",
 		sha:                  "mysha",
@@ -334,8 +358,10 @@ func TestDownloadPackage(t *testing.T) {
 		}, error(nil))
 	unzipArchiveCalled = false
 	deleteWorkDirCalled = false
+	linkedMajorVersionDirPath := ""
 	args = packageDownloaderArgs{
 		io:                   mockIO,
+		hosts:                vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain)),
 		author:               "myauthor",
 		repo:                 "myrepo",
 		sha:                  "mysha",
@@ -354,6 +380,10 @@ func TestDownloadPackage(t *testing.T) {
 			assert.True(t, len(target) > 0)
 			return nil
 		},
+		linkMajorVersionDir: func(io io.IO, archiveDirPath string) error {
+			linkedMajorVersionDirPath = archiveDirPath
+			return nil
+		},
 	}
 	paths, err := downloadPackage(args)
 	assert.Nil(t, err)
@@ -366,4 +396,14 @@ func TestDownloadPackage(t *testing.T) {
 		t,
 		filepath.Join(paths.workDirPath, "akdjshfgaldfkjhjdfhgaksjhfg"),
 		paths.archiveDirPath)
+	assert.Equal(t, paths.archiveDirPath, linkedMajorVersionDirPath)
+
+	// Failing to link the major version directory should fail the download.
+	deleteWorkDirCalled = false
+	args.linkMajorVersionDir = func(io io.IO, archiveDirPath string) error {
+		return errors.New("this is an error")
+	}
+	_, err = downloadPackage(args)
+	assert.NotNil(t, err)
+	assert.True(t, deleteWorkDirCalled)
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archival"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archive"
+	"github.com/gophr-pm/gophr/lib/github"
+)
+
+const (
+	// archivalAwaitTimeout is how long a request waits for a package version to
+	// be archived before giving up and asking the client to try again later.
+	archivalAwaitTimeout = 20 * time.Second
+)
+
+// ensurePackageArchivedArgs is the arguments struct for ensurePackageArchived.
+type ensurePackageArchivedArgs struct {
+	db                    db.Queryable
+	sha                   string
+	repo                  string
+	author                string
+	selector              string
+	awaitArchival         packageArchivalAwaiter
+	enqueueArchival       packageArchivalEnqueuer
+	isPackageArchived     packageArchivalChecker
+	recordPackageArchival packageArchivalRecorder
+}
+
+// ensurePackageArchived queues the specified package version for archival if
+// it has not been archived already, and then waits a little while for the
+// archival to finish. If the archival takes too long, a
+// PackageArchivalPendingError is returned so that the client can retry later.
+// If the archival is waiting for the Github API quota to be reset, a
+// github.RateLimitExceededError is returned instead.
+func ensurePackageArchived(args ensurePackageArchivedArgs) error {
+	// Check whether this package has already been archived.
+	packageArchived, err := args.isPackageArchived(packageArchivalCheckerArgs{
+		db:                    args.db,
+		sha:                   args.sha,
+		repo:                  args.repo,
+		author:                args.author,
+		readDepotArchiveHash:  readDepotArchiveHash,
+		recordPackageArchival: args.recordPackageArchival,
+		isPackageArchivedInDB: archives.Exists,
+	})
+	// If we cannot check whether a package has been archived, return
+	// unsuccessfully.
+	if err != nil {
+		return err
+	}
+
+	// Only archive this package if we haven't before.
+	if packageArchived {
+		return nil
+	}
+
+	// Indicate in the logs that the package was not archived.
+	log.Printf(
+		"Package %s/%s@%s has not yet been archived.\n",
+		args.author,
+		args.repo,
+		args.sha)
+
+	// Hand the package version off to the archival workers.
+	job, err := args.enqueueArchival(packageArchivalEnqueuerArgs{
+		sha:      args.sha,
+		repo:     args.repo,
+		author:   args.author,
+		selector: args.selector,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Give the workers a chance to finish before responding.
+	if !job.IsDone() && !job.IsDeferred() {
+		if job, err = args.awaitArchival(packageArchivalAwaiterArgs{
+			sha:     args.sha,
+			repo:    args.repo,
+			author:  args.author,
+			timeout: archivalAwaitTimeout,
+		}); err != nil {
+			return err
+		}
+	}
+
+	switch job.Status {
+	case archival.StatusSucceeded:
+		return nil
+	case archival.StatusFailed:
+		return NewPackageArchivalFailedError(
+			args.author,
+			args.repo,
+			args.sha,
+			job.LastError)
+	}
+
+	// Jobs that are waiting for the Github API quota won't be done before it is
+	// reset, so tell the client when that is.
+	if job.IsDeferred() {
+		return github.NewRateLimitExceededError(job.DateDeferredUntil)
+	}
+
+	return NewPackageArchivalPendingError(args.author, args.repo, args.sha)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db/model/package/archival"
+	"github.com/gophr-pm/gophr/lib/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsurePackageArchived(t *testing.T) {
+	fakeArchivalChecker := func(archived bool, err error) packageArchivalChecker {
+		return func(args packageArchivalCheckerArgs) (bool, error) {
+			assert.Equal(t, "a", args.author)
+			assert.Equal(t, "b", args.repo)
+			assert.Equal(t, "c", args.sha)
+			return archived, err
+		}
+	}
+	fakeArchivalEnqueuer := func(status string, err error) packageArchivalEnqueuer {
+		return func(args packageArchivalEnqueuerArgs) (*archival.Job, error) {
+			assert.Equal(t, "a", args.author)
+			assert.Equal(t, "b", args.repo)
+			assert.Equal(t, "c", args.sha)
+			assert.Equal(t, "1.x", args.selector)
+			if err != nil {
+				return nil, err
+			}
+			return &archival.Job{Status: status, LastError: "oops"}, nil
+		}
+	}
+	fakeArchivalAwaiter := func(status string, err error) packageArchivalAwaiter {
+		return func(args packageArchivalAwaiterArgs) (*archival.Job, error) {
+			assert.Equal(t, archivalAwaitTimeout, args.timeout)
+			if err != nil {
+				return nil, err
+			}
+			return &archival.Job{Status: status, LastError: "oops"}, nil
+		}
+	}
+	args := ensurePackageArchivedArgs{
+		sha:      "c",
+		repo:     "b",
+		author:   "a",
+		selector: "1.x",
+	}
+
+	// Already archived.
+	args.isPackageArchived = fakeArchivalChecker(true, nil)
+	assert.Nil(t, ensurePackageArchived(args))
+
+	// Archival check fails.
+	args.isPackageArchived = fakeArchivalChecker(false, errors.New("this is an error"))
+	assert.NotNil(t, ensurePackageArchived(args))
+
+	// Enqueueing fails.
+	args.isPackageArchived = fakeArchivalChecker(false, nil)
+	args.enqueueArchival = fakeArchivalEnqueuer("", errors.New("this is an error"))
+	assert.NotNil(t, ensurePackageArchived(args))
+
+	// Archived by the time it is enqueued.
+	args.enqueueArchival = fakeArchivalEnqueuer(archival.StatusSucceeded, nil)
+	assert.Nil(t, ensurePackageArchived(args))
+
+	// Archived while waiting.
+	args.enqueueArchival = fakeArchivalEnqueuer(archival.StatusQueued, nil)
+	args.awaitArchival = fakeArchivalAwaiter(archival.StatusSucceeded, nil)
+	assert.Nil(t, ensurePackageArchived(args))
+
+	// Waiting fails.
+	args.awaitArchival = fakeArchivalAwaiter("", errors.New("this is an error"))
+	assert.NotNil(t, ensurePackageArchived(args))
+
+	// Failed while waiting.
+	args.awaitArchival = fakeArchivalAwaiter(archival.StatusFailed, nil)
+	err := ensurePackageArchived(args)
+	assert.Equal(t, NewPackageArchivalFailedError("a", "b", "c", "oops"), err)
+
+	// Still running after waiting.
+	args.awaitArchival = fakeArchivalAwaiter(archival.StatusRunning, nil)
+	err = ensurePackageArchived(args)
+	assert.Equal(t, NewPackageArchivalPendingError("a", "b", "c"), err)
+
+	// Deferred until the quota is reset, so there is no point in waiting.
+	resetTime := time.Now().Add(time.Hour)
+	args.enqueueArchival = func(args packageArchivalEnqueuerArgs) (*archival.Job, error) {
+		return &archival.Job{Status: archival.StatusQueued, DateDeferredUntil: resetTime}, nil
+	}
+	args.awaitArchival = func(args packageArchivalAwaiterArgs) (*archival.Job, error) {
+		assert.Fail(t, "deferred jobs should not be awaited")
+		return nil, nil
+	}
+	err = ensurePackageArchived(args)
+	assert.Equal(t, github.NewRateLimitExceededError(resetTime), err)
+
+	// Deferred while waiting.
+	args.enqueueArchival = fakeArchivalEnqueuer(archival.StatusQueued, nil)
+	args.awaitArchival = func(args packageArchivalAwaiterArgs) (*archival.Job, error) {
+		return &archival.Job{Status: archival.StatusQueued, DateDeferredUntil: resetTime}, nil
+	}
+	err = ensurePackageArchived(args)
+	assert.Equal(t, github.NewRateLimitExceededError(resetTime), err)
+}
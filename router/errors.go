@@ -194,3 +194,395 @@ func (err NoSuchPackageVersionError) Causes() []error {
 func (err NoSuchPackageVersionError) PublicError() (int, string) {
 	return http.StatusNotFound, err.Error()
 }
+
+/********************* AMBIGUOUS PACKAGE VERSION SELECTOR *********************/
+
+// AmbiguousPackageVersionSelectorError is an error that occurs when the
+// selector of a package request is both a SHA or semver selector, and the name
+// of a branch in the package's repository.
+type AmbiguousPackageVersionSelectorError struct {
+	PackageAuthor string
+	PackageRepo   string
+	Selector      string
+	SelectorType  string
+}
+
+// NewAmbiguousPackageVersionSelectorError creates a new
+// AmbiguousPackageVersionSelectorError.
+func NewAmbiguousPackageVersionSelectorError(
+	packageAuthor string,
+	packageRepo string,
+	selector string,
+	selectorType string,
+) AmbiguousPackageVersionSelectorError {
+	return AmbiguousPackageVersionSelectorError{
+		PackageAuthor: packageAuthor,
+		PackageRepo:   packageRepo,
+		Selector:      selector,
+		SelectorType:  selectorType,
+	}
+}
+
+func (err AmbiguousPackageVersionSelectorError) Error() string {
+	return fmt.Sprintf(
+		`"%s" is both a %s and a branch of "%s/%s". Use "%s%s" to select the branch.`,
+		err.Selector,
+		err.SelectorType,
+		err.PackageAuthor,
+		err.PackageRepo,
+		branchSelectorPrefix,
+		err.Selector,
+	)
+}
+
+// Causes returns the error(s) that caused this error.
+func (err AmbiguousPackageVersionSelectorError) Causes() []error {
+	return nil
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err AmbiguousPackageVersionSelectorError) PublicError() (int, string) {
+	return http.StatusBadRequest, err.Error()
+}
+
+/******************** UNSUPPORTED PACKAGE VERSION SELECTOR ********************/
+
+// UnsupportedPackageVersionSelectorError is an error that occurs when the host
+// of a package can't resolve the kind of selector that was requested.
+type UnsupportedPackageVersionSelectorError struct {
+	PackageAuthor string
+	PackageRepo   string
+	Selector      string
+	CausedBy      []error
+}
+
+// NewUnsupportedPackageVersionSelectorError creates a new
+// UnsupportedPackageVersionSelectorError.
+func NewUnsupportedPackageVersionSelectorError(
+	packageAuthor string,
+	packageRepo string,
+	selector string,
+	causes ...error,
+) UnsupportedPackageVersionSelectorError {
+	return UnsupportedPackageVersionSelectorError{
+		PackageAuthor: packageAuthor,
+		PackageRepo:   packageRepo,
+		Selector:      selector,
+		CausedBy:      causes,
+	}
+}
+
+func (err UnsupportedPackageVersionSelectorError) Error() string {
+	return fmt.Sprintf(
+		`The host of "%s/%s" does not support selectors like "%s".`,
+		err.PackageAuthor,
+		err.PackageRepo,
+		err.Selector,
+	)
+}
+
+// Causes returns the error(s) that caused this error.
+func (err UnsupportedPackageVersionSelectorError) Causes() []error {
+	return err.CausedBy
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err UnsupportedPackageVersionSelectorError) PublicError() (int, string) {
+	return http.StatusBadRequest, err.Error()
+}
+
+/************************** UNSUPPORTED PACKAGE HOST **************************/
+
+// UnsupportedPackageHostError is an error that occurs when a package is
+// requested from a host that gophr has not been configured to reach.
+type UnsupportedPackageHostError struct {
+	Domain string
+}
+
+// NewUnsupportedPackageHostError creates a new UnsupportedPackageHostError.
+func NewUnsupportedPackageHostError(domain string) UnsupportedPackageHostError {
+	return UnsupportedPackageHostError{Domain: domain}
+}
+
+func (err UnsupportedPackageHostError) Error() string {
+	return fmt.Sprintf(`Packages hosted on "%s" are not supported.`, err.Domain)
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err UnsupportedPackageHostError) PublicError() (int, string) {
+	return http.StatusBadRequest, err.Error()
+}
+
+/************************ INVALID MODULE PROXY REQUEST ************************/
+
+// InvalidModuleProxyRequestURLError is an error that occurs when an incoming
+// request URL is an invalid go module proxy request.
+type InvalidModuleProxyRequestURLError struct {
+	RequestURL string
+	CausedBy   []error
+}
+
+// NewInvalidModuleProxyRequestURLError creates a new
+// InvalidModuleProxyRequestURLError.
+func NewInvalidModuleProxyRequestURLError(
+	requestURL string,
+	causes ...error,
+) InvalidModuleProxyRequestURLError {
+	return InvalidModuleProxyRequestURLError{
+		RequestURL: requestURL,
+		CausedBy:   causes,
+	}
+}
+
+func (err InvalidModuleProxyRequestURLError) Error() string {
+	return fmt.Sprintf(
+		`"%s" is not a valid module proxy request URL.`,
+		err.RequestURL,
+	)
+}
+
+// Causes returns the error(s) that caused this error.
+func (err InvalidModuleProxyRequestURLError) Causes() []error {
+	return err.CausedBy
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err InvalidModuleProxyRequestURLError) PublicError() (int, string) {
+	return http.StatusBadRequest, err.Error()
+}
+
+/************************** INVALID ARCHIVAL REQUEST **************************/
+
+// InvalidArchivalRequestURLError is an error that occurs when an incoming
+// request URL is an invalid archival request.
+type InvalidArchivalRequestURLError struct {
+	RequestURL string
+	CausedBy   []error
+}
+
+// NewInvalidArchivalRequestURLError creates a new
+// InvalidArchivalRequestURLError.
+func NewInvalidArchivalRequestURLError(
+	requestURL string,
+	causes ...error,
+) InvalidArchivalRequestURLError {
+	return InvalidArchivalRequestURLError{
+		RequestURL: requestURL,
+		CausedBy:   causes,
+	}
+}
+
+func (err InvalidArchivalRequestURLError) Error() string {
+	return fmt.Sprintf(
+		`"%s" is not a valid archival request URL.`,
+		err.RequestURL,
+	)
+}
+
+// Causes returns the error(s) that caused this error.
+func (err InvalidArchivalRequestURLError) Causes() []error {
+	return err.CausedBy
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err InvalidArchivalRequestURLError) PublicError() (int, string) {
+	return http.StatusBadRequest, err.Error()
+}
+
+/******************** UNSUPPORTED ARCHIVAL REQUEST METHOD *********************/
+
+// UnsupportedArchivalRequestMethodError is an error that occurs when an
+// archival request is made with an HTTP method other than GET or POST.
+type UnsupportedArchivalRequestMethodError struct {
+	Method string
+}
+
+// NewUnsupportedArchivalRequestMethodError creates a new
+// UnsupportedArchivalRequestMethodError.
+func NewUnsupportedArchivalRequestMethodError(
+	method string,
+) UnsupportedArchivalRequestMethodError {
+	return UnsupportedArchivalRequestMethodError{Method: method}
+}
+
+func (err UnsupportedArchivalRequestMethodError) Error() string {
+	return fmt.Sprintf(
+		`Archival requests do not support the "%s" method.`,
+		err.Method,
+	)
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err UnsupportedArchivalRequestMethodError) PublicError() (int, string) {
+	return http.StatusMethodNotAllowed, err.Error()
+}
+
+/**************************** NO SUCH ARCHIVAL JOB ****************************/
+
+// NoSuchArchivalJobError is an error that occurs when the archival status of a
+// package version that was never queued for archival is requested.
+type NoSuchArchivalJobError struct {
+	PackageAuthor string
+	PackageRepo   string
+	PackageSHA    string
+}
+
+// NewNoSuchArchivalJobError creates a new NoSuchArchivalJobError.
+func NewNoSuchArchivalJobError(
+	packageAuthor string,
+	packageRepo string,
+	packageSHA string,
+) NoSuchArchivalJobError {
+	return NoSuchArchivalJobError{
+		PackageAuthor: packageAuthor,
+		PackageRepo:   packageRepo,
+		PackageSHA:    packageSHA,
+	}
+}
+
+func (err NoSuchArchivalJobError) Error() string {
+	return fmt.Sprintf(
+		`"%s/%s@%s" has not been queued for archival.`,
+		err.PackageAuthor,
+		err.PackageRepo,
+		err.PackageSHA,
+	)
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err NoSuchArchivalJobError) PublicError() (int, string) {
+	return http.StatusNotFound, err.Error()
+}
+
+/************************** PACKAGE ARCHIVAL PENDING **************************/
+
+// PackageArchivalPendingError is an error that occurs when a package version
+// is requested before a worker has finished archiving it.
+type PackageArchivalPendingError struct {
+	PackageAuthor string
+	PackageRepo   string
+	PackageSHA    string
+}
+
+// NewPackageArchivalPendingError creates a new PackageArchivalPendingError.
+func NewPackageArchivalPendingError(
+	packageAuthor string,
+	packageRepo string,
+	packageSHA string,
+) PackageArchivalPendingError {
+	return PackageArchivalPendingError{
+		PackageAuthor: packageAuthor,
+		PackageRepo:   packageRepo,
+		PackageSHA:    packageSHA,
+	}
+}
+
+func (err PackageArchivalPendingError) Error() string {
+	return fmt.Sprintf(
+		`"%s/%s@%s" is still being archived. Please try again shortly.`,
+		err.PackageAuthor,
+		err.PackageRepo,
+		err.PackageSHA,
+	)
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err PackageArchivalPendingError) PublicError() (int, string) {
+	return http.StatusServiceUnavailable, err.Error()
+}
+
+/************************** PACKAGE ARCHIVAL FAILED ***************************/
+
+// PackageArchivalFailedError is an error that occurs when a package version
+// could not be archived after every attempt was spent.
+type PackageArchivalFailedError struct {
+	PackageAuthor string
+	PackageRepo   string
+	PackageSHA    string
+	Reason        string
+}
+
+// NewPackageArchivalFailedError creates a new PackageArchivalFailedError.
+func NewPackageArchivalFailedError(
+	packageAuthor string,
+	packageRepo string,
+	packageSHA string,
+	reason string,
+) PackageArchivalFailedError {
+	return PackageArchivalFailedError{
+		PackageAuthor: packageAuthor,
+		PackageRepo:   packageRepo,
+		PackageSHA:    packageSHA,
+		Reason:        reason,
+	}
+}
+
+func (err PackageArchivalFailedError) Error() string {
+	return fmt.Sprintf(
+		`Failed to archive "%s/%s@%s": %s`,
+		err.PackageAuthor,
+		err.PackageRepo,
+		err.PackageSHA,
+		err.Reason,
+	)
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err PackageArchivalFailedError) PublicError() (int, string) {
+	return http.StatusInternalServerError, fmt.Sprintf(
+		`Failed to archive "%s/%s@%s". Queue it for archival again to retry.`,
+		err.PackageAuthor,
+		err.PackageRepo,
+		err.PackageSHA,
+	)
+}
+
+// ArchivalLockLostError is an error that occurs when the archival lock of a
+// package version could not be renewed while it was being archived.
+type ArchivalLockLostError struct {
+	PackageAuthor string
+	PackageRepo   string
+	PackageSHA    string
+}
+
+// NewArchivalLockLostError creates a new ArchivalLockLostError.
+func NewArchivalLockLostError(
+	packageAuthor string,
+	packageRepo string,
+	packageSHA string,
+) ArchivalLockLostError {
+	return ArchivalLockLostError{
+		PackageAuthor: packageAuthor,
+		PackageRepo:   packageRepo,
+		PackageSHA:    packageSHA,
+	}
+}
+
+func (err ArchivalLockLostError) Error() string {
+	return fmt.Sprintf(
+		`Lost the archival lock of "%s/%s@%s" before archival was done`,
+		err.PackageAuthor,
+		err.PackageRepo,
+		err.PackageSHA,
+	)
+}
+
+// PublicError returns an outside-friendly error message, and a
+// corresponding status code.
+func (err ArchivalLockLostError) PublicError() (int, string) {
+	return http.StatusServiceUnavailable, fmt.Sprintf(
+		`Archival of "%s/%s@%s" was interrupted. Try again shortly.`,
+		err.PackageAuthor,
+		err.PackageRepo,
+		err.PackageSHA,
+	)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+// unknownCommitDate is the date that commits are assumed to have on hosts that
+// do not support commit lookups. It is fixed so that archives stay identical.
+var unknownCommitDate = time.Unix(0, 0).UTC()
+
+// fetchCommitDate fetches the date of a commit of a package from its host.
+func fetchCommitDate(
+	hosts vcs.Hosts,
+	author string,
+	repo string,
+	sha string,
+) (time.Time, error) {
+	host, bareAuthor, err := hosts.Of(author)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	commitDate, err := host.FetchCommitTimestamp(bareAuthor, repo, sha)
+	if err == vcs.ErrUnsupported {
+		return unknownCommitDate, nil
+	} else if err != nil {
+		return time.Time{}, err
+	}
+
+	return commitDate, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchCommitDate(t *testing.T) {
+	// Hosts that can't look commits up date them all the same.
+	hosts := vcs.NewHosts(vcs.NewGenericHost("example.com"))
+	commitDate, err := fetchCommitDate(hosts, "example.com:myauthor", "myrepo", "mysha")
+	assert.Nil(t, err)
+	assert.Equal(t, unknownCommitDate, commitDate)
+
+	// Hosts that aren't supported should not be asked at all.
+	_, err = fetchCommitDate(hosts, "example.org:myauthor", "myrepo", "mysha")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+// maxGoModFileSize is the size of the largest go.mod file that will be read.
+// The go tool refuses go.mod files that are any larger than this anyway.
+const maxGoModFileSize = 16 << 20
+
+// newGoModFetcher creates a goModFetcher that downloads go.mod files from the
+// hosts of packages using doHTTPGet.
+func newGoModFetcher(doHTTPGet httpGetter) goModFetcher {
+	return func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+		host, bareAuthor, err := hosts.Of(author)
+		if err != nil {
+			return nil, err
+		}
+
+		goModURL := host.RawFileURL(bareAuthor, repo, sha, goModFileName)
+		goModResp, err := doHTTPGet(goModURL)
+		if err != nil {
+			return nil, fmt.Errorf("Could not fetch go.mod %s: %v.", goModURL, err)
+		}
+
+		defer goModResp.Body.Close()
+
+		if goModResp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		} else if goModResp.StatusCode != 200 {
+			return nil, fmt.Errorf(
+				"Bumped into a status code %d while fetching go.mod %s.",
+				goModResp.StatusCode,
+				goModURL)
+		}
+
+		var buffer bytes.Buffer
+		if _, err = io.Copy(
+			&buffer,
+			io.LimitReader(goModResp.Body, maxGoModFileSize+1)); err != nil {
+			return nil, fmt.Errorf("Could not read go.mod %s: %v.", goModURL, err)
+		} else if buffer.Len() > maxGoModFileSize {
+			return nil, fmt.Errorf("go.mod %s is too large.", goModURL)
+		}
+
+		return buffer.Bytes(), nil
+	}
+}
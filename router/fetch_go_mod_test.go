@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchGoMod(t *testing.T) {
+	hosts := vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain))
+
+	// Hosts that aren't supported should not be reached.
+	_, err := newGoModFetcher(func(url string) (*http.Response, error) {
+		t.Fatalf("%s should not have been fetched", url)
+		return nil, nil
+	})(hosts, "git.example.com:a", "b", "sha")
+	assert.NotNil(t, err)
+
+	// The request fails.
+	_, err = newGoModFetcher(func(url string) (*http.Response, error) {
+		return nil, errors.New("this is an error")
+	})(hosts, "a", "b", "sha")
+	assert.NotNil(t, err)
+
+	// The host is having a bad day.
+	body := lib.NewMockHTTPResponseBody(nil)
+	_, err = newGoModFetcher(func(url string) (*http.Response, error) {
+		return &http.Response{StatusCode: 500, Body: body}, nil
+	})(hosts, "a", "b", "sha")
+	assert.NotNil(t, err)
+	assert.True(t, body.WasClosed())
+
+	// The go.mod doesn't exist.
+	body = lib.NewMockHTTPResponseBody(nil)
+	goMod, err := newGoModFetcher(func(url string) (*http.Response, error) {
+		return &http.Response{StatusCode: 404, Body: body}, nil
+	})(hosts, "a", "b", "sha")
+	assert.Nil(t, err)
+	assert.Nil(t, goMod)
+	assert.True(t, body.WasClosed())
+
+	// The go.mod exists.
+	body = lib.NewMockHTTPResponseBody([]byte("module github.com/a/b/v2\n"))
+	goMod, err = newGoModFetcher(func(url string) (*http.Response, error) {
+		assert.Equal(t, "https://github.com/a/b/raw/sha/go.mod", url)
+		return &http.Response{StatusCode: 200, Body: body}, nil
+	})(hosts, "a", "b", "sha")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("module github.com/a/b/v2\n"), goMod)
+	assert.True(t, body.WasClosed())
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+// maxUpstreamArchiveSize is the size of the largest upstream archive that will
+// be read. Module zips can't be any larger than this anyway.
+const maxUpstreamArchiveSize = 500 << 20
+
+// newUpstreamArchiveFetcher creates an upstreamArchiveFetcher that downloads
+// archives from the hosts of packages using doHTTPGet.
+func newUpstreamArchiveFetcher(doHTTPGet httpGetter) upstreamArchiveFetcher {
+	return func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+		host, bareAuthor, err := hosts.Of(author)
+		if err != nil {
+			return nil, err
+		}
+
+		zipURL := host.ArchiveURL(bareAuthor, repo, sha)
+		zipResp, err := doHTTPGet(zipURL)
+		if err != nil {
+			return nil, fmt.Errorf("Could not fetch archive %s: %v.", zipURL, err)
+		}
+
+		defer zipResp.Body.Close()
+
+		if zipResp.StatusCode != 200 {
+			return nil, fmt.Errorf(
+				"Bumped into a status code %d while fetching archive %s.",
+				zipResp.StatusCode,
+				zipURL)
+		}
+
+		var buffer bytes.Buffer
+		if _, err = io.Copy(
+			&buffer,
+			io.LimitReader(zipResp.Body, maxUpstreamArchiveSize+1)); err != nil {
+			return nil, fmt.Errorf("Could not read archive %s: %v.", zipURL, err)
+		} else if buffer.Len() > maxUpstreamArchiveSize {
+			return nil, fmt.Errorf("Archive %s is too large.", zipURL)
+		}
+
+		return buffer.Bytes(), nil
+	}
+}
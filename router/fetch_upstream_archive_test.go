@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/vcs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchUpstreamArchive(t *testing.T) {
+	var (
+		hosts   = vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain))
+		archive = fakeZipArchive(map[string]string{"b-sha/main.go": "package main"})
+	)
+
+	// Hosts that aren't supported should not be reached.
+	_, err := newUpstreamArchiveFetcher(func(url string) (*http.Response, error) {
+		t.Fatalf("%s should not have been fetched", url)
+		return nil, nil
+	})(hosts, "git.example.com:a", "b", "sha")
+	assert.NotNil(t, err)
+
+	// The request fails.
+	_, err = newUpstreamArchiveFetcher(func(url string) (*http.Response, error) {
+		return nil, errors.New("this is an error")
+	})(hosts, "a", "b", "sha")
+	assert.NotNil(t, err)
+
+	// The archive doesn't exist.
+	body := lib.NewMockHTTPResponseBody(nil)
+	_, err = newUpstreamArchiveFetcher(func(url string) (*http.Response, error) {
+		return &http.Response{StatusCode: 404, Body: body}, nil
+	})(hosts, "a", "b", "sha")
+	assert.NotNil(t, err)
+	assert.True(t, body.WasClosed())
+
+	// The archive exists.
+	body = lib.NewMockHTTPResponseBody(archive)
+	actualArchive, err := newUpstreamArchiveFetcher(func(url string) (*http.Response, error) {
+		assert.Equal(t, "https://github.com/a/b/archive/sha.zip", url)
+		return &http.Response{StatusCode: 200, Body: body}, nil
+	})(hosts, "a", "b", "sha")
+	assert.Nil(t, err)
+	assert.Equal(t, archive, actualArchive)
+	assert.True(t, body.WasClosed())
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// flight is a call of a flightGroup that is in flight.
+type flight struct {
+	wg    sync.WaitGroup
+	err   error
+	value interface{}
+}
+
+// flightGroup coalesces identical calls that are in flight at the same time:
+// callers that make a call with the same key as a call that is in flight wait
+// for that call to finish, and share its result instead of making their own.
+// The zero value is ready to use.
+type flightGroup struct {
+	lock    sync.Mutex
+	flights map[string]*flight
+}
+
+// do makes the call of fn unless a call with the same key is already in
+// flight, in which case it waits for the result of that call instead.
+func (g *flightGroup) do(
+	key string,
+	fn func() (interface{}, error),
+) (interface{}, error) {
+	g.lock.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	if f, exists := g.flights[key]; exists {
+		g.lock.Unlock()
+		f.wg.Wait()
+		return f.value, f.err
+	}
+
+	f := &flight{}
+	f.wg.Add(1)
+	g.flights[key] = f
+	g.lock.Unlock()
+
+	// Land the flight even if fn panics, so that waiters are not stuck. The
+	// waiters get an error instead of a result, and the panic carries on in
+	// the caller that made the call.
+	defer func() {
+		r := recover()
+		if r != nil {
+			f.value, f.err = nil, fmt.Errorf("Call %s panicked: %v", key, r)
+		}
+
+		g.lock.Lock()
+		delete(g.flights, key)
+		g.lock.Unlock()
+		f.wg.Done()
+
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	f.value, f.err = fn()
+	return f.value, f.err
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlightGroup(t *testing.T) {
+	var (
+		group   flightGroup
+		calls   int
+		release = make(chan struct{})
+		started = make(chan struct{})
+		joining = make(chan struct{}, 2)
+		results = make(chan interface{}, 3)
+		wg      sync.WaitGroup
+	)
+
+	// The first call stays in flight until it is released.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		value, err := group.do("a/b", func() (interface{}, error) {
+			calls++
+			close(started)
+			<-release
+			return "value", nil
+		})
+		assert.Nil(t, err)
+		results <- value
+	}()
+	<-started
+
+	// Calls with the same key wait for the first one.
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			joining <- struct{}{}
+			value, err := group.do("a/b", func() (interface{}, error) {
+				calls++
+				return "other value", nil
+			})
+			assert.Nil(t, err)
+			results <- value
+		}()
+	}
+
+	// Calls with other keys don't.
+	value, err := group.do("a/c", func() (interface{}, error) {
+		return nil, errors.New("this is an error")
+	})
+	assert.Nil(t, value)
+	assert.NotNil(t, err)
+
+	// Give the waiters time to join the flight before landing it.
+	<-joining
+	<-joining
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for value := range results {
+		assert.Equal(t, "value", value)
+	}
+	assert.Equal(t, 1, calls)
+	assert.Empty(t, group.flights)
+
+	// Landed flights aren't shared.
+	value, err = group.do("a/b", func() (interface{}, error) {
+		return "new value", nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "new value", value)
+}
+
+func TestFlightGroupPanic(t *testing.T) {
+	var (
+		group   flightGroup
+		started = make(chan struct{})
+		joined  = make(chan struct{})
+		errs    = make(chan error, 1)
+	)
+
+	// The waiter gets an error instead of getting stuck.
+	go func() {
+		<-started
+		close(joined)
+		_, err := group.do("a/b", func() (interface{}, error) {
+			return "value", nil
+		})
+		errs <- err
+	}()
+
+	// The panic carries on in the caller that made the call.
+	assert.Panics(t, func() {
+		group.do("a/b", func() (interface{}, error) {
+			close(started)
+			<-joined
+			time.Sleep(10 * time.Millisecond)
+			panic("this is a panic")
+		})
+	})
+
+	err := <-errs
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "this is a panic")
+	assert.Empty(t, group.flights)
+}
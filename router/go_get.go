@@ -11,14 +11,15 @@ const (
 <head>
 <meta name="go-import" content="%s git %s">
 <meta name="go-source" content="%s _ %s %s">
-</head>
+%s</head>
 <body>
 go get %s
 </body>
 </html>
 `
-	githubTreeURLTemplate = "https://github.com/%s/%s/tree/%s{/dir}"
-	depotBlobURLTemplate  = "https://%s/api/blob/%s/%s/%s{/dir}/{file}#L{line}"
+	gophrVersionMetaFormat = `<meta name="gophr-version" content="%s">
+`
+	depotBlobURLTemplate = "https://%s/api/blob/%s/%s/%s{/dir}/{file}#L{line}"
 )
 
 type generateGoGetMetadataArgs struct {
@@ -26,6 +27,8 @@ type generateGoGetMetadataArgs struct {
 	depotURL        string // e.g. "https://gophr.pm/depot/3abc4wxyz-97e17db9944a97a72765fcc18a237aaa0bb200a3.git"
 	treeURLTemplate string // e.g. "https://github.com/urfave/cli/tree/v1.18.1{/dir}"
 	blobURLTemplate string // e.g. "https://github.com/urfave/cli/blob/v1.18.1{/dir}/{file}#L{line}"
+	matchedSHA      string // e.g. "97e17db9944a97a72765fcc18a237aaa0bb200a3"
+	matchedSHALabel string // e.g. "1.1.0", "develop" or "2017-03-14T15:09:26Z"
 }
 
 // TODO(skeswa): write the formatter and comapre against gopkg.
@@ -46,20 +49,26 @@ func generateGophrURL(domain, author, repo, selector string) string {
 	return buffer.String()
 }
 
-// generateGithubTreeURLTemplate generates a github tree url.
-func generateGithubTreeURLTemplate(author, repo, ref string) string {
-	if len(ref) < 1 {
-		ref = "master"
-	}
-
-	return fmt.Sprintf(githubTreeURLTemplate, author, repo, ref)
-}
-
 // generateDepotBlobURLTemplate generates a depot blob url.
 func generateDepotBlobURLTemplate(domain, author, repo, sha string) string {
 	return fmt.Sprintf(depotBlobURLTemplate, domain, author, repo, sha)
 }
 
+// generateGophrVersionMeta generates the meta tag that reports the commit that
+// the selector resolved to, followed by its label if it has one.
+func generateGophrVersionMeta(matchedSHA, matchedSHALabel string) string {
+	if len(matchedSHA) < 1 {
+		return ""
+	}
+
+	content := matchedSHA
+	if len(matchedSHALabel) > 0 {
+		content = content + " " + matchedSHALabel
+	}
+
+	return fmt.Sprintf(gophrVersionMetaFormat, content)
+}
+
 // generateGoGetMetadata generates metadata in the format that go-get likes it.
 func generateGoGetMetadata(args generateGoGetMetadataArgs) string {
 	return fmt.Sprintf(
@@ -69,5 +78,6 @@ func generateGoGetMetadata(args generateGoGetMetadataArgs) string {
 		args.gophrURL,
 		args.treeURLTemplate,
 		args.blobURLTemplate,
+		generateGophrVersionMeta(args.matchedSHA, args.matchedSHALabel),
 		args.gophrURL)
 }
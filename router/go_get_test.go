@@ -11,11 +11,6 @@ func TestGenerateGophrURL(t *testing.T) {
 	assert.Equal(t, "a.b/c/d@e", generateGophrURL("a.b", "c", "d", "e"))
 }
 
-func TestGenerateGithubTreeURLTemplate(t *testing.T) {
-	assert.Equal(t, "https://github.com/a/b/tree/c{/dir}", generateGithubTreeURLTemplate("a", "b", "c"))
-	assert.Equal(t, "https://github.com/a/b/tree/master{/dir}", generateGithubTreeURLTemplate("a", "b", ""))
-}
-
 func TestGenerateDepotBlobURLTemplate(t *testing.T) {
 	assert.Equal(t, "https://a/api/blob/b/c/d{/dir}/{file}#L{line}", generateDepotBlobURLTemplate("a", "b", "c", "d"))
 	assert.Equal(t, "https://a/api/blob/b/c/{/dir}/{file}#L{line}", generateDepotBlobURLTemplate("a", "b", "c", ""))
@@ -49,3 +44,30 @@ go get a
 		treeURLTemplate: "b",
 		blobURLTemplate: "c"}))
 }
+
+func TestGenerateGoGetMetadata_version(t *testing.T) {
+	assert.Equal(t, `
+<html>
+<head>
+<meta name="go-import" content="a git d">
+<meta name="go-source" content="a _ b c">
+<meta name="gophr-version" content="e 2017-03-14T15:09:26Z">
+</head>
+<body>
+go get a
+</body>
+</html>
+`, generateGoGetMetadata(generateGoGetMetadataArgs{
+		gophrURL:        "a",
+		depotURL:        "d",
+		treeURLTemplate: "b",
+		blobURLTemplate: "c",
+		matchedSHA:      "e",
+		matchedSHALabel: "2017-03-14T15:09:26Z"}))
+
+	assert.Equal(
+		t,
+		"<meta name=\"gophr-version\" content=\"e\">\n",
+		generateGophrVersionMeta("e", ""))
+	assert.Equal(t, "", generateGophrVersionMeta("", "develop"))
+}
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"net/http"
+	"time"
 
 	"github.com/gophr-pm/gophr/lib"
 	"github.com/gophr-pm/gophr/lib/config"
 	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archival"
+	"github.com/gophr-pm/gophr/lib/db/model/package/refsnapshot"
 	"github.com/gophr-pm/gophr/lib/git"
 	"github.com/gophr-pm/gophr/lib/github"
 	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/vcs"
 	"github.com/gophr-pm/gophr/lib/verdeps"
 )
 
@@ -39,6 +44,7 @@ type packageArchivalRecorderArgs struct {
 	db     db.Queryable
 	sha    string
 	repo   string
+	hash   string
 	author string
 }
 
@@ -54,7 +60,7 @@ type packageArchivalCheckerArgs struct {
 	sha                   string
 	repo                  string
 	author                string
-	packageExistsInDepot  depotExistenceChecker
+	readDepotArchiveHash  depotArchiveHashReader
 	recordPackageArchival packageArchivalRecorder
 	isPackageArchivedInDB dbPackageArchivalChecker
 }
@@ -64,26 +70,128 @@ type packageArchivalCheckerArgs struct {
 // false otherwise.
 type packageArchivalChecker func(args packageArchivalCheckerArgs) (bool, error)
 
+// packageArchivalEnqueuerArgs is the arguments struct for
+// packageArchivalEnqueuers.
+type packageArchivalEnqueuerArgs struct {
+	sha      string
+	repo     string
+	author   string
+	selector string
+}
+
+// packageArchivalEnqueuer is responsible for queueing a package version for
+// archival. Returns the resulting archival job.
+type packageArchivalEnqueuer func(
+	args packageArchivalEnqueuerArgs) (*archival.Job, error)
+
+// packageArchivalAwaiterArgs is the arguments struct for
+// packageArchivalAwaiters.
+type packageArchivalAwaiterArgs struct {
+	sha     string
+	repo    string
+	author  string
+	timeout time.Duration
+}
+
+// packageArchivalAwaiter is responsible for waiting until the archival job of
+// a package version is done, or until the timeout elapses. Returns the latest
+// state of the archival job.
+type packageArchivalAwaiter func(
+	args packageArchivalAwaiterArgs) (*archival.Job, error)
+
+// archivalJobGetter fetches the archival job of a package version. Returns nil
+// if the package version was never queued for archival.
+type archivalJobGetter func(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string) (*archival.Job, error)
+
+// dbRefsSnapshotGetter gets the refs snapshot of a package from the database.
+// Returns nil if there is none.
+type dbRefsSnapshotGetter func(
+	q db.Queryable,
+	author string,
+	repo string) (*refsnapshot.Snapshot, error)
+
+// dbRefsSnapshotSaver saves the refs snapshot of a package in the database.
+type dbRefsSnapshotSaver func(
+	q db.Queryable,
+	author string,
+	repo string,
+	refs []byte) error
+
+// versionLabelPinnerArgs is the arguments struct for versionLabelPinners.
+type versionLabelPinnerArgs struct {
+	db                  db.Queryable
+	sha                 string
+	refs                lib.Refs
+	repo                string
+	label               string
+	author              string
+	pinLabelInDB        dbVersionLabelPinner
+	getPinnedLabels     dbPinnedVersionLabelsGetter
+	recordLabelMutation dbVersionLabelMutationRecorder
+}
+
+// versionLabelPinner pins a version label of a package to the SHA that it
+// resolved to. Returns the SHA that the label is pinned to, which is what the
+// label should resolve to.
+type versionLabelPinner func(args versionLabelPinnerArgs) (string, error)
+
+// dbVersionLabelPinner pins a version label of a package to a SHA in the
+// database unless it was pinned before. Returns the SHA that the label is
+// pinned to.
+type dbVersionLabelPinner func(
+	q db.Queryable,
+	author string,
+	repo string,
+	label string,
+	sha string) (string, error)
+
+// dbPinnedVersionLabelsGetter gets every pinned version label of a package
+// from the database, mapped to the SHA that it is pinned to.
+type dbPinnedVersionLabelsGetter func(
+	q db.Queryable,
+	author string,
+	repo string) (map[string]string, error)
+
+// dbVersionLabelMutationRecorder records that a pinned version label of a
+// package was moved upstream. Returns true if the mutation had not been
+// recorded before.
+type dbVersionLabelMutationRecorder func(
+	q db.Queryable,
+	author string,
+	repo string,
+	label string,
+	sha string,
+	upstreamSHA string) (bool, error)
+
 // packageVersionerArgs is the arguments struct for packageVersioners.
 type packageVersionerArgs struct {
-	io                         io.IO
-	db                         db.Queryable
-	sha                        string
-	repo                       string
-	conf                       *config.Config
-	creds                      *config.Credentials
-	ghSvc                      github.RequestService
-	author                     string
-	pushToDepot                packagePusher
-	versionDeps                depsVersioner
-	createDepotRepo            depotRepoCreator
-	downloadPackage            packageDownloader
-	destroyDepotRepo           depotRepoDestroyer
-	isPackageArchived          packageArchivalChecker
-	constructionZonePath       string
-	recordPackageArchival      packageArchivalRecorder
-	attemptWorkDirDeletion     workDirDeletionAttempter
-	archiveExistenceCheckDelay int
+	io                     io.IO
+	db                     db.BatchingQueryable
+	sha                    string
+	repo                   string
+	conf                   *config.Config
+	creds                  *config.Credentials
+	ghSvc                  github.RequestService
+	hosts                  vcs.Hosts
+	author                 string
+	signingKey             ed25519.PrivateKey
+	hashArchive            archiveHasher
+	pushToDepot            packagePusher
+	downloadRefs           refsDownloader
+	lockArchival           packageArchivalLocker
+	versionDeps            depsVersioner
+	createDepotRepo        depotRepoCreator
+	downloadPackage        packageDownloader
+	destroyDepotRepo       depotRepoDestroyer
+	fetchCommitDate        commitDateFetcher
+	isPackageArchived      packageArchivalChecker
+	constructionZonePath   string
+	recordPackageArchival  packageArchivalRecorder
+	attemptWorkDirDeletion workDirDeletionAttempter
 }
 
 // packageVersioner is responsible for versioning a downloaded package.
@@ -92,12 +200,14 @@ type packageVersioner func(args packageVersionerArgs) error
 // packageDownloaderArgs is the arguments struct for packageDownloader.
 type packageDownloaderArgs struct {
 	io                   io.IO
+	hosts                vcs.Hosts
 	author               string
 	repo                 string
 	sha                  string
 	doHTTPGet            httpGetter
 	unzipArchive         archiveUnzipper
 	deleteWorkDir        workDirDeletionAttempter
+	linkMajorVersionDir  majorVersionDirLinker
 	constructionZonePath string
 }
 
@@ -116,7 +226,11 @@ type packagePusherArgs struct {
 	author       string
 	repo         string
 	sha          string
+	hash         string
+	branch       string
 	creds        *config.Credentials
+	signingKey   ed25519.PrivateKey
+	commitDate   time.Time
 	packagePaths packageDownloadPaths
 	gitClient    git.Client
 }
@@ -139,21 +253,53 @@ type packagePusher func(args packagePusherArgs) error
 // depsVersioner is responsible for versioning the dependencies in a package.
 type depsVersioner func(args verdeps.VersionDepsArgs) error
 
+// majorVersionDirLinker links the directory of the major version that a
+// package declares in its go.mod to the root of the package if need be.
+type majorVersionDirLinker func(io io.IO, archiveDirPath string) error
+
+// archiveHasher computes the tree hash of an archive (see depot.HashArchive).
+type archiveHasher func(io io.IO, archiveDirPath string) (string, error)
+
+// commitDateFetcher fetches the date of a commit of a package.
+type commitDateFetcher func(
+	hosts vcs.Hosts,
+	author string,
+	repo string,
+	sha string) (time.Time, error)
+
 // archiveUnzipper unzips a zip archive.
 type archiveUnzipper func(archive, target string) error
 
 // depotRepoCreator creates a repository in depot in accordance to the author,
-// repo and sha specified. Returns true if the repo was created by this func.,
-// or returns false is the the directory already existed.
-type depotRepoCreator func(author, repo, sha string) (bool, error)
+// repo and sha specified, whose HEAD points to the specified branch. Returns
+// true if the repo was created by this func., or returns false is the the
+// directory already existed.
+type depotRepoCreator func(author, repo, sha, branch string) (bool, error)
 
 // depotRepoDestroyer destroys a repository in depot according to the author,
 // repo and sha.
 type depotRepoDestroyer func(author, repo, sha string) error
 
-// depotExistenceChecker checks if a package matching author, repo and sha
-// exists in depot.
-type depotExistenceChecker func(author, repo, sha string) (bool, error)
+// upstreamArchiveFetcher fetches the zip archive of the package matching
+// author, repo and sha from its host, exactly as it was committed.
+type upstreamArchiveFetcher func(
+	hosts vcs.Hosts,
+	author string,
+	repo string,
+	sha string) ([]byte, error)
+
+// goModFetcher fetches the go.mod file at the root of the package matching
+// author, repo and sha from its host. Returns nil if there is no such file.
+type goModFetcher func(
+	hosts vcs.Hosts,
+	author string,
+	repo string,
+	sha string) ([]byte, error)
+
+// depotArchiveHashReader reads the archive hash of the package matching author,
+// repo and sha from depot. Returns false if the package was never pushed to
+// depot.
+type depotArchiveHashReader func(author, repo, sha string) (string, bool, error)
 
 // workDirDeletionAttempter attempts to delete a working directory. If it fails,
 // instead of returning the error, it logs the problem and moves on. Functions
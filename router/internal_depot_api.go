@@ -4,13 +4,20 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
-const internalDepotAPIDomain = "depot-int-svc"
+const (
+	internalDepotAPIDomain = "depot-int-svc"
+	// depotBranchQueryParam is the query parameter that tells depot which branch
+	// the HEAD of a new repo should point to.
+	depotBranchQueryParam = "branch"
+)
 
-// packageExistsInDepot will return true if a package matching author, repo and
-// sha exists in depot.
-func packageExistsInDepot(author, repo, sha string) (bool, error) {
+// readDepotArchiveHash returns the archive hash of the package matching
+// author, repo and sha in depot. Returns false if the package was never pushed
+// to depot, even if its repo was created.
+func readDepotArchiveHash(author, repo, sha string) (string, bool, error) {
 	req, err := http.NewRequest(
 		"GET",
 		fmt.Sprintf(
@@ -21,40 +28,48 @@ func packageExistsInDepot(author, repo, sha string) (bool, error) {
 			sha),
 		nil)
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 
 	client := &http.Client{}
 	res, err := client.Do(req)
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 
 	defer res.Body.Close()
 	switch res.StatusCode {
 	case http.StatusOK:
-		return true, nil
+		hashBuffer := bytes.Buffer{}
+		if _, err = hashBuffer.ReadFrom(res.Body); err != nil {
+			return "", false, err
+		}
+
+		return hashBuffer.String(), true, nil
 	case http.StatusNotFound:
-		return false, nil
+		return "", false, nil
 	default:
 		errBuffer := bytes.Buffer{}
 		errBuffer.ReadFrom(res.Body)
-		return false, fmt.Errorf("Could not create repo in depot: %s.", errBuffer.String())
+		return "", false, fmt.Errorf("Could not check repo in depot: %s.", errBuffer.String())
 	}
 }
 
 // createRepoInDepot creates a package repo in depot matching the author, repo &
-// sha specified. Returns true if the repo was created by this func., or returns
-// false is the the directory already existed.
-func createRepoInDepot(author, repo, sha string) (bool, error) {
+// sha specified, whose HEAD points to branch. Returns true if the repo was
+// created by this func., or returns false is the the directory already
+// existed.
+func createRepoInDepot(author, repo, sha, branch string) (bool, error) {
 	req, err := http.NewRequest(
 		"POST",
 		fmt.Sprintf(
-			"http://%s/api/repos/%s/%s/%s",
+			"http://%s/api/repos/%s/%s/%s?%s=%s",
 			internalDepotAPIDomain,
 			author,
 			repo,
-			sha),
+			sha,
+			depotBranchQueryParam,
+			url.QueryEscape(branch)),
 		nil)
 	if err != nil {
 		return false, err
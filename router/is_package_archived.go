@@ -16,8 +16,12 @@ func isPackageArchived(args packageArchivalCheckerArgs) (bool, error) {
 		return true, nil
 	}
 
-	// Check if this package version is in depot already.
-	archivedInDepot, err := args.packageExistsInDepot(args.author, args.repo, args.sha)
+	// Check if this package version was pushed to depot already. Depot repos
+	// that were created but never pushed to don't count.
+	hash, archivedInDepot, err := args.readDepotArchiveHash(
+		args.author,
+		args.repo,
+		args.sha)
 	if err != nil {
 		return false, err
 	}
@@ -33,6 +37,7 @@ func isPackageArchived(args packageArchivalCheckerArgs) (bool, error) {
 		db:     args.db,
 		sha:    args.sha,
 		repo:   args.repo,
+		hash:   hash,
 		author: args.author,
 	})
 
@@ -50,11 +50,11 @@ func TestIsPackageArchived(t *testing.T) {
 			assert.Equal(t, client, q)
 			return false, nil
 		},
-		packageExistsInDepot: func(author, repo, sha string) (bool, error) {
+		readDepotArchiveHash: func(author, repo, sha string) (string, bool, error) {
 			assert.Equal(t, "myauthor", author)
 			assert.Equal(t, "myrepo", repo)
 			assert.Equal(t, "mysha", sha)
-			return false, errors.New("this a big scary error")
+			return "", false, errors.New("this a big scary error")
 		},
 	}
 	archived, err = isPackageArchived(args)
@@ -73,11 +73,11 @@ func TestIsPackageArchived(t *testing.T) {
 			assert.Equal(t, client, q)
 			return false, nil
 		},
-		packageExistsInDepot: func(author, repo, sha string) (bool, error) {
+		readDepotArchiveHash: func(author, repo, sha string) (string, bool, error) {
 			assert.Equal(t, "myauthor", author)
 			assert.Equal(t, "myrepo", repo)
 			assert.Equal(t, "mysha", sha)
-			return false, nil
+			return "", false, nil
 		},
 	}
 	archived, err = isPackageArchived(args)
@@ -98,16 +98,17 @@ func TestIsPackageArchived(t *testing.T) {
 			assert.Equal(t, client, q)
 			return false, nil
 		},
-		packageExistsInDepot: func(author, repo, sha string) (bool, error) {
+		readDepotArchiveHash: func(author, repo, sha string) (string, bool, error) {
 			assert.Equal(t, "myauthor", author)
 			assert.Equal(t, "myrepo", repo)
 			assert.Equal(t, "mysha", sha)
-			return true, nil
+			return "myhash", true, nil
 		},
 		recordPackageArchival: func(args packageArchivalRecorderArgs) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
 			assert.Equal(t, "mysha", args.sha)
+			assert.Equal(t, "myhash", args.hash)
 			assert.Equal(t, client, args.db)
 			wg.Done()
 		},
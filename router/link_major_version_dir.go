@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/gophr-pm/gophr/lib/io"
+)
+
+const (
+	majorVersionDirLinkTarget = "."
+)
+
+var (
+	// modulePathMajorVersionRegex matches go module paths that end with a major
+	// version suffix (e.g. "github.com/a/b/v3"). The only capture group is the
+	// last path element (e.g. "v3").
+	modulePathMajorVersionRegex = regexp.MustCompile(`/(v(?:[2-9]|[1-9][0-9]+))$`)
+)
+
+// linkMajorVersionDir preserves the meaning of module-style imports of major
+// versions (e.g. "github.com/a/b/v3/c") in archives of packages that keep each
+// major version on its own branch. Such packages declare the major version in
+// the module path of their go.mod without a directory to match, so the
+// directory is linked to the root of the archive. That way, imports of the
+// major version resolve the same in depot as they do for go modules.
+func linkMajorVersionDir(io io.IO, archiveDirPath string) error {
+	goMod, err := io.ReadFile(filepath.Join(archiveDirPath, goModFileName))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	match := modulePathMajorVersionRegex.FindStringSubmatch(
+		readModulePath(goMod))
+	if match == nil {
+		return nil
+	}
+
+	// Packages that keep major versions in directories need no help.
+	majorVersionDirPath := filepath.Join(archiveDirPath, match[1])
+	if _, err = io.Stat(majorVersionDirPath); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return io.Symlink(majorVersionDirLinkTarget, majorVersionDirPath)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkMajorVersionDir(t *testing.T) {
+	var (
+		goModPath = filepath.Join("archive", goModFileName)
+		v3DirPath = filepath.Join("archive", "v3")
+		notExist  = &os.PathError{Op: "open", Err: os.ErrNotExist}
+	)
+
+	// Packages without go.mod files are left alone.
+	mockIO := io.NewMockIO()
+	mockIO.On("ReadFile", goModPath).Return([]byte(nil), notExist)
+	assert.Nil(t, linkMajorVersionDir(mockIO, "archive"))
+	mockIO.AssertExpectations(t)
+
+	// So are packages without major version suffixes.
+	mockIO = io.NewMockIO()
+	mockIO.On("ReadFile", goModPath).Return([]byte("module github.com/a/b\n"), nil)
+	assert.Nil(t, linkMajorVersionDir(mockIO, "archive"))
+	mockIO.AssertExpectations(t)
+
+	// Packages that keep major versions in directories already have them.
+	mockIO = io.NewMockIO()
+	mockIO.On("ReadFile", goModPath).Return([]byte("module github.com/a/b/v3\n"), nil)
+	mockIO.On("Stat", v3DirPath).Return(io.NewFakeFileInfo("v3", 0, true), nil)
+	assert.Nil(t, linkMajorVersionDir(mockIO, "archive"))
+	mockIO.AssertExpectations(t)
+
+	// Packages that keep major versions on branches get a link to the root.
+	mockIO = io.NewMockIO()
+	mockIO.On("ReadFile", goModPath).Return([]byte("// The module.\nmodule \"github.com/a/b/v3\"\n\nrequire github.com/c/d v1.0.0\n"), nil)
+	mockIO.On("Stat", v3DirPath).Return(io.FakeFileInfo{}, notExist)
+	mockIO.On("Symlink", ".", v3DirPath).Return(nil)
+	assert.Nil(t, linkMajorVersionDir(mockIO, "archive"))
+	mockIO.AssertExpectations(t)
+
+	// Read failures fail.
+	mockIO = io.NewMockIO()
+	mockIO.On("ReadFile", goModPath).Return([]byte(nil), errors.New("this is an error"))
+	assert.NotNil(t, linkMajorVersionDir(mockIO, "archive"))
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archive"
+)
+
+const (
+	// archiveLockTTL is how long an archival lock lasts without being renewed.
+	// It is what allows the locks of crashed routers to be taken over.
+	archiveLockTTL = 60 * time.Second
+	// archiveLockFailureTTL is how long the failure left behind by a failed
+	// archival stays readable by waiters.
+	archiveLockFailureTTL = 5 * time.Minute
+	// defaultArchiveLockHeartbeatInterval is how often a held archival lock is
+	// renewed.
+	defaultArchiveLockHeartbeatInterval = 20 * time.Second
+	// defaultArchiveLockPollInterval is how often the archival lock is checked
+	// while somebody else holds it.
+	defaultArchiveLockPollInterval = 1 * time.Second
+)
+
+// archiveLockAcquirer attempts to acquire the archival lock of a package
+// version. Returns true if it was acquired.
+type archiveLockAcquirer func(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	owner string,
+	ttl time.Duration) (bool, error)
+
+// archiveLockRenewer extends the lease of a held archival lock. Returns false
+// if the lock is no longer held by the owner.
+type archiveLockRenewer func(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	owner string,
+	ttl time.Duration) (bool, error)
+
+// archiveLockReleaser releases an archival lock after a successful archival.
+type archiveLockReleaser func(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	owner string) error
+
+// archiveLockFailureReleaser releases an archival lock after a failed
+// archival.
+type archiveLockFailureReleaser func(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string,
+	owner string,
+	failure string,
+	ttl time.Duration) error
+
+// archiveLockGetter reads the archival lock of a package version.
+type archiveLockGetter func(
+	q db.Queryable,
+	author string,
+	repo string,
+	sha string) (*archives.Lock, error)
+
+// packageArchivalLockerArgs is the arguments struct for
+// packageArchivalLockers.
+type packageArchivalLockerArgs struct {
+	db     db.Queryable
+	sha    string
+	repo   string
+	author string
+}
+
+// packageArchivalUnlocker releases a held archival lock. If cause is not nil,
+// waiters are told that archival failed because of it.
+type packageArchivalUnlocker func(cause error)
+
+// packageArchivalLocker is responsible for making sure that a package version
+// is only archived in one place at a time. It blocks until the archival lock
+// is acquired, and returns the function that releases it, along with a
+// channel that is closed if the lock is lost before then.
+type packageArchivalLocker func(
+	args packageArchivalLockerArgs) (packageArchivalUnlocker, <-chan struct{}, error)
+
+// lockPackageArchivalArgs is the arguments struct for lockPackageArchival.
+type lockPackageArchivalArgs struct {
+	db                     db.Queryable
+	sha                    string
+	repo                   string
+	owner                  string
+	author                 string
+	lockTTL                time.Duration
+	getLock                archiveLockGetter
+	renewLock              archiveLockRenewer
+	acquireLock            archiveLockAcquirer
+	releaseLock            archiveLockReleaser
+	pollInterval           time.Duration
+	heartbeatInterval      time.Duration
+	releaseLockWithFailure archiveLockFailureReleaser
+}
+
+// archiveLockOwnerCount makes the owners created by this process unique.
+var archiveLockOwnerCount uint64
+
+// newArchiveLockOwner creates a name that identifies this process (and this
+// particular archival) as the owner of an archival lock.
+func newArchiveLockOwner() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf(
+		"%s:%d:%d",
+		hostname,
+		os.Getpid(),
+		atomic.AddUint64(&archiveLockOwnerCount, 1))
+}
+
+// lockPackageArchivalInDB is the packageArchivalLocker backed by the archival
+// locks in the database.
+func lockPackageArchivalInDB(
+	args packageArchivalLockerArgs,
+) (packageArchivalUnlocker, <-chan struct{}, error) {
+	return lockPackageArchival(lockPackageArchivalArgs{
+		db:                     args.db,
+		sha:                    args.sha,
+		repo:                   args.repo,
+		owner:                  newArchiveLockOwner(),
+		author:                 args.author,
+		lockTTL:                archiveLockTTL,
+		getLock:                archives.GetLock,
+		renewLock:              archives.RenewLock,
+		acquireLock:            archives.AcquireLock,
+		releaseLock:            archives.ReleaseLock,
+		pollInterval:           defaultArchiveLockPollInterval,
+		heartbeatInterval:      defaultArchiveLockHeartbeatInterval,
+		releaseLockWithFailure: archives.ReleaseLockWithFailure,
+	})
+}
+
+// lockPackageArchival acquires the archival lock of a package version. If
+// somebody else holds it, this waits until they let go of it. If they let go
+// of it because archival failed, a PackageArchivalFailedError is returned.
+// While the lock is held, it is renewed in the background. If it is taken over,
+// or if it can't be renewed before its lease runs out, the returned channel is
+// closed so that the archival can be called off before somebody else takes
+// the lock over.
+func lockPackageArchival(
+	args lockPackageArchivalArgs,
+) (packageArchivalUnlocker, <-chan struct{}, error) {
+	var (
+		waited     = false
+		acquiredAt time.Time
+	)
+	for {
+		acquiredAt = time.Now()
+		acquired, err := args.acquireLock(
+			args.db,
+			args.author,
+			args.repo,
+			args.sha,
+			args.owner,
+			args.lockTTL)
+		if err != nil {
+			return nil, nil, err
+		} else if acquired {
+			break
+		}
+
+		lock, err := args.getLock(args.db, args.author, args.repo, args.sha)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Only report failures that happened while waiting. Older failures are
+		// exactly what the caller is trying to recover from.
+		if lock != nil && !lock.IsHeld() && len(lock.Failure) > 0 && waited {
+			return nil, nil, NewPackageArchivalFailedError(
+				args.author,
+				args.repo,
+				args.sha,
+				lock.Failure)
+		}
+
+		// Somebody else is archiving this package version, or beat us to the
+		// lock, so wait for them.
+		if lock != nil && lock.IsHeld() {
+			waited = true
+		}
+		time.Sleep(args.pollInterval)
+	}
+
+	// Keep the lock alive until it is released. If it is taken over, or if the
+	// lease could run out before the next renewal, there is no telling whether
+	// the lease outlives the archival, so the archival has to be called off.
+	var (
+		lost          = make(chan struct{})
+		stopHeartbeat = make(chan struct{})
+	)
+	go func() {
+		var (
+			ticker      = time.NewTicker(args.heartbeatInterval)
+			leaseExpiry = acquiredAt.Add(args.lockTTL)
+		)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopHeartbeat:
+				return
+			case <-ticker.C:
+				renewedAt := time.Now()
+				renewed, err := args.renewLock(
+					args.db,
+					args.author,
+					args.repo,
+					args.sha,
+					args.owner,
+					args.lockTTL)
+				if err == nil && renewed {
+					leaseExpiry = renewedAt.Add(args.lockTTL)
+					continue
+				}
+
+				// Failing to reach the database doesn't mean that the lock is gone,
+				// so keep trying for as long as the lease is sure to last until the
+				// next renewal.
+				if err != nil {
+					log.Println(err)
+					if time.Now().Add(args.heartbeatInterval).Before(leaseExpiry) {
+						continue
+					}
+				}
+
+				log.Printf(
+					"Lost the archival lock of %s/%s@%s.\n",
+					args.author,
+					args.repo,
+					args.sha)
+				close(lost)
+				return
+			}
+		}
+	}()
+
+	return func(cause error) {
+		close(stopHeartbeat)
+
+		var err error
+		if cause == nil {
+			err = args.releaseLock(
+				args.db,
+				args.author,
+				args.repo,
+				args.sha,
+				args.owner)
+		} else {
+			err = args.releaseLockWithFailure(
+				args.db,
+				args.author,
+				args.repo,
+				args.sha,
+				args.owner,
+				cause.Error(),
+				archiveLockFailureTTL)
+		}
+
+		if err != nil {
+			log.Println(err)
+		}
+	}, lost, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockPackageArchival(t *testing.T) {
+	var (
+		releases        = make(chan string, 1)
+		renewals        = make(chan string, 10)
+		failedReleases  = make(chan string, 1)
+		fakeLockArgsFor = func(
+			acquire archiveLockAcquirer,
+			get archiveLockGetter,
+		) lockPackageArchivalArgs {
+			return lockPackageArchivalArgs{
+				sha:         "c",
+				repo:        "b",
+				owner:       "me",
+				author:      "a",
+				lockTTL:     archiveLockTTL,
+				getLock:     get,
+				acquireLock: acquire,
+				renewLock: func(q db.Queryable, author, repo, sha, owner string, ttl time.Duration) (bool, error) {
+					select {
+					case renewals <- owner:
+					default:
+					}
+					return true, nil
+				},
+				releaseLock: func(q db.Queryable, author, repo, sha, owner string) error {
+					releases <- owner
+					return nil
+				},
+				pollInterval:      time.Millisecond,
+				heartbeatInterval: time.Millisecond,
+				releaseLockWithFailure: func(q db.Queryable, author, repo, sha, owner, failure string, ttl time.Duration) error {
+					assert.Equal(t, archiveLockFailureTTL, ttl)
+					failedReleases <- failure
+					return nil
+				},
+			}
+		}
+		acquireOnAttempt = func(attempt int) archiveLockAcquirer {
+			attempts := 0
+			return func(q db.Queryable, author, repo, sha, owner string, ttl time.Duration) (bool, error) {
+				assert.Equal(t, "a", author)
+				assert.Equal(t, "b", repo)
+				assert.Equal(t, "c", sha)
+				assert.Equal(t, "me", owner)
+				assert.Equal(t, archiveLockTTL, ttl)
+				attempts++
+				return attempts >= attempt, nil
+			}
+		}
+		fakeLockGetter = func(locks ...*archives.Lock) archiveLockGetter {
+			return func(q db.Queryable, author, repo, sha string) (*archives.Lock, error) {
+				lock := locks[0]
+				if len(locks) > 1 {
+					locks = locks[1:]
+				}
+				return lock, nil
+			}
+		}
+	)
+
+	// Acquiring fails.
+	_, _, err := lockPackageArchival(fakeLockArgsFor(
+		func(q db.Queryable, author, repo, sha, owner string, ttl time.Duration) (bool, error) {
+			return false, errors.New("this is an error")
+		},
+		nil))
+	assert.NotNil(t, err)
+
+	// Reading the lock fails.
+	_, _, err = lockPackageArchival(fakeLockArgsFor(
+		acquireOnAttempt(2),
+		func(q db.Queryable, author, repo, sha string) (*archives.Lock, error) {
+			return nil, errors.New("this is an error")
+		}))
+	assert.NotNil(t, err)
+
+	// Acquired right away, renewed, and released successfully.
+	unlock, lost, err := lockPackageArchival(fakeLockArgsFor(acquireOnAttempt(1), nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "me", <-renewals)
+	assert.NotNil(t, lost)
+	unlock(nil)
+	assert.Equal(t, "me", <-releases)
+
+	// Acquired after the previous owner is done, and released with a failure.
+	unlock, _, err = lockPackageArchival(fakeLockArgsFor(
+		acquireOnAttempt(3),
+		fakeLockGetter(&archives.Lock{Owner: "them"}, nil)))
+	assert.Nil(t, err)
+	unlock(errors.New("oops"))
+	assert.Equal(t, "oops", <-failedReleases)
+
+	// An old failure does not stop the lock from being taken over.
+	unlock, _, err = lockPackageArchival(fakeLockArgsFor(
+		acquireOnAttempt(2),
+		fakeLockGetter(&archives.Lock{Failure: "old"})))
+	assert.Nil(t, err)
+	unlock(nil)
+	assert.Equal(t, "me", <-releases)
+
+	// The previous owner fails while we wait.
+	_, _, err = lockPackageArchival(fakeLockArgsFor(
+		acquireOnAttempt(10),
+		fakeLockGetter(&archives.Lock{Owner: "them"}, &archives.Lock{Failure: "oops"})))
+	assert.Equal(t, NewPackageArchivalFailedError("a", "b", "c", "oops"), err)
+
+	// The lock being taken over calls off the archival right away.
+	renewalAttempts := 0
+	args := fakeLockArgsFor(acquireOnAttempt(1), nil)
+	args.renewLock = func(q db.Queryable, author, repo, sha, owner string, ttl time.Duration) (bool, error) {
+		renewalAttempts++
+		return false, nil
+	}
+	unlock, lost, err = lockPackageArchival(args)
+	assert.Nil(t, err)
+	<-lost
+	unlock(nil)
+	assert.Equal(t, "me", <-releases)
+	assert.Equal(t, 1, renewalAttempts)
+
+	// Failing to renew the lock only calls off the archival once the lease
+	// could run out.
+	renewalAttempts = 0
+	args = fakeLockArgsFor(
+		func(q db.Queryable, author, repo, sha, owner string, ttl time.Duration) (bool, error) {
+			assert.Equal(t, 20*time.Millisecond, ttl)
+			return true, nil
+		},
+		nil)
+	args.lockTTL = 20 * time.Millisecond
+	args.renewLock = func(q db.Queryable, author, repo, sha, owner string, ttl time.Duration) (bool, error) {
+		assert.Equal(t, 20*time.Millisecond, ttl)
+		renewalAttempts++
+		return false, errors.New("this is an error")
+	}
+	unlock, lost, err = lockPackageArchival(args)
+	assert.Nil(t, err)
+	<-lost
+	unlock(nil)
+	assert.Equal(t, "me", <-releases)
+	assert.True(t, renewalAttempts > 1, "renewal should have been retried")
+
+	// The lock survives renewals that fail for a while.
+	renewed := make(chan struct{})
+	renewalAttempts = 0
+	args = fakeLockArgsFor(acquireOnAttempt(1), nil)
+	args.renewLock = func(q db.Queryable, author, repo, sha, owner string, ttl time.Duration) (bool, error) {
+		renewalAttempts++
+		if renewalAttempts <= 3 {
+			return false, errors.New("this is an error")
+		} else if renewalAttempts == 4 {
+			close(renewed)
+		}
+		return true, nil
+	}
+	unlock, lost, err = lockPackageArchival(args)
+	assert.Nil(t, err)
+	<-renewed
+	select {
+	case <-lost:
+		assert.Fail(t, "the lock should not have been lost")
+	default:
+	}
+	unlock(nil)
+	assert.Equal(t, "me", <-releases)
+}
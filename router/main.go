@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"fmt"
 	"log"
 	"net/http"
@@ -8,8 +9,11 @@ import (
 	"github.com/gophr-pm/gophr/lib"
 	"github.com/gophr-pm/gophr/lib/config"
 	"github.com/gophr-pm/gophr/lib/datadog"
+	"github.com/gophr-pm/gophr/lib/db/model/package/refsnapshot"
+	"github.com/gophr-pm/gophr/lib/depot"
 	"github.com/gophr-pm/gophr/lib/github"
 	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/vcs"
 )
 
 func main() {
@@ -25,6 +29,19 @@ func main() {
 		log.Fatalln("Failed to read credentials secret:", err)
 	}
 
+	// Read the key that depot commits are signed with. Without one, depot
+	// commits are left unsigned.
+	var signingKey ed25519.PrivateKey
+	depotSigningKey, err := config.ReadDepotSigningKey(conf)
+	if err != nil {
+		log.Fatalln("Failed to read depot signing key secret:", err)
+	} else if depotSigningKey == nil {
+		log.Println("No depot signing key secret found; depot commits will not be signed.")
+	} else if signingKey, err = depot.ParseSigningKey(
+		depotSigningKey.PrivateKey); err != nil {
+		log.Fatalln("Failed to read depot signing key secret:", err)
+	}
+
 	// Initialize datadog client.
 	ddClient, err := datadog.NewClient(conf, "router.")
 	if err != nil {
@@ -43,15 +60,56 @@ func main() {
 		log.Fatalln("Failed to create Github API request service:", err)
 	}
 
-	// Instantiate the IO module for use in package downloading and versioning.
-	io := io.NewIO()
+	// Plain git hosts are only reached if they were configured explicitly.
+	genericHosts, err := vcs.NewGenericHosts(conf.GenericHosts)
+	if err != nil {
+		log.Fatalln("Failed to configure the generic package hosts:", err)
+	}
+
+	// Every package host goes through the same Github request service.
+	hosts := github.NewHosts(ghSvc, genericHosts...)
+
+	// Refs are served from snapshots in the database whenever possible.
+	// Identical downloads that are in flight at the same time are coalesced,
+	// both on the way to the snapshots and on the way to the package hosts.
+	downloadRefs := newCoalescingRefsDownloader(
+		newSnapshottingRefsDownloader(snapshottingRefsDownloaderArgs{
+			db:           client,
+			fetchRefs:    newCoalescingRefsDownloader(lib.FetchRefs),
+			getSnapshot:  refsnapshot.Get,
+			saveSnapshot: refsnapshot.Save,
+		}))
+
+	// Archival checks are coalesced, and remembered once they come back
+	// positive.
+	checkArchival := newCachingPackageArchivalChecker(
+		isPackageArchived,
+		archivedPackageCacheCapacity)
+
+	// Start archiving packages in the background.
+	queue := newArchivalQueue(archivalQueueArgs{
+		io:                    io.NewIO(),
+		db:                    client,
+		conf:                  conf,
+		creds:                 creds,
+		ghSvc:                 ghSvc,
+		hosts:                 hosts,
+		signingKey:            signingKey,
+		downloadRefs:          downloadRefs,
+		versionPackage:        versionAndArchivePackage,
+		isPackageArchived:     checkArchival,
+		recordPackageArchival: recordPackageArchival,
+	})
+	queue.start(conf.ArchivalWorkers)
 
 	// Start serving.
 	http.HandleFunc(wildcardHandlerPattern, RequestHandler(
-		io,
-		conf,
-		creds,
 		ghSvc,
+		hosts,
+		queue,
+		downloadRefs,
+		checkArchival,
+		conf.ServeStale,
 		client,
 		ddClient))
 	log.Printf("Servicing HTTP requests on port %d.\n", conf.Port)
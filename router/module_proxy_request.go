@@ -0,0 +1,497 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/github"
+	"github.com/gophr-pm/gophr/lib/semver"
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+const (
+	contentTypeZip                   = "application/zip"
+	contentTypeJSON                  = "application/json"
+	contentTypeText                  = "text/plain; charset=utf-8"
+	moduleProxyListFileName          = "list"
+	moduleProxyInfoFileExtension     = ".info"
+	moduleProxyModFileExtension      = ".mod"
+	moduleProxyZipFileExtension      = ".zip"
+	moduleProxyLatestPathSuffix      = "/@latest"
+	moduleProxyVersionsPathSeparator = "/@v/"
+)
+
+const (
+	// moduleProxyRequestTypeList is the type of request that lists every
+	// version of a module.
+	moduleProxyRequestTypeList = iota
+	// moduleProxyRequestTypeInfo is the type of request that fetches the
+	// metadata of a specific module version.
+	moduleProxyRequestTypeInfo
+	// moduleProxyRequestTypeMod is the type of request that fetches the go.mod
+	// of a specific module version.
+	moduleProxyRequestTypeMod
+	// moduleProxyRequestTypeZip is the type of request that fetches the source
+	// code of a specific module version.
+	moduleProxyRequestTypeZip
+	// moduleProxyRequestTypeLatest is the type of request that fetches the
+	// metadata of the latest module version.
+	moduleProxyRequestTypeLatest
+)
+
+// moduleVersionInfo is the JSON-serializable metadata of a module version as
+// go expects it from a module proxy.
+type moduleVersionInfo struct {
+	Version string
+	Time    time.Time
+}
+
+// moduleProxyRequest is a request made by go against the GOPROXY protocol
+// endpoints of the router.
+type moduleProxyRequest struct {
+	req         *http.Request
+	repo        string
+	author      string
+	version     string
+	requestType int
+	// majorVersion is the major version suffix of the requested module path
+	// (e.g. 3 for "gophr.pm/a/b/v3"). It is zero if there is none.
+	majorVersion int
+}
+
+// isModuleProxyRequest returns true if the request was made against one of the
+// GOPROXY protocol endpoints.
+func isModuleProxyRequest(req *http.Request) bool {
+	return strings.Contains(req.URL.Path, moduleProxyVersionsPathSeparator) ||
+		strings.HasSuffix(req.URL.Path, moduleProxyLatestPathSuffix)
+}
+
+// readModuleProxyRequest breaks the URL of a GOPROXY protocol request into its
+// constituent parts.
+func readModuleProxyRequest(req *http.Request) (*moduleProxyRequest, error) {
+	var (
+		err           error
+		url           = strings.TrimSpace(req.URL.Path)
+		version       string
+		modulePath    string
+		requestType   int
+		separatorSpot = strings.Index(url, moduleProxyVersionsPathSeparator)
+	)
+
+	if separatorSpot != -1 {
+		modulePath = url[:separatorSpot]
+		fileName := url[separatorSpot+len(moduleProxyVersionsPathSeparator):]
+
+		// Figure out what kind of file is being requested.
+		if fileName == moduleProxyListFileName {
+			requestType = moduleProxyRequestTypeList
+		} else if strings.HasSuffix(fileName, moduleProxyInfoFileExtension) {
+			requestType = moduleProxyRequestTypeInfo
+			version = strings.TrimSuffix(fileName, moduleProxyInfoFileExtension)
+		} else if strings.HasSuffix(fileName, moduleProxyModFileExtension) {
+			requestType = moduleProxyRequestTypeMod
+			version = strings.TrimSuffix(fileName, moduleProxyModFileExtension)
+		} else if strings.HasSuffix(fileName, moduleProxyZipFileExtension) {
+			requestType = moduleProxyRequestTypeZip
+			version = strings.TrimSuffix(fileName, moduleProxyZipFileExtension)
+		} else {
+			return nil, NewInvalidModuleProxyRequestURLError(url)
+		}
+
+		// Every file other than the list is specific to a version.
+		if requestType != moduleProxyRequestTypeList {
+			if version, err = unescapeModulePath(version); err != nil {
+				return nil, NewInvalidModuleProxyRequestURLError(url, err)
+			} else if !strings.HasPrefix(version, moduleVersionPrefix) {
+				return nil, NewInvalidModuleProxyRequestURLError(url)
+			}
+		}
+	} else if strings.HasSuffix(url, moduleProxyLatestPathSuffix) {
+		modulePath = url[:len(url)-len(moduleProxyLatestPathSuffix)]
+		requestType = moduleProxyRequestTypeLatest
+	} else {
+		return nil, NewInvalidModuleProxyRequestURLError(url)
+	}
+
+	// The module path should be exactly "/author/repo", or "/domain/author/repo"
+	// for packages that are not hosted on Github, optionally followed by a
+	// major version suffix. Go asks for module paths in full, so they start
+	// with the domain of gophr itself (see getModulePath).
+	if modulePath, err = unescapeModulePath(modulePath); err != nil {
+		return nil, NewInvalidModuleProxyRequestURLError(url, err)
+	}
+	if gophrDomain := "/" + getRequestDomain(req); strings.HasPrefix(
+		modulePath,
+		gophrDomain+"/") {
+		modulePath = modulePath[len(gophrDomain):]
+	}
+	var (
+		domain       string
+		majorVersion int
+		moduleParts  = strings.Split(strings.TrimPrefix(modulePath, "/"), "/")
+	)
+	if len(moduleParts) == 4 ||
+		(len(moduleParts) == 3 && strings.IndexByte(moduleParts[0], dot) == -1) {
+		match := majorVersionSuffixRegex.FindStringSubmatch(
+			moduleParts[len(moduleParts)-1])
+		if match == nil {
+			return nil, NewInvalidModuleProxyRequestURLError(url)
+		}
+
+		majorVersion, _ = strconv.Atoi(match[1])
+		moduleParts = moduleParts[:len(moduleParts)-1]
+	}
+	if len(moduleParts) == 3 && strings.IndexByte(moduleParts[0], dot) != -1 {
+		domain = moduleParts[0]
+		moduleParts = moduleParts[1:]
+	}
+	if (len(domain) > 0 && !vcs.IsPublicDomain(domain)) ||
+		len(moduleParts) != 2 ||
+		len(moduleParts[0]) < 1 ||
+		len(moduleParts[1]) < 1 ||
+		strings.IndexByte(modulePath, at) != -1 {
+		return nil, NewInvalidModuleProxyRequestURLError(url)
+	}
+
+	return &moduleProxyRequest{
+		req:          req,
+		repo:         moduleParts[1],
+		author:       vcs.QualifyAuthor(domain, moduleParts[0]),
+		version:      version,
+		requestType:  requestType,
+		majorVersion: majorVersion,
+	}, nil
+}
+
+// getModulePath returns the go module path of the requested package.
+func (mpr *moduleProxyRequest) getModulePath() string {
+	modulePath := getRequestDomain(mpr.req) +
+		"/" + vcs.AuthorPath(mpr.author) +
+		"/" + mpr.repo
+	if mpr.majorVersion > 0 {
+		modulePath += "/" + moduleVersionPrefix + strconv.Itoa(mpr.majorVersion)
+	}
+
+	return modulePath
+}
+
+// respondToModuleProxyRequestArgs is the arguments struct for
+// moduleProxyRequest#respond.
+type respondToModuleProxyRequestArgs struct {
+	db                    db.Client
+	res                   http.ResponseWriter
+	ghSvc                 github.RequestService
+	hosts                 vcs.Hosts
+	fetchGoMod            goModFetcher
+	fetchArchive          upstreamArchiveFetcher
+	downloadRefs          refsDownloader
+	pinVersionLabel       versionLabelPinner
+	recordPackageDownload packageDownloadRecorder
+}
+
+// respond serves the GOPROXY protocol file that was requested. Files that
+// depend on source code are served from the upstream archive of the package.
+func (mpr *moduleProxyRequest) respond(
+	args respondToModuleProxyRequestArgs,
+) error {
+	if err := assertSupportedHost(args.hosts, mpr.author); err != nil {
+		return err
+	}
+
+	switch mpr.requestType {
+	case moduleProxyRequestTypeList:
+		refs, err := args.downloadRefs(mpr.author, mpr.repo)
+		if err != nil {
+			return err
+		}
+
+		candidates, err := mpr.findModuleVersionCandidates(args, refs)
+		if err != nil {
+			return err
+		}
+
+		var buffer bytes.Buffer
+		for _, candidate := range candidates {
+			buffer.WriteString(moduleVersionOf(candidate, mpr.majorVersion))
+			buffer.WriteByte('\n')
+		}
+
+		args.res.Header().Set(httpContentTypeHeader, contentTypeText)
+		args.res.Write(buffer.Bytes())
+		return nil
+
+	case moduleProxyRequestTypeLatest:
+		refs, err := args.downloadRefs(mpr.author, mpr.repo)
+		if err != nil {
+			return err
+		}
+
+		candidates, err := mpr.findModuleVersionCandidates(args, refs)
+		if err != nil {
+			return err
+		}
+
+		// Prefer tagged versions. If there are none, fall back to the default
+		// branch.
+		if candidate := latestModuleVersionCandidate(candidates); candidate != nil {
+			sha, err := pinCandidate(pinCandidateArgs{
+				db:              args.db,
+				refs:            refs,
+				repo:            mpr.repo,
+				author:          mpr.author,
+				candidate:       *candidate,
+				pinVersionLabel: args.pinVersionLabel,
+			})
+			if err != nil {
+				return err
+			}
+
+			return mpr.respondWithInfo(
+				args,
+				moduleVersionOf(*candidate, mpr.majorVersion),
+				sha)
+		}
+
+		// The default branch only belongs to a module path with a major version
+		// suffix if its go.mod says so.
+		if mpr.majorVersion > 0 {
+			goMod, err := args.fetchGoMod(
+				args.hosts,
+				mpr.author,
+				mpr.repo,
+				refs.DefaultRefHash)
+			if err != nil {
+				return err
+			} else if goModMajorVersionOf(goMod) != mpr.majorVersion {
+				return NewNoSuchPackageVersionError(
+					mpr.author,
+					mpr.repo,
+					moduleVersionPrefix+strconv.Itoa(mpr.majorVersion))
+			}
+		}
+
+		host, bareAuthor, err := args.hosts.Of(mpr.author)
+		if err != nil {
+			return err
+		}
+
+		commitTime, err := host.FetchCommitTimestamp(
+			bareAuthor,
+			mpr.repo,
+			refs.DefaultRefHash)
+		if err != nil {
+			return err
+		}
+
+		return mpr.respondWithInfo(
+			args,
+			pseudoVersionOf(refs.DefaultRefHash, commitTime, mpr.majorVersion),
+			refs.DefaultRefHash)
+	}
+
+	// All the remaining request types are version-specific.
+	sha, err := mpr.resolveSHA(args)
+	if err != nil {
+		return err
+	}
+
+	if mpr.requestType == moduleProxyRequestTypeInfo {
+		return mpr.respondWithInfo(args, mpr.version, sha)
+	}
+
+	// The go.mod and zip both come from the source of the package as it was
+	// committed upstream. The archive in depot won't do, since the imports in
+	// it were rewritten to paths that aren't valid in module mode.
+	upstreamZip, err := args.fetchArchive(args.hosts, mpr.author, mpr.repo, sha)
+	if err != nil {
+		return err
+	}
+
+	archive, err := readUpstreamArchive(upstreamZip)
+	if err != nil {
+		return err
+	}
+
+	originalGoMod, err := readGoModFile(archive)
+	if err != nil {
+		return err
+	}
+
+	modulePath := mpr.getModulePath()
+	goMod := generateModFile(modulePath, originalGoMod)
+	if mpr.requestType == moduleProxyRequestTypeMod {
+		args.res.Header().Set(httpContentTypeHeader, contentTypeText)
+		args.res.Write(goMod)
+		return nil
+	}
+
+	// Packages without a go.mod import themselves by the path of their
+	// repository.
+	originalModulePath := readModulePath(originalGoMod)
+	if len(originalModulePath) == 0 {
+		originalModulePath = vcs.RepoPath(mpr.author, mpr.repo)
+	}
+
+	moduleZip, err := buildModuleZip(buildModuleZipArgs{
+		goMod:              goMod,
+		archive:            archive,
+		version:            mpr.version,
+		modulePath:         modulePath,
+		originalModulePath: originalModulePath,
+	})
+	if err != nil {
+		return err
+	}
+
+	args.res.Header().Set(httpContentTypeHeader, contentTypeZip)
+	args.res.Write(moduleZip)
+
+	// Without blocking, count go fetching the module zip as a download in the
+	// database.
+	go args.recordPackageDownload(packageDownloadRecorderArgs{
+		db:     args.db,
+		sha:    sha,
+		repo:   mpr.repo,
+		ghSvc:  args.ghSvc,
+		author: mpr.author,
+	})
+
+	return nil
+}
+
+// resolveSHA finds the commit SHA that corresponds to the requested version.
+func (mpr *moduleProxyRequest) resolveSHA(
+	args respondToModuleProxyRequestArgs,
+) (string, error) {
+	// Pseudo-versions carry a short SHA, so expand it.
+	if shortSHA, isPseudoVersion := readPseudoVersion(mpr.version); isPseudoVersion {
+		host, bareAuthor, err := args.hosts.Of(mpr.author)
+		if err != nil {
+			return "", err
+		}
+
+		return host.ExpandPartialSHA(bareAuthor, mpr.repo, shortSHA)
+	}
+
+	// Otherwise, the version has to be one of the tagged versions.
+	refs, err := args.downloadRefs(mpr.author, mpr.repo)
+	if err != nil {
+		return "", err
+	}
+
+	candidate := findModuleVersionCandidate(
+		refs.Candidates,
+		mpr.version,
+		mpr.majorVersion)
+	if candidate == nil {
+		return "", NewNoSuchPackageVersionError(mpr.author, mpr.repo, mpr.version)
+	}
+
+	// Tagged versions resolve to the same SHA as they do for go get, even if
+	// their tags are moved upstream.
+	sha, err := pinCandidate(pinCandidateArgs{
+		db:              args.db,
+		refs:            refs,
+		repo:            mpr.repo,
+		author:          mpr.author,
+		candidate:       *candidate,
+		pinVersionLabel: args.pinVersionLabel,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Make sure that the version belongs to the requested module path.
+	if belongs, err := mpr.isModuleVersionCandidate(
+		args,
+		*candidate,
+		sha); err != nil {
+		return "", err
+	} else if !belongs {
+		return "", NewNoSuchPackageVersionError(mpr.author, mpr.repo, mpr.version)
+	}
+
+	return sha, nil
+}
+
+// findModuleVersionCandidates returns the candidates of the package that are
+// versions of the requested module path (see isModuleVersionCandidate).
+func (mpr *moduleProxyRequest) findModuleVersionCandidates(
+	args respondToModuleProxyRequestArgs,
+	refs lib.Refs,
+) (semver.SemverCandidateList, error) {
+	var candidates semver.SemverCandidateList
+	for _, candidate := range refs.Candidates {
+		belongs, err := mpr.isModuleVersionCandidate(
+			args,
+			candidate,
+			candidate.GitRefHash)
+		if err != nil {
+			return nil, err
+		} else if belongs {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates, nil
+}
+
+// isModuleVersionCandidate returns true if the candidate committed at sha is a
+// version of the requested module path. Like go does, versions with a major
+// version greater than one belong to the module path with the matching major
+// version suffix if their go.mod declares it. Otherwise, they are incompatible
+// versions of the module path without a suffix.
+func (mpr *moduleProxyRequest) isModuleVersionCandidate(
+	args respondToModuleProxyRequestArgs,
+	candidate semver.SemverCandidate,
+	sha string,
+) (bool, error) {
+	if candidate.MajorVersion < 2 {
+		return mpr.majorVersion == 0, nil
+	} else if mpr.majorVersion > 0 && mpr.majorVersion != candidate.MajorVersion {
+		return false, nil
+	}
+
+	goMod, err := args.fetchGoMod(args.hosts, mpr.author, mpr.repo, sha)
+	if err != nil {
+		return false, err
+	}
+
+	hasMajorVersionGoMod := goModMajorVersionOf(goMod) == candidate.MajorVersion
+	return hasMajorVersionGoMod == (mpr.majorVersion > 0), nil
+}
+
+// respondWithInfo responds with the JSON metadata of a module version.
+func (mpr *moduleProxyRequest) respondWithInfo(
+	args respondToModuleProxyRequestArgs,
+	version string,
+	sha string,
+) error {
+	host, bareAuthor, err := args.hosts.Of(mpr.author)
+	if err != nil {
+		return err
+	}
+
+	commitTime, err := host.FetchCommitTimestamp(bareAuthor, mpr.repo, sha)
+	if err != nil {
+		return err
+	}
+
+	info, err := json.Marshal(moduleVersionInfo{
+		Version: version,
+		Time:    commitTime.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	args.res.Header().Set(httpContentTypeHeader, contentTypeJSON)
+	args.res.Write(info)
+	return nil
+}
@@ -0,0 +1,468 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/github"
+	"github.com/gophr-pm/gophr/lib/semver"
+	"github.com/gophr-pm/gophr/lib/vcs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIsModuleProxyRequest(t *testing.T) {
+	assert.True(t, isModuleProxyRequest(fakeHTTPRequest("gophr.pm", "/a/b/@v/list", false)))
+	assert.True(t, isModuleProxyRequest(fakeHTTPRequest("gophr.pm", "/a/b/@v/v1.0.0.zip", false)))
+	assert.True(t, isModuleProxyRequest(fakeHTTPRequest("gophr.pm", "/a/b/@latest", false)))
+	assert.False(t, isModuleProxyRequest(fakeHTTPRequest("gophr.pm", "/a/b@v1", true)))
+	assert.False(t, isModuleProxyRequest(fakeHTTPRequest("gophr.pm", "/a/b/info/refs", false)))
+}
+
+func TestReadModuleProxyRequest(t *testing.T) {
+	for _, test := range []struct {
+		path        string
+		author      string
+		repo        string
+		version     string
+		requestType int
+	}{
+		{"/a/b/@v/list", "a", "b", "", moduleProxyRequestTypeList},
+		{"/a/b/@v/v1.0.0.info", "a", "b", "v1.0.0", moduleProxyRequestTypeInfo},
+		{"/a/b/@v/v1.0.0.mod", "a", "b", "v1.0.0", moduleProxyRequestTypeMod},
+		{"/a/b/@v/v1.0.0-!r!c1.zip", "a", "b", "v1.0.0-RC1", moduleProxyRequestTypeZip},
+		{"/!burnt!sushi/toml/@latest", "BurntSushi", "toml", "", moduleProxyRequestTypeLatest},
+		{"/gitlab.com/a/b/@v/list", "gitlab.com:a", "b", "", moduleProxyRequestTypeList},
+		// These are the paths that go actually asks for, since the module paths
+		// served by gophr start with its domain.
+		{"/gophr.pm/a/b/@v/list", "a", "b", "", moduleProxyRequestTypeList},
+		{"/gophr.pm/a/b/@v/v1.0.0.zip", "a", "b", "v1.0.0", moduleProxyRequestTypeZip},
+		{"/gophr.pm/gitlab.com/a/b/@latest", "gitlab.com:a", "b", "", moduleProxyRequestTypeLatest},
+	} {
+		mpr, err := readModuleProxyRequest(fakeHTTPRequest("gophr.pm", test.path, false))
+		assert.Nil(t, err, test.path)
+		assert.Equal(t, test.author, mpr.author, test.path)
+		assert.Equal(t, test.repo, mpr.repo, test.path)
+		assert.Equal(t, test.version, mpr.version, test.path)
+		assert.Equal(t, test.requestType, mpr.requestType, test.path)
+	}
+
+	// Module paths may end with a major version suffix.
+	for _, test := range []struct {
+		path         string
+		author       string
+		majorVersion int
+	}{
+		{"/a/b/@v/list", "a", 0},
+		{"/a/b/v2/@v/list", "a", 2},
+		{"/gophr.pm/a/b/v3/@latest", "a", 3},
+		{"/gitlab.com/a/b/v12/@v/v12.0.0.info", "gitlab.com:a", 12},
+	} {
+		mpr, err := readModuleProxyRequest(fakeHTTPRequest("gophr.pm", test.path, false))
+		assert.Nil(t, err, test.path)
+		assert.Equal(t, test.author, mpr.author, test.path)
+		assert.Equal(t, "b", mpr.repo, test.path)
+		assert.Equal(t, test.majorVersion, mpr.majorVersion, test.path)
+	}
+
+	// The module path that go asked for should be the one that is served.
+	mpr, err := readModuleProxyRequest(fakeHTTPRequest("gophr.pm", "/gophr.pm/gitlab.com/a/b/@v/list", false))
+	assert.Nil(t, err)
+	assert.Equal(t, "gophr.pm/gitlab.com/a/b", mpr.getModulePath())
+	mpr, err = readModuleProxyRequest(fakeHTTPRequest("gophr.pm", "/gophr.pm/a/b/v2/@v/list", false))
+	assert.Nil(t, err)
+	assert.Equal(t, "gophr.pm/a/b/v2", mpr.getModulePath())
+
+	for _, path := range []string{
+		"/a/b/@v/",
+		"/a/b/@v/v1.0.0.tar",
+		"/a/b/@v/1.0.0.zip",
+		"/a/b/@v/v1.0.0!.zip",
+		"/a/@v/list",
+		"/a/b/c/@latest",
+		"/a/b/v0/@latest",
+		"/a/b/v1/@latest",
+		"/a/b/v02/@latest",
+		"/gitlab.com/a/b/v1/@latest",
+		"/gitlab.com/a/b/c/@latest",
+		"/localhost.localdomain/a/b/@latest",
+		"/127.0.0.1/a/b/@v/list",
+		"/git.internal/a/b/@v/list",
+		"/gophr.pm/a/@v/list",
+		"/a/b@v1/@latest",
+		"/A/b/@latest",
+		"/a/b/c",
+	} {
+		_, err := readModuleProxyRequest(fakeHTTPRequest("gophr.pm", path, false))
+		assert.NotNil(t, err, path)
+	}
+}
+
+// fakeGoModFetcher fetches the go.mod files in goMods by SHA. SHAs that are
+// not in goMods have no go.mod.
+func fakeGoModFetcher(goMods map[string]string) goModFetcher {
+	return func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+		if goMod, ok := goMods[sha]; ok {
+			return []byte(goMod), nil
+		}
+
+		return nil, nil
+	}
+}
+
+func TestRespondToModuleProxyRequest(t *testing.T) {
+	var (
+		commitTime = time.Date(2017, 3, 14, 15, 9, 26, 0, time.UTC)
+		candidates = semver.SemverCandidateList{
+			{GitRefHash: "hash1", MajorVersion: 1},
+			{GitRefHash: "hash2", MajorVersion: 2, MinorVersion: 1},
+			{GitRefHash: "hash3", MajorVersion: 3},
+		}
+		refs       = lib.Refs{DefaultRefHash: "masterhash", Candidates: candidates}
+		hosts      = vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain))
+		fetchGoMod = fakeGoModFetcher(map[string]string{
+			"hash2":      "module github.com/a/b\n",
+			"hash3":      "module github.com/a/b/v3\n",
+			"masterhash": "module github.com/a/b/v4\n",
+		})
+	)
+
+	// Unsupported host.
+	err := (&moduleProxyRequest{
+		repo:        "b",
+		author:      "git.example.com:a",
+		requestType: moduleProxyRequestTypeList,
+	}).respond(respondToModuleProxyRequestArgs{
+		hosts: hosts,
+		downloadRefs: func(author, repo string) (lib.Refs, error) {
+			t.Fatal("the refs of packages on unsupported hosts should not be downloaded")
+			return lib.Refs{}, nil
+		},
+	})
+	assert.Equal(t, NewUnsupportedPackageHostError("git.example.com"), err)
+
+	// List. Versions that declare their major version in their go.mod belong
+	// to the module path with the matching suffix, and only to that one.
+	w := httptest.NewRecorder()
+	err = (&moduleProxyRequest{
+		repo:        "b",
+		author:      "a",
+		requestType: moduleProxyRequestTypeList,
+	}).respond(respondToModuleProxyRequestArgs{
+		hosts:        hosts,
+		res:          w,
+		fetchGoMod:   fetchGoMod,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "v1.0.0\nv2.1.0+incompatible\n", w.Body.String())
+
+	for majorVersion, expectedList := range map[int]string{
+		2: "",
+		3: "v3.0.0\n",
+	} {
+		w = httptest.NewRecorder()
+		err = (&moduleProxyRequest{
+			repo:         "b",
+			author:       "a",
+			requestType:  moduleProxyRequestTypeList,
+			majorVersion: majorVersion,
+		}).respond(respondToModuleProxyRequestArgs{
+			hosts:        hosts,
+			res:          w,
+			fetchGoMod:   fetchGoMod,
+			downloadRefs: fakeRefsDownloader(refs, nil),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, expectedList, w.Body.String())
+	}
+
+	// The go.mod files can't be fetched.
+	err = (&moduleProxyRequest{
+		repo:        "b",
+		author:      "a",
+		requestType: moduleProxyRequestTypeList,
+	}).respond(respondToModuleProxyRequestArgs{
+		hosts: hosts,
+		res:   httptest.NewRecorder(),
+		fetchGoMod: func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+			return nil, errors.New("this is an error")
+		},
+		downloadRefs: fakeRefsDownloader(refs, nil),
+	})
+	assert.NotNil(t, err)
+
+	// Latest with tagged versions.
+	w = httptest.NewRecorder()
+	ghSvc := github.NewMockRequestService()
+	ghSvc.On("FetchCommitTimestamp", "a", "b", "hash2").Return(commitTime, nil)
+	err = (&moduleProxyRequest{
+		repo:        "b",
+		author:      "a",
+		requestType: moduleProxyRequestTypeLatest,
+	}).respond(respondToModuleProxyRequestArgs{
+		res:          w,
+		hosts:        vcs.NewHosts(github.NewHost(ghSvc, nil)),
+		fetchGoMod:   fetchGoMod,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, contentTypeJSON, w.Header().Get(httpContentTypeHeader))
+	assert.Equal(
+		t,
+		`{"Version":"v2.1.0+incompatible","Time":"2017-03-14T15:09:26Z"}`,
+		w.Body.String())
+
+	// Latest of a major version with tagged versions.
+	w = httptest.NewRecorder()
+	ghSvc = github.NewMockRequestService()
+	ghSvc.On("FetchCommitTimestamp", "a", "b", "hash3").Return(commitTime, nil)
+	err = (&moduleProxyRequest{
+		repo:         "b",
+		author:       "a",
+		requestType:  moduleProxyRequestTypeLatest,
+		majorVersion: 3,
+	}).respond(respondToModuleProxyRequestArgs{
+		res:          w,
+		hosts:        vcs.NewHosts(github.NewHost(ghSvc, nil)),
+		fetchGoMod:   fetchGoMod,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+	})
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`{"Version":"v3.0.0","Time":"2017-03-14T15:09:26Z"}`,
+		w.Body.String())
+
+	// Latest of a major version that only the default branch declares.
+	w = httptest.NewRecorder()
+	ghSvc = github.NewMockRequestService()
+	ghSvc.On("FetchCommitTimestamp", "a", "b", "masterhash").Return(commitTime, nil)
+	err = (&moduleProxyRequest{
+		repo:         "b",
+		author:       "a",
+		requestType:  moduleProxyRequestTypeLatest,
+		majorVersion: 4,
+	}).respond(respondToModuleProxyRequestArgs{
+		res:          w,
+		hosts:        vcs.NewHosts(github.NewHost(ghSvc, nil)),
+		fetchGoMod:   fetchGoMod,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+	})
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`{"Version":"v4.0.0-20170314150926-masterhash","Time":"2017-03-14T15:09:26Z"}`,
+		w.Body.String())
+
+	// Latest of a major version that doesn't exist.
+	err = (&moduleProxyRequest{
+		repo:         "b",
+		author:       "a",
+		requestType:  moduleProxyRequestTypeLatest,
+		majorVersion: 5,
+	}).respond(respondToModuleProxyRequestArgs{
+		res:          httptest.NewRecorder(),
+		hosts:        hosts,
+		fetchGoMod:   fetchGoMod,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+	})
+	assert.IsType(t, NoSuchPackageVersionError{}, err)
+
+	// Latest without tagged versions.
+	w = httptest.NewRecorder()
+	ghSvc = github.NewMockRequestService()
+	ghSvc.On("FetchCommitTimestamp", "a", "b", "masterhash").Return(commitTime, nil)
+	err = (&moduleProxyRequest{
+		repo:        "b",
+		author:      "a",
+		requestType: moduleProxyRequestTypeLatest,
+	}).respond(respondToModuleProxyRequestArgs{
+		res:          w,
+		hosts:        vcs.NewHosts(github.NewHost(ghSvc, nil)),
+		downloadRefs: fakeRefsDownloader(lib.Refs{DefaultRefHash: "masterhash"}, nil),
+	})
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`{"Version":"v0.0.0-20170314150926-masterhash","Time":"2017-03-14T15:09:26Z"}`,
+		w.Body.String())
+
+	// Info for versions that do not exist.
+	for _, version := range []string{"v4.0.0", "v3.0.0", "v3.0.0+incompatible"} {
+		err = (&moduleProxyRequest{
+			repo:        "b",
+			author:      "a",
+			version:     version,
+			requestType: moduleProxyRequestTypeInfo,
+		}).respond(respondToModuleProxyRequestArgs{
+			hosts:        hosts,
+			res:          httptest.NewRecorder(),
+			fetchGoMod:   fetchGoMod,
+			downloadRefs: fakeRefsDownloader(refs, nil),
+		})
+		assert.IsType(t, NoSuchPackageVersionError{}, err, version)
+	}
+
+	// Info for a tag that was moved upstream after it was pinned.
+	w = httptest.NewRecorder()
+	ghSvc = github.NewMockRequestService()
+	ghSvc.On("FetchCommitTimestamp", "a", "b", "pinnedhash").Return(commitTime, nil)
+	err = (&moduleProxyRequest{
+		repo:        "b",
+		author:      "a",
+		version:     "v1.2.0",
+		requestType: moduleProxyRequestTypeInfo,
+	}).respond(respondToModuleProxyRequestArgs{
+		res:   w,
+		hosts: vcs.NewHosts(github.NewHost(ghSvc, nil)),
+		downloadRefs: fakeRefsDownloader(lib.Refs{
+			DefaultRefHash: "masterhash",
+			Candidates: semver.SemverCandidateList{{
+				GitRefName:   "refs/tags/v1.2.0",
+				GitRefHash:   "movedhash",
+				MajorVersion: 1,
+				MinorVersion: 2,
+			}},
+		}, nil),
+		pinVersionLabel: fakeVersionLabelPinner(map[string]string{
+			"v1.2.0": "pinnedhash",
+		}),
+	})
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`{"Version":"v1.2.0","Time":"2017-03-14T15:09:26Z"}`,
+		w.Body.String())
+	ghSvc.AssertExpectations(t)
+
+	// Info for a pseudo-version.
+	w = httptest.NewRecorder()
+	ghSvc = github.NewMockRequestService()
+	ghSvc.On("ExpandPartialSHA", mock.AnythingOfType("github.ExpandPartialSHAArgs")).
+		Return("14c0d48ead0c0000000000000000000000000000", nil)
+	ghSvc.On(
+		"FetchCommitTimestamp",
+		"a",
+		"b",
+		"14c0d48ead0c0000000000000000000000000000").Return(commitTime, nil)
+	err = (&moduleProxyRequest{
+		repo:        "b",
+		author:      "a",
+		version:     "v0.0.0-20170314150926-14c0d48ead0c",
+		requestType: moduleProxyRequestTypeInfo,
+	}).respond(respondToModuleProxyRequestArgs{
+		res:   w,
+		hosts: vcs.NewHosts(github.NewHost(ghSvc, nil)),
+	})
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`{"Version":"v0.0.0-20170314150926-14c0d48ead0c","Time":"2017-03-14T15:09:26Z"}`,
+		w.Body.String())
+
+	// Zip when the archive can't be fetched.
+	w = httptest.NewRecorder()
+	err = (&moduleProxyRequest{
+		repo:        "b",
+		author:      "a",
+		version:     "v1.0.0",
+		requestType: moduleProxyRequestTypeZip,
+	}).respond(respondToModuleProxyRequestArgs{
+		hosts:        hosts,
+		res:          w,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+		fetchArchive: func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+			assert.Equal(t, "hash1", sha)
+			return nil, errors.New("this is an error")
+		},
+	})
+	assert.NotNil(t, err)
+
+	// Mod.
+	w = httptest.NewRecorder()
+	archive := fakeZipArchive(map[string]string{
+		"b-hash1/go.mod":      "module github.com/a/b\n\nrequire github.com/c/d v1.0.0\n",
+		"b-hash1/main.go":     "package main\n\nimport (\n\t\"github.com/a/b/sub\"\n\t\"github.com/c/d\"\n)\n",
+		"b-hash1/sub/file.go": "package sub",
+	})
+	err = (&moduleProxyRequest{
+		req:         fakeHTTPRequest("gophr.pm", "/a/b/@v/v1.0.0.mod", false),
+		repo:        "b",
+		author:      "a",
+		version:     "v1.0.0",
+		requestType: moduleProxyRequestTypeMod,
+	}).respond(respondToModuleProxyRequestArgs{
+		hosts:        hosts,
+		res:          w,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+		fetchArchive: func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+			assert.Equal(t, "a", author)
+			assert.Equal(t, "b", repo)
+			assert.Equal(t, "hash1", sha)
+			return archive, nil
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "module gophr.pm/a/b\n\nrequire github.com/c/d v1.0.0\n", w.Body.String())
+
+	// Mod of a major version.
+	w = httptest.NewRecorder()
+	err = (&moduleProxyRequest{
+		req:          fakeHTTPRequest("gophr.pm", "/a/b/v3/@v/v3.0.0.mod", false),
+		repo:         "b",
+		author:       "a",
+		version:      "v3.0.0",
+		requestType:  moduleProxyRequestTypeMod,
+		majorVersion: 3,
+	}).respond(respondToModuleProxyRequestArgs{
+		hosts:        hosts,
+		res:          w,
+		fetchGoMod:   fetchGoMod,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+		fetchArchive: func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+			assert.Equal(t, "hash3", sha)
+			return fakeZipArchive(map[string]string{
+				"b-hash3/go.mod": "module github.com/a/b/v3\n",
+			}), nil
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "module gophr.pm/a/b/v3\n", w.Body.String())
+
+	// Zip.
+	w = httptest.NewRecorder()
+	downloads := make(chan packageDownloadRecorderArgs, 1)
+	err = (&moduleProxyRequest{
+		req:         fakeHTTPRequest("gophr.pm", "/a/b/@v/v1.0.0.zip", false),
+		repo:        "b",
+		author:      "a",
+		version:     "v1.0.0",
+		requestType: moduleProxyRequestTypeZip,
+	}).respond(respondToModuleProxyRequestArgs{
+		hosts:        hosts,
+		res:          w,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+		fetchArchive: func(hosts vcs.Hosts, author, repo, sha string) ([]byte, error) {
+			return archive, nil
+		},
+		recordPackageDownload: func(args packageDownloadRecorderArgs) {
+			downloads <- args
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, contentTypeZip, w.Header().Get(httpContentTypeHeader))
+	assert.Equal(t, map[string]string{
+		"gophr.pm/a/b@v1.0.0/go.mod":      "module gophr.pm/a/b\n\nrequire github.com/c/d v1.0.0\n",
+		"gophr.pm/a/b@v1.0.0/main.go":     "package main\n\nimport (\n\t\"gophr.pm/a/b/sub\"\n\t\"github.com/c/d\"\n)\n",
+		"gophr.pm/a/b@v1.0.0/sub/file.go": "package sub",
+	}, readZipArchive(w.Body.Bytes()))
+
+	download := <-downloads
+	assert.Equal(t, "hash1", download.sha)
+	assert.Equal(t, "b", download.repo)
+	assert.Equal(t, "a", download.author)
+}
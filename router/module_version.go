@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/semver"
+)
+
+const (
+	moduleVersionPrefix             = "v"
+	pseudoVersionBaseFormat         = "v%d.0.0"
+	pseudoVersionShortSHALength     = 12
+	pseudoVersionTimestampFormat    = "20060102150405"
+	moduleVersionIncompatibleSuffix = "+incompatible"
+)
+
+var (
+	// pseudoVersionRegex is the regular expression used to recognize go module
+	// pseudo-versions (e.g. "v0.0.0-20170915032832-14c0d48ead0c"). The only
+	// capture group is the short SHA of the commit.
+	pseudoVersionRegex = regexp.MustCompile(
+		`^v[0-9]+\.[0-9]+\.[0-9]+-(?:[0-9A-Za-z\.\-]+\.)?[0-9]{14}-([0-9a-f]{12})(?:\+incompatible)?$`)
+	// majorVersionSuffixRegex matches the last element of module paths that
+	// have a major version suffix (e.g. "v3"). The only capture group is the
+	// major version.
+	majorVersionSuffixRegex = regexp.MustCompile(`^v([2-9]|[1-9][0-9]+)$`)
+)
+
+// moduleVersionOf turns a semver candidate into a go module version string.
+// modulePathMajorVersion is the major version suffix of the module path that
+// the version is served under (zero if there is none). Candidates with a major
+// version greater than one are marked as incompatible when served under a
+// module path without a major version suffix.
+func moduleVersionOf(
+	candidate semver.SemverCandidate,
+	modulePathMajorVersion int,
+) string {
+	var buffer bytes.Buffer
+	buffer.WriteString(moduleVersionPrefix)
+	buffer.WriteString(candidate.String())
+
+	if candidate.MajorVersion > 1 && modulePathMajorVersion == 0 {
+		buffer.WriteString(moduleVersionIncompatibleSuffix)
+	}
+
+	return buffer.String()
+}
+
+// goModMajorVersionOf returns the major version suffix of the module path in
+// a go.mod file (e.g. 3 for "github.com/a/b/v3"). Returns zero if the module
+// path has no such suffix, or if there is no go.mod file at all.
+func goModMajorVersionOf(goMod []byte) int {
+	match := modulePathMajorVersionRegex.FindStringSubmatch(readModulePath(goMod))
+	if match == nil {
+		return 0
+	}
+
+	majorVersion, _ := strconv.Atoi(strings.TrimPrefix(match[1], moduleVersionPrefix))
+	return majorVersion
+}
+
+// pseudoVersionOf generates a go module pseudo-version for a commit that does
+// not correspond to any tagged version. modulePathMajorVersion is the major
+// version suffix of the module path that the commit is served under (zero if
+// there is none).
+func pseudoVersionOf(
+	sha string,
+	commitTime time.Time,
+	modulePathMajorVersion int,
+) string {
+	shortSHA := sha
+	if len(shortSHA) > pseudoVersionShortSHALength {
+		shortSHA = shortSHA[:pseudoVersionShortSHALength]
+	}
+
+	return fmt.Sprintf(
+		pseudoVersionBaseFormat+"-%s-%s",
+		modulePathMajorVersion,
+		commitTime.UTC().Format(pseudoVersionTimestampFormat),
+		shortSHA)
+}
+
+// readPseudoVersion returns the short SHA of a go module pseudo-version. If
+// the version is not a pseudo-version, then false is returned instead.
+func readPseudoVersion(version string) (string, bool) {
+	match := pseudoVersionRegex.FindStringSubmatch(version)
+	if match == nil {
+		return "", false
+	}
+
+	return match[1], true
+}
+
+// findModuleVersionCandidate finds the candidate that corresponds exactly to a
+// go module version string served under a module path with the specified
+// major version suffix (see moduleVersionOf). Returns nil if no such
+// candidate exists.
+func findModuleVersionCandidate(
+	candidates semver.SemverCandidateList,
+	version string,
+	modulePathMajorVersion int,
+) *semver.SemverCandidate {
+	for i := range candidates {
+		if moduleVersionOf(candidates[i], modulePathMajorVersion) == version {
+			return &candidates[i]
+		}
+	}
+
+	return nil
+}
+
+// latestModuleVersionCandidate returns the highest candidate that is not a
+// pre-release. If there are only pre-release candidates, the highest of those
+// is returned instead. Returns nil if there are no candidates at all.
+func latestModuleVersionCandidate(
+	candidates semver.SemverCandidateList,
+) *semver.SemverCandidate {
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if len(candidates[i].PrereleaseLabel) == 0 {
+			return &candidates[i]
+		}
+	}
+
+	return candidates.Highest()
+}
+
+// unescapeModulePath reverses the case-encoding that go applies to module
+// paths and versions in module proxy requests (e.g. "!azure" is "Azure").
+func unescapeModulePath(escaped string) (string, error) {
+	var (
+		buffer bytes.Buffer
+		bang   = false
+	)
+
+	for i := 0; i < len(escaped); i++ {
+		c := escaped[i]
+
+		if bang {
+			if c < 'a' || c > 'z' {
+				return "", fmt.Errorf("Invalid escaped module path \"%s\"", escaped)
+			}
+
+			buffer.WriteByte(c - 'a' + 'A')
+			bang = false
+		} else if c == '!' {
+			bang = true
+		} else if c >= 'A' && c <= 'Z' {
+			return "", fmt.Errorf("Invalid escaped module path \"%s\"", escaped)
+		} else {
+			buffer.WriteByte(c)
+		}
+	}
+
+	if bang {
+		return "", fmt.Errorf("Invalid escaped module path \"%s\"", escaped)
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleVersionOf(t *testing.T) {
+	candidate := semver.SemverCandidate{MajorVersion: 1, MinorVersion: 2, PatchVersion: 3}
+	assert.Equal(t, "v1.2.3", moduleVersionOf(candidate, 0))
+
+	candidate = semver.SemverCandidate{
+		MajorVersion:      2,
+		PrereleaseLabel:   "beta",
+		PrereleaseVersion: 1,
+	}
+	assert.Equal(t, "v2.0.0-beta.1+incompatible", moduleVersionOf(candidate, 0))
+	assert.Equal(t, "v2.0.0-beta.1", moduleVersionOf(candidate, 2))
+}
+
+func TestGoModMajorVersionOf(t *testing.T) {
+	assert.Equal(t, 0, goModMajorVersionOf(nil))
+	assert.Equal(t, 0, goModMajorVersionOf([]byte("module github.com/a/b\n")))
+	assert.Equal(t, 0, goModMajorVersionOf([]byte("module github.com/a/v1\n")))
+	assert.Equal(t, 3, goModMajorVersionOf([]byte("module github.com/a/b/v3\n")))
+	assert.Equal(t, 12, goModMajorVersionOf([]byte("module \"github.com/a/b/v12\"\n")))
+}
+
+func TestPseudoVersionOf(t *testing.T) {
+	commitTime := time.Date(2017, 9, 15, 3, 28, 32, 0, time.UTC)
+
+	assert.Equal(
+		t,
+		"v0.0.0-20170915032832-14c0d48ead0c",
+		pseudoVersionOf("14c0d48ead0c3b0c1c1d9a4b5e8a1c0f8e1a2b3c", commitTime, 0))
+	assert.Equal(
+		t,
+		"v0.0.0-20170915032832-14c0d4",
+		pseudoVersionOf("14c0d4", commitTime, 0))
+	assert.Equal(
+		t,
+		"v2.0.0-20170915032832-14c0d4",
+		pseudoVersionOf("14c0d4", commitTime, 2))
+}
+
+func TestReadPseudoVersion(t *testing.T) {
+	shortSHA, ok := readPseudoVersion("v0.0.0-20170915032832-14c0d48ead0c")
+	assert.True(t, ok)
+	assert.Equal(t, "14c0d48ead0c", shortSHA)
+
+	shortSHA, ok = readPseudoVersion("v1.2.4-0.20170915032832-14c0d48ead0c")
+	assert.True(t, ok)
+	assert.Equal(t, "14c0d48ead0c", shortSHA)
+
+	_, ok = readPseudoVersion("v1.2.3")
+	assert.False(t, ok)
+
+	_, ok = readPseudoVersion("v0.0.0-2017091503-14c0d48ead0c")
+	assert.False(t, ok)
+}
+
+func TestFindModuleVersionCandidate(t *testing.T) {
+	c1 := semver.SemverCandidate{GitRefHash: "hash1", MajorVersion: 1}
+	c2 := semver.SemverCandidate{GitRefHash: "hash2", MajorVersion: 2, MinorVersion: 1}
+	candidates := semver.SemverCandidateList{c1, c2}
+
+	assert.Equal(t, "hash1", findModuleVersionCandidate(candidates, "v1.0.0", 0).GitRefHash)
+	assert.Equal(t, "hash2", findModuleVersionCandidate(candidates, "v2.1.0+incompatible", 0).GitRefHash)
+	assert.Equal(t, "hash2", findModuleVersionCandidate(candidates, "v2.1.0", 2).GitRefHash)
+	assert.Nil(t, findModuleVersionCandidate(candidates, "v2.1.0", 0))
+	assert.Nil(t, findModuleVersionCandidate(candidates, "v2.1.0+incompatible", 2))
+	assert.Nil(t, findModuleVersionCandidate(candidates, "v3.0.0", 0))
+}
+
+func TestLatestModuleVersionCandidate(t *testing.T) {
+	assert.Nil(t, latestModuleVersionCandidate(nil))
+
+	c1 := semver.SemverCandidate{GitRefHash: "hash1", MajorVersion: 1}
+	c2 := semver.SemverCandidate{
+		GitRefHash:      "hash2",
+		MajorVersion:    1,
+		MinorVersion:    1,
+		PrereleaseLabel: "alpha",
+	}
+	assert.Equal(
+		t,
+		"hash1",
+		latestModuleVersionCandidate(semver.SemverCandidateList{c1, c2}).GitRefHash)
+	assert.Equal(
+		t,
+		"hash2",
+		latestModuleVersionCandidate(semver.SemverCandidateList{c2}).GitRefHash)
+}
+
+func TestUnescapeModulePath(t *testing.T) {
+	unescaped, err := unescapeModulePath("/!burnt!sushi/toml")
+	assert.Nil(t, err)
+	assert.Equal(t, "/BurntSushi/toml", unescaped)
+
+	_, err = unescapeModulePath("/BurntSushi/toml")
+	assert.NotNil(t, err)
+
+	_, err = unescapeModulePath("/burnt!")
+	assert.NotNil(t, err)
+
+	_, err = unescapeModulePath("/!1burnt")
+	assert.NotNil(t, err)
+}
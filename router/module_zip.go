@@ -0,0 +1,308 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	goModFileName        = "go.mod"
+	goModModuleDirective = "module"
+	goFileExtension      = ".go"
+	vendorDirName        = "vendor"
+)
+
+// upstreamArchiveFile is a file of an upstream archive.
+type upstreamArchiveFile struct {
+	// name is the path of the file relative to the root of the repository.
+	name string
+	file *zip.File
+}
+
+// upstreamArchive is the zip archive of a package exactly as it was committed
+// upstream (see upstreamArchiveFetcher).
+type upstreamArchive []upstreamArchiveFile
+
+// readUpstreamArchive reads an upstream archive. Hosts put every file beneath
+// a single top-level directory (e.g. "repo-sha/"), so it is left out of the
+// names of the files.
+func readUpstreamArchive(archive []byte) (upstreamArchive, error) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		files  upstreamArchive
+		prefix string
+	)
+
+	for _, file := range reader.File {
+		if len(prefix) == 0 {
+			i := strings.IndexByte(file.Name, '/')
+			if i < 1 {
+				return nil, errors.New("The archive has no top-level directory.")
+			}
+
+			prefix = file.Name[:i+1]
+		}
+
+		if !strings.HasPrefix(file.Name, prefix) {
+			return nil, errors.New("The archive has more than one top-level directory.")
+		} else if name := file.Name[len(prefix):]; len(name) > 0 {
+			files = append(files, upstreamArchiveFile{name: name, file: file})
+		}
+	}
+
+	return files, nil
+}
+
+// readGoModFile reads the go.mod file at the root of an upstream archive.
+// Returns nil if the archive does not have one.
+func readGoModFile(archive upstreamArchive) ([]byte, error) {
+	for _, file := range archive {
+		if file.name == goModFileName {
+			return readUpstreamArchiveFile(file)
+		}
+	}
+
+	return nil, nil
+}
+
+// readUpstreamArchiveFile reads the contents of a file of an upstream archive.
+func readUpstreamArchiveFile(file upstreamArchiveFile) ([]byte, error) {
+	fileReader, err := file.file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer fileReader.Close()
+
+	return ioutil.ReadAll(fileReader)
+}
+
+// readModulePath reads the module path out of the module directive of a go.mod
+// file. Returns an empty string if there is no module directive.
+func readModulePath(goMod []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(goMod))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, goModModuleDirective+" ") {
+			continue
+		}
+
+		modulePath := strings.TrimSpace(line[len(goModModuleDirective):])
+		if i := strings.Index(modulePath, "//"); i != -1 {
+			modulePath = strings.TrimSpace(modulePath[:i])
+		}
+		if unquoted, err := strconv.Unquote(modulePath); err == nil {
+			modulePath = unquoted
+		}
+
+		return modulePath
+	}
+
+	return ""
+}
+
+// generateModFile generates the go.mod file served for a module. The module
+// directive of the original go.mod (if there was one) is swapped out for the
+// gophr module path so that go accepts it.
+func generateModFile(modulePath string, originalGoMod []byte) []byte {
+	var (
+		buffer          bytes.Buffer
+		scanner         = bufio.NewScanner(bytes.NewReader(originalGoMod))
+		moduleDirective = goModModuleDirective + " " + modulePath + "\n"
+		moduleWritten   = false
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Replace the first module directive we bump into.
+		if !moduleWritten &&
+			strings.HasPrefix(strings.TrimSpace(line), goModModuleDirective+" ") {
+			buffer.WriteString(moduleDirective)
+			moduleWritten = true
+			continue
+		}
+
+		buffer.WriteString(line)
+		buffer.WriteByte('\n')
+	}
+
+	// If there was no module directive, the module directive is all we have.
+	if !moduleWritten {
+		return []byte(moduleDirective)
+	}
+
+	return buffer.Bytes()
+}
+
+// buildModuleZipArgs is the arguments struct for buildModuleZip.
+type buildModuleZipArgs struct {
+	goMod   []byte
+	archive upstreamArchive
+	version string
+	// modulePath is the gophr module path that the zip is served under.
+	modulePath string
+	// originalModulePath is the module path that the package imports itself
+	// with upstream.
+	originalModulePath string
+}
+
+// buildModuleZip re-packages an upstream archive as a go module zip. Every file
+// is moved beneath the "module@version/" prefix, and the go.mod at the root is
+// replaced with goMod. Imports of the package by its own files are rewritten to
+// the gophr module path; every other import is left alone, since the go.mod
+// requires the modules that provide them. Like go itself, it leaves out vendor
+// directories and the directories of nested modules.
+func buildModuleZip(args buildModuleZipArgs) ([]byte, error) {
+	var (
+		buffer           bytes.Buffer
+		writer           = zip.NewWriter(&buffer)
+		prefix           = args.modulePath + "@" + args.version + "/"
+		nestedModuleDirs []string
+	)
+
+	for _, file := range args.archive {
+		if path.Base(file.name) == goModFileName && file.name != goModFileName {
+			nestedModuleDirs = append(nestedModuleDirs, path.Dir(file.name)+"/")
+		}
+	}
+
+	for _, file := range args.archive {
+		// Directories are implied by the paths of the files. Symbolic links are
+		// not allowed in module zips.
+		if file.file.FileInfo().IsDir() ||
+			file.file.Mode()&os.ModeSymlink != 0 ||
+			file.name == goModFileName ||
+			isVendoredFile(file.name) ||
+			hasAnyPrefix(file.name, nestedModuleDirs) {
+			continue
+		}
+
+		contents, err := readUpstreamArchiveFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasSuffix(file.name, goFileExtension) {
+			contents = rewriteSelfImports(rewriteSelfImportsArgs{
+				source:             contents,
+				modulePath:         args.modulePath,
+				nestedModuleDirs:   nestedModuleDirs,
+				originalModulePath: args.originalModulePath,
+			})
+		}
+
+		fileWriter, err := writer.Create(prefix + file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = fileWriter.Write(contents); err != nil {
+			return nil, err
+		}
+	}
+
+	// Lastly, write the go.mod that matches the one served by the proxy.
+	fileWriter, err := writer.Create(prefix + goModFileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = fileWriter.Write(args.goMod); err != nil {
+		return nil, err
+	}
+
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// isVendoredFile returns true if the file is inside of a vendor directory.
+func isVendoredFile(name string) bool {
+	return strings.HasPrefix(name, vendorDirName+"/") ||
+		strings.Contains(name, "/"+vendorDirName+"/")
+}
+
+// hasAnyPrefix returns true if s starts with any of the prefixes.
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rewriteSelfImportsArgs is the arguments struct for rewriteSelfImports.
+type rewriteSelfImportsArgs struct {
+	source             []byte
+	modulePath         string
+	nestedModuleDirs   []string
+	originalModulePath string
+}
+
+// rewriteSelfImports rewrites the imports of a go source file that refer to
+// packages of the original module so that they refer to the same packages of
+// the gophr module instead. Packages of nested modules belong to other
+// modules, so their imports are left alone. Source files that can't be parsed
+// are returned as-is; go will complain about them itself.
+func rewriteSelfImports(args rewriteSelfImportsArgs) []byte {
+	var (
+		fileSet   = token.NewFileSet()
+		file, err = parser.ParseFile(fileSet, "", args.source, parser.ImportsOnly)
+	)
+	if err != nil {
+		return args.source
+	}
+
+	var (
+		buffer  bytes.Buffer
+		written = 0
+	)
+
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		// Only imports of the original module are of interest.
+		var subpath string
+		if importPath == args.originalModulePath {
+			subpath = ""
+		} else if strings.HasPrefix(importPath, args.originalModulePath+"/") {
+			subpath = importPath[len(args.originalModulePath):]
+			if hasAnyPrefix(subpath[1:]+"/", args.nestedModuleDirs) {
+				continue
+			}
+		} else {
+			continue
+		}
+
+		start := fileSet.Position(spec.Path.Pos()).Offset
+		end := fileSet.Position(spec.Path.End()).Offset
+		buffer.Write(args.source[written:start])
+		buffer.WriteString(strconv.Quote(args.modulePath + subpath))
+		written = end
+	}
+
+	if written == 0 {
+		return args.source
+	}
+
+	buffer.Write(args.source[written:])
+	return buffer.Bytes()
+}
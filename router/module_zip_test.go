@@ -0,0 +1,142 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeZipArchive(files map[string]string) []byte {
+	var (
+		buffer bytes.Buffer
+		writer = zip.NewWriter(&buffer)
+	)
+
+	for name, contents := range files {
+		fileWriter, _ := writer.Create(name)
+		fileWriter.Write([]byte(contents))
+	}
+
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func readZipArchive(archive []byte) map[string]string {
+	files := map[string]string{}
+	reader, _ := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	for _, file := range reader.File {
+		fileReader, _ := file.Open()
+		contents, _ := ioutil.ReadAll(fileReader)
+		fileReader.Close()
+		files[file.Name] = string(contents)
+	}
+
+	return files
+}
+
+func fakeUpstreamArchive(files map[string]string) upstreamArchive {
+	prefixed := make(map[string]string)
+	for name, contents := range files {
+		prefixed["repo-sha/"+name] = contents
+	}
+
+	archive, _ := readUpstreamArchive(fakeZipArchive(prefixed))
+	return archive
+}
+
+func TestReadUpstreamArchive(t *testing.T) {
+	archive, err := readUpstreamArchive(fakeZipArchive(map[string]string{
+		"repo-sha/":            "",
+		"repo-sha/main.go":     "package main",
+		"repo-sha/sub/file.go": "package sub",
+	}))
+	assert.Nil(t, err)
+
+	var names []string
+	for _, file := range archive {
+		names = append(names, file.name)
+	}
+	assert.ElementsMatch(t, []string{"main.go", "sub/file.go"}, names)
+
+	_, err = readUpstreamArchive(fakeZipArchive(map[string]string{
+		"repo-sha/main.go":  "package main",
+		"other-sha/main.go": "package main",
+	}))
+	assert.NotNil(t, err, "archives should have exactly one top-level directory")
+
+	_, err = readUpstreamArchive(fakeZipArchive(map[string]string{
+		"main.go": "package main",
+	}))
+	assert.NotNil(t, err, "archives should have a top-level directory")
+
+	_, err = readUpstreamArchive([]byte("this is not a zip"))
+	assert.NotNil(t, err)
+}
+
+func TestReadGoModFile(t *testing.T) {
+	goMod, err := readGoModFile(fakeUpstreamArchive(map[string]string{
+		"main.go":     "package main",
+		"sub/go.mod":  "module sub",
+		"go.mod":      "module github.com/a/b",
+		"sub/main.go": "package main",
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, "module github.com/a/b", string(goMod))
+
+	goMod, err = readGoModFile(fakeUpstreamArchive(map[string]string{
+		"main.go": "package main",
+	}))
+	assert.Nil(t, err)
+	assert.Nil(t, goMod)
+}
+
+func TestReadModulePath(t *testing.T) {
+	assert.Equal(t, "github.com/a/b", readModulePath([]byte("// Comment.\nmodule github.com/a/b\n")))
+	assert.Equal(t, "github.com/a/b/v2", readModulePath([]byte("module \"github.com/a/b/v2\" // Comment.\n")))
+	assert.Equal(t, "", readModulePath([]byte("go 1.12\n")))
+	assert.Equal(t, "", readModulePath(nil))
+}
+
+func TestGenerateModFile(t *testing.T) {
+	assert.Equal(
+		t,
+		"module gophr.pm/a/b\n",
+		string(generateModFile("gophr.pm/a/b", nil)))
+	assert.Equal(
+		t,
+		"// Comment.\nmodule gophr.pm/a/b\n\nrequire github.com/c/d v1.0.0\n",
+		string(generateModFile("gophr.pm/a/b", []byte(
+			"// Comment.\nmodule github.com/a/b\n\nrequire github.com/c/d v1.0.0\n"))))
+}
+
+func TestBuildModuleZip(t *testing.T) {
+	moduleZip, err := buildModuleZip(buildModuleZipArgs{
+		goMod: []byte("module gophr.pm/a/b\n"),
+		archive: fakeUpstreamArchive(map[string]string{
+			"go.mod":               "module github.com/a/b",
+			"main.go":              "package main\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/a/b/sub\"\n\tc \"github.com/a/bc\"\n\t\"github.com/a/b/nested/pkg\"\n)\n",
+			"sub/file.go":          "package sub\n\nimport \"github.com/a/b\"\n",
+			"sub/README.md":        "import \"github.com/a/b\"",
+			"broken.go":            "package broken\n\nimport \"github.com/a/b",
+			"vendor/x/y/y.go":      "package y",
+			"sub/vendor/x/y/y.go":  "package y",
+			"nested/go.mod":        "module github.com/a/b/nested",
+			"nested/pkg/nested.go": "package pkg",
+		}),
+		version:            "v1.0.0",
+		modulePath:         "gophr.pm/a/b",
+		originalModulePath: "github.com/a/b",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"gophr.pm/a/b@v1.0.0/go.mod":        "module gophr.pm/a/b\n",
+		"gophr.pm/a/b@v1.0.0/main.go":       "package main\n\nimport (\n\t\"fmt\"\n\n\t\"gophr.pm/a/b/sub\"\n\tc \"github.com/a/bc\"\n\t\"github.com/a/b/nested/pkg\"\n)\n",
+		"gophr.pm/a/b@v1.0.0/sub/file.go":   "package sub\n\nimport \"gophr.pm/a/b\"\n",
+		"gophr.pm/a/b@v1.0.0/sub/README.md": "import \"github.com/a/b\"",
+		"gophr.pm/a/b@v1.0.0/broken.go":     "package broken\n\nimport \"github.com/a/b",
+	}, readZipArchive(moduleZip))
+}
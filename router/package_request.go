@@ -2,17 +2,16 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"net/http"
+	"time"
 
 	"github.com/gophr-pm/gophr/lib"
-	"github.com/gophr-pm/gophr/lib/config"
 	"github.com/gophr-pm/gophr/lib/db"
-	"github.com/gophr-pm/gophr/lib/db/model/package/archive"
+	"github.com/gophr-pm/gophr/lib/db/model/package/label"
 	"github.com/gophr-pm/gophr/lib/depot"
 	"github.com/gophr-pm/gophr/lib/github"
-	"github.com/gophr-pm/gophr/lib/io"
-	"github.com/gophr-pm/gophr/lib/verdeps"
+	"github.com/gophr-pm/gophr/lib/semver"
+	"github.com/gophr-pm/gophr/lib/vcs"
 )
 
 const (
@@ -36,14 +35,20 @@ type packageRequest struct {
 	parts           *packageRequestParts
 	matchedSHA      string
 	matchedSHALabel string
+	// findStaleVersions finds the versions that may be served instead of the
+	// matched version while the matched version is being archived. It is nil
+	// unless stale versions are to be served.
+	findStaleVersions func() ([]packageVersion, error)
 }
 
 // newPackageRequestArgs is the arguments struct for newPackageRequest.
 type newPackageRequestArgs struct {
-	req           *http.Request
-	ghSvc         github.RequestService
-	DoHTTPHeadReq github.HTTPHeadReq
-	downloadRefs  refsDownloader
+	db              db.Queryable
+	req             *http.Request
+	hosts           vcs.Hosts
+	serveStale      bool
+	downloadRefs    refsDownloader
+	pinVersionLabel versionLabelPinner
 }
 
 // newPackageRequest parses and simplifies the information in a package version
@@ -53,87 +58,273 @@ func newPackageRequest(args newPackageRequestArgs) (*packageRequest, error) {
 	parts, err := readPackageRequestParts(args.req)
 	if err != nil {
 		return nil, err
+	} else if err = assertSupportedHost(args.hosts, parts.author); err != nil {
+		return nil, err
 	}
 
 	var (
-		refs            lib.Refs
-		matchedSHA      string
-		matchedSHALabel string
+		matchedSHA        string
+		matchedSHALabel   string
+		findStaleVersions func() ([]packageVersion, error)
 	)
 
 	if isGoGetRequest(args.req) {
-		// Check if we have a SHA selector.
-		if parts.hasSHASelector() {
-			// If we have a short SHA selector convert it to a full SHA.
-			if parts.hasShortSHASelector {
-				matchedSHA, err = args.ghSvc.ExpandPartialSHA(
-					github.ExpandPartialSHAArgs{
-						Author:     parts.author,
-						Repo:       parts.repo,
-						ShortSHA:   parts.shaSelector,
-						DoHTTPHead: args.DoHTTPHeadReq,
-					})
-				if err != nil {
-					return nil, err
-				}
-			}
+		if matchedSHA, matchedSHALabel, err = resolvePackageVersion(
+			resolvePackageVersionArgs{
+				db:              args.db,
+				parts:           parts,
+				hosts:           args.hosts,
+				downloadRefs:    args.downloadRefs,
+				pinVersionLabel: args.pinVersionLabel,
+			}); err != nil {
+			return nil, err
+		}
 
-			// If we have a full SHA selector set the matchedSHA.
-			if parts.hasFullSHASelector {
-				matchedSHA = parts.shaSelector
+		// Floating semver selectors may be served an older version that has
+		// already been archived while the matched version is archived. They are
+		// only looked for once the matched version turns out not to be archived.
+		if args.serveStale && parts.hasSemverSelector() {
+			findStaleVersions = func() ([]packageVersion, error) {
+				return findStalePackageVersions(findStalePackageVersionsArgs{
+					db:              args.db,
+					parts:           parts,
+					matchedSHA:      matchedSHA,
+					downloadRefs:    args.downloadRefs,
+					getPinnedLabels: label.GetAll,
+				})
 			}
-		} else {
-			if refs, err = args.downloadRefs(
+		}
+	}
+
+	return &packageRequest{
+		req:               args.req,
+		parts:             parts,
+		matchedSHA:        matchedSHA,
+		matchedSHALabel:   matchedSHALabel,
+		findStaleVersions: findStaleVersions,
+	}, nil
+}
+
+// resolvePackageVersionArgs is the arguments struct for
+// resolvePackageVersion.
+type resolvePackageVersionArgs struct {
+	db              db.Queryable
+	parts           *packageRequestParts
+	hosts           vcs.Hosts
+	downloadRefs    refsDownloader
+	pinVersionLabel versionLabelPinner
+}
+
+// resolvePackageVersion finds the full commit SHA that the selector of a
+// package request refers to. If the selector matched a semver candidate or a
+// branch, the candidate or the branch name is returned as the label of the SHA.
+// Date selectors are labelled with the timestamp of the matched commit.
+// Subpaths that start with a major version suffix (e.g. "/v3") restrict
+// versions to that major version. Tagged versions resolve to the SHA that
+// their tag pointed to when they were first resolved, even if the tag has been
+// moved since.
+func resolvePackageVersion(
+	args resolvePackageVersionArgs,
+) (sha string, label string, err error) {
+	parts := args.parts
+
+	// Full SHAs are the only selectors that do not need to be checked against
+	// the refs of the repository.
+	if parts.hasFullSHASelector {
+		return parts.shaSelector, "", nil
+	}
+
+	refs, err := args.downloadRefs(parts.author, parts.repo)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Branch selectors pin the package to the current head of the branch.
+	if parts.hasBranchSelector() {
+		branchHash, ok := refs.Branches[parts.branchSelector]
+		if !ok {
+			return "", "", NewNoSuchPackageVersionError(
 				parts.author,
-				parts.repo); err != nil {
-				return nil, err
-			}
+				parts.repo,
+				parts.selector)
+		}
+
+		return branchHash, parts.branchSelector, nil
+	}
+
+	// Short SHA, date and semver selectors that are also the names of branches
+	// could mean either, so make the client say which one it means.
+	if _, ok := refs.Branches[parts.selector]; ok {
+		selectorType := "version selector"
+		if parts.hasShortSHASelector {
+			selectorType = "short SHA"
+		} else if parts.hasDateSelector() {
+			selectorType = "date"
+		}
+
+		return "", "", NewAmbiguousPackageVersionSelectorError(
+			parts.author,
+			parts.repo,
+			parts.selector,
+			selectorType)
+	}
+
+	// If we have a short SHA selector convert it to a full SHA.
+	if parts.hasShortSHASelector {
+		host, bareAuthor, err := args.hosts.Of(parts.author)
+		if err != nil {
+			return "", "", err
+		}
 
-			if parts.hasSemverSelector() {
-				// If there are no candidates, return in failure.
-				if refs.Candidates == nil || len(refs.Candidates) < 1 {
-					return nil, NewNoSuchPackageVersionError(
-						parts.author,
-						parts.repo,
-						parts.semverSelector.String())
-				}
-				// Find the best candidate.
-				bestCandidate := refs.Candidates.Best(parts.semverSelector)
-				if bestCandidate == nil {
-					return nil, NewNoSuchPackageVersionError(
-						parts.author,
-						parts.repo,
-						parts.semverSelector.String())
-				}
-
-				// Re-serialize the refs data with said candidate.
-				matchedSHA = bestCandidate.GitRefHash
-				matchedSHALabel = bestCandidate.String()
-			} else {
-				// Set the default matched sha in case there is no semver selector.
-				matchedSHA = refs.MasterRefHash
+		if sha, err = host.ExpandPartialSHA(
+			bareAuthor,
+			parts.repo,
+			parts.shaSelector); err != nil {
+			return "", "", err
+		}
+
+		return sha, "", nil
+	}
+
+	// Date selectors pin the package to the last commit before the date.
+	if parts.hasDateSelector() {
+		return resolveDateSelector(args)
+	}
+
+	candidates := candidatesOfPackageRequest(parts, refs)
+
+	// Module-style requests of a major version (e.g. "/author/repo/v3") only
+	// consider versions of that major version.
+	if majorVersion := parts.majorVersion(); majorVersion > 1 {
+		// Without a semver selector, use the latest version of the major version.
+		if !parts.hasSemverSelector() {
+			latestCandidate := latestModuleVersionCandidate(candidates)
+			if latestCandidate == nil {
+				return "", "", NewNoSuchPackageVersionError(
+					parts.author,
+					parts.repo,
+					fmt.Sprintf("v%d", majorVersion))
 			}
+
+			return resolveCandidate(args, refs, *latestCandidate)
 		}
 	}
 
-	return &packageRequest{
-		req:             args.req,
-		parts:           parts,
-		matchedSHA:      matchedSHA,
-		matchedSHALabel: matchedSHALabel,
-	}, nil
+	// Without a semver selector, use the default branch.
+	if !parts.hasSemverSelector() {
+		return refs.DefaultRefHash, "", nil
+	}
+
+	// If there are no candidates, return in failure.
+	semverConstraint := parts.semverConstraint()
+	if candidates == nil || len(candidates) < 1 {
+		return "", "", NewNoSuchPackageVersionError(
+			parts.author,
+			parts.repo,
+			semverConstraint.String())
+	}
+
+	// Find the best candidate.
+	bestCandidate := candidates.Best(semverConstraint)
+	if bestCandidate == nil {
+		return "", "", NewNoSuchPackageVersionError(
+			parts.author,
+			parts.repo,
+			semverConstraint.String())
+	}
+
+	return resolveCandidate(args, refs, *bestCandidate)
+}
+
+// candidatesOfPackageRequest returns the semver candidates that a package
+// request may resolve to. Requests of go modules in sub-directories (e.g.
+// "/author/repo/sub/module") only consider the versions that the modules were
+// tagged with (e.g. "sub/module/v1.2.3"). Module-style requests of a major
+// version (e.g. "/author/repo/v3") only consider versions of that major
+// version.
+func candidatesOfPackageRequest(
+	parts *packageRequestParts,
+	refs lib.Refs,
+) semver.SemverCandidateList {
+	candidates := refs.CandidatesOf(parts.subpath)
+	if majorVersion := parts.majorVersion(); majorVersion > 1 {
+		candidates = candidates.OfMajorVersion(majorVersion)
+	}
+
+	return candidates
+}
+
+// resolveCandidate finds the SHA that a matched semver candidate resolves to.
+// The tags of candidates are pinned to the SHA that they resolve to the first
+// time around. Candidates read from branches are not pinned, since branches
+// are meant to move.
+func resolveCandidate(
+	args resolvePackageVersionArgs,
+	refs lib.Refs,
+	candidate semver.SemverCandidate,
+) (sha string, versionLabel string, err error) {
+	if sha, err = pinCandidate(pinCandidateArgs{
+		db:              args.db,
+		refs:            refs,
+		repo:            args.parts.repo,
+		author:          args.parts.author,
+		candidate:       candidate,
+		pinVersionLabel: args.pinVersionLabel,
+	}); err != nil {
+		return "", "", err
+	}
+
+	return sha, candidate.String(), nil
+}
+
+// resolveDateSelector finds the last commit on the default branch that was
+// made at or before the instant selected by a date selector.
+func resolveDateSelector(
+	args resolvePackageVersionArgs,
+) (sha string, label string, err error) {
+	parts := args.parts
+	host, bareAuthor, err := args.hosts.Of(parts.author)
+	if err != nil {
+		return "", "", err
+	}
+
+	if sha, err = host.FetchCommitSHA(
+		bareAuthor,
+		parts.repo,
+		parts.dateSelector); err == vcs.ErrUnsupported {
+		return "", "", NewUnsupportedPackageVersionSelectorError(
+			parts.author,
+			parts.repo,
+			parts.selector,
+			err)
+	} else if err != nil {
+		return "", "", err
+	}
+
+	// Hosts fall back to later commits when there are none before the date, so
+	// make sure that the commit is not from the future.
+	commitDate, err := host.FetchCommitTimestamp(bareAuthor, parts.repo, sha)
+	if err != nil {
+		return "", "", err
+	} else if commitDate.After(parts.dateSelector) {
+		return "", "", NewNoSuchPackageVersionError(
+			parts.author,
+			parts.repo,
+			parts.selector)
+	}
+
+	return sha, commitDate.UTC().Format(time.RFC3339), nil
 }
 
 // respondToPackageRequestArgs is the arguments struct for
 // packageRequest#respond.
 type respondToPackageRequestArgs struct {
-	io                    io.IO
 	db                    db.Client
 	res                   http.ResponseWriter
-	conf                  *config.Config
-	creds                 *config.Credentials
 	ghSvc                 github.RequestService
-	versionPackage        packageVersioner
+	hosts                 vcs.Hosts
+	awaitArchival         packageArchivalAwaiter
+	enqueueArchival       packageArchivalEnqueuer
 	isPackageArchived     packageArchivalChecker
 	recordPackageArchival packageArchivalRecorder
 	recordPackageDownload packageDownloadRecorder
@@ -144,65 +335,40 @@ type respondToPackageRequestArgs struct {
 func (pr *packageRequest) respond(args respondToPackageRequestArgs) error {
 	// This means that go-get is requesting package/repository metadata.
 	if isGoGetRequest(pr.req) {
-		// Check whether this package has already been archived.
-		packageArchived, err := args.isPackageArchived(packageArchivalCheckerArgs{
+		// Package versions are checked at most once while responding, no matter
+		// how many steps want to know whether they are archived.
+		args.isPackageArchived = memoizePackageArchivalChecks(
+			args.isPackageArchived)
+
+		// If the matched version has yet to be archived, settle for a stale one
+		// in the meantime.
+		if pr.findStaleVersions != nil {
+			pr.fallBackToStaleVersion(args)
+		}
+
+		// Make sure that this package version has been archived before
+		// responding.
+		if err := ensurePackageArchived(ensurePackageArchivedArgs{
 			db:                    args.db,
 			sha:                   pr.matchedSHA,
 			repo:                  pr.parts.repo,
 			author:                pr.parts.author,
-			packageExistsInDepot:  packageExistsInDepot,
+			selector:              pr.parts.selector,
+			awaitArchival:         args.awaitArchival,
+			enqueueArchival:       args.enqueueArchival,
+			isPackageArchived:     args.isPackageArchived,
 			recordPackageArchival: args.recordPackageArchival,
-			isPackageArchivedInDB: archives.Exists,
-		})
-		// If we cannot check whether a package has been archived, return
-		// unsuccessfully.
-		if err != nil {
+		}); err != nil {
 			return err
 		}
 
-		// Only run the sub-versioning for this package if we haven't before.
-		if !packageArchived {
-			// Indicate in the logs that the package was archived.
-			log.Printf(
-				"Package %s/%s@%s has not yet been archived.\n",
-				pr.parts.author,
-				pr.parts.repo,
-				pr.matchedSHA)
-
-			// Perform sub-versioning.
-			if err := args.versionPackage(packageVersionerArgs{
-				io:                     args.io,
-				db:                     args.db,
-				sha:                    pr.matchedSHA,
-				repo:                   pr.parts.repo,
-				conf:                   args.conf,
-				creds:                  args.creds,
-				ghSvc:                  args.ghSvc,
-				author:                 pr.parts.author,
-				pushToDepot:            pushToDepot,
-				versionDeps:            verdeps.VersionDeps,
-				downloadPackage:        downloadPackage,
-				createDepotRepo:        createRepoInDepot,
-				destroyDepotRepo:       deleteRepoInDepot,
-				isPackageArchived:      isPackageArchived,
-				constructionZonePath:   args.conf.ConstructionZonePath,
-				recordPackageArchival:  args.recordPackageArchival,
-				attemptWorkDirDeletion: deleteFolder,
-			}); err != nil {
-				// Report the sub-versioning failure to the logs.
-				log.Printf(
-					"Sub-versioning failed for package %s/%s@%s: %v\n",
-					pr.parts.author,
-					pr.parts.repo,
-					pr.matchedSHA,
-					err)
-
-				return err
-			}
-		}
-
 		// At this point, this must be a go-get request. Compile the go-get metadata
 		// accordingly.
+		host, bareAuthor, err := args.hosts.Of(pr.parts.author)
+		if err != nil {
+			return err
+		}
+
 		var (
 			domain   = getRequestDomain(pr.req)
 			metaData = []byte(generateGoGetMetadata(generateGoGetMetadataArgs{
@@ -214,8 +380,8 @@ func (pr *packageRequest) respond(args respondToPackageRequestArgs) error {
 						pr.parts.author,
 						pr.parts.repo,
 						pr.matchedSHA)),
-				treeURLTemplate: generateGithubTreeURLTemplate(
-					pr.parts.author,
+				treeURLTemplate: host.TreeURLTemplate(
+					bareAuthor,
 					pr.parts.repo,
 					pr.matchedSHA),
 				blobURLTemplate: generateDepotBlobURLTemplate(
@@ -223,6 +389,8 @@ func (pr *packageRequest) respond(args respondToPackageRequestArgs) error {
 					pr.parts.author,
 					pr.parts.repo,
 					pr.matchedSHA),
+				matchedSHA:      pr.matchedSHA,
+				matchedSHALabel: pr.matchedSHALabel,
 			}))
 		)
 
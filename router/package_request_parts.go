@@ -5,34 +5,40 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gophr-pm/gophr/lib/semver"
+	"github.com/gophr-pm/gophr/lib/vcs"
 )
 
 const (
-	at                          = '@'
-	dot                         = '.'
-	slash                       = '/'
-	hyphen                      = '-'
-	shaLength                   = 40
-	minShortSHALength           = 6
-	semverSelectorRegexTemplate = `([\%c\%c]?)([0-9]+)(?:\.([0-9]+|%c))?(?:\.([0-9]+|%c))?(?:\-([a-zA-Z0-9\-_]+[a-zA-Z0-9])(?:\.([0-9]+|%c))?)?([\%c\%c]?)`
+	at                   = '@'
+	dot                  = '.'
+	slash                = '/'
+	hyphen               = '-'
+	shaLength            = 40
+	minShortSHALength    = 6
+	branchSelectorPrefix = "branch:"
+	dateSelectorPrefix   = "date:"
+	dateSelectorLayout   = "2006-01-02"
 )
 
 var (
-	// semverSelectorRegex is the regular expression used to parse semver package
-	// version selectors.
-	semverSelectorRegex = regexp.MustCompile(fmt.Sprintf(
-		semverSelectorRegexTemplate,
-		semver.SemverSelectorTildeChar,
-		semver.SemverSelectorCaratChar,
-		semver.SemverSelectorWildcardChar,
-		semver.SemverSelectorWildcardChar,
-		semver.SemverSelectorWildcardChar,
-		semver.SemverSelectorLessThanChar,
-		semver.SemverSelectorGreaterThanChar,
-	))
+	// hexRegex matches strings that could be (partial) commit SHAs.
+	hexRegex = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+	// dateSelectorRegex matches selectors shaped like dates (e.g. "2017-03-14")
+	// or RFC3339 timestamps (e.g. "2017-03-14T15:09:26Z").
+	dateSelectorRegex = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}(?:T.*)?$`)
+	// branchNameRegex matches the branch names that may be used as selectors.
+	// It is stricter than git itself: branch names with slashes are not
+	// supported, since slashes separate the selector from the subpath.
+	branchNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_\.\-\+]*$`)
+	// majorVersionSubpathRegex matches subpaths that start with a go module
+	// major version suffix (e.g. "/v3/c"). The only capture group is the major
+	// version.
+	majorVersionSubpathRegex = regexp.MustCompile(`^/v([2-9]|[1-9][0-9]+)(?:/|$)`)
 )
 
 // packageRequestParts represents the piecewise breakdown of a package request.
@@ -44,6 +50,9 @@ type packageRequestParts struct {
 	subpath               string
 	selector              string
 	shaSelector           string
+	dateSelector          time.Time
+	branchSelector        string
+	semverRange           semver.SemverRange
 	semverSelector        semver.SemverSelector
 	hasFullSHASelector    bool
 	hasShortSHASelector   bool
@@ -60,12 +69,50 @@ func (parts *packageRequestParts) hasSemverSelector() bool {
 	return parts.semverSelectorDefined
 }
 
+// semverConstraint returns the semver range of this parts struct if it has
+// one, and its semver selector otherwise.
+func (parts *packageRequestParts) semverConstraint() semver.SemverConstraint {
+	if len(parts.semverRange.Groups) > 0 {
+		return parts.semverRange
+	}
+
+	return parts.semverSelector
+}
+
+// hasDateSelector returns true if this parts struct has a date selector.
+func (parts *packageRequestParts) hasDateSelector() bool {
+	return !parts.dateSelector.IsZero()
+}
+
+// hasBranchSelector returns true if this parts struct has a branch selector.
+func (parts *packageRequestParts) hasBranchSelector() bool {
+	return len(parts.branchSelector) > 0
+}
+
+// majorVersion returns the go module major version that the subpath of this
+// parts struct starts with (e.g. 3 for "/v3/c"). Returns 0 if the subpath
+// doesn't start with a major version suffix.
+func (parts *packageRequestParts) majorVersion() int {
+	match := majorVersionSubpathRegex.FindStringSubmatch(parts.subpath)
+	if match == nil {
+		return 0
+	}
+
+	majorVersion, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0
+	}
+
+	return majorVersion
+}
+
 // getBasePackagePath returns the base package path of the data in parts.
-// Simply, it is everything minus the base path and the domain.
+// Simply, it is everything minus the base path and the domain. Packages hosted
+// somewhere other than Github keep the domain of their host.
 func (parts *packageRequestParts) getBasePackagePath() string {
 	var buffer bytes.Buffer
 	buffer.WriteByte(slash)
-	buffer.WriteString(parts.author)
+	buffer.WriteString(vcs.AuthorPath(parts.author))
 	buffer.WriteByte(slash)
 	buffer.WriteString(parts.repo)
 
@@ -106,9 +153,17 @@ func (parts *packageRequestParts) String() string {
 	if parts != nil {
 		b.WriteString(parts.shaSelector)
 	}
-	b.WriteString("\", semverSelector: ")
+	b.WriteString("\", dateSelector: \"")
+	if parts != nil && parts.hasDateSelector() {
+		b.WriteString(parts.dateSelector.Format(time.RFC3339))
+	}
+	b.WriteString("\", branchSelector: \"")
 	if parts != nil {
-		b.WriteString(parts.semverSelector.String())
+		b.WriteString(parts.branchSelector)
+	}
+	b.WriteString("\", semverSelector: ")
+	if parts != nil && parts.hasSemverSelector() {
+		b.WriteString(parts.semverConstraint().String())
 	}
 	b.WriteString("\", hasFullSHASelector: ")
 	if parts != nil {
@@ -135,11 +190,17 @@ func (parts *packageRequestParts) String() string {
 // request, and breaks down the URL of the request into parts. Lastly, the parts
 // are composed into a parts struct and returned.
 func readPackageRequestParts(req *http.Request) (*packageRequestParts, error) {
+	return parsePackageRequestPath(strings.TrimSpace(req.URL.Path))
+}
+
+// parsePackageRequestPath breaks down the path of a package request (e.g.
+// "/author/repo@selector/subpath") into parts.
+func parsePackageRequestPath(url string) (*packageRequestParts, error) {
 	var (
 		i      = 0
-		url    = strings.TrimSpace(req.URL.Path)
 		urlLen = len(url)
 
+		domain              string
 		repoEndIndex        = -1 // Exclusive
 		repoStartIndex      = -1 // Inclusive
 		authorEndIndex      = -1
@@ -151,6 +212,9 @@ func readPackageRequestParts(req *http.Request) (*packageRequestParts, error) {
 
 		selector              string
 		shaSelector           string
+		dateSelector          time.Time
+		branchSelector        string
+		semverRange           semver.SemverRange
 		semverSelector        semver.SemverSelector
 		semverSelectorDefined bool
 	)
@@ -165,6 +229,19 @@ func readPackageRequestParts(req *http.Request) (*packageRequestParts, error) {
 	// the beginning of the author.
 	authorStartIndex = 1
 
+	// Unless, of course, the first segment is a domain. In that case, the
+	// package lives on a host other than Github, and the author comes after it.
+	// Domains that aren't on the public internet can never host packages.
+	if i = strings.IndexByte(url[1:], slash) + 1; i > 1 &&
+		strings.IndexByte(url[1:i], dot) != -1 {
+		domain = url[1:i]
+		authorStartIndex = i + 1
+
+		if !vcs.IsPublicDomain(domain) {
+			return nil, NewInvalidPackageVersionRequestURLError(url)
+		}
+	}
+
 	// Next step is to scan to the next slash to find the beginning of the repo.
 	for i = authorStartIndex + 1; i < urlLen && url[i] != slash; i = i + 1 {
 	}
@@ -180,6 +257,7 @@ func readPackageRequestParts(req *http.Request) (*packageRequestParts, error) {
 	// So, we have arrived at the slash that prefixes the repo.
 	authorEndIndex = i
 	repoStartIndex = i + 1
+	author := vcs.QualifyAuthor(domain, url[authorStartIndex:authorEndIndex])
 
 	// Next step is to scan to the next slash OR at OR end of the string.
 	for i = repoStartIndex; i < urlLen; i = i + 1 {
@@ -206,7 +284,7 @@ func readPackageRequestParts(req *http.Request) (*packageRequestParts, error) {
 		return &packageRequestParts{
 			url:    url,
 			repo:   url[repoStartIndex:urlLen],
-			author: url[authorStartIndex:authorEndIndex],
+			author: author,
 		}, nil
 	}
 
@@ -223,21 +301,57 @@ func readPackageRequestParts(req *http.Request) (*packageRequestParts, error) {
 		// Whatever the case may be, this is where the selector ends.
 		selector = url[selectorStartIndex:i]
 
-		// Read the selector to figure out what it is.
-		if isShortSHASelector(selector) {
-			shaSelector = selector
-			hasShortSHASelector = true
+		// Read the selector to figure out what it is. The rules are applied in
+		// order, so every selector means exactly one thing:
+		//   1. "branch:name" always selects the head of the branch "name".
+		//   2. "date:timestamp" always selects the last commit before timestamp.
+		//   3. 40 hexadecimal characters are a full SHA.
+		//   4. 6 to 39 hexadecimal characters are a short SHA.
+		//   5. Anything shaped like a date or an RFC3339 timestamp is a date.
+		//   6. Anything shaped like a semver selector or range is semver.
+		//   7. Anything else that is a valid branch name selects that branch.
+		// Since hexadecimal, date and semver selectors can also be valid branch
+		// names, they are checked against the branches of the repository once it is
+		// resolved (see resolvePackageVersion).
+		if strings.HasPrefix(selector, branchSelectorPrefix) {
+			branchSelector = selector[len(branchSelectorPrefix):]
+			if !isBranchSelector(branchSelector) {
+				return nil, NewInvalidPackageVersionRequestURLError(
+					url,
+					fmt.Errorf("Invalid branch name \"%s\"", branchSelector))
+			}
+		} else if strings.HasPrefix(selector, dateSelectorPrefix) {
+			var err error
+			if dateSelector, err = readDateSelector(
+				selector[len(dateSelectorPrefix):]); err != nil {
+				return nil, NewInvalidPackageVersionRequestURLError(url, err)
+			}
 		} else if isFullSHASelector(selector) {
 			shaSelector = selector
 			hasFullSHASelector = true
-		} else {
+		} else if isShortSHASelector(selector) {
+			shaSelector = selector
+			hasShortSHASelector = true
+		} else if dateSelectorRegex.MatchString(selector) {
+			var err error
+			if dateSelector, err = readDateSelector(selector); err != nil {
+				return nil, NewInvalidPackageVersionRequestURLError(url, err)
+			}
+		} else if semver.IsSemverSelectorString(selector) {
 			var err error
-			if semverSelector, err = readSemverSelector(selector); err != nil {
+			if semverSelector, semverRange, err = semver.ReadSemverSelector(
+				selector); err != nil {
 				return nil, NewInvalidPackageVersionRequestURLError(url, err)
 			}
 
 			// If we got here, the semver selector exists.
 			semverSelectorDefined = true
+		} else if isBranchSelector(selector) {
+			branchSelector = selector
+		} else {
+			return nil, NewInvalidPackageVersionRequestURLError(
+				url,
+				fmt.Errorf("Invalid version selector \"%s\"", selector))
 		}
 
 		// If we're out of url bytes then there is no subpath.
@@ -245,9 +359,12 @@ func readPackageRequestParts(req *http.Request) (*packageRequestParts, error) {
 			return &packageRequestParts{
 				url:                   url,
 				repo:                  url[repoStartIndex:repoEndIndex],
-				author:                url[authorStartIndex:authorEndIndex],
+				author:                author,
 				selector:              selector,
 				shaSelector:           shaSelector,
+				dateSelector:          dateSelector,
+				branchSelector:        branchSelector,
+				semverRange:           semverRange,
 				semverSelector:        semverSelector,
 				hasFullSHASelector:    hasFullSHASelector,
 				hasShortSHASelector:   hasShortSHASelector,
@@ -268,10 +385,13 @@ func readPackageRequestParts(req *http.Request) (*packageRequestParts, error) {
 	return &packageRequestParts{
 		url:                   url,
 		repo:                  url[repoStartIndex:repoEndIndex],
-		author:                url[authorStartIndex:authorEndIndex],
+		author:                author,
 		subpath:               url[subpathStartIndex:urlLen],
 		selector:              selector,
 		shaSelector:           shaSelector,
+		dateSelector:          dateSelector,
+		branchSelector:        branchSelector,
+		semverRange:           semverRange,
 		semverSelector:        semverSelector,
 		hasFullSHASelector:    hasFullSHASelector,
 		hasShortSHASelector:   hasShortSHASelector,
@@ -279,40 +399,41 @@ func readPackageRequestParts(req *http.Request) (*packageRequestParts, error) {
 	}, nil
 }
 
-// isFullSHASelector returns true if the selector is in a full sha
+// isFullSHASelector returns true if the selector is a full commit SHA.
 func isFullSHASelector(selector string) bool {
-	// If it isn't a sha hash (which is 40 characters long), then its a semver
-	// selector for out purposes.
-	return len(selector) == shaLength && strings.IndexByte(selector, dot) == -1
+	return len(selector) == shaLength && hexRegex.MatchString(selector)
 }
 
+// isShortSHASelector returns true if the selector could be an abbreviated
+// commit SHA.
 func isShortSHASelector(selector string) bool {
-	// If it isn't a 6 character short SHA return false, it might be semvar
 	return len(selector) >= minShortSHALength &&
 		len(selector) < shaLength &&
-		strings.IndexByte(selector, dot) == -1 &&
-		strings.IndexByte(selector, hyphen) == -1
+		hexRegex.MatchString(selector)
 }
 
-// isSemverSelector converts a semver selector string into a semver selector.
-func readSemverSelector(selector string) (semver.SemverSelector, error) {
-	match := semverSelectorRegex.FindStringSubmatch(selector)
-	if match == nil {
-		return semver.SemverSelector{}, fmt.Errorf("Invalid version selector \"%s\"", selector)
+// isBranchSelector returns true if the selector is a usable branch name. On top
+// of the characters allowed by branchNameRegex, git forbids "..", and names
+// ending with "." or ".lock".
+func isBranchSelector(selector string) bool {
+	return branchNameRegex.MatchString(selector) &&
+		!strings.Contains(selector, "..") &&
+		!strings.HasSuffix(selector, ".") &&
+		!strings.HasSuffix(selector, ".lock")
+}
+
+// readDateSelector converts a date (e.g. "2017-03-14") or an RFC3339 timestamp
+// (e.g. "2017-03-14T15:09:26Z") into the instant that it selects. Dates select
+// midnight UTC at the start of the day.
+func readDateSelector(selector string) (time.Time, error) {
+	if date, err := time.Parse(dateSelectorLayout, selector); err == nil {
+		return date, nil
 	}
 
-	semverSelector, err := semver.NewSemverSelector(
-		match[1], // Prefix
-		match[2], // Major Version
-		match[3], // Minor Version
-		match[4], // Patch Version
-		match[5], // Pre-release Label
-		match[6], // Pre-release Version
-		match[7], // Suffix
-	)
+	timestamp, err := time.Parse(time.RFC3339, selector)
 	if err != nil {
-		return semver.SemverSelector{}, err
+		return time.Time{}, fmt.Errorf("Invalid date selector \"%s\"", selector)
 	}
 
-	return semverSelector, nil
+	return timestamp, nil
 }
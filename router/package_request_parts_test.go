@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gophr-pm/gophr/lib/semver"
 	"github.com/stretchr/testify/assert"
@@ -39,7 +40,7 @@ func TestHasSemverSelector(t *testing.T) {
 
 func TestReadPackageRequestParts_invalidPackageRequest(t *testing.T) {
 	// Invalid semvers
-	req := &http.Request{URL: &url.URL{Path: "/abc/def@sddm/ghi"}}
+	req := &http.Request{URL: &url.URL{Path: "/abc/def@s..dm/ghi"}}
 	_, err := readPackageRequestParts(req)
 	assert.NotNil(t, err)
 
@@ -179,3 +180,169 @@ func TestReadPackageRequestParts(t *testing.T) {
 		reflect.DeepEqual(expectedParts, actualParts),
 		fmt.Sprintf("%s should equal %s", actualParts.String(), expectedParts.String()))
 }
+
+func TestReadPackageRequestParts_otherHosts(t *testing.T) {
+	req := &http.Request{URL: &url.URL{Path: "/gitlab.com/abc/def@1.2/ghi"}}
+	actualParts, err := readPackageRequestParts(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "gitlab.com:abc", actualParts.author)
+	assert.Equal(t, "def", actualParts.repo)
+	assert.Equal(t, "/ghi", actualParts.subpath)
+	assert.Equal(t, "1.2", actualParts.selector)
+	assert.Equal(t, "/gitlab.com/abc/def@1.2", actualParts.getBasePackagePath())
+
+	// Github is the default, so its domain is redundant.
+	req = &http.Request{URL: &url.URL{Path: "/github.com/abc/def"}}
+	actualParts, err = readPackageRequestParts(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", actualParts.author)
+	assert.Equal(t, "def", actualParts.repo)
+	assert.Equal(t, "/abc/def", actualParts.getBasePackagePath())
+
+	req = &http.Request{URL: &url.URL{Path: "/gitlab.com/abc"}}
+	_, err = readPackageRequestParts(req)
+	assert.NotNil(t, err)
+
+	req = &http.Request{URL: &url.URL{Path: "/gitlab.com//def"}}
+	_, err = readPackageRequestParts(req)
+	assert.NotNil(t, err)
+
+	// Domains that aren't on the public internet can't host packages.
+	for _, path := range []string{
+		"/127.0.0.1/abc/def",
+		"/169.254.169.254/abc/def",
+		"/localhost.localdomain/abc/def",
+		"/git.internal/abc/def",
+		"/git.example.com:8080/abc/def",
+	} {
+		req = &http.Request{URL: &url.URL{Path: path}}
+		_, err = readPackageRequestParts(req)
+		assert.IsType(t, InvalidPackageVersionRequestURLError{}, err, path)
+	}
+}
+
+func TestReadPackageRequestParts_selectorTypes(t *testing.T) {
+	for _, test := range []struct {
+		selector       string
+		shaSelector    string
+		branchSelector string
+		isSemver       bool
+	}{
+		{"develop", "", "develop", false},
+		{"release-2.x", "", "release-2.x", false},
+		{"master", "", "master", false},
+		{"v1", "", "v1", false},
+		{"branch:cafe12", "", "cafe12", false},
+		{"branch:1.x", "", "1.x", false},
+		{"cafe12", "cafe12", "", false},
+		{"123456", "123456", "", false},
+		{"1.x", "", "", true},
+		{"^1.2", "", "", true},
+		{"2.3.4-beta.1+", "", "", true},
+	} {
+		req := &http.Request{URL: &url.URL{Path: "/ab/cd@" + test.selector + "/c"}}
+		parts, err := readPackageRequestParts(req)
+		assert.Nil(t, err, test.selector)
+		assert.Equal(t, test.selector, parts.selector, test.selector)
+		assert.Equal(t, test.shaSelector, parts.shaSelector, test.selector)
+		assert.Equal(t, test.branchSelector, parts.branchSelector, test.selector)
+		assert.Equal(t, test.isSemver, parts.hasSemverSelector(), test.selector)
+		assert.Equal(t, "/c", parts.subpath, test.selector)
+	}
+
+	for _, selector := range []string{
+		"branch:",
+		"branch:a..b",
+		"-develop",
+		"develop.",
+		"develop.lock",
+		"feature~1",
+		"1.x.x+",
+		"2017-13-01",
+		"2017-03-14T25:00:00Z",
+		"date:yesterday",
+	} {
+		req := &http.Request{URL: &url.URL{Path: "/ab/cd@" + selector}}
+		_, err := readPackageRequestParts(req)
+		assert.NotNil(t, err, selector)
+	}
+}
+
+func TestReadPackageRequestParts_dateSelectors(t *testing.T) {
+	for _, test := range []struct {
+		selector string
+		date     time.Time
+	}{
+		{"2017-03-14", time.Date(2017, 3, 14, 0, 0, 0, 0, time.UTC)},
+		{"2017-03-14T15:09:26Z", time.Date(2017, 3, 14, 15, 9, 26, 0, time.UTC)},
+		{"2017-03-14T15:09:26+01:00", time.Date(2017, 3, 14, 14, 9, 26, 0, time.UTC)},
+		{"date:2017-03-14", time.Date(2017, 3, 14, 0, 0, 0, 0, time.UTC)},
+	} {
+		req := &http.Request{URL: &url.URL{Path: "/ab/cd@" + test.selector + "/c"}}
+		parts, err := readPackageRequestParts(req)
+		assert.Nil(t, err, test.selector)
+		assert.True(t, parts.hasDateSelector(), test.selector)
+		assert.True(t, test.date.Equal(parts.dateSelector), test.selector)
+		assert.False(t, parts.hasSemverSelector(), test.selector)
+		assert.False(t, parts.hasBranchSelector(), test.selector)
+		assert.Equal(t, "/c", parts.subpath, test.selector)
+	}
+}
+
+func TestReadPackageRequestParts_semverRanges(t *testing.T) {
+	for _, test := range []struct {
+		selector string
+		groups   int
+	}{
+		{"ge1.2.0,lt1.5.0", 1},
+		{"1.x;2.3.x", 2},
+		{"gt1", 1},
+		{"ge1.2.0-beta,lt1.3;~2.1.0;3.x", 3},
+	} {
+		req := &http.Request{URL: &url.URL{Path: "/ab/cd@" + test.selector + "/c"}}
+		parts, err := readPackageRequestParts(req)
+		assert.Nil(t, err, test.selector)
+		assert.True(t, parts.hasSemverSelector(), test.selector)
+		assert.Equal(t, test.groups, len(parts.semverRange.Groups), test.selector)
+		assert.Equal(t, test.selector, parts.semverConstraint().String(), test.selector)
+		assert.Equal(t, "/c", parts.subpath, test.selector)
+	}
+
+	// Plain selectors are not ranges.
+	req := &http.Request{URL: &url.URL{Path: "/ab/cd@^1.2"}}
+	parts, err := readPackageRequestParts(req)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(parts.semverRange.Groups))
+	assert.Equal(t, parts.semverSelector, parts.semverConstraint())
+
+	for _, selector := range []string{
+		"ge1.2.0,",
+		";1.x",
+		"1.x;;2.x",
+		"ge1.x",
+		"lt^1.2",
+		"gt1.2+",
+		"ge1.2.0,,lt2",
+		"ge1.2.0,lt1.x.x",
+	} {
+		req := &http.Request{URL: &url.URL{Path: "/ab/cd@" + selector}}
+		_, err := readPackageRequestParts(req)
+		assert.NotNil(t, err, selector)
+	}
+}
+
+func TestPackageRequestPartsMajorVersion(t *testing.T) {
+	for path, majorVersion := range map[string]int{
+		"/ab/cd":          0,
+		"/ab/cd/v3":       3,
+		"/ab/cd@1.x/v12/": 12,
+		"/ab/cd/v3/ef":    3,
+		"/ab/cd/v1/ef":    0,
+		"/ab/cd/ef/v3":    0,
+		"/ab/cd/v3ef":     0,
+	} {
+		parts, err := parsePackageRequestPath(path)
+		assert.Nil(t, err, path)
+		assert.Equal(t, majorVersion, parts.majorVersion(), path)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/gophr-pm/gophr/lib"
 	"github.com/gophr-pm/gophr/lib/github"
 	"github.com/gophr-pm/gophr/lib/semver"
+	"github.com/gophr-pm/gophr/lib/vcs"
 	"github.com/jinzhu/copier"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -29,7 +31,7 @@ func fakeRefs(masterRefHash string, candidates []semver.SemverCandidate) lib.Ref
 	newFakeRefs := lib.Refs{}
 	copier.Copy(&newFakeRefs, &baseFakeRefs)
 	if len(masterRefHash) > 0 {
-		newFakeRefs.MasterRefHash = masterRefHash
+		newFakeRefs.DefaultRefHash = masterRefHash
 	}
 	if candidates != nil {
 		newFakeRefs.Candidates = candidates
@@ -53,6 +55,18 @@ func fakeRefsDownloader(refs lib.Refs, err error) refsDownloader {
 	}
 }
 
+// fakeVersionLabelPinner pins version labels to the SHAs in pins, or else to
+// what they resolve to upstream.
+func fakeVersionLabelPinner(pins map[string]string) versionLabelPinner {
+	return func(args versionLabelPinnerArgs) (string, error) {
+		if sha, ok := pins[args.label]; ok {
+			return sha, nil
+		}
+
+		return args.sha, nil
+	}
+}
+
 func reflines(lines ...string) string {
 	var buf bytes.Buffer
 	buf.WriteString("001e# service=git-upload-pack\n0000")
@@ -67,7 +81,10 @@ func TestNewPackageRequest(t *testing.T) {
 	// TODO(skeswa): @Shikkic, I need this test to be tweaked. Most of this is
 	// still ok. The stuff that counds on refsData needs to be removed.
 
+	hosts := vcs.NewHosts(vcs.NewGenericHost(vcs.GithubDomain))
+
 	pr, err := newPackageRequest(newPackageRequestArgs{
+		hosts:        hosts,
 		req:          fakeHTTPRequest("testalicious.af", "////", false),
 		downloadRefs: fakeRefsDownloader(lib.Refs{}, nil),
 	})
@@ -75,14 +92,26 @@ func TestNewPackageRequest(t *testing.T) {
 	assert.NotNil(t, err)
 
 	pr, err = newPackageRequest(newPackageRequestArgs{
+		hosts:        hosts,
 		req:          fakeHTTPRequest("testalicious.af", "/myauthor/myrepo/mysubpath", true),
 		downloadRefs: fakeRefsDownloader(lib.Refs{}, errors.New("This is an error.")),
 	})
 	assert.Nil(t, pr)
 	assert.NotNil(t, err)
 
+	// Packages on hosts that aren't supported should be turned away before
+	// anything is fetched.
+	pr, err = newPackageRequest(newPackageRequestArgs{
+		req:          fakeHTTPRequest("testalicious.af", "/git.example.com/myauthor/myrepo", true),
+		hosts:        hosts,
+		downloadRefs: fakeRefsDownloader(lib.Refs{}, errors.New("This is an error.")),
+	})
+	assert.Nil(t, pr)
+	assert.Equal(t, NewUnsupportedPackageHostError("git.example.com"), err)
+
 	req := fakeHTTPRequest("testalicious.af", "/myauthor/myrepo/mysubpath", true)
 	pr, err = newPackageRequest(newPackageRequestArgs{
+		hosts:        hosts,
 		req:          req,
 		downloadRefs: fakeRefsDownloader(fakeRefs("mymasterhash", nil), nil),
 	})
@@ -93,6 +122,7 @@ func TestNewPackageRequest(t *testing.T) {
 
 	req = fakeHTTPRequest("testalicious.af", "/myauthor/myrepo@1.x/mysubpath", true)
 	pr, err = newPackageRequest(newPackageRequestArgs{
+		hosts:        hosts,
 		req:          req,
 		downloadRefs: fakeRefsDownloader(fakeRefs("mymasterhash", []semver.SemverCandidate{}), nil),
 	})
@@ -101,6 +131,7 @@ func TestNewPackageRequest(t *testing.T) {
 
 	req = fakeHTTPRequest("testalicious.af", "/myauthor/myrepo", true)
 	pr, err = newPackageRequest(newPackageRequestArgs{
+		hosts:        hosts,
 		req:          req,
 		downloadRefs: fakeRefsDownloader(fakeRefs("mymasterhash", nil), nil),
 	})
@@ -111,7 +142,9 @@ func TestNewPackageRequest(t *testing.T) {
 
 	req = fakeHTTPRequest("testalicious.af", "/myauthor/myrepo@1.x/mysubpath", true)
 	pr, err = newPackageRequest(newPackageRequestArgs{
-		req: req,
+		hosts:           hosts,
+		req:             req,
+		pinVersionLabel: fakeVersionLabelPinner(nil),
 		downloadRefs: fakeRefsDownloader(fakeRefs(
 			"mymasterhash",
 			[]semver.SemverCandidate{
@@ -141,6 +174,7 @@ func TestNewPackageRequest(t *testing.T) {
 	// Tests Full SHA
 	req = fakeHTTPRequest("testalicious.af", "/myauthor/myrepo@1234567890123456789012345678901234567890", true)
 	pr, err = newPackageRequest(newPackageRequestArgs{
+		hosts:        hosts,
 		req:          req,
 		downloadRefs: fakeRefsDownloader(fakeRefs("somemasterhash", nil), nil),
 	})
@@ -160,7 +194,7 @@ func TestNewPackageRequest(t *testing.T) {
 	req = fakeHTTPRequest("testalicious.af", "/myauthor/myrepo@123456", true)
 	pr, err = newPackageRequest(newPackageRequestArgs{
 		req:          req,
-		ghSvc:        ghSvc,
+		hosts:        vcs.NewHosts(github.NewHost(ghSvc, nil)),
 		downloadRefs: fakeRefsDownloader(fakeRefs("somemasterhash", nil), nil),
 	})
 	assert.NotNil(t, pr)
@@ -179,15 +213,342 @@ func TestNewPackageRequest(t *testing.T) {
 	req = fakeHTTPRequest("testalicious.af", "/myauthor/myrepo@123456", true)
 	pr, err = newPackageRequest(newPackageRequestArgs{
 		req:          req,
-		ghSvc:        ghSvc,
+		hosts:        vcs.NewHosts(github.NewHost(ghSvc, nil)),
 		downloadRefs: fakeRefsDownloader(fakeRefs("somemasterhash", nil), nil),
 	})
 	assert.NotNil(t, err)
 	assert.Nil(t, pr)
 }
 
+func TestResolvePackageVersion_branches(t *testing.T) {
+	refs, _ := lib.NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/heads/develop",
+		"00000000000000000000000000000000000hash3 refs/heads/cafe12",
+		"00000000000000000000000000000000000hash4 refs/heads/1.x",
+		"00000000000000000000000000000000000hash5 refs/tags/1.2.0")))
+
+	resolve := func(path string) (string, string, error) {
+		parts, err := parsePackageRequestPath(path)
+		assert.Nil(t, err, path)
+
+		return resolvePackageVersion(resolvePackageVersionArgs{
+			parts:           parts,
+			downloadRefs:    fakeRefsDownloader(refs, nil),
+			pinVersionLabel: fakeVersionLabelPinner(nil),
+		})
+	}
+
+	// Branches are resolved to their heads, and labelled with their names.
+	sha, label, err := resolve("/ab/cd@develop")
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash2", sha)
+	assert.Equal(t, "develop", label)
+
+	sha, label, err = resolve("/ab/cd@master/c")
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash1", sha)
+	assert.Equal(t, "master", label)
+
+	// Branches that don't exist are reported as such.
+	_, _, err = resolve("/ab/cd@release-2.x")
+	assert.IsType(t, NoSuchPackageVersionError{}, err)
+
+	// Short SHAs and semver selectors that are also branch names are ambiguous.
+	_, _, err = resolve("/ab/cd@cafe12")
+	assert.IsType(t, AmbiguousPackageVersionSelectorError{}, err)
+	assert.Contains(t, err.Error(), "short SHA")
+	_, _, err = resolve("/ab/cd@1.x")
+	assert.IsType(t, AmbiguousPackageVersionSelectorError{}, err)
+	assert.Contains(t, err.Error(), `"branch:1.x"`)
+
+	// Unless the branch is explicitly selected.
+	sha, label, err = resolve("/ab/cd@branch:cafe12")
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash3", sha)
+	assert.Equal(t, "cafe12", label)
+
+	sha, label, err = resolve("/ab/cd@branch:1.x")
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash4", sha)
+	assert.Equal(t, "1.x", label)
+
+	// Semver selectors that are not branch names are unaffected.
+	sha, label, err = resolve("/ab/cd@1.2")
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash5", sha)
+	assert.Equal(t, "1.2.0", label)
+
+	// Refs that can't be downloaded can't be resolved.
+	parts, _ := parsePackageRequestPath("/ab/cd@develop")
+	_, _, err = resolvePackageVersion(resolvePackageVersionArgs{
+		parts:        parts,
+		downloadRefs: fakeRefsDownloader(lib.Refs{}, errors.New("this is an error")),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestResolvePackageVersion_dates(t *testing.T) {
+	var (
+		refs, _ = lib.NewRefs([]byte(reflines(
+			"00000000000000000000000000000000000hash1 HEAD",
+			"00000000000000000000000000000000000hash1 refs/heads/master",
+			"00000000000000000000000000000000000hash2 refs/heads/2017-01-01")))
+		commitDate = time.Date(2017, 3, 13, 12, 0, 0, 0, time.UTC)
+	)
+
+	resolve := func(path string, hosts vcs.Hosts) (string, string, error) {
+		parts, err := parsePackageRequestPath(path)
+		assert.Nil(t, err, path)
+
+		return resolvePackageVersion(resolvePackageVersionArgs{
+			parts:        parts,
+			hosts:        hosts,
+			downloadRefs: fakeRefsDownloader(refs, nil),
+		})
+	}
+
+	// Dates resolve to the last commit before them.
+	ghSvc := github.NewMockRequestService()
+	ghSvc.On(
+		"FetchCommitSHA",
+		"ab",
+		"cd",
+		time.Date(2017, 3, 14, 0, 0, 0, 0, time.UTC)).Return("datesha", nil)
+	ghSvc.On("FetchCommitTimestamp", "ab", "cd", "datesha").Return(commitDate, nil)
+	sha, label, err := resolve(
+		"/ab/cd@2017-03-14",
+		vcs.NewHosts(github.NewHost(ghSvc, nil)))
+	assert.Nil(t, err)
+	assert.Equal(t, "datesha", sha)
+	assert.Equal(t, "2017-03-13T12:00:00Z", label)
+
+	// Commits after the date don't count.
+	ghSvc = github.NewMockRequestService()
+	ghSvc.On(
+		"FetchCommitSHA",
+		"ab",
+		"cd",
+		time.Date(2017, 3, 13, 0, 0, 0, 0, time.UTC)).Return("datesha", nil)
+	ghSvc.On("FetchCommitTimestamp", "ab", "cd", "datesha").Return(commitDate, nil)
+	_, _, err = resolve(
+		"/ab/cd@2017-03-13",
+		vcs.NewHosts(github.NewHost(ghSvc, nil)))
+	assert.IsType(t, NoSuchPackageVersionError{}, err)
+
+	// Failed lookups are reported.
+	ghSvc = github.NewMockRequestService()
+	ghSvc.On(
+		"FetchCommitSHA",
+		"ab",
+		"cd",
+		mock.AnythingOfType("time.Time")).Return("", errors.New("this is an error"))
+	_, _, err = resolve(
+		"/ab/cd@2017-03-14T15:09:26Z",
+		vcs.NewHosts(github.NewHost(ghSvc, nil)))
+	assert.NotNil(t, err)
+
+	// Generic hosts can't look up commits by date.
+	_, _, err = resolve(
+		"/git.example.com/ab/cd@2017-03-14",
+		vcs.NewHosts(vcs.NewGenericHost("git.example.com")))
+	assert.IsType(t, UnsupportedPackageVersionSelectorError{}, err)
+
+	// Dates that are also branch names are ambiguous.
+	_, _, err = resolve("/ab/cd@2017-01-01", vcs.NewHosts())
+	assert.IsType(t, AmbiguousPackageVersionSelectorError{}, err)
+	assert.Contains(t, err.Error(), "date")
+}
+
+func TestResolvePackageVersion_semverRanges(t *testing.T) {
+	refs, _ := lib.NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.2.0",
+		"00000000000000000000000000000000000hash3 refs/tags/v1.4.1",
+		"00000000000000000000000000000000000hash4 refs/tags/v1.5.0",
+		"00000000000000000000000000000000000hash5 refs/tags/v2.3.2",
+		"00000000000000000000000000000000000hash6 refs/tags/v2.4.0")))
+
+	for _, test := range []struct {
+		path  string
+		sha   string
+		label string
+	}{
+		{"/ab/cd@ge1.2.0,lt1.5.0", "00000000000000000000000000000000000hash3", "1.4.1"},
+		{"/ab/cd@1.x;2.3.x", "00000000000000000000000000000000000hash5", "2.3.2"},
+		{"/ab/cd@lt2/ef", "00000000000000000000000000000000000hash4", "1.5.0"},
+	} {
+		parts, err := parsePackageRequestPath(test.path)
+		assert.Nil(t, err, test.path)
+
+		sha, label, err := resolvePackageVersion(resolvePackageVersionArgs{
+			parts:           parts,
+			downloadRefs:    fakeRefsDownloader(refs, nil),
+			pinVersionLabel: fakeVersionLabelPinner(nil),
+		})
+		assert.Nil(t, err, test.path)
+		assert.Equal(t, test.sha, sha, test.path)
+		assert.Equal(t, test.label, label, test.path)
+	}
+
+	parts, _ := parsePackageRequestPath("/ab/cd@gt1.5.0,lt2.3.0")
+	_, _, err := resolvePackageVersion(resolvePackageVersionArgs{
+		parts:           parts,
+		downloadRefs:    fakeRefsDownloader(refs, nil),
+		pinVersionLabel: fakeVersionLabelPinner(nil),
+	})
+	assert.IsType(t, NoSuchPackageVersionError{}, err)
+	assert.Contains(t, err.Error(), "gt1.5.0,lt2.3.0")
+}
+
 // TODO(skeswa): Fix this
 /*
+func TestResolvePackageVersion_majorVersions(t *testing.T) {
+	refs, _ := lib.NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.2.0",
+		"00000000000000000000000000000000000hash3 refs/tags/v3.0.0",
+		"00000000000000000000000000000000000hash4 refs/tags/v3.1.0",
+		"00000000000000000000000000000000000hash5 refs/tags/v3.2.0-rc.1",
+		"00000000000000000000000000000000000hash6 refs/tags/v4.0.0")))
+
+	for _, test := range []struct {
+		path  string
+		sha   string
+		label string
+	}{
+		{"/ab/cd/v3", "00000000000000000000000000000000000hash4", "3.1.0"},
+		{"/ab/cd/v3/ef", "00000000000000000000000000000000000hash4", "3.1.0"},
+		{"/ab/cd@lt3.1/v3/ef", "00000000000000000000000000000000000hash3", "3.0.0"},
+		{"/ab/cd@1.x;3.x/v3", "00000000000000000000000000000000000hash4", "3.1.0"},
+		{"/ab/cd/ef", "00000000000000000000000000000000000hash1", ""},
+	} {
+		parts, err := parsePackageRequestPath(test.path)
+		assert.Nil(t, err, test.path)
+
+		sha, label, err := resolvePackageVersion(resolvePackageVersionArgs{
+			parts:           parts,
+			downloadRefs:    fakeRefsDownloader(refs, nil),
+			pinVersionLabel: fakeVersionLabelPinner(nil),
+		})
+		assert.Nil(t, err, test.path)
+		assert.Equal(t, test.sha, sha, test.path)
+		assert.Equal(t, test.label, label, test.path)
+	}
+
+	for _, path := range []string{"/ab/cd/v2", "/ab/cd@1.x/v3"} {
+		parts, _ := parsePackageRequestPath(path)
+		_, _, err := resolvePackageVersion(resolvePackageVersionArgs{
+			parts:           parts,
+			downloadRefs:    fakeRefsDownloader(refs, nil),
+			pinVersionLabel: fakeVersionLabelPinner(nil),
+		})
+		assert.IsType(t, NoSuchPackageVersionError{}, err, path)
+	}
+}
+
+func TestResolvePackageVersion_subdirModules(t *testing.T) {
+	refs, _ := lib.NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.2.0",
+		"00000000000000000000000000000000000hash3 refs/tags/v1.3.0",
+		"00000000000000000000000000000000000hash4 refs/tags/sub/module/v1.2.0",
+		"00000000000000000000000000000000000hash5 refs/tags/sub/module/v1.2.5",
+		"00000000000000000000000000000000000hash6 refs/tags/sub/module/v2.0.0")))
+
+	for _, test := range []struct {
+		path  string
+		sha   string
+		label string
+	}{
+		{"/ab/cd@1.2/sub/module", "00000000000000000000000000000000000hash5", "1.2.5"},
+		{"/ab/cd@1.2/sub/module/ef", "00000000000000000000000000000000000hash5", "1.2.5"},
+		{"/ab/cd@1.x/sub/module", "00000000000000000000000000000000000hash5", "1.2.5"},
+		{"/ab/cd@1.x", "00000000000000000000000000000000000hash3", "1.3.0"},
+		{"/ab/cd@1.x/sub", "00000000000000000000000000000000000hash3", "1.3.0"},
+		{"/ab/cd/sub/module", "00000000000000000000000000000000000hash1", ""},
+	} {
+		parts, err := parsePackageRequestPath(test.path)
+		assert.Nil(t, err, test.path)
+
+		sha, label, err := resolvePackageVersion(resolvePackageVersionArgs{
+			parts:           parts,
+			downloadRefs:    fakeRefsDownloader(refs, nil),
+			pinVersionLabel: fakeVersionLabelPinner(nil),
+		})
+		assert.Nil(t, err, test.path)
+		assert.Equal(t, test.sha, sha, test.path)
+		assert.Equal(t, test.label, label, test.path)
+	}
+
+	parts, _ := parsePackageRequestPath("/ab/cd@1.3/sub/module")
+	_, _, err := resolvePackageVersion(resolvePackageVersionArgs{
+		parts:           parts,
+		downloadRefs:    fakeRefsDownloader(refs, nil),
+		pinVersionLabel: fakeVersionLabelPinner(nil),
+	})
+	assert.IsType(t, NoSuchPackageVersionError{}, err, "the tags of the root module should not apply to sub-directory modules")
+}
+
+func TestResolvePackageVersion_pinnedLabels(t *testing.T) {
+	refs, _ := lib.NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.2.0",
+		"00000000000000000000000000000000000hash3 refs/tags/sub/module/v1.2.5",
+		"00000000000000000000000000000000000hash4 refs/heads/v2")))
+
+	var pinnedLabels []string
+	pinVersionLabel := func(args versionLabelPinnerArgs) (string, error) {
+		assert.Equal(t, "ab", args.author)
+		assert.Equal(t, "cd", args.repo)
+		assert.Equal(t, refs, args.refs)
+		pinnedLabels = append(pinnedLabels, args.label)
+		return fakeVersionLabelPinner(map[string]string{
+			"v1.2.0": "00000000000000000000000000000000000hash9",
+		})(args)
+	}
+
+	for _, test := range []struct {
+		path  string
+		sha   string
+		label string
+	}{
+		{"/ab/cd@1.2.0", "00000000000000000000000000000000000hash9", "1.2.0"},
+		{"/ab/cd@1.x/sub/module", "00000000000000000000000000000000000hash3", "1.2.5"},
+		{"/ab/cd@2", "00000000000000000000000000000000000hash4", "2"},
+	} {
+		parts, err := parsePackageRequestPath(test.path)
+		assert.Nil(t, err, test.path)
+
+		sha, label, err := resolvePackageVersion(resolvePackageVersionArgs{
+			parts:           parts,
+			downloadRefs:    fakeRefsDownloader(refs, nil),
+			pinVersionLabel: pinVersionLabel,
+		})
+		assert.Nil(t, err, test.path)
+		assert.Equal(t, test.sha, sha, test.path)
+		assert.Equal(t, test.label, label, test.path)
+	}
+
+	assert.Equal(t, []string{"v1.2.0", "sub/module/v1.2.5"}, pinnedLabels, "only tags should be pinned")
+
+	// Pinning failures should fail the resolution.
+	parts, _ := parsePackageRequestPath("/ab/cd@1.2.0")
+	_, _, err := resolvePackageVersion(resolvePackageVersionArgs{
+		parts:        parts,
+		downloadRefs: fakeRefsDownloader(refs, nil),
+		pinVersionLabel: func(args versionLabelPinnerArgs) (string, error) {
+			return "", errors.New("this is an error")
+		},
+	})
+	assert.NotNil(t, err)
+}
+
 func TestRespondToPackageRequest(t *testing.T) {
 	// TODO(skeswa): @Shikkic, I need this test re-written. The stuff here fails	// and should probably be commented out.
 
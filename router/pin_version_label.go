@@ -0,0 +1,95 @@
+package main
+
+import (
+	"log"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/label"
+	"github.com/gophr-pm/gophr/lib/semver"
+)
+
+// pinVersionLabel pins a version label of a package to the SHA that it
+// resolved to, unless the label was pinned before. Returns the SHA that the
+// label is pinned to: once a label has been served, it keeps resolving to the
+// same SHA even if it is moved upstream. Along the way, every pinned label of
+// the package is checked against the freshly fetched refs so that labels that
+// were moved upstream are logged and recorded as mutations.
+func pinVersionLabel(args versionLabelPinnerArgs) (string, error) {
+	pins, err := args.getPinnedLabels(args.db, args.author, args.repo)
+	if err != nil {
+		return "", err
+	}
+
+	for tagName, upstreamSHA := range args.refs.VersionTags() {
+		pinnedSHA, pinned := pins[tagName]
+		if !pinned || pinnedSHA == upstreamSHA {
+			continue
+		}
+
+		// Mutations are not worth failing the request over.
+		recorded, err := args.recordLabelMutation(
+			args.db,
+			args.author,
+			args.repo,
+			tagName,
+			pinnedSHA,
+			upstreamSHA)
+		if err != nil {
+			log.Printf("[ERR] %v\n", err)
+		} else if recorded {
+			log.Printf(
+				"Version label %s/%s@%s was moved upstream from %s to %s; it stays pinned to %s.\n",
+				args.author,
+				args.repo,
+				tagName,
+				pinnedSHA,
+				upstreamSHA,
+				pinnedSHA)
+		}
+	}
+
+	if pinnedSHA, pinned := pins[args.label]; pinned {
+		return pinnedSHA, nil
+	}
+
+	return args.pinLabelInDB(
+		args.db,
+		args.author,
+		args.repo,
+		args.label,
+		args.sha)
+}
+
+// pinCandidateArgs is the arguments struct for pinCandidate.
+type pinCandidateArgs struct {
+	db              db.Queryable
+	refs            lib.Refs
+	repo            string
+	author          string
+	candidate       semver.SemverCandidate
+	pinVersionLabel versionLabelPinner
+}
+
+// pinCandidate finds the SHA that a semver candidate resolves to. The tags of
+// candidates are pinned to the SHA that they resolve to the first time around.
+// Candidates read from branches are not pinned, since branches are meant to
+// move.
+func pinCandidate(args pinCandidateArgs) (string, error) {
+	tagName := lib.TagNameOf(args.candidate)
+	if len(tagName) < 1 {
+		return args.candidate.GitRefHash, nil
+	}
+
+	return args.pinVersionLabel(versionLabelPinnerArgs{
+		db:                  args.db,
+		sha:                 args.candidate.GitRefHash,
+		refs:                args.refs,
+		repo:                args.repo,
+		label:               tagName,
+		author:              args.author,
+		pinLabelInDB:        label.Pin,
+		getPinnedLabels:     label.GetAll,
+		recordLabelMutation: label.RecordMutation,
+	})
+}
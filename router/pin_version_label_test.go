@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinVersionLabel(t *testing.T) {
+	client := db.NewMockClient()
+	refs, _ := lib.NewRefs([]byte(reflines(
+		"00000000000000000000000000000000000hash1 HEAD",
+		"00000000000000000000000000000000000hash1 refs/heads/master",
+		"00000000000000000000000000000000000hash2 refs/tags/v1.2.0",
+		"00000000000000000000000000000000000hash3 refs/tags/v1.3.0",
+		"00000000000000000000000000000000000hash4 refs/tags/sub/module/v1.0.0")))
+
+	type mutation struct{ label, sha, upstreamSHA string }
+	var (
+		pinned    []string
+		mutations []mutation
+		args      = versionLabelPinnerArgs{
+			db:     client,
+			sha:    "00000000000000000000000000000000000hash3",
+			refs:   refs,
+			repo:   "myrepo",
+			label:  "v1.3.0",
+			author: "myauthor",
+			getPinnedLabels: func(q db.Queryable, author, repo string) (map[string]string, error) {
+				assert.Equal(t, client, q)
+				assert.Equal(t, "myauthor", author)
+				assert.Equal(t, "myrepo", repo)
+				return map[string]string{
+					"v1.2.0":            "00000000000000000000000000000000000hash2",
+					"sub/module/v1.0.0": "00000000000000000000000000000000000hash9",
+					"v0.1.0":            "00000000000000000000000000000000000hash8",
+				}, nil
+			},
+			pinLabelInDB: func(q db.Queryable, author, repo, label, sha string) (string, error) {
+				assert.Equal(t, client, q)
+				assert.Equal(t, "myauthor", author)
+				assert.Equal(t, "myrepo", repo)
+				pinned = append(pinned, label+"@"+sha)
+				return sha, nil
+			},
+			recordLabelMutation: func(q db.Queryable, author, repo, label, sha, upstreamSHA string) (bool, error) {
+				mutations = append(mutations, mutation{label, sha, upstreamSHA})
+				return true, nil
+			},
+		}
+	)
+
+	// Labels that were never pinned get pinned to what they resolved to.
+	sha, err := pinVersionLabel(args)
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash3", sha)
+	assert.Equal(t, []string{"v1.3.0@00000000000000000000000000000000000hash3"}, pinned)
+	assert.Equal(t, []mutation{{
+		label:       "sub/module/v1.0.0",
+		sha:         "00000000000000000000000000000000000hash9",
+		upstreamSHA: "00000000000000000000000000000000000hash4",
+	}}, mutations, "tags that were moved upstream should be recorded")
+
+	// Labels that were pinned keep resolving to the pinned SHA.
+	pinned, mutations = nil, nil
+	args.label = "sub/module/v1.0.0"
+	args.sha = "00000000000000000000000000000000000hash4"
+	sha, err = pinVersionLabel(args)
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash9", sha)
+	assert.Empty(t, pinned)
+	assert.Len(t, mutations, 1)
+
+	// Failing to record mutations is not fatal.
+	args.recordLabelMutation = func(q db.Queryable, author, repo, label, sha, upstreamSHA string) (bool, error) {
+		return false, errors.New("this is an error")
+	}
+	sha, err = pinVersionLabel(args)
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash9", sha)
+
+	// Failing to read or write pins is.
+	args.label = "v1.3.0"
+	args.pinLabelInDB = func(q db.Queryable, author, repo, label, sha string) (string, error) {
+		return "", errors.New("this is an error")
+	}
+	_, err = pinVersionLabel(args)
+	assert.NotNil(t, err)
+
+	args.getPinnedLabels = func(q db.Queryable, author, repo string) (map[string]string, error) {
+		return nil, errors.New("this is an error")
+	}
+	_, err = pinVersionLabel(args)
+	assert.NotNil(t, err)
+}
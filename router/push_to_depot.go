@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"time"
 
 	git "github.com/libgit2/git2go"
 	"github.com/gophr-pm/gophr/lib/depot"
@@ -11,12 +10,18 @@ import (
 const (
 	commitAuthor        = "Gophr Archiver"
 	commitAuthorEmail   = "archiver@gophr.pm"
-	masterBranchName    = "master"
-	masterBranchRef     = "refs/heads/master"
-	masterPushDirective = "refs/heads/master:refs/heads/master"
+	depotDefaultBranch  = "master"
+	branchRefFormat     = "refs/heads/%s"
+	branchPushDirective = "refs/heads/%s:refs/heads/%s"
 )
 
 func pushToDepot(args packagePusherArgs) error {
+	// Push to the default branch of the original repo.
+	branch := args.branch
+	if len(branch) < 1 {
+		branch = depotDefaultBranch
+	}
+
 	// Initialize Git Repo.
 	repo, err := args.gitClient.InitRepo(
 		args.packagePaths.archiveDirPath,
@@ -51,11 +56,12 @@ func pushToDepot(args packagePusherArgs) error {
 		return fmt.Errorf("Could not retrieve repo tree: %v.", err)
 	}
 
-	// Create commit Signature
+	// Create commit Signature. The signature is dated with the original commit
+	// so that every push of the same package version creates the same commit.
 	sig := &git.Signature{
 		Name:  commitAuthor,
 		Email: commitAuthorEmail,
-		When:  time.Now(),
+		When:  args.commitDate.UTC(),
 	}
 	commitMessage := fmt.Sprintf(
 		"Gophr versioned repo %s/%s@%s",
@@ -63,33 +69,60 @@ func pushToDepot(args packagePusherArgs) error {
 		args.repo,
 		args.sha,
 	)
-	if err = args.gitClient.CreateCommit(
-		repo,
-		"HEAD",
-		sig,
-		sig,
-		commitMessage,
-		tree,
-	); err != nil {
+
+	// The archive hash trailer is what the signature vouches for.
+	if len(args.hash) > 0 {
+		commitMessage = fmt.Sprintf(
+			"%s\n\n%s: %s\n",
+			commitMessage,
+			depot.ArchiveHashTrailer,
+			args.hash,
+		)
+	}
+
+	if args.signingKey == nil {
+		err = args.gitClient.CreateCommit(
+			repo,
+			"HEAD",
+			sig,
+			sig,
+			commitMessage,
+			tree,
+		)
+	} else {
+		// Signed commits go straight to the branch.
+		err = args.gitClient.CreateSignedCommit(
+			repo,
+			fmt.Sprintf(branchRefFormat, branch),
+			sig,
+			sig,
+			commitMessage,
+			tree,
+			func(commit []byte) string {
+				return depot.SignCommit(args.signingKey, commit)
+			},
+		)
+	}
+	if err != nil {
 		return fmt.Errorf("Could not commit data: %v.", err)
 	}
 
-	// Create ref for master
+	// Create ref for the branch.
 	if err = args.gitClient.CreateRef(
 		repo,
 		"HEAD",
-		masterBranchRef,
+		fmt.Sprintf(branchRefFormat, branch),
 		true,
 		"headOne",
 	); err != nil {
-		return fmt.Errorf("Could not create ref for master: %v.", err)
+		return fmt.Errorf("Could not create ref for %s: %v.", branch, err)
 	}
 
-	// Check out master.
+	// Check out the branch.
 	if err = args.gitClient.CheckoutHead(repo, &git.CheckoutOpts{
 		Strategy: git.CheckoutSafe | git.CheckoutRecreateMissing,
 	}); err != nil {
-		return fmt.Errorf("Could not checkout master: %v.", err)
+		return fmt.Errorf("Could not checkout %s: %v.", branch, err)
 	}
 
 	// Create remote origin.
@@ -120,10 +153,10 @@ func pushToDepot(args packagePusherArgs) error {
 
 	if err = args.gitClient.Push(
 		remote,
-		[]string{masterPushDirective},
+		[]string{fmt.Sprintf(branchPushDirective, branch, branch)},
 		pushOptions,
 	); err != nil {
-		return fmt.Errorf("Could not push to master: %v.", err)
+		return fmt.Errorf("Could not push to %s: %v.", branch, err)
 	}
 
 	return nil
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"errors"
+	"strings"
 	"testing"
 
 	git "github.com/libgit2/git2go"
@@ -288,6 +290,89 @@ func TestPushtoDepot(t *testing.T) {
 	}
 	err = pushToDepot(args)
 	assert.Nil(t, err)
+
+	// Packages are pushed to the default branch of the original repo.
+	mockGitClient = g.NewMockClient()
+	mockGitClient.On("InitRepo", "/archive/dir/path", false).Return(&git.Repository{}, nil)
+	mockGitClient.On("CreateIndex", &git.Repository{}).Return(&git.Index{}, nil)
+	mockGitClient.On("IndexAddAll", &git.Index{}).Return(nil)
+	mockGitClient.On("WriteToIndexTree", &git.Index{}, &git.Repository{}).Return(&git.Oid{}, nil)
+	mockGitClient.On("WriteIndex", &git.Index{}).Return(nil)
+	mockGitClient.On("LookUpTree", &git.Repository{}, &git.Oid{}).Return(&git.Tree{}, nil)
+	mockGitClient.On("CreateCommit", &git.Repository{}, "HEAD", sig, sig, "Gophr versioned repo authorName/repoName@repoSHA", &git.Tree{}).Return(nil)
+	mockGitClient.On("CreateRef", &git.Repository{}, "HEAD", "refs/heads/main", true, "headOne").Return(nil)
+	mockGitClient.On("CheckoutHead", &git.Repository{}, checkoutOpts).Return(nil)
+	mockGitClient.On("CreateRemote", &git.Repository{}, "origin", remoteURL).Return(&git.Remote{}, nil)
+	mockGitClient.On("Push", &git.Remote{}, []string{"refs/heads/main:refs/heads/main"}, pushOpts).Return(nil)
+	args.branch = "main"
+	args.gitClient = mockGitClient
+	err = pushToDepot(args)
+	assert.Nil(t, err)
+	mockGitClient.AssertExpectations(t)
+}
+
+func TestPushToDepot_signed(t *testing.T) {
+	var (
+		sig           = mock.AnythingOfType("*git.Signature")
+		signer        = mock.AnythingOfType("git.CommitSigner")
+		pushOpts      = mock.AnythingOfType("*git.PushOptions")
+		remoteURL     = mock.AnythingOfType("string")
+		signingKey    = ed25519.NewKeyFromSeed([]byte("0123456789abcdef0123456789abcdef"))
+		checkoutOpts  = mock.AnythingOfType("*git.CheckoutOpts")
+		commitMessage = "Gophr versioned repo authorName/repoName@repoSHA\n\nArchive-Hash: tree1:hash\n"
+		signature     string
+	)
+
+	// Signed commits are created on the branch, and vouch for the archive hash.
+	mockGitClient := g.NewMockClient()
+	mockGitClient.On("InitRepo", "/archive/dir/path", false).Return(&git.Repository{}, nil)
+	mockGitClient.On("CreateIndex", &git.Repository{}).Return(&git.Index{}, nil)
+	mockGitClient.On("IndexAddAll", &git.Index{}).Return(nil)
+	mockGitClient.On("WriteToIndexTree", &git.Index{}, &git.Repository{}).Return(&git.Oid{}, nil)
+	mockGitClient.On("WriteIndex", &git.Index{}).Return(nil)
+	mockGitClient.On("LookUpTree", &git.Repository{}, &git.Oid{}).Return(&git.Tree{}, nil)
+	mockGitClient.On("CreateSignedCommit", &git.Repository{}, "refs/heads/master", sig, sig, commitMessage, &git.Tree{}, signer).Run(func(args mock.Arguments) {
+		signature = args.Get(6).(g.CommitSigner)([]byte("tree 1234\n\n" + commitMessage))
+	}).Return(nil)
+	mockGitClient.On("CreateRef", &git.Repository{}, "HEAD", "refs/heads/master", true, "headOne").Return(nil)
+	mockGitClient.On("CheckoutHead", &git.Repository{}, checkoutOpts).Return(nil)
+	mockGitClient.On("CreateRemote", &git.Repository{}, "origin", remoteURL).Return(&git.Remote{}, nil)
+	mockGitClient.On("Push", &git.Remote{}, []string{"refs/heads/master:refs/heads/master"}, pushOpts).Return(nil)
+	args := packagePusherArgs{
+		author:     "authorName",
+		repo:       "repoName",
+		sha:        "repoSHA",
+		hash:       "tree1:hash",
+		signingKey: signingKey,
+		packagePaths: packageDownloadPaths{
+			archiveDirPath: "/archive/dir/path",
+		},
+		gitClient: mockGitClient,
+		creds: &config.Credentials{
+			GithubPush: config.UserPass{
+				User: "test",
+				Pass: "testpassword",
+			},
+		},
+	}
+	err := pushToDepot(args)
+	assert.Nil(t, err)
+	mockGitClient.AssertExpectations(t)
+	mockGitClient.AssertNotCalled(t, "CreateCommit", &git.Repository{}, "HEAD", sig, sig, commitMessage, &git.Tree{})
+	assert.True(t, strings.HasPrefix(signature, "-----BEGIN SSH SIGNATURE-----\n"))
+
+	// Signing failures should fail the push.
+	mockGitClient = g.NewMockClient()
+	mockGitClient.On("InitRepo", "/archive/dir/path", false).Return(&git.Repository{}, nil)
+	mockGitClient.On("CreateIndex", &git.Repository{}).Return(&git.Index{}, nil)
+	mockGitClient.On("IndexAddAll", &git.Index{}).Return(nil)
+	mockGitClient.On("WriteToIndexTree", &git.Index{}, &git.Repository{}).Return(&git.Oid{}, nil)
+	mockGitClient.On("WriteIndex", &git.Index{}).Return(nil)
+	mockGitClient.On("LookUpTree", &git.Repository{}, &git.Oid{}).Return(&git.Tree{}, nil)
+	mockGitClient.On("CreateSignedCommit", &git.Repository{}, "refs/heads/master", sig, sig, commitMessage, &git.Tree{}, signer).Return(errors.New("this is an error"))
+	args.gitClient = mockGitClient
+	err = pushToDepot(args)
+	assert.NotNil(t, err)
 }
 
 func TestGenerateCredentialsCallback(t *testing.T) {
@@ -14,7 +14,8 @@ func recordPackageArchival(args packageArchivalRecorderArgs) {
 		args.db,
 		args.author,
 		args.repo,
-		args.sha); err != nil {
+		args.sha,
+		args.hash); err != nil {
 		// Instead of bubbling this error, just commit it to the logs. This is
 		// necessary because this function is executed asynchronously.
 		log.Printf(
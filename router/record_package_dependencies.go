@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/dependency"
+	"github.com/gophr-pm/gophr/lib/verdeps"
+)
+
+// newPackageDependenciesRecorder creates a verdeps.DependencyRecorder that
+// records the dependencies of a specific version of a package in the
+// database.
+func newPackageDependenciesRecorder(
+	q db.BatchingQueryable,
+	author string,
+	repo string,
+	sha string,
+) verdeps.DependencyRecorder {
+	return func(deps []verdeps.Dependency) error {
+		recordedDeps := make([]dependency.Dependency, len(deps))
+		for i, dep := range deps {
+			recordedDeps[i] = dependency.Dependency{
+				SHA:      dep.SHA,
+				Repo:     dep.Repo,
+				Author:   dep.Author,
+				Strategy: dep.Strategy,
+				Subpaths: dep.Subpaths,
+			}
+		}
+
+		return dependency.Record(q, author, repo, sha, recordedDeps)
+	}
+}
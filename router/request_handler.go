@@ -5,13 +5,12 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-go/statsd"
-	"github.com/gophr-pm/gophr/lib"
-	"github.com/gophr-pm/gophr/lib/config"
 	"github.com/gophr-pm/gophr/lib/datadog"
 	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archival"
 	"github.com/gophr-pm/gophr/lib/errors"
 	"github.com/gophr-pm/gophr/lib/github"
-	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/vcs"
 )
 
 const (
@@ -27,13 +26,27 @@ var (
 // RequestHandler creates an HTTP request handler that responds to all incoming
 // router requests.
 func RequestHandler(
-	io io.IO,
-	conf *config.Config,
-	creds *config.Credentials,
 	ghSvc github.RequestService,
+	hosts vcs.Hosts,
+	queue *archivalQueue,
+	downloadRefs refsDownloader,
+	checkArchival packageArchivalChecker,
+	serveStale bool,
 	client db.Client,
 	dataDogClient datadog.Client,
 ) func(http.ResponseWriter, *http.Request) {
+	// Module zips are built from the source of packages as it was committed.
+	// Upstream archives are big, so they are fetched once and then kept around
+	// for the go.mod and zip requests that follow.
+	fetchUpstreamArchive := newCachingUpstreamFileFetcher(
+		newUpstreamArchiveFetcher(http.Get),
+		upstreamArchiveCacheCapacity)
+	// The go.mod files of tagged versions decide which module path each version
+	// belongs to, so they are needed to list versions.
+	fetchGoMod := newCachingUpstreamFileFetcher(
+		newGoModFetcher(http.Get),
+		goModCacheCapacity)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		trackingArgs := datadog.TrackTransactionArgs{
 			Tags: []string{
@@ -56,22 +69,79 @@ func RequestHandler(
 		// Make sure that this isn't a simple health check before getting more
 		// complicated.
 		if r.URL.Path == healthCheckRoute {
+			ghSvc.Quota().SetHeaders(w.Header())
 			w.Write(statusCheckResponse)
 			return
 		}
 
 		// First, create the necessary variables.
 		var (
+			ar  *archivalRequest
 			pr  *packageRequest
+			mpr *moduleProxyRequest
 			err error
 		)
 
+		// Requests to queue up or check on package archival are not package
+		// requests either.
+		if isArchivalRequest(r) {
+			if ar, err = readArchivalRequest(r); err == nil {
+				err = ar.respond(respondToArchivalRequestArgs{
+					db:                    client,
+					res:                   w,
+					hosts:                 hosts,
+					downloadRefs:          downloadRefs,
+					getArchivalJob:        archival.Get,
+					pinVersionLabel:       pinVersionLabel,
+					enqueueArchival:       queue.enqueue,
+					isPackageArchived:     checkArchival,
+					recordPackageArchival: recordPackageArchival,
+				})
+			}
+
+			if err != nil {
+				trackingArgs.AlertType = datadog.Error
+				trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+				errors.RespondWithError(w, err)
+			}
+
+			return
+		}
+
+		// Requests made by go against the module proxy protocol are handled
+		// separately from go get requests.
+		if isModuleProxyRequest(r) {
+			if mpr, err = readModuleProxyRequest(r); err == nil {
+				err = mpr.respond(respondToModuleProxyRequestArgs{
+					db:                    client,
+					res:                   w,
+					ghSvc:                 ghSvc,
+					hosts:                 hosts,
+					fetchGoMod:            fetchGoMod,
+					fetchArchive:          fetchUpstreamArchive,
+					downloadRefs:          downloadRefs,
+					pinVersionLabel:       pinVersionLabel,
+					recordPackageDownload: recordPackageDownload,
+				})
+			}
+
+			if err != nil {
+				trackingArgs.AlertType = datadog.Error
+				trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
+				errors.RespondWithError(w, err)
+			}
+
+			return
+		}
+
 		// Create a new package request.
 		if pr, err = newPackageRequest(newPackageRequestArgs{
-			req:           r,
-			ghSvc:         ghSvc,
-			downloadRefs:  lib.FetchRefs,
-			DoHTTPHeadReq: github.DoHTTPHeadReq,
+			db:              client,
+			req:             r,
+			hosts:           hosts,
+			serveStale:      serveStale,
+			downloadRefs:    downloadRefs,
+			pinVersionLabel: pinVersionLabel,
 		}); err != nil {
 			trackingArgs.AlertType = datadog.Error
 			trackingArgs.EventInfo = append(trackingArgs.EventInfo, err.Error())
@@ -81,14 +151,13 @@ func RequestHandler(
 
 		// Use the package request to respond.
 		if err = pr.respond(respondToPackageRequestArgs{
-			io:                    io,
 			db:                    client,
 			res:                   w,
-			conf:                  conf,
-			creds:                 creds,
 			ghSvc:                 ghSvc,
-			versionPackage:        versionAndArchivePackage,
-			isPackageArchived:     isPackageArchived,
+			hosts:                 hosts,
+			awaitArchival:         queue.await,
+			enqueueArchival:       queue.enqueue,
+			isPackageArchived:     checkArchival,
 			recordPackageDownload: recordPackageDownload,
 			recordPackageArchival: recordPackageArchival,
 		}); err != nil {
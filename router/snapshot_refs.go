@@ -0,0 +1,135 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/vcs"
+)
+
+const (
+	// refsSnapshotTTL is how long refs snapshots are served as they are.
+	refsSnapshotTTL = 1 * time.Minute
+	// refsSnapshotMaxStaleness is how long refs snapshots are served while
+	// they are refreshed in the background once they have outlived their TTL.
+	// Older snapshots are only served if the refs cannot be fetched.
+	refsSnapshotMaxStaleness = 1 * time.Hour
+)
+
+// snapshottingRefsDownloaderArgs is the arguments struct for
+// newSnapshottingRefsDownloader.
+type snapshottingRefsDownloaderArgs struct {
+	db           db.Queryable
+	fetchRefs    refsDownloader
+	getSnapshot  dbRefsSnapshotGetter
+	saveSnapshot dbRefsSnapshotSaver
+}
+
+// newSnapshottingRefsDownloader creates a refsDownloader that keeps a snapshot
+// of the refs of every package in the database. Fresh snapshots are served
+// without fetching the refs. Stale snapshots are served while the refs are
+// fetched in the background, once per package at a time. Beyond that, the refs are fetched, and the last
+// snapshot is only served if the host is unreachable or the repository is
+// gone, so that package versions that were archived stay reachable.
+func newSnapshottingRefsDownloader(
+	args snapshottingRefsDownloaderArgs,
+) refsDownloader {
+	// fetchAndSave fetches the refs, and saves them if that worked.
+	fetchAndSave := func(author, repo string) (lib.Refs, error) {
+		refs, err := args.fetchRefs(author, repo)
+		if err != nil {
+			return lib.Refs{}, err
+		}
+
+		if err = args.saveSnapshot(args.db, author, repo, refs.Data); err != nil {
+			log.Printf("[ERR] %v\n", err)
+		}
+
+		return refs, nil
+	}
+
+	// refreshing is the set of packages whose snapshots are being refreshed in
+	// the background, so that each is only refreshed once at a time.
+	var (
+		refreshLock sync.Mutex
+		refreshing  = make(map[string]bool)
+	)
+
+	// refreshInBackground refreshes the snapshot of the refs of a package
+	// without waiting for it, unless it is already being refreshed.
+	refreshInBackground := func(author, repo string) {
+		key := vcs.RepoPath(author, repo)
+
+		refreshLock.Lock()
+		if refreshing[key] {
+			refreshLock.Unlock()
+			return
+		}
+		refreshing[key] = true
+		refreshLock.Unlock()
+
+		go func() {
+			defer func() {
+				refreshLock.Lock()
+				delete(refreshing, key)
+				refreshLock.Unlock()
+			}()
+
+			if _, err := fetchAndSave(author, repo); err != nil {
+				log.Printf(
+					"[ERR] Failed to refresh the refs snapshot of %s/%s: %v\n",
+					author,
+					repo,
+					err)
+			}
+		}()
+	}
+
+	return func(author, repo string) (lib.Refs, error) {
+		var (
+			snapshotRefs  lib.Refs
+			snapshotAge   time.Duration
+			hasSnapshot   = false
+			snapshot, err = args.getSnapshot(args.db, author, repo)
+		)
+
+		// The refs can still be fetched without the snapshot.
+		if err != nil {
+			log.Printf("[ERR] %v\n", err)
+		} else if snapshot != nil {
+			if snapshotRefs, err = lib.NewRefs(snapshot.Refs); err != nil {
+				log.Printf(
+					"[ERR] Failed to read the refs snapshot of %s/%s: %v\n",
+					author,
+					repo,
+					err)
+			} else {
+				hasSnapshot = true
+				snapshotAge = time.Since(snapshot.DateFetched)
+			}
+		}
+
+		if hasSnapshot && snapshotAge < refsSnapshotTTL {
+			return snapshotRefs, nil
+		} else if hasSnapshot && snapshotAge < refsSnapshotMaxStaleness {
+			refreshInBackground(author, repo)
+			return snapshotRefs, nil
+		}
+
+		refs, err := fetchAndSave(author, repo)
+		if err != nil && hasSnapshot {
+			log.Printf(
+				"Serving the refs snapshot of %s/%s from %s since the refs could not be fetched: %v\n",
+				author,
+				repo,
+				snapshot.DateFetched.UTC().Format(time.RFC3339),
+				err)
+			return snapshotRefs, nil
+		}
+
+		return refs, err
+	}
+}
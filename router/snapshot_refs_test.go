@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/refsnapshot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshottingRefsDownloader(t *testing.T) {
+	var (
+		client       = db.NewMockClient()
+		liveData     = []byte(reflines("00000000000000000000000000000000000hash2 HEAD"))
+		snapshotData = []byte(reflines("00000000000000000000000000000000000hash1 HEAD"))
+		liveRefs, _  = lib.NewRefs(liveData)
+		fetches      int
+		saves        chan []byte
+	)
+
+	newDownloader := func(
+		snapshot *refsnapshot.Snapshot,
+		snapshotErr error,
+		fetchErr error,
+	) refsDownloader {
+		fetches, saves = 0, make(chan []byte, 1)
+		return newSnapshottingRefsDownloader(snapshottingRefsDownloaderArgs{
+			db: client,
+			fetchRefs: func(author, repo string) (lib.Refs, error) {
+				assert.Equal(t, "myauthor", author)
+				assert.Equal(t, "myrepo", repo)
+				fetches++
+				if fetchErr != nil {
+					return lib.Refs{}, fetchErr
+				}
+
+				return liveRefs, nil
+			},
+			getSnapshot: func(q db.Queryable, author, repo string) (*refsnapshot.Snapshot, error) {
+				assert.Equal(t, client, q)
+				assert.Equal(t, "myauthor", author)
+				assert.Equal(t, "myrepo", repo)
+				return snapshot, snapshotErr
+			},
+			saveSnapshot: func(q db.Queryable, author, repo string, refs []byte) error {
+				assert.Equal(t, client, q)
+				saves <- refs
+				return errors.New("saving failures should only be logged")
+			},
+		})
+	}
+
+	snapshotFrom := func(age time.Duration) *refsnapshot.Snapshot {
+		return &refsnapshot.Snapshot{
+			Refs:        snapshotData,
+			DateFetched: time.Now().Add(-age),
+		}
+	}
+
+	// Without a snapshot, the refs are fetched and saved.
+	refs, err := newDownloader(nil, nil, nil)("myauthor", "myrepo")
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash2", refs.DefaultRefHash)
+	assert.Equal(t, liveData, <-saves)
+
+	refs, err = newDownloader(nil, errors.New("this is an error"), nil)("myauthor", "myrepo")
+	assert.Nil(t, err, "the refs should be fetched if the snapshot cannot be read")
+	assert.Equal(t, "00000000000000000000000000000000000hash2", refs.DefaultRefHash)
+
+	_, err = newDownloader(nil, nil, errors.New("this is an error"))("myauthor", "myrepo")
+	assert.NotNil(t, err)
+
+	// Fresh snapshots are served as they are.
+	refs, err = newDownloader(snapshotFrom(0), nil, nil)("myauthor", "myrepo")
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash1", refs.DefaultRefHash)
+	assert.Equal(t, 0, fetches)
+
+	// Stale snapshots are served while they are refreshed.
+	refs, err = newDownloader(snapshotFrom(refsSnapshotTTL), nil, nil)("myauthor", "myrepo")
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash1", refs.DefaultRefHash)
+	assert.Equal(t, liveData, <-saves, "the snapshot should be refreshed in the background")
+
+	// Older snapshots are only served if the refs cannot be fetched.
+	refs, err = newDownloader(snapshotFrom(refsSnapshotMaxStaleness), nil, nil)("myauthor", "myrepo")
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash2", refs.DefaultRefHash)
+	assert.Equal(t, 1, fetches)
+
+	refs, err = newDownloader(
+		snapshotFrom(24*time.Hour),
+		nil,
+		errors.New("Could not find a repository at github.com/myauthor/myrepo"),
+	)("myauthor", "myrepo")
+	assert.Nil(t, err)
+	assert.Equal(t, "00000000000000000000000000000000000hash1", refs.DefaultRefHash)
+	assert.Equal(t, 1, fetches)
+
+	// Unreadable snapshots are as good as none.
+	_, err = newDownloader(
+		&refsnapshot.Snapshot{Refs: []byte("zzzz"), DateFetched: time.Now()},
+		nil,
+		errors.New("this is an error"),
+	)("myauthor", "myrepo")
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, fetches)
+}
+
+func TestSnapshottingRefsDownloaderRefreshesOnce(t *testing.T) {
+	var (
+		client       = db.NewMockClient()
+		data         = []byte(reflines("00000000000000000000000000000000000hash1 HEAD"))
+		fetches      = make(chan string, 4)
+		unblock      = make(chan struct{})
+		saves        = make(chan struct{}, 4)
+		downloadRefs = newSnapshottingRefsDownloader(snapshottingRefsDownloaderArgs{
+			db: client,
+			fetchRefs: func(author, repo string) (lib.Refs, error) {
+				fetches <- author + "/" + repo
+				<-unblock
+				return lib.NewRefs(data)
+			},
+			getSnapshot: func(q db.Queryable, author, repo string) (*refsnapshot.Snapshot, error) {
+				return &refsnapshot.Snapshot{
+					Refs:        data,
+					DateFetched: time.Now().Add(-refsSnapshotTTL),
+				}, nil
+			},
+			saveSnapshot: func(q db.Queryable, author, repo string, refs []byte) error {
+				saves <- struct{}{}
+				return nil
+			},
+		})
+	)
+
+	// Requests in the stale window share the refresh that is in flight.
+	for i := 0; i < 3; i++ {
+		_, err := downloadRefs("a", "b")
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, "a/b", <-fetches)
+
+	// Other packages are refreshed on their own.
+	_, err := downloadRefs("a", "c")
+	assert.Nil(t, err)
+	assert.Equal(t, "a/c", <-fetches)
+
+	close(unblock)
+	<-saves
+	<-saves
+	assert.Empty(t, fetches)
+
+	// Once the refresh is done, the next stale request starts another one.
+	// Saving happens before the package is let go of, so wait for that too.
+	for {
+		if _, err = downloadRefs("a", "b"); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-fetches:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"log"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archive"
+)
+
+// maxStalePackageVersions is the most stale versions of a package that are
+// considered while the version that a package request matched is archived.
+const maxStalePackageVersions = 3
+
+// packageVersion is a version of a package that a package request may be
+// served.
+type packageVersion struct {
+	sha   string
+	label string
+}
+
+// findStalePackageVersionsArgs is the arguments struct for
+// findStalePackageVersions.
+type findStalePackageVersionsArgs struct {
+	db              db.Queryable
+	parts           *packageRequestParts
+	matchedSHA      string
+	downloadRefs    refsDownloader
+	getPinnedLabels dbPinnedVersionLabelsGetter
+}
+
+// findStalePackageVersions lists the versions of a package, other than the
+// matched one, that also satisfy the semver selector of a package request,
+// from the best to the worst. Tags resolve to the SHA that they are pinned to.
+// Tags that have never been pinned are left out, since they have never been
+// served, and serving them now would pin them before their time.
+func findStalePackageVersions(
+	args findStalePackageVersionsArgs,
+) ([]packageVersion, error) {
+	parts := args.parts
+
+	refs, err := args.downloadRefs(parts.author, parts.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	pins, err := args.getPinnedLabels(args.db, parts.author, parts.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		constraint = parts.semverConstraint()
+		matches    = candidatesOfPackageRequest(parts, refs).Match(constraint)
+		versions   []packageVersion
+	)
+
+	for i := range matches {
+		if len(versions) >= maxStalePackageVersions {
+			break
+		}
+
+		// Walk the matches from the best to the worst.
+		candidate := matches[i]
+		if constraint.PrefersHighest() {
+			candidate = matches[len(matches)-1-i]
+		}
+
+		sha := candidate.GitRefHash
+		if tagName := lib.TagNameOf(candidate); len(tagName) > 0 {
+			pinnedSHA, pinned := pins[tagName]
+			if !pinned {
+				continue
+			}
+
+			sha = pinnedSHA
+		}
+
+		if sha != args.matchedSHA {
+			versions = append(versions, packageVersion{
+				sha:   sha,
+				label: candidate.String(),
+			})
+		}
+	}
+
+	return versions, nil
+}
+
+// fallBackToStaleVersion swaps the matched version of the package request for
+// the best stale version that has already been archived, if the matched
+// version has yet to be archived itself. The matched version is queued for
+// archival so that it can be served once it is ready. The stale versions are
+// only looked for if the matched version has yet to be archived. Nothing is
+// swapped if none of the stale versions have been archived either, or if they
+// or their archival can't be checked; the request is then served the usual
+// way.
+func (pr *packageRequest) fallBackToStaleVersion(
+	args respondToPackageRequestArgs,
+) {
+	isArchived := func(sha string) (bool, error) {
+		return args.isPackageArchived(packageArchivalCheckerArgs{
+			db:                    args.db,
+			sha:                   sha,
+			repo:                  pr.parts.repo,
+			author:                pr.parts.author,
+			readDepotArchiveHash:  readDepotArchiveHash,
+			recordPackageArchival: args.recordPackageArchival,
+			isPackageArchivedInDB: archives.Exists,
+		})
+	}
+
+	if archived, err := isArchived(pr.matchedSHA); err != nil {
+		log.Printf(
+			"Failed to check whether %s/%s@%s is archived: %v\n",
+			pr.parts.author,
+			pr.parts.repo,
+			pr.matchedSHA,
+			err)
+		return
+	} else if archived {
+		return
+	}
+
+	staleVersions, err := pr.findStaleVersions()
+	if err != nil {
+		// Stale versions are a nice-to-have, so don't fail the request.
+		log.Printf(
+			"Failed to find stale versions of %s/%s: %v\n",
+			pr.parts.author,
+			pr.parts.repo,
+			err)
+		return
+	}
+
+	for _, version := range staleVersions {
+		archived, err := isArchived(version.sha)
+		if err != nil {
+			log.Printf(
+				"Failed to check whether %s/%s@%s is archived: %v\n",
+				pr.parts.author,
+				pr.parts.repo,
+				version.sha,
+				err)
+			continue
+		} else if !archived {
+			continue
+		}
+
+		// Archive the matched version in the background. If it can't be queued,
+		// the next request will have another go at it.
+		if _, err = args.enqueueArchival(packageArchivalEnqueuerArgs{
+			sha:      pr.matchedSHA,
+			repo:     pr.parts.repo,
+			author:   pr.parts.author,
+			selector: pr.parts.selector,
+		}); err != nil {
+			log.Printf(
+				"Failed to queue %s/%s@%s for archival: %v\n",
+				pr.parts.author,
+				pr.parts.repo,
+				pr.matchedSHA,
+				err)
+		}
+
+		log.Printf(
+			"Serving %s/%s@%s while %s is being archived.\n",
+			pr.parts.author,
+			pr.parts.repo,
+			version.sha,
+			pr.matchedSHA)
+
+		pr.matchedSHA = version.sha
+		pr.matchedSHALabel = version.label
+		return
+	}
+}
+
+// memoizePackageArchivalChecks wraps a packageArchivalChecker so that each
+// package version is only checked once. Only successful checks are
+// remembered. The returned checker is meant to live as long as a single
+// request, and is not safe for concurrent use.
+func memoizePackageArchivalChecks(
+	isPackageArchived packageArchivalChecker,
+) packageArchivalChecker {
+	checked := make(map[string]bool)
+
+	return func(args packageArchivalCheckerArgs) (bool, error) {
+		key := packageVersionKey(args.author, args.repo, args.sha)
+		if archived, exists := checked[key]; exists {
+			return archived, nil
+		}
+
+		archived, err := isPackageArchived(args)
+		if err != nil {
+			return false, err
+		}
+
+		checked[key] = archived
+		return archived, nil
+	}
+}
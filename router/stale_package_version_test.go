@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/db"
+	"github.com/gophr-pm/gophr/lib/db/model/package/archival"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindStalePackageVersions(t *testing.T) {
+	var (
+		client  = db.NewMockClient()
+		refs, _ = lib.NewRefs([]byte(reflines(
+			"00000000000000000000000000000000000hash1 HEAD",
+			"00000000000000000000000000000000000hash1 refs/heads/master",
+			"00000000000000000000000000000000000hash2 refs/tags/v1.1.0",
+			"00000000000000000000000000000000000hash3 refs/tags/v1.2.0",
+			"00000000000000000000000000000000000hash4 refs/tags/v1.3.0",
+			"00000000000000000000000000000000000hash5 refs/tags/v1.4.0",
+			"00000000000000000000000000000000000hash6 refs/heads/v1.5")))
+		pins = map[string]string{
+			"v1.1.0": "00000000000000000000000000000000000hash2",
+			"v1.2.0": "00000000000000000000000000000000000hash9",
+			"v1.4.0": "00000000000000000000000000000000000hash5",
+		}
+	)
+
+	find := func(path, matchedSHA string, getPinnedLabelsErr error) ([]packageVersion, error) {
+		parts, err := parsePackageRequestPath(path)
+		assert.Nil(t, err, path)
+
+		return findStalePackageVersions(findStalePackageVersionsArgs{
+			db:           client,
+			parts:        parts,
+			matchedSHA:   matchedSHA,
+			downloadRefs: fakeRefsDownloader(refs, nil),
+			getPinnedLabels: func(q db.Queryable, author, repo string) (map[string]string, error) {
+				assert.Equal(t, client, q)
+				assert.Equal(t, "ab", author)
+				assert.Equal(t, "cd", repo)
+				return pins, getPinnedLabelsErr
+			},
+		})
+	}
+
+	// Unpinned tags are left out, pinned ones resolve to their pins, and
+	// branches resolve to their heads.
+	versions, err := find("/ab/cd@1.x", "00000000000000000000000000000000000hash6", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []packageVersion{
+		{sha: "00000000000000000000000000000000000hash5", label: "1.4.0"},
+		{sha: "00000000000000000000000000000000000hash9", label: "1.2.0"},
+		{sha: "00000000000000000000000000000000000hash2", label: "1.1.0"},
+	}, versions)
+
+	// Selectors that prefer lower versions walk the matches the other way around, and there are only
+	// ever so many stale versions.
+	versions, err = find("/ab/cd@1.1.0+", "00000000000000000000000000000000000hash2", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []packageVersion{
+		{sha: "00000000000000000000000000000000000hash9", label: "1.2.0"},
+		{sha: "00000000000000000000000000000000000hash5", label: "1.4.0"},
+		{sha: "00000000000000000000000000000000000hash6", label: "1.5.0"},
+	}, versions)
+
+	_, err = find("/ab/cd@1.x", "00000000000000000000000000000000000hash6", errors.New("this is an error"))
+	assert.NotNil(t, err)
+}
+
+func TestFallBackToStaleVersion(t *testing.T) {
+	var (
+		archived         map[string]bool
+		enqueued         []string
+		staleVersions    []packageVersion
+		staleVersionsErr error
+		staleLookups     int
+	)
+
+	newRequest := func() *packageRequest {
+		staleLookups = 0
+		staleVersionsErr = nil
+		staleVersions = []packageVersion{
+			{sha: "sha2", label: "1.2.0"},
+			{sha: "sha1", label: "1.1.0"},
+		}
+
+		return &packageRequest{
+			parts: &packageRequestParts{
+				repo:     "cd",
+				author:   "ab",
+				selector: "^1.1",
+			},
+			matchedSHA:      "sha3",
+			matchedSHALabel: "1.3.0",
+			findStaleVersions: func() ([]packageVersion, error) {
+				staleLookups++
+				return staleVersions, staleVersionsErr
+			},
+		}
+	}
+
+	respondArgs := respondToPackageRequestArgs{
+		isPackageArchived: func(args packageArchivalCheckerArgs) (bool, error) {
+			assert.Equal(t, "ab", args.author)
+			assert.Equal(t, "cd", args.repo)
+			if args.sha == "broken" {
+				return false, errors.New("this is an error")
+			}
+
+			return archived[args.sha], nil
+		},
+		enqueueArchival: func(args packageArchivalEnqueuerArgs) (*archival.Job, error) {
+			assert.Equal(t, "^1.1", args.selector)
+			enqueued = append(enqueued, args.sha)
+			return nil, errors.New("enqueueing failures should only be logged")
+		},
+	}
+
+	// The best archived stale version is served while the match is archived.
+	archived, enqueued = map[string]bool{"sha1": true}, nil
+	pr := newRequest()
+	pr.fallBackToStaleVersion(respondArgs)
+	assert.Equal(t, "sha1", pr.matchedSHA)
+	assert.Equal(t, "1.1.0", pr.matchedSHALabel)
+	assert.Equal(t, []string{"sha3"}, enqueued)
+
+	// Archived matches are served as they are, without looking for stale
+	// versions.
+	archived, enqueued = map[string]bool{"sha1": true, "sha3": true}, nil
+	pr = newRequest()
+	pr.fallBackToStaleVersion(respondArgs)
+	assert.Equal(t, "sha3", pr.matchedSHA)
+	assert.Empty(t, enqueued)
+	assert.Equal(t, 0, staleLookups)
+
+	// So are matches without archived stale versions.
+	archived, enqueued = map[string]bool{}, nil
+	pr = newRequest()
+	pr.fallBackToStaleVersion(respondArgs)
+	assert.Equal(t, "sha3", pr.matchedSHA)
+	assert.Empty(t, enqueued)
+
+	// Failed checks of the match leave it to be archived the usual way.
+	archived, enqueued = map[string]bool{"sha1": true}, nil
+	pr = newRequest()
+	pr.matchedSHA = "broken"
+	pr.fallBackToStaleVersion(respondArgs)
+	assert.Equal(t, "broken", pr.matchedSHA)
+	assert.Empty(t, enqueued)
+
+	// Failed checks of stale versions move on to the next one.
+	archived, enqueued = map[string]bool{"sha1": true}, nil
+	pr = newRequest()
+	staleVersions[0].sha = "broken"
+	pr.fallBackToStaleVersion(respondArgs)
+	assert.Equal(t, "sha1", pr.matchedSHA)
+	assert.Equal(t, []string{"sha3"}, enqueued)
+
+	// Failing to find stale versions leaves the match to be archived the usual
+	// way.
+	archived, enqueued = map[string]bool{"sha1": true}, nil
+	pr = newRequest()
+	staleVersionsErr = errors.New("this is an error")
+	pr.fallBackToStaleVersion(respondArgs)
+	assert.Equal(t, "sha3", pr.matchedSHA)
+	assert.Empty(t, enqueued)
+	assert.Equal(t, 1, staleLookups)
+}
+
+func TestMemoizePackageArchivalChecks(t *testing.T) {
+	var (
+		checks  int
+		failing bool
+		check   = memoizePackageArchivalChecks(
+			func(args packageArchivalCheckerArgs) (bool, error) {
+				checks++
+				if failing {
+					return false, errors.New("this is an error")
+				}
+
+				return args.sha == "sha1", nil
+			})
+	)
+
+	// Each package version is only checked once.
+	archived, err := check(packageArchivalCheckerArgs{author: "a", repo: "b", sha: "sha1"})
+	assert.Nil(t, err)
+	assert.True(t, archived)
+	archived, err = check(packageArchivalCheckerArgs{author: "a", repo: "b", sha: "sha1"})
+	assert.Nil(t, err)
+	assert.True(t, archived)
+	archived, err = check(packageArchivalCheckerArgs{author: "a", repo: "b", sha: "sha2"})
+	assert.Nil(t, err)
+	assert.False(t, archived)
+	archived, err = check(packageArchivalCheckerArgs{author: "a", repo: "b", sha: "sha2"})
+	assert.Nil(t, err)
+	assert.False(t, archived)
+	assert.Equal(t, 2, checks)
+
+	// Failed checks are not remembered.
+	failing = true
+	_, err = check(packageArchivalCheckerArgs{author: "a", repo: "b", sha: "sha3"})
+	assert.NotNil(t, err)
+	failing = false
+	archived, err = check(packageArchivalCheckerArgs{author: "a", repo: "b", sha: "sha3"})
+	assert.Nil(t, err)
+	assert.False(t, archived)
+	assert.Equal(t, 4, checks)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+const (
+	// upstreamArchiveCacheCapacity is how many bytes of upstream archives the
+	// upstream archive cache of a router holds.
+	upstreamArchiveCacheCapacity = 256 << 20
+	// goModCacheCapacity is how many bytes of go.mod files the go.mod cache of
+	// a router holds.
+	goModCacheCapacity = 16 << 20
+)
+
+// upstreamFileCacheEntry is an entry of an upstreamFileCache.
+type upstreamFileCacheEntry struct {
+	key  string
+	file []byte
+}
+
+// upstreamFileCache is an in-memory LRU cache of files fetched from package
+// hosts at specific commits. The contents of a commit never change, so the
+// files never expire. The cache is bounded by the total size of its files
+// rather than by how many files there are.
+type upstreamFileCache struct {
+	lock     sync.Mutex
+	size     int
+	entries  *list.List
+	elements map[string]*list.Element
+	capacity int
+}
+
+// newUpstreamFileCache creates a new upstreamFileCache that holds up to
+// capacity bytes of files.
+func newUpstreamFileCache(capacity int) *upstreamFileCache {
+	return &upstreamFileCache{
+		entries:  list.New(),
+		elements: make(map[string]*list.Element),
+		capacity: capacity,
+	}
+}
+
+// get returns the file of the package version. Returns false if the file is
+// not in the cache.
+func (c *upstreamFileCache) get(author, repo, sha string) ([]byte, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, exists := c.elements[packageVersionKey(author, repo, sha)]
+	if !exists {
+		return nil, false
+	}
+
+	c.entries.MoveToFront(element)
+	return element.Value.(upstreamFileCacheEntry).file, true
+}
+
+// add remembers the file of the package version. The files that were used the
+// least recently are forgotten until the cache fits its capacity again. Files
+// that are larger than the capacity are not remembered at all.
+func (c *upstreamFileCache) add(author, repo, sha string, file []byte) {
+	if len(file) > c.capacity {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := packageVersionKey(author, repo, sha)
+	if element, exists := c.elements[key]; exists {
+		c.entries.MoveToFront(element)
+		return
+	}
+
+	c.elements[key] = c.entries.PushFront(upstreamFileCacheEntry{
+		key:  key,
+		file: file,
+	})
+	c.size += len(file)
+	for c.size > c.capacity {
+		oldest := c.entries.Back()
+		entry := oldest.Value.(upstreamFileCacheEntry)
+		c.entries.Remove(oldest)
+		delete(c.elements, entry.key)
+		c.size -= len(entry.file)
+	}
+}
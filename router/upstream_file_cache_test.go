@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpstreamFileCache(t *testing.T) {
+	cache := newUpstreamFileCache(6)
+	_, exists := cache.get("a", "b", "sha1")
+	assert.False(t, exists)
+
+	cache.add("a", "b", "sha1", []byte("aa"))
+	cache.add("a", "b", "sha2", []byte("bb"))
+	file, exists := cache.get("a", "b", "sha1")
+	assert.True(t, exists)
+	assert.Equal(t, []byte("aa"), file)
+	_, exists = cache.get("a", "c", "sha1")
+	assert.False(t, exists)
+
+	// sha2 was used less recently than sha1, so it goes first.
+	cache.add("a", "b", "sha3", []byte("cccc"))
+	_, exists = cache.get("a", "b", "sha2")
+	assert.False(t, exists)
+	file, exists = cache.get("a", "b", "sha1")
+	assert.True(t, exists)
+	assert.Equal(t, []byte("aa"), file)
+	file, exists = cache.get("a", "b", "sha3")
+	assert.True(t, exists)
+	assert.Equal(t, []byte("cccc"), file)
+	assert.Equal(t, 6, cache.size)
+
+	// Files that can never fit are not remembered.
+	cache.add("a", "b", "sha4", []byte("ddddddd"))
+	_, exists = cache.get("a", "b", "sha4")
+	assert.False(t, exists)
+	assert.Equal(t, 2, cache.entries.Len())
+	assert.Len(t, cache.elements, 2)
+	assert.Equal(t, 6, cache.size)
+}
@@ -4,48 +4,87 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"time"
 
 	"github.com/gophr-pm/gophr/lib/db/model/package/archive"
 	"github.com/gophr-pm/gophr/lib/git"
+	"github.com/gophr-pm/gophr/lib/github"
 	"github.com/gophr-pm/gophr/lib/verdeps"
 )
 
-const (
-	// defaultArchiveExistenceCheckDelay is the time gap between existence check
-	// attempts.
-	defaultArchiveExistenceCheckDelay = 3000
-	// archiveExistenceCheckAttemptsLimit sets the cap on how many times an archive
-	// existence check is attempted before the an error is recorded.
-	archiveExistenceCheckAttemptsLimit = 5
-)
-
 // versionAndArchivePackage takes a package, locks all of its versions
 // in a chronologically accurate way, and archives it in depot to be queried
-// later.
-func versionAndArchivePackage(args packageVersionerArgs) error {
+// later. If the Github API quota runs out along the way, the
+// github.RateLimitExceededError is returned as it is, so that the archival can
+// be tried again once the quota is reset.
+func versionAndArchivePackage(args packageVersionerArgs) (err error) {
+	// Make sure that nobody else archives this package at the same time. If
+	// archival fails, whoever is waiting on the lock finds out why.
+	unlock, lockLost, err := args.lockArchival(packageArchivalLockerArgs{
+		db:     args.db,
+		sha:    args.sha,
+		repo:   args.repo,
+		author: args.author,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Running out of quota is no fault of the package, and the archival is
+		// tried again once the quota is reset, so waiters aren't told that it
+		// failed.
+		if isRateLimited(err) {
+			unlock(nil)
+		} else {
+			unlock(err)
+		}
+	}()
+
+	// Once the lock is lost, somebody else may be archiving the package
+	// version too, so the depot repo must be left alone.
+	checkLock := func() error {
+		select {
+		case <-lockLost:
+			return NewArchivalLockLostError(args.author, args.repo, args.sha)
+		default:
+			return nil
+		}
+	}
+
+	// Whoever held the lock before may have archived the package already.
+	archived, err := args.isPackageArchived(packageArchivalCheckerArgs{
+		db:                    args.db,
+		sha:                   args.sha,
+		repo:                  args.repo,
+		author:                args.author,
+		readDepotArchiveHash:  readDepotArchiveHash,
+		isPackageArchivedInDB: archives.Exists,
+		recordPackageArchival: args.recordPackageArchival,
+	})
+	if err != nil {
+		return fmt.Errorf(
+			"Could not check if package has already been archived: %v.",
+			err)
+	} else if archived {
+		return nil
+	}
+
 	log.Printf(
 		"Preparing to sub-version %s/%s@%s \n",
 		args.author,
 		args.repo,
 		args.sha)
 
-	// If args.archiveExistenceCheckDelay was left unspecified, then use the
-	// default.
-	archiveExistenceCheckDelay := args.archiveExistenceCheckDelay
-	if archiveExistenceCheckDelay < 1 {
-		archiveExistenceCheckDelay = defaultArchiveExistenceCheckDelay
-	}
-
 	// Download the package in the construction zone.
 	downloadPaths, err := args.downloadPackage(packageDownloaderArgs{
 		io:                   args.io,
 		sha:                  args.sha,
+		hosts:                args.hosts,
 		repo:                 args.repo,
 		author:               args.author,
 		doHTTPGet:            http.Get,
 		unzipArchive:         unzipArchive,
 		deleteWorkDir:        deleteFolder,
+		linkMajorVersionDir:  linkMajorVersionDir,
 		constructionZonePath: args.constructionZonePath,
 	})
 	if err != nil {
@@ -55,61 +94,96 @@ func versionAndArchivePackage(args packageVersionerArgs) error {
 	// Perform clean-up after function exits.
 	defer args.attemptWorkDirDeletion(downloadPaths.workDirPath)
 
-	// Version lock all of the Github dependencies in the packageModel.
+	// Version lock all of the dependencies in the packageModel.
 	if err = args.versionDeps(verdeps.VersionDepsArgs{
 		IO:            args.io,
 		SHA:           args.sha,
 		Repo:          args.repo,
 		Path:          downloadPaths.archiveDirPath,
+		Hosts:         args.hosts,
 		Author:        args.author,
 		GithubService: args.ghSvc,
-	}); err != nil {
+		RecordDependencies: newPackageDependenciesRecorder(
+			args.db,
+			args.author,
+			args.repo,
+			args.sha),
+	}); isRateLimited(err) {
+		return err
+	} else if err != nil {
 		return fmt.Errorf("Could not version deps properly: %v.", err)
 	}
 
+	// Hash the archive now that it won't change anymore.
+	hash, err := args.hashArchive(args.io, downloadPaths.archiveDirPath)
+	if err != nil {
+		return fmt.Errorf("Could not hash the archive: %v.", err)
+	}
+
+	// The depot commit is dated with the original commit, so that every archive
+	// of the package version is identical.
+	commitDate, err := args.fetchCommitDate(
+		args.hosts,
+		args.author,
+		args.repo,
+		args.sha)
+	if isRateLimited(err) {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("Could not fetch the commit date: %v.", err)
+	}
+
+	// The depot repo mirrors the default branch of the original repo, so that
+	// it looks just like the original to go get.
+	branch := depotDefaultBranch
+	if refs, refsErr := args.downloadRefs(args.author, args.repo); refsErr != nil {
+		log.Printf(
+			"Could not read the default branch of %s/%s, so using %s: %v\n",
+			args.author,
+			args.repo,
+			branch,
+			refsErr)
+	} else if len(refs.DefaultBranch) > 0 {
+		branch = refs.DefaultBranch
+	}
+
+	if err = checkLock(); err != nil {
+		return err
+	}
+
 	// Create a new repository in the depot before pushing to it.
 	if repoIsNew, repoCreationErr := args.createDepotRepo(
 		args.author,
 		args.repo,
 		args.sha,
+		branch,
 	); repoCreationErr != nil {
 		return repoCreationErr
 	} else if !repoIsNew {
-		// If the repo is not new, that means this package is already being
-		// versioned, or has already been versioned. So, we must wait for this
-		// package to be archived.
-		for attempts := 0; attempts < archiveExistenceCheckAttemptsLimit; attempts = attempts + 1 {
-			// Enforce a time delay between attempts so as to allow for archival to
-			// occur.
-			if attempts > 0 {
-				time.Sleep(time.Duration(archiveExistenceCheckDelay) * time.Millisecond)
-			}
-
-			if archived, archiveCheckErr := args.isPackageArchived(packageArchivalCheckerArgs{
-				db:                    args.db,
-				sha:                   args.sha,
-				repo:                  args.repo,
-				author:                args.author,
-				packageExistsInDepot:  packageExistsInDepot,
-				isPackageArchivedInDB: archives.Exists,
-				recordPackageArchival: args.recordPackageArchival,
-			}); archiveCheckErr != nil {
-				return fmt.Errorf(
-					"Could not check if package has been versioned in another context: %v.",
-					archiveCheckErr)
-			} else if archived {
-				// Since the package was archived elsewhere, exit here.
-				return nil
-			}
+		// Since we hold the archival lock and the package is not archived, the
+		// repo must have been left behind by an interrupted archival. Clear it
+		// away and start over.
+		if deletionErr := args.destroyDepotRepo(
+			args.author,
+			args.repo,
+			args.sha,
+		); deletionErr != nil {
+			return fmt.Errorf(
+				"Could not delete the depot repo of an interrupted archival: %v.",
+				deletionErr)
 		}
-
-		// The other package versioner context failed to deliver on time - complain
-		// about it.
-		return fmt.Errorf(
-			"Was waiting for package archival of \"%s/%s@%s\" in another context, but it did not happen fast enough.",
+		if _, repoCreationErr = args.createDepotRepo(
 			args.author,
 			args.repo,
-			args.sha)
+			args.sha,
+			branch,
+		); repoCreationErr != nil {
+			return repoCreationErr
+		}
+	}
+
+	if err = checkLock(); err != nil {
+		return err
 	}
 
 	// Push versioned package to depot, then delete the package directory from
@@ -118,7 +192,11 @@ func versionAndArchivePackage(args packageVersionerArgs) error {
 		author:       args.author,
 		repo:         args.repo,
 		sha:          args.sha,
+		hash:         hash,
 		creds:        args.creds,
+		branch:       branch,
+		signingKey:   args.signingKey,
+		commitDate:   commitDate,
 		gitClient:    git.NewClient(),
 		packagePaths: downloadPaths,
 	}); err != nil {
@@ -147,8 +225,16 @@ func versionAndArchivePackage(args packageVersionerArgs) error {
 		db:     args.db,
 		sha:    args.sha,
 		repo:   args.repo,
+		hash:   hash,
 		author: args.author,
 	})
 
 	return nil
 }
+
+// isRateLimited returns true if err means that the Github API quota has been
+// spent.
+func isRateLimited(err error) bool {
+	_, rateLimited := err.(github.RateLimitExceededError)
+	return rateLimited
+}
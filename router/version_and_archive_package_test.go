@@ -5,15 +5,142 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gophr-pm/gophr/lib"
+	"github.com/gophr-pm/gophr/lib/github"
+	"github.com/gophr-pm/gophr/lib/io"
+	"github.com/gophr-pm/gophr/lib/vcs"
 	"github.com/gophr-pm/gophr/lib/verdeps"
 	"github.com/stretchr/testify/assert"
 )
 
+func fakePackageArchivalLocker(
+	err error,
+	unlockCauses chan error,
+) packageArchivalLocker {
+	return fakeLosablePackageArchivalLocker(err, unlockCauses, nil)
+}
+
+// fakeLosablePackageArchivalLocker is fakePackageArchivalLocker with a lock
+// that is lost once lost is closed.
+func fakeLosablePackageArchivalLocker(
+	err error,
+	unlockCauses chan error,
+	lost chan struct{},
+) packageArchivalLocker {
+	return func(args packageArchivalLockerArgs) (packageArchivalUnlocker, <-chan struct{}, error) {
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return func(cause error) {
+			if unlockCauses != nil {
+				unlockCauses <- cause
+			}
+		}, lost, nil
+	}
+}
+
+// testCommitDate is the date of the commit of the package being archived.
+var testCommitDate = time.Date(2016, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func fakeArchiveHasher(hash string, err error) archiveHasher {
+	return func(io io.IO, archiveDirPath string) (string, error) {
+		return hash, err
+	}
+}
+
+func fakeCommitDateFetcher(commitDate time.Time, err error) commitDateFetcher {
+	return func(hosts vcs.Hosts, author, repo, sha string) (time.Time, error) {
+		return commitDate, err
+	}
+}
+
+func fakePackageArchivalChecker(archived bool, err error) packageArchivalChecker {
+	return func(args packageArchivalCheckerArgs) (bool, error) {
+		return archived, err
+	}
+}
+
 func TestVersionAndArchivePackage(t *testing.T) {
+	// Archival is locked elsewhere, and fails there.
+	err := versionAndArchivePackage(packageVersionerArgs{
+		sha:          "mysha",
+		repo:         "myrepo",
+		author:       "myauthor",
+		lockArchival: fakePackageArchivalLocker(errors.New("this is an error"), nil),
+	})
+	assert.NotNil(t, err)
+
+	// Failures are handed to whoever waits on the lock.
+	unlockCauses := make(chan error, 1)
+	err = versionAndArchivePackage(packageVersionerArgs{
+		sha:               "mysha",
+		repo:              "myrepo",
+		author:            "myauthor",
+		lockArchival:      fakePackageArchivalLocker(nil, unlockCauses),
+		isPackageArchived: fakePackageArchivalChecker(false, nil),
+		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
+			return packageDownloadPaths{}, errors.New("this is an error")
+		},
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, err, <-unlockCauses)
+
+	// Losing the lock calls off the archival before depot is touched.
+	lost := make(chan struct{})
+	close(lost)
+	err = versionAndArchivePackage(packageVersionerArgs{
+		sha:               "mysha",
+		repo:              "myrepo",
+		author:            "myauthor",
+		lockArchival:      fakeLosablePackageArchivalLocker(nil, unlockCauses, lost),
+		isPackageArchived: fakePackageArchivalChecker(false, nil),
+		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
+			return packageDownloadPaths{archiveDirPath: "/archive/dir/path"}, nil
+		},
+		versionDeps: func(args verdeps.VersionDepsArgs) error {
+			return nil
+		},
+		attemptWorkDirDeletion: func(workDirPath string) {},
+		downloadRefs:           fakeRefsDownloader(lib.Refs{DefaultBranch: "main"}, nil),
+		hashArchive:            fakeArchiveHasher("tree1:myhash", nil),
+		fetchCommitDate:        fakeCommitDateFetcher(testCommitDate, nil),
+		createDepotRepo: func(author, repo, sha, branch string) (bool, error) {
+			assert.Fail(t, "the depot repo should not be created without the lock")
+			return true, nil
+		},
+	})
+	assert.Equal(t, NewArchivalLockLostError("myauthor", "myrepo", "mysha"), err)
+	assert.Equal(t, err, <-unlockCauses)
+
+	// Running out of quota is reported as it is, so that the archival can be
+	// deferred until the quota is reset. It isn't a failure of the archival,
+	// so waiters aren't told that it failed.
+	quotaErr := github.NewRateLimitExceededError(time.Now().Add(time.Hour))
+	quotaUnlockCauses := make(chan error, 1)
+	err = versionAndArchivePackage(packageVersionerArgs{
+		sha:               "mysha",
+		repo:              "myrepo",
+		author:            "myauthor",
+		lockArchival:      fakePackageArchivalLocker(nil, quotaUnlockCauses),
+		isPackageArchived: fakePackageArchivalChecker(false, nil),
+		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
+			return packageDownloadPaths{archiveDirPath: "/archive/dir/path"}, nil
+		},
+		versionDeps: func(args verdeps.VersionDepsArgs) error {
+			return quotaErr
+		},
+		attemptWorkDirDeletion: func(workDirPath string) {},
+	})
+	assert.Equal(t, quotaErr, err)
+	assert.Nil(t, <-quotaUnlockCauses)
+
 	args := packageVersionerArgs{
-		sha:    "mysha",
-		repo:   "myrepo",
-		author: "myauthor",
+		lockArchival:      fakePackageArchivalLocker(nil, nil),
+		isPackageArchived: fakePackageArchivalChecker(false, nil),
+		sha:               "mysha",
+		repo:              "myrepo",
+		author:            "myauthor",
 		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
@@ -23,13 +150,15 @@ func TestVersionAndArchivePackage(t *testing.T) {
 		},
 		constructionZonePath: "/my/cons/path",
 	}
-	err := versionAndArchivePackage(args)
+	err = versionAndArchivePackage(args)
 	assert.NotNil(t, err, "this should return an error")
 
 	args = packageVersionerArgs{
-		sha:    "mysha",
-		repo:   "myrepo",
-		author: "myauthor",
+		lockArchival:      fakePackageArchivalLocker(nil, nil),
+		isPackageArchived: fakePackageArchivalChecker(false, nil),
+		sha:               "mysha",
+		repo:              "myrepo",
+		author:            "myauthor",
 		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
@@ -55,9 +184,11 @@ func TestVersionAndArchivePackage(t *testing.T) {
 	assert.NotNil(t, err, "this should return an error")
 
 	args = packageVersionerArgs{
-		sha:    "mysha",
-		repo:   "myrepo",
-		author: "myauthor",
+		lockArchival:      fakePackageArchivalLocker(nil, nil),
+		isPackageArchived: fakePackageArchivalChecker(false, nil),
+		sha:               "mysha",
+		repo:              "myrepo",
+		author:            "myauthor",
 		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
@@ -78,7 +209,10 @@ func TestVersionAndArchivePackage(t *testing.T) {
 		attemptWorkDirDeletion: func(workDirPath string) {
 			return
 		},
-		createDepotRepo: func(author, repo, sha string) (bool, error) {
+		downloadRefs:    fakeRefsDownloader(lib.Refs{DefaultBranch: "main"}, nil),
+		hashArchive:     fakeArchiveHasher("tree1:myhash", nil),
+		fetchCommitDate: fakeCommitDateFetcher(testCommitDate, nil),
+		createDepotRepo: func(author, repo, sha, branch string) (bool, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
 			assert.Equal(t, "mysha", args.sha)
@@ -89,9 +223,10 @@ func TestVersionAndArchivePackage(t *testing.T) {
 	assert.NotNil(t, err)
 
 	args = packageVersionerArgs{
-		sha:    "mysha",
-		repo:   "myrepo",
-		author: "myauthor",
+		lockArchival: fakePackageArchivalLocker(nil, nil),
+		sha:          "mysha",
+		repo:         "myrepo",
+		author:       "myauthor",
 		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
@@ -112,7 +247,10 @@ func TestVersionAndArchivePackage(t *testing.T) {
 		attemptWorkDirDeletion: func(workDirPath string) {
 			return
 		},
-		createDepotRepo: func(author, repo, sha string) (bool, error) {
+		downloadRefs:    fakeRefsDownloader(lib.Refs{DefaultBranch: "main"}, nil),
+		hashArchive:     fakeArchiveHasher("tree1:myhash", nil),
+		fetchCommitDate: fakeCommitDateFetcher(testCommitDate, nil),
+		createDepotRepo: func(author, repo, sha, branch string) (bool, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
 			assert.Equal(t, "mysha", args.sha)
@@ -129,9 +267,10 @@ func TestVersionAndArchivePackage(t *testing.T) {
 	assert.NotNil(t, err)
 
 	args = packageVersionerArgs{
-		sha:    "mysha",
-		repo:   "myrepo",
-		author: "myauthor",
+		lockArchival: fakePackageArchivalLocker(nil, nil),
+		sha:          "mysha",
+		repo:         "myrepo",
+		author:       "myauthor",
 		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
@@ -152,7 +291,10 @@ func TestVersionAndArchivePackage(t *testing.T) {
 		attemptWorkDirDeletion: func(workDirPath string) {
 			return
 		},
-		createDepotRepo: func(author, repo, sha string) (bool, error) {
+		downloadRefs:    fakeRefsDownloader(lib.Refs{DefaultBranch: "main"}, nil),
+		hashArchive:     fakeArchiveHasher("tree1:myhash", nil),
+		fetchCommitDate: fakeCommitDateFetcher(testCommitDate, nil),
+		createDepotRepo: func(author, repo, sha, branch string) (bool, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
 			assert.Equal(t, "mysha", args.sha)
@@ -169,9 +311,10 @@ func TestVersionAndArchivePackage(t *testing.T) {
 	assert.Nil(t, err)
 
 	args = packageVersionerArgs{
-		sha:    "mysha",
-		repo:   "myrepo",
-		author: "myauthor",
+		lockArchival: fakePackageArchivalLocker(nil, nil),
+		sha:          "mysha",
+		repo:         "myrepo",
+		author:       "myauthor",
 		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
@@ -192,7 +335,10 @@ func TestVersionAndArchivePackage(t *testing.T) {
 		attemptWorkDirDeletion: func(workDirPath string) {
 			return
 		},
-		createDepotRepo: func(author, repo, sha string) (bool, error) {
+		downloadRefs:    fakeRefsDownloader(lib.Refs{DefaultBranch: "main"}, nil),
+		hashArchive:     fakeArchiveHasher("tree1:myhash", nil),
+		fetchCommitDate: fakeCommitDateFetcher(testCommitDate, nil),
+		createDepotRepo: func(author, repo, sha, branch string) (bool, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
 			assert.Equal(t, "mysha", args.sha)
@@ -204,24 +350,69 @@ func TestVersionAndArchivePackage(t *testing.T) {
 			assert.Equal(t, "mysha", args.sha)
 			return false, nil
 		},
-		archiveExistenceCheckDelay: 30,
+		destroyDepotRepo: func(author, repo, sha string) error {
+			assert.Equal(t, "myauthor", author)
+			assert.Equal(t, "myrepo", repo)
+			assert.Equal(t, "mysha", sha)
+			return errors.New("this is an error")
+		},
 	}
-	startTime := time.Now()
 	err = versionAndArchivePackage(args)
-	actualExecutionDuration := time.Since(startTime)
-	expectedExecutionDuration := time.Duration(
-		30*(archiveExistenceCheckAttemptsLimit-1)) * time.Millisecond
 	assert.NotNil(t, err)
-	assert.True(
-		t,
-		actualExecutionDuration >= expectedExecutionDuration,
-		"Multiple attempts should have been made "+
-			"to check if the package was archived")
+
+	var (
+		depotReposCreated   = 0
+		depotReposDestroyed = 0
+	)
+	args = packageVersionerArgs{
+		lockArchival: fakePackageArchivalLocker(nil, nil),
+		sha:          "mysha",
+		repo:         "myrepo",
+		author:       "myauthor",
+		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
+			return packageDownloadPaths{
+				archiveDirPath: "/archive/dir/path",
+			}, nil
+		},
+		constructionZonePath: "/my/cons/path",
+		versionDeps: func(args verdeps.VersionDepsArgs) error {
+			return nil
+		},
+		attemptWorkDirDeletion: func(workDirPath string) {
+			return
+		},
+		downloadRefs:    fakeRefsDownloader(lib.Refs{DefaultBranch: "main"}, nil),
+		hashArchive:     fakeArchiveHasher("tree1:myhash", nil),
+		fetchCommitDate: fakeCommitDateFetcher(testCommitDate, nil),
+		createDepotRepo: func(author, repo, sha, branch string) (bool, error) {
+			depotReposCreated++
+			return depotReposCreated > 1, nil
+		},
+		isPackageArchived: func(args packageArchivalCheckerArgs) (bool, error) {
+			return false, nil
+		},
+		destroyDepotRepo: func(author, repo, sha string) error {
+			depotReposDestroyed++
+			return nil
+		},
+		pushToDepot: func(args packagePusherArgs) error {
+			return nil
+		},
+		recordPackageArchival: func(args packageArchivalRecorderArgs) {
+			return
+		},
+	}
+	err = versionAndArchivePackage(args)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, depotReposCreated, "The depot repo should be re-created")
+	assert.Equal(t, 1, depotReposDestroyed, "The stale depot repo should be destroyed")
 
 	args = packageVersionerArgs{
-		sha:    "mysha",
-		repo:   "myrepo",
-		author: "myauthor",
+		lockArchival:      fakePackageArchivalLocker(nil, nil),
+		isPackageArchived: fakePackageArchivalChecker(false, nil),
+		sha:               "mysha",
+		repo:              "myrepo",
+		author:            "myauthor",
 		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
@@ -242,7 +433,10 @@ func TestVersionAndArchivePackage(t *testing.T) {
 		attemptWorkDirDeletion: func(workDirPath string) {
 			return
 		},
-		createDepotRepo: func(author, repo, sha string) (bool, error) {
+		downloadRefs:    fakeRefsDownloader(lib.Refs{DefaultBranch: "main"}, nil),
+		hashArchive:     fakeArchiveHasher("tree1:myhash", nil),
+		fetchCommitDate: fakeCommitDateFetcher(testCommitDate, nil),
+		createDepotRepo: func(author, repo, sha, branch string) (bool, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
 			assert.Equal(t, "mysha", args.sha)
@@ -265,9 +459,11 @@ func TestVersionAndArchivePackage(t *testing.T) {
 	assert.NotNil(t, err)
 
 	args = packageVersionerArgs{
-		sha:    "mysha",
-		repo:   "myrepo",
-		author: "myauthor",
+		lockArchival:      fakePackageArchivalLocker(nil, nil),
+		isPackageArchived: fakePackageArchivalChecker(false, nil),
+		sha:               "mysha",
+		repo:              "myrepo",
+		author:            "myauthor",
 		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
@@ -290,7 +486,10 @@ func TestVersionAndArchivePackage(t *testing.T) {
 			assert.Equal(t, "/work/dir/path", workDirPath)
 			return
 		},
-		createDepotRepo: func(author, repo, sha string) (bool, error) {
+		downloadRefs:    fakeRefsDownloader(lib.Refs{DefaultBranch: "main"}, nil),
+		hashArchive:     fakeArchiveHasher("tree1:myhash", nil),
+		fetchCommitDate: fakeCommitDateFetcher(testCommitDate, nil),
+		createDepotRepo: func(author, repo, sha, branch string) (bool, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
 			assert.Equal(t, "mysha", args.sha)
@@ -313,9 +512,11 @@ func TestVersionAndArchivePackage(t *testing.T) {
 	assert.NotNil(t, err)
 
 	args = packageVersionerArgs{
-		sha:    "mysha",
-		repo:   "myrepo",
-		author: "myauthor",
+		lockArchival:      fakePackageArchivalLocker(nil, nil),
+		isPackageArchived: fakePackageArchivalChecker(false, nil),
+		sha:               "mysha",
+		repo:              "myrepo",
+		author:            "myauthor",
 		downloadPackage: func(args packageDownloaderArgs) (packageDownloadPaths, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
@@ -338,25 +539,60 @@ func TestVersionAndArchivePackage(t *testing.T) {
 			assert.Equal(t, "/work/dir/path", workDirPath)
 			return
 		},
-		createDepotRepo: func(author, repo, sha string) (bool, error) {
+		downloadRefs:    fakeRefsDownloader(lib.Refs{DefaultBranch: "main"}, nil),
+		hashArchive:     fakeArchiveHasher("tree1:myhash", nil),
+		fetchCommitDate: fakeCommitDateFetcher(testCommitDate, nil),
+		createDepotRepo: func(author, repo, sha, branch string) (bool, error) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
 			assert.Equal(t, "mysha", args.sha)
+			assert.Equal(t, "main", branch)
 			return true, nil
 		},
 		pushToDepot: func(args packagePusherArgs) error {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
 			assert.Equal(t, "mysha", args.sha)
+			assert.Equal(t, "main", args.branch)
+			assert.Equal(t, testCommitDate, args.commitDate, "depot commits should be dated with the original commit")
+			assert.Equal(t, "tree1:myhash", args.hash, "depot commits should vouch for the hash of the archive")
 			return nil
 		},
 		recordPackageArchival: func(args packageArchivalRecorderArgs) {
 			assert.Equal(t, "myauthor", args.author)
 			assert.Equal(t, "myrepo", args.repo)
 			assert.Equal(t, "mysha", args.sha)
+			assert.Equal(t, "tree1:myhash", args.hash, "the hash of the archive should be recorded")
 			return
 		},
 	}
 	err = versionAndArchivePackage(args)
 	assert.Nil(t, err)
+
+	// Without a readable default branch, depot falls back to master.
+	args.downloadRefs = fakeRefsDownloader(lib.Refs{}, errors.New("this is an error"))
+	args.createDepotRepo = func(author, repo, sha, branch string) (bool, error) {
+		assert.Equal(t, depotDefaultBranch, branch)
+		return true, nil
+	}
+	args.pushToDepot = func(args packagePusherArgs) error {
+		assert.Equal(t, depotDefaultBranch, args.branch)
+		return nil
+	}
+	err = versionAndArchivePackage(args)
+	assert.Nil(t, err)
+
+	// Archives that cannot be hashed, or dated, are not pushed.
+	args.pushToDepot = func(args packagePusherArgs) error {
+		assert.Fail(t, "nothing should have been pushed")
+		return nil
+	}
+	args.hashArchive = fakeArchiveHasher("", errors.New("this is an error"))
+	err = versionAndArchivePackage(args)
+	assert.NotNil(t, err)
+
+	args.hashArchive = fakeArchiveHasher("tree1:myhash", nil)
+	args.fetchCommitDate = fakeCommitDateFetcher(time.Time{}, errors.New("this is an error"))
+	err = versionAndArchivePackage(args)
+	assert.NotNil(t, err)
 }
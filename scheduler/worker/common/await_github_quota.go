@@ -0,0 +1,27 @@
+package common
+
+import (
+	"time"
+
+	"github.com/gophr-pm/gophr/lib/github"
+)
+
+// AwaitGithubQuota pauses the calling job until the Github API may be used
+// again if err says that the Github API rate limit was exceeded. Returns true
+// if the job was paused, in which case whatever failed should be retried.
+// Jobs pause instead of failing since they are in no hurry, unlike the
+// requests that the router responds to.
+func AwaitGithubQuota(logger JobLogger, err error) bool {
+	rateLimitErr, ok := err.(github.RateLimitExceededError)
+	if !ok {
+		return false
+	}
+
+	logger.Infof(
+		"Ran out of Github API quota. Pausing until %s.\n",
+		rateLimitErr.ResetTime.Format(time.RFC3339))
+	time.Sleep(rateLimitErr.RetryAfter())
+	logger.Info("Resuming now that the Github API quota has been reset.")
+
+	return true
+}
@@ -3,12 +3,17 @@ package main
 import (
 	"fmt"
 	"net/http"
+
+	"github.com/gophr-pm/gophr/lib/github"
 )
 
 // StatusHandler creates an HTTP request handler that responds to status
-// requests.
-func StatusHandler() func(http.ResponseWriter, *http.Request) {
+// requests. The remaining Github API quota is described in the headers.
+func StatusHandler(
+	ghSvc github.RequestService,
+) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
+		ghSvc.Quota().SetHeaders(w.Header())
 		fmt.Fprint(w, "OK")
 	}
 }
@@ -76,6 +76,7 @@ func index(args indexArgs) {
 				wg:                &insertionFactoryWG,
 				errs:              args.errs,
 				ghSvc:             args.ghSvc,
+				logger:            args.logger,
 				isAwesome:         awesome.IncludesPackage,
 				newPackages:       newPackages,
 				packageInsertions: packageInsertions,
@@ -7,6 +7,7 @@ import (
 	"github.com/gophr-pm/gophr/lib/db/model/package"
 	"github.com/gophr-pm/gophr/lib/dtos"
 	"github.com/gophr-pm/gophr/lib/github"
+	"github.com/gophr-pm/gophr/scheduler/worker/common"
 )
 
 // awesomeChecker is a proxy for awesome.IncludesPackage.
@@ -23,6 +24,7 @@ type packageInsertionFactoryArgs struct {
 	wg                *sync.WaitGroup
 	errs              chan error
 	ghSvc             github.RequestService
+	logger            common.JobLogger
 	isAwesome         awesomeChecker
 	newPackages       chan packageSetEntry
 	packageInsertions chan pkg.InsertArgs
@@ -41,11 +43,17 @@ func packageInsertionFactory(args packageInsertionFactoryArgs) {
 	)
 
 	for newPackage := range args.newPackages {
-		// Fetch metadata for this project from github.
-		if repoData, err = args.ghSvc.FetchRepoData(
+		// Fetch metadata for this project from github, pausing whenever the
+		// Github API quota runs out.
+		repoData, err = args.ghSvc.FetchRepoData(
 			newPackage.author,
-			newPackage.repo,
-		); err != nil {
+			newPackage.repo)
+		for common.AwaitGithubQuota(args.logger, err) {
+			repoData, err = args.ghSvc.FetchRepoData(
+				newPackage.author,
+				newPackage.repo)
+		}
+		if err != nil {
 			args.errs <- err
 			continue
 		}
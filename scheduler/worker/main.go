@@ -55,7 +55,7 @@ func main() {
 
 	// Register all of the routes.
 	r := mux.NewRouter()
-	r.HandleFunc("/status", StatusHandler()).Methods("GET")
+	r.HandleFunc("/status", StatusHandler(ghSvc)).Methods("GET")
 	r.HandleFunc(
 		"/update/metrics",
 		metrics.UpdateHandler(
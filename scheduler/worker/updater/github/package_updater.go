@@ -35,6 +35,9 @@ func packageUpdater(args packageUpdaterArgs) {
 			summary.Repo)
 
 		repoData, err := args.ghSvc.FetchRepoData(summary.Author, summary.Repo)
+		for common.AwaitGithubQuota(args.logger, err) {
+			repoData, err = args.ghSvc.FetchRepoData(summary.Author, summary.Repo)
+		}
 		if err != nil {
 			args.errs <- err
 			continue
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/gophr-pm/gophr/lib/db"
+)
+
+// dependentsCounter is a proxy for dependency.CountDependents.
+type dependentsCounter func(
+	q db.Queryable,
+	author string,
+	repo string,
+) (int, error)
+
+// countDependentsWrapperResult is the results struct for
+// countDependentsWrapper.
+type countDependentsWrapperResult struct {
+	err        error
+	dependents int
+}
+
+// countDependentsWrapperArgs is the arguments struct for
+// countDependentsWrapper.
+type countDependentsWrapperArgs struct {
+	q               db.Queryable
+	wg              *sync.WaitGroup
+	repo            string
+	author          string
+	result          *countDependentsWrapperResult
+	countDependents dependentsCounter
+}
+
+// countDependentsWrapper wraps the countDependents function and formats the
+// outputs for use by packageUpdater.
+func countDependentsWrapper(args countDependentsWrapperArgs) {
+	var result countDependentsWrapperResult
+
+	result.dependents, result.err = args.countDependents(
+		args.q,
+		args.author,
+		args.repo)
+
+	*args.result = result
+	args.wg.Done()
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gophr-pm/gophr/lib/db"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCountDependentsWrapper(t *testing.T) {
+	Convey("Given a package whose dependents are to be counted", t, func() {
+		client := db.NewMockClient()
+
+		Convey("the count should be passed along", func() {
+			var (
+				wg     sync.WaitGroup
+				result countDependentsWrapperResult
+			)
+
+			wg.Add(1)
+			countDependentsWrapper(countDependentsWrapperArgs{
+				q:      client,
+				wg:     &wg,
+				repo:   "myrepo",
+				author: "myauthor",
+				result: &result,
+				countDependents: func(q db.Queryable, author, repo string) (int, error) {
+					So(q, ShouldEqual, client)
+					So(author, ShouldEqual, "myauthor")
+					So(repo, ShouldEqual, "myrepo")
+					return 42, nil
+				},
+			})
+			wg.Wait()
+
+			So(result.err, ShouldBeNil)
+			So(result.dependents, ShouldEqual, 42)
+		})
+
+		Convey("failures should be passed along", func() {
+			var (
+				wg     sync.WaitGroup
+				result countDependentsWrapperResult
+			)
+
+			wg.Add(1)
+			countDependentsWrapper(countDependentsWrapperArgs{
+				q:      client,
+				wg:     &wg,
+				result: &result,
+				countDependents: func(q db.Queryable, author, repo string) (int, error) {
+					return 0, errors.New("this is an error")
+				},
+			})
+			wg.Wait()
+
+			So(result.err, ShouldNotBeNil)
+			So(result.dependents, ShouldEqual, 0)
+		})
+	})
+}
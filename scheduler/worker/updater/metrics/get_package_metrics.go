@@ -8,6 +8,7 @@ import (
 	"github.com/gophr-pm/gophr/lib/db"
 	"github.com/gophr-pm/gophr/lib/db/model/package"
 	"github.com/gophr-pm/gophr/lib/db/model/package/awesome"
+	"github.com/gophr-pm/gophr/lib/db/model/package/dependency"
 	"github.com/gophr-pm/gophr/lib/db/model/package/download"
 )
 
@@ -23,10 +24,11 @@ func getPackageMetrics(
 		searchScore               float32
 		getSplitsResult           getSplitsWrapperResult
 		awesomeCheckResult        awesomeCheckWrapperResult
+		countDependentsResult     countDependentsWrapperResult
 		getVersionDownloadsResult getVersionDownloadsWrapperResult
 	)
 
-	wg.Add(3)
+	wg.Add(4)
 	go getSplitsWrapper(getSplitsWrapperArgs{
 		q:         q,
 		wg:        &wg,
@@ -52,6 +54,14 @@ func getPackageMetrics(
 		fetchRefs:           lib.FetchRefs,
 		getVersionDownloads: download.GetForVersions,
 	})
+	go countDependentsWrapper(countDependentsWrapperArgs{
+		q:               q,
+		wg:              &wg,
+		repo:            summary.Repo,
+		author:          summary.Author,
+		result:          &countDependentsResult,
+		countDependents: dependency.CountDependents,
+	})
 	wg.Wait()
 
 	if getSplitsResult.err != nil {
@@ -77,6 +87,13 @@ func getPackageMetrics(
 			summary.Author,
 			getVersionDownloadsResult.err)
 	}
+	if countDependentsResult.err != nil {
+		return pkg.UpdateMetricsArgs{}, fmt.Errorf(
+			`Failed to count the dependents of package "%s/%s": %v`,
+			summary.Author,
+			summary.Repo,
+			countDependentsResult.err)
+	}
 
 	// Calculate the derived metrics.
 	trendScore = pkg.CalcTrendScore(
@@ -87,7 +104,8 @@ func getPackageMetrics(
 		summary.Stars,
 		getSplitsResult.splits.AllTime,
 		summary.Awesome,
-		trendScore)
+		trendScore,
+		countDependentsResult.dependents)
 
 	return pkg.UpdateMetricsArgs{
 		Repo:                    summary.Repo,